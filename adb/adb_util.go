@@ -24,6 +24,7 @@ type ExecuteParams struct {
 	Ctxt        context.Context
 	DeviceId    string
 	AdbPath     string
+	UserId      string // 目标用户，为空时 pm/am 作用于当前用户
 }
 
 var (
@@ -140,12 +141,12 @@ func filterFragmentLines(content string) []string {
 }
 
 func KillApp(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("am force-stop %s", param.PackageName))
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("am force-stop%s %s", param.userArg(), param.PackageName))
 	return execCmd(cmd)
 }
 
 func ClearApp(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm clear %s", param.PackageName))
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm clear%s %s", param.userArg(), param.PackageName))
 	return execCmd(cmd)
 }
 
@@ -214,8 +215,8 @@ func GrantAllPermission(param ExecuteParams) types.ExecResult {
 	var grantCmdsForDisplay []string
 
 	for _, perm := range grantablePermissions {
-		grantCmdsForExec = append(grantCmdsForExec, fmt.Sprintf("pm grant %s %s 2>&1", param.PackageName, perm))
-		displayCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm grant %s %s", param.PackageName, perm))
+		grantCmdsForExec = append(grantCmdsForExec, fmt.Sprintf("pm grant%s %s %s 2>&1", param.userArg(), param.PackageName, perm))
+		displayCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm grant%s %s %s", param.userArg(), param.PackageName, perm))
 		grantCmdsForDisplay = append(grantCmdsForDisplay, displayCmd)
 	}
 
@@ -260,7 +261,7 @@ func RevokePermission(param ExecuteParams) types.ExecResult {
 			parts := strings.Split(line, ":")
 			if len(parts) > 0 {
 				permission := strings.TrimSpace(parts[0])
-				revokeCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm revoke %s %s", param.PackageName, permission))
+				revokeCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm revoke%s %s %s", param.userArg(), param.PackageName, permission))
 				resCmd = resCmd + "\n" + revokeCmd
 				execCmd(revokeCmd)
 			}
@@ -333,42 +334,78 @@ func listInputMethodService(param ExecuteParams) types.ExecResult {
 	return result
 }
 
+// StartActivity 启动应用的入口 Activity；monkey 不支持指定用户，指定用户时先解析入口再用 am start 启动
 func StartActivity(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("monkey -p %s -c android.intent.category.LAUNCHER 1", param.PackageName))
+	if param.UserId == "" {
+		cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("monkey -p %s -c android.intent.category.LAUNCHER 1", param.PackageName))
+		return execCmd(cmd)
+	}
+	resolved := ResolveLauncherActivity(param)
+	if resolved.Error != "" {
+		return resolved
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("am start%s -n %s", param.userArg(), resolved.Res))
 	return execCmd(cmd)
 }
 
+// ResolveLauncherActivity 解析应用在目标用户下的入口 Activity，成功时 Res 为 包名/类名
+func ResolveLauncherActivity(param ExecuteParams) types.ExecResult {
+	resolveCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId,
+		fmt.Sprintf("cmd package resolve-activity --brief%s -a android.intent.action.MAIN -c android.intent.category.LAUNCHER %s", param.userArg(), param.PackageName))
+	resolved := execCmd(resolveCmd)
+	if resolved.Error != "" {
+		return resolved
+	}
+	lines := util.MultiLine(resolved.Res)
+	component := ""
+	if len(lines) > 0 {
+		component = strings.TrimSpace(lines[len(lines)-1])
+	}
+	if !strings.Contains(component, "/") {
+		return types.NewExecResultErrorString(resolveCmd, fmt.Sprintf("找不到 %s 的入口 Activity: %s", param.PackageName, resolved.Res))
+	}
+	return types.NewExecResultSuccess(resolveCmd, component)
+}
+
 func Shutdown(param ExecuteParams) types.ExecResult {
 	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "reboot -p")
 	return execCmd(cmd)
 }
 
 func GetAppInstallPath(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm path %s", param.PackageName))
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm path%s %s", param.userArg(), param.PackageName))
 	return execCmd(cmd)
 }
 
+// ExportAppPackagePath 导出应用安装包，拆分安装的应用导出为包含全部拆分包的 .apks
 func ExportAppPackagePath(param ExecuteParams) types.ExecResult {
 	pathCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm path %s", param.PackageName))
-	pathResult := execCmd(pathCmd)
-	if pathResult.Error != "" {
-		return pathResult
+	apks, err := ListPackageApks(param, param.PackageName)
+	if err != nil {
+		return types.NewExecResultError(pathCmd, err)
 	}
 	dir, err := runtime.OpenDirectoryDialog(param.Ctxt, runtime.OpenDialogOptions{
 		Title: "选择导出目录",
 	})
 
 	if err != nil {
-		return types.NewExecResultErrorString(pathCmd, pathResult.Error)
+		return types.NewExecResultError(pathCmd, err)
 	}
 
 	if dir == "" {
 		return types.NewExecResultErrorString(pathCmd, "用户取消选择导出目录")
 	}
-	path := strings.TrimPrefix(strings.TrimSpace(pathResult.Res), "package:")
+
+	if len(apks) > 1 {
+		result := ExportAppBundle(param.Ctxt, param, "", param.PackageName, strings.TrimSpace(dir), AppExportOptions{Format: AppExportApks}, func(TransferProgress) {})
+		if result.Status != TransferCompleted {
+			return types.NewExecResultErrorString(pathCmd, strings.Join(result.Errors, "\n"))
+		}
+		return types.NewExecResultSuccess(pathCmd, fmt.Sprintf("已导出 %d 个 APK 到 %s", result.Splits, result.File))
+	}
 
 	targetApkName := filepath.Join(strings.TrimSpace(dir), param.PackageName+".apk")
-	cmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", path, targetApkName))
+	cmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", quoteHostArg(apks[0]), quoteHostArg(targetApkName)))
 
 	return execCmd(cmd)
 }
@@ -443,7 +480,7 @@ func getRequestedPermissions(lines []string) []string {
 }
 
 func GetAllPackages(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "pm list packages")
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "pm list packages"+param.userArg())
 	execResult := execCmd(cmd)
 	var packages []string
 	if execResult.Error != "" {
@@ -495,7 +532,7 @@ func InstallApp(param ExecuteParams) types.ExecResult {
 }
 
 func UninstallApp(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("uninstall %s", param.PackageName))
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm uninstall%s %s", param.userArg(), param.PackageName))
 	return execCmd(cmd)
 }
 
@@ -657,35 +694,7 @@ func GetDeviceInfo(param ExecuteParams) types.ExecResult {
 	}
 
 	// 解析密度
-	var densityRes string
-	var densityScale float64
-
-	if !strings.Contains(density, "Override density") {
-		idx := strings.Index(density, ":")
-		if idx != -1 {
-			densityRes = strings.TrimSpace(density[idx+1:])
-			if d, err := strconv.ParseFloat(densityRes, 64); err == nil {
-				densityScale = d / 160
-			}
-		}
-	} else {
-		lines := util.MultiLine(density)
-		if len(lines) >= 2 {
-			idx := strings.Index(lines[0], ":")
-			if idx != -1 {
-				densityRes = strings.TrimSpace(lines[0][idx+1:])
-			}
-
-			idx = strings.Index(lines[1], ":")
-			if idx != -1 {
-				overrideDensity := strings.TrimSpace(lines[1][idx+1:])
-				if d, err := strconv.ParseFloat(overrideDensity, 64); err == nil {
-					densityScale = d / 160
-				}
-				densityRes = overrideDensity // 直接赋值
-			}
-		}
-	}
+	densityRes, densityScale := parseWmDensity(density)
 
 	// 获取版本构建信息
 	versionBuild := util.GetVersionBuild(sdkVersion)
@@ -741,6 +750,41 @@ IP 地址：%s`,
 	return types.NewExecResultSuccess(allCmds, result)
 }
 
+// parseWmDensity 解析 wm density 的输出，有 Override density 时以覆盖值为准，返回 dpi 与相对 160dpi 的缩放
+func parseWmDensity(density string) (string, float64) {
+	var densityRes string
+	var densityScale float64
+
+	if !strings.Contains(density, "Override density") {
+		idx := strings.Index(density, ":")
+		if idx != -1 {
+			densityRes = strings.TrimSpace(density[idx+1:])
+			if d, err := strconv.ParseFloat(densityRes, 64); err == nil {
+				densityScale = d / 160
+			}
+		}
+	} else {
+		lines := util.MultiLine(density)
+		if len(lines) >= 2 {
+			idx := strings.Index(lines[0], ":")
+			if idx != -1 {
+				densityRes = strings.TrimSpace(lines[0][idx+1:])
+			}
+
+			idx = strings.Index(lines[1], ":")
+			if idx != -1 {
+				overrideDensity := strings.TrimSpace(lines[1][idx+1:])
+				if d, err := strconv.ParseFloat(overrideDensity, 64); err == nil {
+					densityScale = d / 160
+				}
+				densityRes = overrideDensity // 直接赋值
+			}
+		}
+	}
+
+	return densityRes, densityScale
+}
+
 func getFormatCpuCount(msg string) int {
 	var cpuCount = 0
 	if len(msg) > 0 {
@@ -1324,7 +1368,7 @@ func JumpToSettings(param ExecuteParams) types.ExecResult {
 
 // 跳转到 App 详情页
 func JumpToAppDetailSettings(param ExecuteParams) types.ExecResult {
-	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("am start -a android.settings.APPLICATION_DETAILS_SETTINGS -d package:%s", param.PackageName))
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("am start%s -a android.settings.APPLICATION_DETAILS_SETTINGS -d package:%s", param.userArg(), param.PackageName))
 	return execCmd(cmd)
 }
 
@@ -0,0 +1,213 @@
+package adb
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ResTable_entry 的标志位
+const (
+	resEntryComplex = 0x0001
+	resEntryCompact = 0x0008
+)
+
+// ResTable_type 的标志位
+const (
+	resTypeSparse   = 0x01
+	resTypeOffset16 = 0x02
+)
+
+const resNoEntry = 0xffffffff
+
+// 资源表 chunk 头部的最小长度，ResTable_type 之后紧跟至少包含 size 字段的 ResTable_config
+const (
+	resTablePackageHeaderSize = 12
+	resTableTypeHeaderSize    = 24
+)
+
+// resValue 资源表中某个配置下的值
+type resValue struct {
+	dataType byte
+	data     uint32
+	// 默认配置（无语言等限定符）的值优先使用
+	isDefault bool
+}
+
+// resTable resources.arsc 中解析出的简单值，只保留字符串、整数等单值资源
+type resTable struct {
+	pool   []string
+	values map[uint32][]resValue
+}
+
+// parseResourceTable 解析 resources.arsc
+func parseResourceTable(data []byte) (*resTable, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint16(data) != resTableType {
+		return nil, fmt.Errorf("不是资源表")
+	}
+	table := &resTable{values: make(map[uint32][]resValue)}
+
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+8 <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if size < 8 || offset+size > len(data) {
+			return nil, fmt.Errorf("chunk 越界: offset=%d", offset)
+		}
+		chunk := data[offset : offset+size]
+		switch chunkType {
+		case resStringPoolType:
+			pool, err := parseStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			table.pool = pool
+		case resTablePackageType:
+			if err := table.parsePackage(chunk); err != nil {
+				return nil, err
+			}
+		}
+		offset += size
+	}
+	return table, nil
+}
+
+func (t *resTable) parsePackage(chunk []byte) error {
+	if len(chunk) < resTablePackageHeaderSize {
+		return fmt.Errorf("资源包长度不足")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if headerSize < resTablePackageHeaderSize || headerSize > len(chunk) {
+		return fmt.Errorf("资源包头部长度无效: %d", headerSize)
+	}
+	packageId := binary.LittleEndian.Uint32(chunk[8:])
+
+	offset := headerSize
+	for offset+8 <= len(chunk) {
+		chunkType := binary.LittleEndian.Uint16(chunk[offset:])
+		size := int(binary.LittleEndian.Uint32(chunk[offset+4:]))
+		if size < 8 || offset+size > len(chunk) {
+			return fmt.Errorf("资源包 chunk 越界: offset=%d", offset)
+		}
+		if chunkType == resTableTypeType {
+			if err := t.parseType(packageId, chunk[offset:offset+size]); err != nil {
+				return err
+			}
+		}
+		offset += size
+	}
+	return nil
+}
+
+func (t *resTable) parseType(packageId uint32, chunk []byte) error {
+	if len(chunk) < resTableTypeHeaderSize {
+		return fmt.Errorf("资源类型长度不足")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if headerSize < resTableTypeHeaderSize || headerSize > len(chunk) {
+		return fmt.Errorf("资源类型头部长度无效: %d", headerSize)
+	}
+	typeId := uint32(chunk[8])
+	flags := chunk[9]
+	entryCount := int(binary.LittleEndian.Uint32(chunk[12:]))
+	entriesStart := int(binary.LittleEndian.Uint32(chunk[16:]))
+	isDefault := isDefaultConfig(chunk[20:headerSize])
+
+	add := func(index int, entryOffset int) {
+		e := entriesStart + entryOffset
+		if e+8 > len(chunk) {
+			return
+		}
+		entryFlags := binary.LittleEndian.Uint16(chunk[e+2:])
+		id := packageId<<24 | typeId<<16 | uint32(index)
+		if entryFlags&resEntryCompact != 0 {
+			// 紧凑格式：key 放在 size 字段，类型放在标志位高 8 位，后面直接是数据
+			t.values[id] = append(t.values[id], resValue{dataType: byte(entryFlags >> 8), data: binary.LittleEndian.Uint32(chunk[e+4:]), isDefault: isDefault})
+			return
+		}
+		if entryFlags&resEntryComplex != 0 {
+			return
+		}
+		entrySize := int(binary.LittleEndian.Uint16(chunk[e:]))
+		v := e + entrySize
+		if v+8 > len(chunk) {
+			return
+		}
+		t.values[id] = append(t.values[id], resValue{dataType: chunk[v+3], data: binary.LittleEndian.Uint32(chunk[v+4:]), isDefault: isDefault})
+	}
+
+	switch {
+	case flags&resTypeSparse != 0:
+		for i := 0; i < entryCount; i++ {
+			p := headerSize + i*4
+			if p+4 > len(chunk) {
+				return nil
+			}
+			index := int(binary.LittleEndian.Uint16(chunk[p:]))
+			add(index, int(binary.LittleEndian.Uint16(chunk[p+2:]))*4)
+		}
+	case flags&resTypeOffset16 != 0:
+		for i := 0; i < entryCount; i++ {
+			p := headerSize + i*2
+			if p+2 > len(chunk) {
+				return nil
+			}
+			if off := binary.LittleEndian.Uint16(chunk[p:]); off != 0xffff {
+				add(i, int(off)*4)
+			}
+		}
+	default:
+		for i := 0; i < entryCount; i++ {
+			p := headerSize + i*4
+			if p+4 > len(chunk) {
+				return nil
+			}
+			if off := binary.LittleEndian.Uint32(chunk[p:]); off != resNoEntry {
+				add(i, int(off))
+			}
+		}
+	}
+	return nil
+}
+
+// isDefaultConfig ResTable_config 除 size 外全为 0 时是默认配置
+func isDefaultConfig(config []byte) bool {
+	if len(config) < 4 {
+		return true
+	}
+	size := int(binary.LittleEndian.Uint32(config))
+	if size < 4 {
+		return true
+	}
+	if size > len(config) {
+		size = len(config)
+	}
+	for _, b := range config[4:size] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve 把资源引用解析为字符串，优先使用默认配置，无法解析时返回空字符串
+func (t *resTable) resolve(id uint32) string {
+	for depth := 0; depth < 8; depth++ {
+		values := t.values[id]
+		if len(values) == 0 {
+			return ""
+		}
+		v := values[0]
+		for _, candidate := range values {
+			if candidate.isDefault {
+				v = candidate
+				break
+			}
+		}
+		if v.dataType != resValueReference {
+			value, _ := formatResValue(v.dataType, v.data, t.pool)
+			return value
+		}
+		id = v.data
+	}
+	return ""
+}
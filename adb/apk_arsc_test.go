@@ -0,0 +1,197 @@
+package adb
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPackageId = 0x7f
+
+// testEntry 资源类型中的一个简单值
+type testEntry struct {
+	dataType byte
+	data     uint32
+}
+
+// buildTypeChunk 生成 ResTable_type，language 不为空时写入配置的语言限定符
+// headerSize 为 0 时使用 20 字节固定头部加 configSize
+func buildTypeChunk(typeId byte, headerSize uint16, configSize uint32, language string, entries ...testEntry) []byte {
+	config := make([]byte, max(int(configSize), 4))
+	copy(config, appendUint32(nil, configSize))
+	if language != "" && len(config) >= 10 {
+		copy(config[8:], language)
+	}
+	if headerSize == 0 {
+		headerSize = uint16(20 + len(config))
+	}
+
+	var offsets, data []byte
+	for _, e := range entries {
+		offsets = appendUint32(offsets, uint32(len(data)))
+		data = appendUint16(data, 8)
+		data = appendUint16(data, 0)
+		data = appendUint32(data, 0)
+		data = appendUint16(data, 8)
+		data = append(data, 0, e.dataType)
+		data = appendUint32(data, e.data)
+	}
+
+	entriesStart := 20 + len(config) + len(offsets)
+	b := chunkHeader(resTableTypeType, headerSize, entriesStart+len(data))
+	b = append(b, typeId, 0, 0, 0)
+	b = appendUint32(b, uint32(len(entries)))
+	b = appendUint32(b, uint32(entriesStart))
+	b = append(b, config...)
+	b = append(b, offsets...)
+	return append(b, data...)
+}
+
+// buildPackageChunk headerSize 为 0 时使用实际的 288 字节头部
+func buildPackageChunk(headerSize uint16, types ...[]byte) []byte {
+	const fullHeader = 288
+	if headerSize == 0 {
+		headerSize = fullHeader
+	}
+	size := fullHeader
+	for _, t := range types {
+		size += len(t)
+	}
+	b := chunkHeader(resTablePackageType, headerSize, size)
+	b = appendUint32(b, testPackageId)
+	b = append(b, make([]byte, fullHeader-12)...)
+	for _, t := range types {
+		b = append(b, t...)
+	}
+	return b
+}
+
+func buildResourceTable(chunks ...[]byte) []byte {
+	size := 12
+	for _, c := range chunks {
+		size += len(c)
+	}
+	b := chunkHeader(resTableType, 12, size)
+	b = appendUint32(b, 1)
+	for _, c := range chunks {
+		b = append(b, c...)
+	}
+	return b
+}
+
+func testResourceTable() []byte {
+	return buildResourceTable(
+		buildStringPool("Demo", "演示"),
+		buildPackageChunk(0,
+			// 非默认配置在前，解析时仍应优先默认配置
+			buildTypeChunk(1, 0, 28, "zh", testEntry{resValueString, 1}),
+			buildTypeChunk(1, 0, 28, "",
+				testEntry{resValueString, 0},
+				testEntry{resValueReference, testPackageId<<24 | 1<<16},
+				testEntry{resValueIntDec, 7},
+			),
+		),
+	)
+}
+
+func TestParseResourceTable(t *testing.T) {
+	table, err := parseResourceTable(testResourceTable())
+	if err != nil {
+		t.Fatalf("parseResourceTable: %v", err)
+	}
+	tests := []struct {
+		name string
+		id   uint32
+		want string
+	}{
+		{"default config preferred", 0x7f010000, "Demo"},
+		{"reference resolved", 0x7f010001, "Demo"},
+		{"integer", 0x7f010002, "7"},
+		{"missing", 0x7f010003, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := table.resolve(tt.id); got != tt.want {
+				t.Errorf("resolve(0x%08x) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResourceTableErrors(t *testing.T) {
+	pool := buildStringPool("Demo")
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "not a resource table",
+			data:    buildBinaryXML(),
+			wantErr: "不是资源表",
+		},
+		{
+			name:    "type header past chunk",
+			data:    buildResourceTable(pool, buildPackageChunk(0, buildTypeChunk(1, 0xffff, 28, "", testEntry{resValueString, 0}))),
+			wantErr: "资源类型头部长度无效",
+		},
+		{
+			name:    "type header too small",
+			data:    buildResourceTable(pool, buildPackageChunk(0, buildTypeChunk(1, 8, 28, "", testEntry{resValueString, 0}))),
+			wantErr: "资源类型头部长度无效",
+		},
+		{
+			name:    "package header past chunk",
+			data:    buildResourceTable(pool, buildPackageChunk(0xffff)),
+			wantErr: "资源包头部长度无效",
+		},
+		{
+			name:    "package header too small",
+			data:    buildResourceTable(pool, buildPackageChunk(4)),
+			wantErr: "资源包头部长度无效",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseResourceTable(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsDefaultConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config []byte
+		want   bool
+	}{
+		{"empty", nil, true},
+		{"zero", appendUint32(make([]byte, 0, 28), 28), true},
+		// size 小于 4 时不能按 size 截取
+		{"size below header", append(appendUint32(nil, 2), 1, 2, 3, 4), true},
+		{"language", append(appendUint32(nil, 12), 0, 0, 0, 0, 'z', 'h', 0, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDefaultConfig(tt.config); got != tt.want {
+				t.Errorf("isDefaultConfig = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzParseResourceTable(f *testing.F) {
+	f.Add(testResourceTable())
+	f.Add(buildResourceTable(buildPackageChunk(0, buildTypeChunk(1, 0xffff, 28, ""))))
+	f.Add(buildResourceTable(buildPackageChunk(0, buildTypeChunk(1, 0, 2, ""))))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		table, err := parseResourceTable(data)
+		if err != nil {
+			return
+		}
+		for id := range table.values {
+			table.resolve(id)
+		}
+	})
+}
@@ -0,0 +1,305 @@
+package adb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+)
+
+// 二进制 XML 与资源表共用的 chunk 类型
+const (
+	resStringPoolType   = 0x0001
+	resTableType        = 0x0002
+	resXMLType          = 0x0003
+	resXMLStartElement  = 0x0102
+	resXMLEndElement    = 0x0103
+	resXMLResourceMap   = 0x0180
+	resTablePackageType = 0x0200
+	resTableTypeType    = 0x0201
+)
+
+// Res_value 的数据类型
+const (
+	resValueReference = 0x01
+	resValueString    = 0x03
+	resValueIntDec    = 0x10
+	resValueIntHex    = 0x11
+	resValueBoolean   = 0x12
+)
+
+const stringPoolUTF8 = 1 << 8
+
+// 清单中常用属性的资源 id，属性名被混淆时按 id 识别
+var manifestAttrIds = map[uint32]string{
+	0x01010001: "label",
+	0x01010003: "name",
+	0x0101000f: "debuggable",
+	0x01010010: "exported",
+	0x0101020c: "minSdkVersion",
+	0x0101021b: "versionCode",
+	0x0101021c: "versionName",
+	0x01010270: "targetSdkVersion",
+	0x01010271: "maxSdkVersion",
+	0x01010272: "testOnly",
+	0x01010576: "versionCodeMajor",
+}
+
+// xmlAttr 二进制 XML 中的一个属性，Ref 非 0 时表示值是资源引用
+type xmlAttr struct {
+	Name  string
+	Value string
+	Ref   uint32
+}
+
+// xmlNode 二进制 XML 解析后的元素
+type xmlNode struct {
+	Tag      string
+	Attrs    []xmlAttr
+	Children []*xmlNode
+}
+
+// attr 返回属性值，不存在时返回空字符串
+func (n *xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// attrRef 返回属性引用的资源 id，不是引用时返回 0
+func (n *xmlNode) attrRef(name string) uint32 {
+	for _, a := range n.Attrs {
+		if a.Name == name {
+			return a.Ref
+		}
+	}
+	return 0
+}
+
+// children 返回指定标签的直接子元素
+func (n *xmlNode) children(tag string) []*xmlNode {
+	var nodes []*xmlNode
+	for _, c := range n.Children {
+		if c.Tag == tag {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// parseStringPool 解析字符串池 chunk，data 从 chunk 头开始
+func parseStringPool(data []byte) ([]string, error) {
+	if len(data) < 28 {
+		return nil, fmt.Errorf("字符串池长度不足")
+	}
+	count := int(binary.LittleEndian.Uint32(data[8:]))
+	flags := binary.LittleEndian.Uint32(data[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(data[20:]))
+	headerSize := int(binary.LittleEndian.Uint16(data[2:]))
+	if headerSize+count*4 > len(data) || stringsStart > len(data) {
+		return nil, fmt.Errorf("字符串池越界")
+	}
+
+	pool := make([]string, count)
+	for i := 0; i < count; i++ {
+		offset := stringsStart + int(binary.LittleEndian.Uint32(data[headerSize+i*4:]))
+		if offset >= len(data) {
+			continue
+		}
+		if flags&stringPoolUTF8 != 0 {
+			pool[i] = decodeUTF8PoolString(data[offset:])
+		} else {
+			pool[i] = decodeUTF16PoolString(data[offset:])
+		}
+	}
+	return pool, nil
+}
+
+func decodeUTF8PoolString(b []byte) string {
+	// 先是 UTF-16 长度，再是 UTF-8 字节数，各占 1 或 2 字节
+	_, n := poolLength8(b)
+	if n == 0 {
+		return ""
+	}
+	length, m := poolLength8(b[n:])
+	start := n + m
+	if m == 0 || start+length > len(b) {
+		return ""
+	}
+	return string(b[start : start+length])
+}
+
+func poolLength8(b []byte) (int, int) {
+	if len(b) < 1 {
+		return 0, 0
+	}
+	if b[0]&0x80 == 0 {
+		return int(b[0]), 1
+	}
+	if len(b) < 2 {
+		return 0, 0
+	}
+	return int(b[0]&0x7f)<<8 | int(b[1]), 2
+}
+
+func decodeUTF16PoolString(b []byte) string {
+	if len(b) < 2 {
+		return ""
+	}
+	length := int(binary.LittleEndian.Uint16(b))
+	start := 2
+	if length&0x8000 != 0 {
+		if len(b) < 4 {
+			return ""
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(b[2:]))
+		start = 4
+	}
+	if start+length*2 > len(b) {
+		return ""
+	}
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[start+i*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// formatResValue 把 Res_value 格式化为字符串，引用类型返回资源 id
+func formatResValue(dataType byte, data uint32, pool []string) (string, uint32) {
+	switch dataType {
+	case resValueString:
+		if int(data) < len(pool) {
+			return pool[data], 0
+		}
+		return "", 0
+	case resValueReference:
+		return fmt.Sprintf("@0x%08x", data), data
+	case resValueIntDec:
+		return strconv.Itoa(int(int32(data))), 0
+	case resValueIntHex:
+		return fmt.Sprintf("0x%x", data), 0
+	case resValueBoolean:
+		return strconv.FormatBool(data != 0), 0
+	default:
+		return strconv.FormatUint(uint64(data), 10), 0
+	}
+}
+
+// parseBinaryXML 解析 APK 中编译后的二进制 XML，返回根元素
+func parseBinaryXML(data []byte) (*xmlNode, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint16(data) != resXMLType {
+		return nil, fmt.Errorf("不是二进制 XML")
+	}
+
+	var pool []string
+	var resMap []uint32
+	var root *xmlNode
+	var stack []*xmlNode
+
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+8 <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		headerSize := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if size < 8 || offset+size > len(data) {
+			return nil, fmt.Errorf("chunk 越界: offset=%d", offset)
+		}
+		chunk := data[offset : offset+size]
+
+		switch chunkType {
+		case resStringPoolType:
+			p, err := parseStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			pool = p
+		case resXMLResourceMap:
+			for i := headerSize; i+4 <= size; i += 4 {
+				resMap = append(resMap, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case resXMLStartElement:
+			node, err := parseStartElement(chunk, headerSize, pool, resMap)
+			if err != nil {
+				return nil, err
+			}
+			if len(stack) == 0 {
+				if root == nil {
+					root = node
+				}
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			}
+			stack = append(stack, node)
+		case resXMLEndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		offset += size
+	}
+	if root == nil {
+		return nil, fmt.Errorf("二进制 XML 中没有元素")
+	}
+	return root, nil
+}
+
+// resXMLNodeHeaderSize ResXMLTree_node 的最小头部：chunk 头、行号与注释
+const resXMLNodeHeaderSize = 16
+
+func parseStartElement(chunk []byte, headerSize int, pool []string, resMap []uint32) (*xmlNode, error) {
+	if headerSize < resXMLNodeHeaderSize || headerSize > len(chunk) {
+		return nil, fmt.Errorf("元素头部长度无效: %d", headerSize)
+	}
+	ext := chunk[headerSize:]
+	if len(ext) < 20 {
+		return nil, fmt.Errorf("元素长度不足")
+	}
+	node := &xmlNode{Tag: poolString(pool, binary.LittleEndian.Uint32(ext[4:]))}
+	attrStart := int(binary.LittleEndian.Uint16(ext[8:]))
+	attrSize := int(binary.LittleEndian.Uint16(ext[10:]))
+	attrCount := int(binary.LittleEndian.Uint16(ext[12:]))
+	if attrSize < 20 {
+		attrSize = 20
+	}
+
+	for i := 0; i < attrCount; i++ {
+		a := attrStart + i*attrSize
+		if a+20 > len(ext) {
+			break
+		}
+		nameIdx := binary.LittleEndian.Uint32(ext[a+4:])
+		name := poolString(pool, nameIdx)
+		if id := resourceMapId(resMap, nameIdx); id != 0 {
+			if known, ok := manifestAttrIds[id]; ok {
+				name = known
+			}
+		}
+		rawValue := binary.LittleEndian.Uint32(ext[a+8:])
+		dataType := ext[a+15]
+		value, ref := formatResValue(dataType, binary.LittleEndian.Uint32(ext[a+16:]), pool)
+		if dataType == resValueString && rawValue != 0xffffffff {
+			value = poolString(pool, rawValue)
+		}
+		node.Attrs = append(node.Attrs, xmlAttr{Name: name, Value: value, Ref: ref})
+	}
+	return node, nil
+}
+
+func poolString(pool []string, index uint32) string {
+	if int(index) < len(pool) {
+		return pool[index]
+	}
+	return ""
+}
+
+func resourceMapId(resMap []uint32, index uint32) uint32 {
+	if int(index) < len(resMap) {
+		return resMap[index]
+	}
+	return 0
+}
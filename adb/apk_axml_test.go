@@ -0,0 +1,221 @@
+package adb
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// chunkHeader 生成 chunk 头：类型、头部长度与 chunk 总长度
+func chunkHeader(chunkType uint16, headerSize uint16, size int) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint16(b, chunkType)
+	binary.LittleEndian.PutUint16(b[2:], headerSize)
+	binary.LittleEndian.PutUint32(b[4:], uint32(size))
+	return b
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return binary.LittleEndian.AppendUint16(b, v)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(b, v)
+}
+
+// buildStringPool 生成 UTF-8 字符串池，字符串长度需小于 128
+func buildStringPool(values ...string) []byte {
+	var data []byte
+	offsets := make([]uint32, len(values))
+	for i, v := range values {
+		offsets[i] = uint32(len(data))
+		data = append(data, byte(len(v)), byte(len(v)))
+		data = append(data, v...)
+		data = append(data, 0)
+	}
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	const headerSize = 28
+	stringsStart := headerSize + len(values)*4
+	size := stringsStart + len(data)
+	b := chunkHeader(resStringPoolType, headerSize, size)
+	b = appendUint32(b, uint32(len(values)))
+	b = appendUint32(b, 0)
+	b = appendUint32(b, stringPoolUTF8)
+	b = appendUint32(b, uint32(stringsStart))
+	b = appendUint32(b, 0)
+	for _, o := range offsets {
+		b = appendUint32(b, o)
+	}
+	return append(b, data...)
+}
+
+// testAttr 测试用的属性，name 为字符串池下标
+type testAttr struct {
+	name     uint32
+	dataType byte
+	data     uint32
+}
+
+// buildStartElement 生成开始元素，headerSize 为 0 时使用正常的 16
+func buildStartElement(name uint32, headerSize uint16, attrs ...testAttr) []byte {
+	if headerSize == 0 {
+		headerSize = resXMLNodeHeaderSize
+	}
+	var ext []byte
+	ext = appendUint32(ext, 0xffffffff)
+	ext = appendUint32(ext, name)
+	ext = appendUint16(ext, 20)
+	ext = appendUint16(ext, 20)
+	ext = appendUint16(ext, uint16(len(attrs)))
+	ext = append(ext, make([]byte, 6)...)
+	for _, a := range attrs {
+		ext = appendUint32(ext, 0xffffffff)
+		ext = appendUint32(ext, a.name)
+		if a.dataType == resValueString {
+			ext = appendUint32(ext, a.data)
+		} else {
+			ext = appendUint32(ext, 0xffffffff)
+		}
+		ext = appendUint16(ext, 8)
+		ext = append(ext, 0, a.dataType)
+		ext = appendUint32(ext, a.data)
+	}
+
+	b := chunkHeader(resXMLStartElement, headerSize, resXMLNodeHeaderSize+len(ext))
+	b = appendUint32(b, 1)
+	b = appendUint32(b, 0xffffffff)
+	return append(b, ext...)
+}
+
+func buildEndElement(name uint32) []byte {
+	b := chunkHeader(resXMLEndElement, resXMLNodeHeaderSize, 24)
+	b = appendUint32(b, 1)
+	b = appendUint32(b, 0xffffffff)
+	b = appendUint32(b, 0xffffffff)
+	return appendUint32(b, name)
+}
+
+// buildBinaryXML 用 XML 文档头包住各个 chunk
+func buildBinaryXML(chunks ...[]byte) []byte {
+	size := 8
+	for _, c := range chunks {
+		size += len(c)
+	}
+	b := chunkHeader(resXMLType, 8, size)
+	for _, c := range chunks {
+		b = append(b, c...)
+	}
+	return b
+}
+
+// 字符串池下标
+const (
+	testStrManifest = iota
+	testStrApplication
+	testStrPackage
+	testStrVersionCode
+	testStrDebuggable
+	testStrPackageName
+)
+
+func testManifestXML() []byte {
+	return buildBinaryXML(
+		buildStringPool("manifest", "application", "package", "versionCode", "debuggable", "com.example.app"),
+		buildStartElement(testStrManifest, 0,
+			testAttr{name: testStrPackage, dataType: resValueString, data: testStrPackageName},
+			testAttr{name: testStrVersionCode, dataType: resValueIntDec, data: 42},
+		),
+		buildStartElement(testStrApplication, 0,
+			testAttr{name: testStrDebuggable, dataType: resValueBoolean, data: 0xffffffff},
+		),
+		buildEndElement(testStrApplication),
+		buildEndElement(testStrManifest),
+	)
+}
+
+func TestParseBinaryXML(t *testing.T) {
+	root, err := parseBinaryXML(testManifestXML())
+	if err != nil {
+		t.Fatalf("parseBinaryXML: %v", err)
+	}
+	if root.Tag != "manifest" {
+		t.Errorf("root tag = %q, want manifest", root.Tag)
+	}
+	if got := root.attr("package"); got != "com.example.app" {
+		t.Errorf("package = %q", got)
+	}
+	if got := root.attr("versionCode"); got != "42" {
+		t.Errorf("versionCode = %q", got)
+	}
+	apps := root.children("application")
+	if len(apps) != 1 {
+		t.Fatalf("application count = %d, want 1", len(apps))
+	}
+	if got := apps[0].attr("debuggable"); got != "true" {
+		t.Errorf("debuggable = %q", got)
+	}
+}
+
+func TestParseBinaryXMLErrors(t *testing.T) {
+	pool := buildStringPool("manifest")
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "not binary xml",
+			data:    []byte("<manifest/>"),
+			wantErr: "不是二进制 XML",
+		},
+		{
+			// 16 字节：文档头之后是头部长度为 0xffff 的开始元素
+			name:    "start element header past chunk",
+			data:    buildBinaryXML(chunkHeader(resXMLStartElement, 0xffff, 8)),
+			wantErr: "元素头部长度无效",
+		},
+		{
+			name:    "start element header too small",
+			data:    buildBinaryXML(pool, buildStartElement(0, 4)),
+			wantErr: "元素头部长度无效",
+		},
+		{
+			name:    "start element header beyond attributes",
+			data:    buildBinaryXML(pool, buildStartElement(0, 60)),
+			wantErr: "元素头部长度无效",
+		},
+		{
+			name:    "chunk size past data",
+			data:    buildBinaryXML(chunkHeader(resXMLStartElement, resXMLNodeHeaderSize, 1024)),
+			wantErr: "chunk 越界",
+		},
+		{
+			name:    "no elements",
+			data:    buildBinaryXML(pool),
+			wantErr: "没有元素",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseBinaryXML(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func FuzzParseBinaryXML(f *testing.F) {
+	f.Add(testManifestXML())
+	f.Add(buildBinaryXML(chunkHeader(resXMLStartElement, 0xffff, 8)))
+	f.Add(buildBinaryXML(buildStringPool("a"), buildStartElement(0, 4)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root, err := parseBinaryXML(data)
+		if err == nil && root == nil {
+			t.Fatal("nil root without error")
+		}
+	})
+}
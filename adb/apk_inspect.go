@@ -0,0 +1,194 @@
+package adb
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ApkComponent 清单中声明的 Activity
+type ApkComponent struct {
+	Name     string `json:"name"`
+	Exported bool   `json:"exported"`
+	Launcher bool   `json:"launcher"` // 带 MAIN/LAUNCHER 的入口
+}
+
+// ApkInfo 本地 APK 的清单与签名信息
+type ApkInfo struct {
+	File             string           `json:"file"`
+	Size             int64            `json:"size"`
+	Package          string           `json:"package"`
+	Label            string           `json:"label"`
+	VersionCode      int64            `json:"versionCode"`
+	VersionName      string           `json:"versionName"`
+	MinSdkVersion    int              `json:"minSdkVersion"`
+	TargetSdkVersion int              `json:"targetSdkVersion"`
+	Debuggable       bool             `json:"debuggable"`
+	TestOnly         bool             `json:"testOnly"`
+	Split            string           `json:"split"` // 拆分包名称，基础包为空
+	Permissions      []string         `json:"permissions"`
+	Activities       []ApkComponent   `json:"activities"`
+	Abis             []string         `json:"abis"`
+	SignatureSchemes []string         `json:"signatureSchemes"`
+	Certificates     []ApkCertificate `json:"certificates"`
+}
+
+// ParseApk 解析本地 APK 的 AndroidManifest.xml、resources.arsc 与签名
+func ParseApk(file string) (ApkInfo, error) {
+	info := ApkInfo{File: file, Permissions: []string{}, Activities: []ApkComponent{}, Abis: []string{}, SignatureSchemes: []string{}, Certificates: []ApkCertificate{}}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return info, fmt.Errorf("打开 APK 失败: %w", err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return info, err
+	}
+	info.Size = stat.Size()
+	zr, err := zip.NewReader(f, stat.Size())
+	if err != nil {
+		return info, fmt.Errorf("不是有效的 APK: %w", err)
+	}
+
+	manifestData, err := readZipEntry(zr, "AndroidManifest.xml")
+	if err != nil {
+		return info, err
+	}
+	manifest, err := parseBinaryXML(manifestData)
+	if err != nil {
+		return info, fmt.Errorf("解析 AndroidManifest.xml 失败: %w", err)
+	}
+
+	// 资源表缺失或损坏时只影响 label/versionName 等引用的解析
+	var table *resTable
+	if arsc, err := readZipEntry(zr, "resources.arsc"); err == nil {
+		table, _ = parseResourceTable(arsc)
+	}
+	fillManifestInfo(&info, manifest, table)
+
+	abis := map[string]bool{}
+	for _, entry := range zr.File {
+		if dir := path.Dir(entry.Name); strings.HasPrefix(entry.Name, "lib/") && strings.Count(dir, "/") == 1 {
+			abis[path.Base(dir)] = true
+		}
+	}
+	for abi := range abis {
+		info.Abis = append(info.Abis, abi)
+	}
+	sort.Strings(info.Abis)
+
+	certs, schemes, err := readApkCertificates(file, zr)
+	if err != nil {
+		return info, fmt.Errorf("读取签名失败: %w", err)
+	}
+	info.Certificates = certs
+	if schemes != nil {
+		info.SignatureSchemes = schemes
+	}
+	return info, nil
+}
+
+func fillManifestInfo(info *ApkInfo, manifest *xmlNode, table *resTable) {
+	value := func(n *xmlNode, name string) string {
+		if ref := n.attrRef(name); ref != 0 && table != nil {
+			return table.resolve(ref)
+		}
+		return n.attr(name)
+	}
+
+	info.Package = manifest.attr("package")
+	info.Split = manifest.attr("split")
+	info.VersionName = value(manifest, "versionName")
+	info.VersionCode, _ = strconv.ParseInt(value(manifest, "versionCode"), 10, 64)
+	// versionCodeMajor 是 versionCode 的高 32 位
+	if major, err := strconv.ParseInt(manifest.attr("versionCodeMajor"), 10, 64); err == nil && major > 0 {
+		info.VersionCode |= major << 32
+	}
+
+	for _, sdk := range manifest.children("uses-sdk") {
+		info.MinSdkVersion, _ = strconv.Atoi(value(sdk, "minSdkVersion"))
+		info.TargetSdkVersion, _ = strconv.Atoi(value(sdk, "targetSdkVersion"))
+	}
+	// 未声明 minSdkVersion 时默认为 1，targetSdkVersion 默认等于 minSdkVersion
+	if info.MinSdkVersion == 0 {
+		info.MinSdkVersion = 1
+	}
+	if info.TargetSdkVersion == 0 {
+		info.TargetSdkVersion = info.MinSdkVersion
+	}
+
+	for _, tag := range []string{"uses-permission", "uses-permission-sdk-23", "uses-permission-sdk-m"} {
+		for _, p := range manifest.children(tag) {
+			if name := p.attr("name"); name != "" {
+				info.Permissions = append(info.Permissions, name)
+			}
+		}
+	}
+
+	for _, app := range manifest.children("application") {
+		info.Label = value(app, "label")
+		info.Debuggable = app.attr("debuggable") == "true"
+		info.TestOnly = app.attr("testOnly") == "true"
+		for _, tag := range []string{"activity", "activity-alias"} {
+			for _, activity := range app.children(tag) {
+				info.Activities = append(info.Activities, newApkComponent(info.Package, activity))
+			}
+		}
+	}
+}
+
+// componentClassName 把清单中 . 开头或不带包名的类名补全为完整类名
+func componentClassName(pkg string, name string) string {
+	if strings.HasPrefix(name, ".") {
+		return pkg + name
+	} else if !strings.Contains(name, ".") && name != "" {
+		return pkg + "." + name
+	}
+	return name
+}
+
+func newApkComponent(pkg string, node *xmlNode) ApkComponent {
+	component := ApkComponent{Name: componentClassName(pkg, node.attr("name"))}
+
+	hasFilter := false
+	for _, filter := range node.children("intent-filter") {
+		hasFilter = true
+		var main, launcher bool
+		for _, action := range filter.children("action") {
+			main = main || action.attr("name") == "android.intent.action.MAIN"
+		}
+		for _, category := range filter.children("category") {
+			launcher = launcher || category.attr("name") == "android.intent.category.LAUNCHER"
+		}
+		component.Launcher = component.Launcher || (main && launcher)
+	}
+	// 未声明 exported 时，有 intent-filter 的组件默认导出（Android 12 起必须显式声明）
+	if exported := node.attr("exported"); exported != "" {
+		component.Exported = exported == "true"
+	} else {
+		component.Exported = hasFilter
+	}
+	return component
+}
+
+func readZipEntry(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("APK 中缺少 %s", name)
+}
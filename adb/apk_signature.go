@@ -0,0 +1,273 @@
+package adb
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// APK 签名方案
+const (
+	ApkSchemeV1 = "v1"
+	ApkSchemeV2 = "v2"
+	ApkSchemeV3 = "v3"
+)
+
+// APK Signing Block 中各签名方案的 id
+const (
+	apkSigBlockV2  = 0x7109871a
+	apkSigBlockV3  = 0xf05368c0
+	apkSigBlockV31 = 0x1b93ad61
+)
+
+const apkSigBlockMagic = "APK Sig Block 42"
+
+// ApkCertificate 签名证书摘要，摘要为不带分隔符的小写十六进制
+type ApkCertificate struct {
+	Subject   string `json:"subject"`
+	Issuer    string `json:"issuer"`
+	NotBefore int64  `json:"notBefore"` // unix 秒
+	NotAfter  int64  `json:"notAfter"`
+	Sha256    string `json:"sha256"`
+	Sha1      string `json:"sha1"`
+}
+
+// readApkCertificates 读取签名证书，优先使用 v3/v2 签名块，没有时读取 v1 的 META-INF 签名
+func readApkCertificates(file string, zr *zip.Reader) ([]ApkCertificate, []string, error) {
+	var schemes []string
+	var ders [][]byte
+
+	blocks, err := readApkSigningBlock(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range []uint32{apkSigBlockV31, apkSigBlockV3, apkSigBlockV2} {
+		value, ok := blocks[id]
+		if !ok {
+			continue
+		}
+		if id == apkSigBlockV2 {
+			schemes = append(schemes, ApkSchemeV2)
+		} else if !slices.Contains(schemes, ApkSchemeV3) {
+			schemes = append(schemes, ApkSchemeV3)
+		}
+		if len(ders) == 0 {
+			ders, err = parseSchemeCertificates(value, id != apkSigBlockV2)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	v1, err := readV1Certificates(zr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(v1) > 0 {
+		schemes = append([]string{ApkSchemeV1}, schemes...)
+		if len(ders) == 0 {
+			ders = v1
+		}
+	}
+
+	certs := make([]ApkCertificate, 0, len(ders))
+	for _, der := range ders {
+		certs = append(certs, newApkCertificate(der))
+	}
+	return certs, schemes, nil
+}
+
+func newApkCertificate(der []byte) ApkCertificate {
+	s256 := sha256.Sum256(der)
+	s1 := sha1.Sum(der)
+	cert := ApkCertificate{Sha256: hex.EncodeToString(s256[:]), Sha1: hex.EncodeToString(s1[:])}
+	if parsed, err := x509.ParseCertificate(der); err == nil {
+		cert.Subject = parsed.Subject.String()
+		cert.Issuer = parsed.Issuer.String()
+		cert.NotBefore = parsed.NotBefore.Unix()
+		cert.NotAfter = parsed.NotAfter.Unix()
+	}
+	return cert
+}
+
+// readApkSigningBlock 定位中央目录之前的 APK Signing Block，返回 id 到内容的映射，没有签名块时返回空映射
+func readApkSigningBlock(file string) (map[uint32][]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// End of Central Directory 至少 22 字节，末尾最多带 65535 字节注释
+	tailSize := int64(22 + 65535)
+	if tailSize > stat.Size() {
+		tailSize = stat.Size()
+	}
+	tail := make([]byte, tailSize)
+	if _, err := f.ReadAt(tail, stat.Size()-tailSize); err != nil {
+		return nil, err
+	}
+	eocd := bytes.LastIndex(tail, []byte{0x50, 0x4b, 0x05, 0x06})
+	if eocd < 0 || eocd+22 > len(tail) {
+		return nil, fmt.Errorf("找不到 ZIP 中央目录")
+	}
+	cdOffset := int64(binary.LittleEndian.Uint32(tail[eocd+16:]))
+
+	blocks := make(map[uint32][]byte)
+	if cdOffset < 32 {
+		return blocks, nil
+	}
+	footer := make([]byte, 24)
+	if _, err := f.ReadAt(footer, cdOffset-24); err != nil {
+		return nil, err
+	}
+	if string(footer[8:]) != apkSigBlockMagic {
+		return blocks, nil
+	}
+	blockSize := int64(binary.LittleEndian.Uint64(footer))
+	start := cdOffset - blockSize - 8
+	if blockSize < 24 || start < 0 {
+		return nil, fmt.Errorf("签名块长度无效")
+	}
+	block := make([]byte, blockSize-24)
+	if _, err := f.ReadAt(block, start+8); err != nil {
+		return nil, err
+	}
+
+	for p := 0; p+12 <= len(block); {
+		pairSize := int(binary.LittleEndian.Uint64(block[p:]))
+		if pairSize < 4 || p+8+pairSize > len(block) {
+			break
+		}
+		id := binary.LittleEndian.Uint32(block[p+8:])
+		blocks[id] = block[p+12 : p+8+pairSize]
+		p += 8 + pairSize
+	}
+	return blocks, nil
+}
+
+// lengthPrefixed 读取 uint32 长度前缀的数据，返回数据与剩余部分
+func lengthPrefixed(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("签名数据长度不足")
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if 4+n > len(b) {
+		return nil, nil, fmt.Errorf("签名数据越界")
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
+
+// parseSchemeCertificates 从 v2/v3 签名块中取每个签名者的第一个证书
+func parseSchemeCertificates(value []byte, v3 bool) ([][]byte, error) {
+	signers, _, err := lengthPrefixed(value)
+	if err != nil {
+		return nil, err
+	}
+	var ders [][]byte
+	for len(signers) > 0 {
+		var signer []byte
+		signer, signers, err = lengthPrefixed(signers)
+		if err != nil {
+			return nil, err
+		}
+		signedData, _, err := lengthPrefixed(signer)
+		if err != nil {
+			return nil, err
+		}
+		// signed data 依次是 digests、certificates，v3 之后还有 min/max sdk 与属性
+		_, rest, err := lengthPrefixed(signedData)
+		if err != nil {
+			return nil, err
+		}
+		certs, _, err := lengthPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+		cert, _, err := lengthPrefixed(certs)
+		if err != nil {
+			return nil, err
+		}
+		ders = append(ders, cert)
+		// v3.1 与 v3 对同一密钥轮转链只取第一个签名者
+		if v3 {
+			break
+		}
+	}
+	return ders, nil
+}
+
+// pkcs7ContentInfo 与 pkcs7SignedData 只声明读取证书需要的字段
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// readV1Certificates 读取 META-INF 下 .RSA/.DSA/.EC 中的 PKCS#7 证书
+func readV1Certificates(zr *zip.Reader) ([][]byte, error) {
+	for _, f := range zr.File {
+		dir, name := path.Split(f.Name)
+		ext := strings.ToUpper(path.Ext(name))
+		if dir != "META-INF/" || (ext != ".RSA" && ext != ".DSA" && ext != ".EC") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var info pkcs7ContentInfo
+		if _, err := asn1.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", f.Name, err)
+		}
+		var signed pkcs7SignedData
+		if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", f.Name, err)
+		}
+		certs, err := x509.ParseCertificates(signed.Certificates.Bytes)
+		if err != nil || len(certs) == 0 {
+			return nil, fmt.Errorf("解析 %s 中的证书失败", f.Name)
+		}
+		// 证书链中只取签名者证书，通常是第一个
+		return [][]byte{certs[0].Raw}, nil
+	}
+	return nil, nil
+}
+
+// NormalizeCertDigest 去掉分隔符并转为小写，用于比较不同来源的证书摘要
+func NormalizeCertDigest(digest string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(digest) {
+		if (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
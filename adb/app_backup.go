@@ -0,0 +1,432 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 备份私有数据的方式
+const (
+	AppBackupTar = "tar"        // 以 run-as/root/su 身份打包 /data/data/<pkg>
+	AppBackupAdb = "adb-backup" // adb backup，需要在设备上确认，应用声明 allowBackup=false 时为空
+)
+
+// 备份包中的文件
+const (
+	appBackupMetaFile     = "backup.json"
+	appBackupDataFile     = "data.tar"
+	appBackupExternalFile = "external.tar"
+	appBackupAdbFile      = "backup.ab"
+)
+
+// appBackupSkipped 不备份的顶层目录：缓存可以重建，lib 是指向安装目录的链接
+var appBackupSkipped = []string{"cache", "code_cache", "lib"}
+
+// AppBackupOptions 备份内容
+type AppBackupOptions struct {
+	Private  bool `json:"private"`  // /data/data/<pkg>
+	External bool `json:"external"` // /sdcard/Android/data/<pkg>
+}
+
+// AppBackupMeta 备份包中的 backup.json
+type AppBackupMeta struct {
+	Version     int    `json:"version"`
+	Package     string `json:"package"`
+	VersionCode int64  `json:"versionCode"`
+	VersionName string `json:"versionName"`
+	Method      string `json:"method"`           // 见 AppBackup* 常量，没有私有数据时为空
+	Access      string `json:"access,omitempty"` // 打包时使用的身份，见 AccessMode* 常量
+	External    bool   `json:"external"`         // 是否包含外部数据
+	Device      string `json:"device"`           // 设备型号
+	Serial      string `json:"serial"`
+	Sdk         int    `json:"sdk"`
+	CreatedAt   int64  `json:"createdAt"` // unix 秒
+}
+
+// AppBackupResult 备份结果
+type AppBackupResult struct {
+	File     string        `json:"file"`
+	Meta     AppBackupMeta `json:"meta"`
+	Bytes    int64         `json:"bytes"`
+	Warnings []string      `json:"warnings"`
+}
+
+// AppBackupCheck 恢复前对备份与设备上已安装版本的检查，Errors 非空时不能恢复
+type AppBackupCheck struct {
+	File        string        `json:"file"`
+	Meta        AppBackupMeta `json:"meta"`
+	Installed   bool          `json:"installed"`
+	VersionCode int64         `json:"versionCode"` // 设备上已安装的 versionCode
+	Access      string        `json:"access"`      // 恢复私有数据将使用的身份
+	Downgrade   bool          `json:"downgrade"`   // 已安装版本低于备份时的版本，需要强制恢复
+	Errors      []string      `json:"errors"`
+	Warnings    []string      `json:"warnings"`
+}
+
+// installedVersion 从 dumpsys package 读取已安装版本，未安装时 code 为 0
+func installedVersion(param ExecuteParams, packageName string) (int64, string) {
+	dump, _ := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys package "+packageName), true, nil)
+	var code int64
+	var name string
+	if m := reDumpVersionCode.FindStringSubmatch(dump); m != nil {
+		code, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+	if m := reDumpVersionName.FindStringSubmatch(dump); m != nil {
+		name = m[1]
+	}
+	return code, name
+}
+
+// BackupAppData 备份应用数据到本地 zip：能以应用身份访问时打包私有目录，否则退回 adb backup
+// 外部数据以 shell 身份打包，Android 11 起部分系统不允许读取，失败时只记录警告
+func BackupAppData(param ExecuteParams, packageName string, options AppBackupOptions, output string) (AppBackupResult, error) {
+	result := AppBackupResult{File: output, Warnings: []string{}}
+	if !IsValidPackageName(packageName) {
+		return result, fmt.Errorf("无效的包名: %s", packageName)
+	}
+	if !options.Private && !options.External {
+		return result, fmt.Errorf("请选择要备份的内容")
+	}
+	code, name := installedVersion(param, packageName)
+	if code == 0 {
+		return result, fmt.Errorf("应用 %s 未安装", packageName)
+	}
+
+	meta := AppBackupMeta{
+		Version:     1,
+		Package:     packageName,
+		VersionCode: code,
+		VersionName: name,
+		Serial:      param.DeviceId,
+		CreatedAt:   time.Now().Unix(),
+	}
+	model, _ := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "getprop ro.product.model"), true, nil)
+	meta.Device = strings.TrimSpace(model)
+	if spec, err := GetDeviceSpec(param); err == nil {
+		meta.Sdk = spec.Sdk
+	}
+
+	workDir, err := os.MkdirTemp("", "adbtool-backup-")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(workDir)
+	staging, err := PrepareUploadStaging(param)
+	if err != nil {
+		return result, err
+	}
+	defer RemoveStaging(param, staging)
+
+	if options.Private {
+		if access, err := ResolvePackageAccess(param, packageName); err == nil {
+			meta.Method, meta.Access = AppBackupTar, access.Mode
+			remote := joinRemotePath(staging, appBackupDataFile)
+			tarCmd := fmt.Sprintf("cd %s && tar -cf - %s .", deviceQuote(access.DataDir), appBackupExcludeArgs())
+			if err := stageAndPull(param, fmt.Sprintf("%s > %s", access.wrap(tarCmd), deviceQuote(remote)), remote, filepath.Join(workDir, appBackupDataFile)); err != nil {
+				return result, fmt.Errorf("打包私有数据失败: %w", err)
+			}
+		} else {
+			meta.Method = AppBackupAdb
+			local := filepath.Join(workDir, appBackupAdbFile)
+			backupCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("backup -f %s -noapk %s", quoteHostArg(local), packageName))
+			if res, err := util.Exec(backupCmd, true, nil); err != nil {
+				return result, fmt.Errorf("adb backup 失败: %v, 输出: %s", err, strings.TrimSpace(res))
+			}
+			// 应用禁止备份或用户在设备上拒绝时只有 24 字节左右的文件头
+			if info, err := os.Stat(local); err != nil || info.Size() <= 64 {
+				result.Warnings = append(result.Warnings, "adb backup 没有得到数据：应用可能声明了 allowBackup=false，或未在设备上确认")
+			}
+		}
+	}
+
+	if options.External {
+		remoteDir := "/sdcard/Android/data/" + packageName
+		remote := joinRemotePath(staging, appBackupExternalFile)
+		script := fmt.Sprintf("tar -cf %s -C /sdcard/Android/data %s", deviceQuote(remote), packageName)
+		if !remoteDirExists(param, remoteDir) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s 不存在，已跳过", remoteDir))
+		} else if err := stageAndPull(param, script, remote, filepath.Join(workDir, appBackupExternalFile)); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("外部数据备份失败: %v", err))
+		} else {
+			meta.External = true
+		}
+	}
+
+	if err := writeAppBackupArchive(workDir, meta, output); err != nil {
+		_ = os.Remove(output)
+		return result, fmt.Errorf("写入备份文件失败: %w", err)
+	}
+	if info, err := os.Stat(output); err == nil {
+		result.Bytes = info.Size()
+	}
+	result.Meta = meta
+	return result, nil
+}
+
+// appBackupExcludeArgs 打包数据目录 . 时排除的顶层目录，文件名含空格或目录为空时都不受影响
+func appBackupExcludeArgs() string {
+	parts := make([]string, 0, len(appBackupSkipped))
+	for _, name := range appBackupSkipped {
+		parts = append(parts, "--exclude=./"+name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// appBackupClearCmd 恢复前清空数据目录的顶层条目，保留 appBackupSkipped，需在数据目录下执行
+func appBackupClearCmd() string {
+	parts := []string{"find . -mindepth 1 -maxdepth 1"}
+	for _, name := range appBackupSkipped {
+		parts = append(parts, "! -name "+name)
+	}
+	return strings.Join(append(parts, "-exec rm -rf {} +"), " ")
+}
+
+// stageAndPull 在设备上执行生成 remote 的脚本，再拉取到本地
+func stageAndPull(param ExecuteParams, script string, remote string, local string) error {
+	if res := execCmd(buildRemoteScriptCmd(param, script)); res.Error != "" {
+		return fmt.Errorf("%s", res.Error)
+	} else if strings.Contains(res.Res, "Permission denied") || strings.Contains(res.Res, "No such file") {
+		return fmt.Errorf("%s", res.Res)
+	}
+	pullCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", quoteHostArg(remote), quoteHostArg(local)))
+	if res, err := util.Exec(pullCmd, false, nil); err != nil {
+		return fmt.Errorf("拉取失败: %v, 输出: %s", err, res)
+	}
+	return nil
+}
+
+func writeAppBackupArchive(workDir string, meta AppBackupMeta, output string) error {
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+
+	w, err := zw.Create(appBackupMetaFile)
+	if err == nil {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(meta)
+	}
+	for _, name := range []string{appBackupDataFile, appBackupExternalFile, appBackupAdbFile} {
+		if err != nil {
+			break
+		}
+		err = addFileToZip(zw, filepath.Join(workDir, name), name)
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// addFileToZip 本地文件不存在时跳过
+func addFileToZip(zw *zip.Writer, local string, name string) error {
+	f, err := os.Open(local)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// ReadAppBackupMeta 读取备份包中的 backup.json
+func ReadAppBackupMeta(file string) (AppBackupMeta, error) {
+	var meta AppBackupMeta
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return meta, fmt.Errorf("不是有效的备份文件: %w", err)
+	}
+	defer zr.Close()
+	data, err := readZipEntry(&zr.Reader, appBackupMetaFile)
+	if err != nil {
+		return meta, fmt.Errorf("备份文件缺少 %s", appBackupMetaFile)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("解析 %s 失败: %w", appBackupMetaFile, err)
+	}
+	if !IsValidPackageName(meta.Package) {
+		return meta, fmt.Errorf("备份中的包名无效: %s", meta.Package)
+	}
+	return meta, nil
+}
+
+// CheckAppBackup 检查备份能否恢复到设备：应用需已安装，且版本不低于备份时的版本
+// 新版本的数据库与配置在旧版本上通常无法降级读取，因此 Downgrade 时只在强制恢复时允许
+func CheckAppBackup(param ExecuteParams, file string) (AppBackupCheck, error) {
+	check := AppBackupCheck{File: file, Errors: []string{}, Warnings: []string{}}
+	meta, err := ReadAppBackupMeta(file)
+	if err != nil {
+		return check, err
+	}
+	check.Meta = meta
+
+	installed, _ := installedVersion(param, meta.Package)
+	checkBackupVersion(&check, installed)
+	if !check.Installed {
+		return check, nil
+	}
+
+	if meta.Method == AppBackupTar {
+		if access, err := ResolvePackageAccess(param, meta.Package); err != nil {
+			check.Errors = append(check.Errors, err.Error())
+		} else {
+			check.Access = access.Mode
+		}
+	}
+	if meta.Method == AppBackupAdb {
+		check.Warnings = append(check.Warnings, "该备份通过 adb backup 生成，恢复时需要在设备上确认")
+	}
+	if meta.Serial != param.DeviceId && meta.Device != "" {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("备份来自其他设备 %s，设备绑定的登录态或密钥可能失效", meta.Device))
+	}
+	return check, nil
+}
+
+// checkBackupVersion 按设备上已安装的 versionCode 填写 Installed、Downgrade 与对应的错误和警告，installed 为 0 表示未安装
+func checkBackupVersion(check *AppBackupCheck, installed int64) {
+	meta := check.Meta
+	check.VersionCode = installed
+	check.Installed = installed > 0
+	switch {
+	case !check.Installed:
+		check.Errors = append(check.Errors, fmt.Sprintf("设备上未安装 %s，请先安装应用", meta.Package))
+	case installed < meta.VersionCode:
+		check.Downgrade = true
+		check.Warnings = append(check.Warnings, fmt.Sprintf("已安装 versionCode %d 低于备份时的 %d，恢复后应用可能无法读取数据", installed, meta.VersionCode))
+	case installed > meta.VersionCode:
+		check.Warnings = append(check.Warnings, fmt.Sprintf("已安装 versionCode %d 高于备份时的 %d，应用启动时会按升级处理数据", installed, meta.VersionCode))
+	}
+}
+
+// RestoreAppData 停止应用后用备份替换私有数据与外部数据，force 时允许恢复到低版本
+// root/su 解包后按数据目录的属主修正 uid 并恢复 SELinux 上下文；run-as 写入的文件本就属于应用
+func RestoreAppData(param ExecuteParams, file string, force bool) (AppBackupCheck, error) {
+	check, err := CheckAppBackup(param, file)
+	if err != nil {
+		return check, err
+	}
+	if len(check.Errors) > 0 {
+		return check, fmt.Errorf("%s", strings.Join(check.Errors, "; "))
+	}
+	if check.Downgrade && !force {
+		return check, fmt.Errorf("已安装 versionCode %d 低于备份时的 %d，需要强制恢复", check.VersionCode, check.Meta.VersionCode)
+	}
+	meta := check.Meta
+
+	killParam := param
+	killParam.PackageName = meta.Package
+	if res := KillApp(killParam); res.Error != "" {
+		return check, fmt.Errorf("停止应用失败: %s", res.Error)
+	}
+
+	workDir, err := os.MkdirTemp("", "adbtool-restore-")
+	if err != nil {
+		return check, err
+	}
+	defer os.RemoveAll(workDir)
+	if err := extractZipFiles(file, workDir, appBackupDataFile, appBackupExternalFile, appBackupAdbFile); err != nil {
+		return check, fmt.Errorf("读取备份文件失败: %w", err)
+	}
+	staging, err := PrepareUploadStaging(param)
+	if err != nil {
+		return check, err
+	}
+	defer RemoveStaging(param, staging)
+
+	switch meta.Method {
+	case AppBackupTar:
+		access, err := ResolvePackageAccess(param, meta.Package)
+		if err != nil {
+			return check, err
+		}
+		remote, err := pushToStaging(param, staging, filepath.Join(workDir, appBackupDataFile))
+		if err != nil {
+			return check, err
+		}
+		dataDir := deviceQuote(access.DataDir)
+		clear := fmt.Sprintf("cd %s && %s", dataDir, appBackupClearCmd())
+		untar := fmt.Sprintf("tar -xf - -C %s", dataDir)
+		steps := []string{
+			access.wrap(clear),
+			fmt.Sprintf("cat %s | %s", deviceQuote(remote), access.wrap(untar)),
+		}
+		if access.Mode == AccessModeRoot || access.Mode == AccessModeSu {
+			fix := fmt.Sprintf("chown -R $(stat -c %%u:%%g %s) %s && (restorecon -R %s >/dev/null 2>&1; true)", dataDir, dataDir, dataDir)
+			steps = append(steps, access.wrap(fix))
+		}
+		if res := execSilent(buildRemoteScriptCmd(param, strings.Join(steps, " && ")), ""); res.Error != "" {
+			return check, fmt.Errorf("恢复私有数据失败: %s", res.Error)
+		}
+	case AppBackupAdb:
+		restoreCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("restore %s", quoteHostArg(filepath.Join(workDir, appBackupAdbFile))))
+		if res, err := util.Exec(restoreCmd, true, nil); err != nil {
+			return check, fmt.Errorf("adb restore 失败: %v, 输出: %s", err, strings.TrimSpace(res))
+		}
+	}
+
+	if meta.External {
+		remote, err := pushToStaging(param, staging, filepath.Join(workDir, appBackupExternalFile))
+		if err == nil {
+			target := deviceQuote("/sdcard/Android/data/" + meta.Package)
+			script := fmt.Sprintf("rm -rf %s && tar -xf %s -C /sdcard/Android/data", target, deviceQuote(remote))
+			if res := execSilent(buildRemoteScriptCmd(param, script), ""); res.Error != "" {
+				err = fmt.Errorf("%s", res.Error)
+			}
+		}
+		if err != nil {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("外部数据恢复失败: %v", err))
+		}
+	}
+	return check, nil
+}
+
+func pushToStaging(param ExecuteParams, staging string, local string) (string, error) {
+	remote := joinRemotePath(staging, filepath.Base(local))
+	pushCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("push %s %s", quoteHostArg(local), quoteHostArg(remote)))
+	if res, err := util.Exec(pushCmd, false, nil); err != nil {
+		return "", fmt.Errorf("推送 %s 失败: %v, 输出: %s", filepath.Base(local), err, res)
+	}
+	return remote, nil
+}
+
+// extractZipFiles 把 zip 中指定名称的顶层文件解压到 dir，不存在的跳过
+func extractZipFiles(file string, dir string, names ...string) error {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		wanted := false
+		for _, name := range names {
+			wanted = wanted || f.Name == name
+		}
+		if !wanted {
+			continue
+		}
+		if err := extractZipFile(f, filepath.Join(dir, f.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package adb
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppBackupCommands(t *testing.T) {
+	// 打包 . 而不是展开文件列表：文件名含空格或目录为空时 tar 的参数都不会出错
+	if got, want := appBackupExcludeArgs(), "--exclude=./cache --exclude=./code_cache --exclude=./lib"; got != want {
+		t.Errorf("exclude args = %q, want %q", got, want)
+	}
+	if got, want := appBackupClearCmd(), "find . -mindepth 1 -maxdepth 1 ! -name cache ! -name code_cache ! -name lib -exec rm -rf {} +"; got != want {
+		t.Errorf("clear cmd = %q, want %q", got, want)
+	}
+}
+
+func TestReadAppBackupMeta(t *testing.T) {
+	dir := t.TempDir()
+	workDir := filepath.Join(dir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, appBackupDataFile), []byte("tar data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := AppBackupMeta{Version: 1, Package: "com.example.app", VersionCode: 120, VersionName: "1.2.0",
+		Method: AppBackupTar, Access: AccessModeRunAs, Device: "Pixel 7", Serial: "emulator-5554", Sdk: 34, CreatedAt: 1714531200}
+	output := filepath.Join(dir, "backup.zip")
+	if err := writeAppBackupArchive(workDir, meta, output); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadAppBackupMeta(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != meta {
+		t.Errorf("meta = %+v, want %+v", got, meta)
+	}
+
+	// 只写入存在的数据文件
+	zr, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || names[0] != appBackupMetaFile || names[1] != appBackupDataFile {
+		t.Errorf("archive entries = %q", names)
+	}
+}
+
+func TestReadAppBackupMetaInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeZip := func(name string, entries map[string]string) string {
+		p := filepath.Join(dir, name)
+		f, err := os.Create(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(f)
+		for entry, content := range entries {
+			w, _ := zw.Create(entry)
+			w.Write([]byte(content))
+		}
+		zw.Close()
+		f.Close()
+		return p
+	}
+	notZip := filepath.Join(dir, "plain.txt")
+	os.WriteFile(notZip, []byte("not a zip"), 0644)
+
+	tests := map[string]string{
+		"not a zip":       notZip,
+		"missing meta":    writeZip("no-meta.zip", map[string]string{appBackupDataFile: "x"}),
+		"malformed meta":  writeZip("bad-json.zip", map[string]string{appBackupMetaFile: "{"}),
+		"invalid package": writeZip("bad-pkg.zip", map[string]string{appBackupMetaFile: `{"package":"com.example; reboot"}`}),
+	}
+	for name, file := range tests {
+		if _, err := ReadAppBackupMeta(file); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCheckBackupVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed int64
+		ok        bool // 没有错误，可以恢复
+		downgrade bool
+		warnings  int
+	}{
+		{"not installed", 0, false, false, 0},
+		{"same version", 120, true, false, 0},
+		// 新版本的数据在旧版本上可能无法读取，需要强制恢复
+		{"downgrade", 110, true, true, 1},
+		{"upgrade", 130, true, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := AppBackupCheck{Meta: AppBackupMeta{Package: "com.example.app", VersionCode: 120}}
+			checkBackupVersion(&check, tt.installed)
+			if check.Installed != (tt.installed > 0) || check.VersionCode != tt.installed {
+				t.Errorf("installed = %v code = %d", check.Installed, check.VersionCode)
+			}
+			if (len(check.Errors) == 0) != tt.ok || check.Downgrade != tt.downgrade || len(check.Warnings) != tt.warnings {
+				t.Errorf("check = %+v", check)
+			}
+		})
+	}
+}
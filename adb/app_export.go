@@ -0,0 +1,287 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 导出格式，内容相同，只是扩展名不同
+const (
+	AppExportApks = "apks"
+	AppExportZip  = "zip"
+)
+
+const appExportWorkDirPrefix = "adbtool-export-"
+
+var (
+	reDumpVersionCode = regexp.MustCompile(`versionCode=(\d+)(?:\s+minSdk=(\d+))?(?:\s+targetSdk=(\d+))?`)
+	reDumpVersionName = regexp.MustCompile(`versionName=(\S+)`)
+	reUnsafeFileChars = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+)
+
+// AppExportOptions 导出应用时附带的内容
+type AppExportOptions struct {
+	IncludeObb  bool   `json:"includeObb"`  // /sdcard/Android/obb/<pkg>
+	IncludeData bool   `json:"includeData"` // /sdcard/Android/data/<pkg>
+	Format      string `json:"format"`      // apks 或 zip
+}
+
+// AppExportSplit 与 AppExportExpansion 沿用 XAPK manifest.json 的字段，导出的文件可以直接用拆分包安装重新安装
+type AppExportSplit struct {
+	File string `json:"file"`
+	Id   string `json:"id"`
+}
+
+type AppExportExpansion struct {
+	File            string `json:"file"`
+	InstallLocation string `json:"install_location"`
+	InstallPath     string `json:"install_path"`
+}
+
+// AppExportManifest 导出包中的 manifest.json
+type AppExportManifest struct {
+	XapkVersion      int                  `json:"xapk_version"`
+	PackageName      string               `json:"package_name"`
+	VersionCode      string               `json:"version_code"`
+	VersionName      string               `json:"version_name"`
+	MinSdkVersion    string               `json:"min_sdk_version,omitempty"`
+	TargetSdkVersion string               `json:"target_sdk_version,omitempty"`
+	SplitApks        []AppExportSplit     `json:"split_apks"`
+	Expansions       []AppExportExpansion `json:"expansions"`
+	DataDir          string               `json:"data_dir,omitempty"` // 包内的外部数据目录
+	DataFiles        int                  `json:"data_files"`
+	Device           string               `json:"device"`
+	ExportedAt       int64                `json:"exported_at"` // unix 秒
+}
+
+// AppExportResult 单个应用的导出结果
+type AppExportResult struct {
+	Package   string   `json:"package"`
+	File      string   `json:"file"`
+	Status    string   `json:"status"` // 沿用 Transfer* 状态
+	Splits    int      `json:"splits"`
+	Obbs      int      `json:"obbs"`
+	DataFiles int      `json:"dataFiles"`
+	Bytes     int64    `json:"bytes"`
+	Errors    []string `json:"errors"`
+}
+
+// ListPackageApks 返回 pm path 列出的全部 APK 路径，拆分安装的应用会有多个
+func ListPackageApks(param ExecuteParams, packageName string) ([]string, error) {
+	res, err := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "pm path"+param.userArg()+" "+packageName), true, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range util.MultiLine(res) {
+		if p, ok := strings.CutPrefix(strings.TrimSpace(line), "package:"); ok && p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("应用 %s 未安装", packageName)
+	}
+	return paths, nil
+}
+
+// ExportAppBundle 导出应用的全部 APK，可选附带 OBB 与外部数据目录，打包为带 manifest.json 的 .apks/.zip
+// 文件先下载到临时目录再打包，完成或取消后删除临时目录，取消时不生成导出文件
+func ExportAppBundle(ctx context.Context, param ExecuteParams, taskId string, packageName string, outDir string, options AppExportOptions, onProgress func(TransferProgress)) AppExportResult {
+	result := AppExportResult{Package: packageName, Status: TransferFailed, Errors: []string{}}
+	fail := func(err error) AppExportResult {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	if !IsValidPackageName(packageName) {
+		return fail(fmt.Errorf("无效的包名: %s", packageName))
+	}
+
+	apks, err := ListPackageApks(param, packageName)
+	if err != nil {
+		return fail(err)
+	}
+	workDir, err := os.MkdirTemp("", appExportWorkDirPrefix)
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	manifest := newAppExportManifest(param, packageName)
+	plan := TransferPlan{Direction: TransferDownload}
+
+	splits, err := PlanDownload(param, apks, filepath.Join(workDir, "splits"), ConflictOverwrite)
+	if err != nil {
+		return fail(err)
+	}
+	plan.Files = append(plan.Files, splits.Files...)
+	for _, f := range splits.Files {
+		name := filepath.Base(f.LocalPath)
+		id := strings.TrimPrefix(strings.TrimSuffix(name, ".apk"), "split_")
+		manifest.SplitApks = append(manifest.SplitApks, AppExportSplit{File: "splits/" + name, Id: id})
+	}
+	result.Splits = len(splits.Files)
+
+	extras := map[string]string{}
+	if options.IncludeObb {
+		extras["obb"] = "/sdcard/Android/obb/" + packageName
+	}
+	if options.IncludeData {
+		extras["data"] = "/sdcard/Android/data/" + packageName
+	}
+	for _, kind := range []string{"obb", "data"} {
+		remoteDir, ok := extras[kind]
+		if !ok {
+			continue
+		}
+		if !remoteDirExists(param, remoteDir) {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s 不存在，已跳过", remoteDir))
+			continue
+		}
+		extra, err := PlanDownload(param, []string{remoteDir}, filepath.Join(workDir, kind), ConflictOverwrite)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("读取 %s 失败: %v", remoteDir, err))
+			continue
+		}
+		plan.Files = append(plan.Files, extra.Files...)
+		if kind == "obb" {
+			for _, f := range extra.Files {
+				rel := strings.TrimPrefix(f.RemotePath, "/sdcard/")
+				manifest.Expansions = append(manifest.Expansions, AppExportExpansion{
+					File:            "obb/" + strings.TrimPrefix(f.RemotePath, "/sdcard/Android/obb/"),
+					InstallLocation: "EXTERNAL_STORAGE",
+					InstallPath:     rel,
+				})
+			}
+			result.Obbs = len(extra.Files)
+		} else {
+			manifest.DataDir = "data/" + packageName
+			manifest.DataFiles = len(extra.Files)
+			result.DataFiles = len(extra.Files)
+		}
+	}
+
+	transfer := RunTransfer(ctx, param, taskId, plan, onProgress)
+	result.Bytes = transfer.Bytes
+	result.Errors = append(result.Errors, transfer.Errors...)
+	if transfer.Status == TransferCancelled {
+		result.Status = TransferCancelled
+		return result
+	}
+	// 缺少任何一个 APK 都无法安装，不生成导出文件；OBB 与数据文件失败只记录错误
+	for _, f := range splits.Files {
+		for _, e := range transfer.Errors {
+			if strings.HasPrefix(e, f.LocalPath+":") {
+				result.Status = TransferFailed
+				return result
+			}
+		}
+	}
+
+	ext := options.Format
+	if ext != AppExportZip {
+		ext = AppExportApks
+	}
+	name := packageName
+	if manifest.VersionName != "" {
+		name += "-" + reUnsafeFileChars.ReplaceAllString(manifest.VersionName, "_")
+	}
+	output := uniqueName(filepath.Join(outDir, name+"."+ext), func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	})
+	if err := writeAppExportArchive(workDir, manifest, output); err != nil {
+		_ = os.Remove(output)
+		return fail(fmt.Errorf("打包失败: %w", err))
+	}
+	result.File = output
+	result.Status = TransferCompleted
+	return result
+}
+
+func newAppExportManifest(param ExecuteParams, packageName string) AppExportManifest {
+	manifest := AppExportManifest{
+		XapkVersion: 2,
+		PackageName: packageName,
+		SplitApks:   []AppExportSplit{},
+		Expansions:  []AppExportExpansion{},
+		ExportedAt:  time.Now().Unix(),
+	}
+	dump, _ := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys package "+packageName), true, nil)
+	if m := reDumpVersionCode.FindStringSubmatch(dump); m != nil {
+		manifest.VersionCode, manifest.MinSdkVersion, manifest.TargetSdkVersion = m[1], m[2], m[3]
+	}
+	if m := reDumpVersionName.FindStringSubmatch(dump); m != nil {
+		manifest.VersionName = m[1]
+	}
+	model, _ := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "getprop ro.product.model"), true, nil)
+	manifest.Device = strings.TrimSpace(model)
+	if manifest.Device == "" {
+		manifest.Device = param.DeviceId
+	}
+	return manifest
+}
+
+func remoteDirExists(param ExecuteParams, remoteDir string) bool {
+	res, _ := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId,
+		fmt.Sprintf("[ -d %s ] && echo yes", quoteRemotePath(remoteDir))), true, nil)
+	return strings.TrimSpace(res) == "yes"
+}
+
+// writeAppExportArchive 把工作目录与 manifest.json 打包，APK 与 OBB 本身已压缩，直接存储
+func writeAppExportArchive(workDir string, manifest AppExportManifest, output string) error {
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+
+	w, err := zw.Create("manifest.json")
+	if err == nil {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(manifest)
+	}
+	if err == nil {
+		err = filepath.Walk(workDir, func(p string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil || info.IsDir() {
+				return walkErr
+			}
+			rel, err := filepath.Rel(workDir, p)
+			if err != nil {
+				return err
+			}
+			header := &zip.FileHeader{Name: filepath.ToSlash(rel), Method: zip.Deflate, Modified: info.ModTime()}
+			if ext := strings.ToLower(path.Ext(header.Name)); ext == ".apk" || ext == ".obb" {
+				header.Method = zip.Store
+			}
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		})
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
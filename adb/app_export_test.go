@@ -0,0 +1,84 @@
+package adb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppExportArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	workDir := filepath.Join(dir, "work")
+	// 与 ExportApp 的工作目录结构一致
+	files := map[string]string{
+		"splits/base.apk":                                 "base apk",
+		"splits/split_config.arm64_v8a.apk":               "abi split",
+		"obb/com.example.app/main.12.com.example.app.obb": "obb data",
+		"data/com.example.app/files/settings.json":        "{}",
+	}
+	for name, content := range files {
+		p := filepath.Join(workDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest := AppExportManifest{
+		XapkVersion: 2,
+		PackageName: "com.example.app",
+		VersionCode: "12",
+		VersionName: "1.2",
+		SplitApks: []AppExportSplit{
+			{File: "splits/base.apk", Id: "base"},
+			{File: "splits/split_config.arm64_v8a.apk", Id: "config.arm64_v8a"},
+		},
+		Expansions: []AppExportExpansion{{
+			File:            "obb/com.example.app/main.12.com.example.app.obb",
+			InstallLocation: "EXTERNAL_STORAGE",
+			InstallPath:     "Android/obb/com.example.app/main.12.com.example.app.obb",
+		}},
+		DataDir:   "data/com.example.app",
+		DataFiles: 1,
+	}
+	output := filepath.Join(dir, "com.example.app-1.2.apks")
+	if err := writeAppExportArchive(workDir, manifest, output); err != nil {
+		t.Fatal(err)
+	}
+
+	// 导出的 .apks 带有 XAPK 格式的 manifest.json，按其中的 split_apks 与 expansions 安装
+	plan := InstallPlan{WorkDir: filepath.Join(dir, "install")}
+	if err := os.MkdirAll(plan.WorkDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := extractInstallArchive(output, InstallFormatApks, &plan); err != nil {
+		t.Fatal(err)
+	}
+	if plan.Package != "com.example.app" {
+		t.Errorf("package = %q", plan.Package)
+	}
+	if len(plan.Splits) != 2 {
+		t.Fatalf("splits = %+v", plan.Splits)
+	}
+	for i, want := range []struct{ name, content string }{{"base.apk", "base apk"}, {"split_config.arm64_v8a.apk", "abi split"}} {
+		split := plan.Splits[i]
+		data, err := os.ReadFile(split.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if split.Name != want.name || string(data) != want.content || split.Size != int64(len(want.content)) {
+			t.Errorf("split %d = %+v content %q", i, split, data)
+		}
+	}
+	if len(plan.Obbs) != 1 {
+		t.Fatalf("obbs = %+v", plan.Obbs)
+	}
+	obb := plan.Obbs[0]
+	if obb.RemotePath != "/sdcard/Android/obb/com.example.app/main.12.com.example.app.obb" || obb.Size != int64(len("obb data")) {
+		t.Errorf("obb = %+v", obb)
+	}
+	if data, err := os.ReadFile(obb.File); err != nil || string(data) != "obb data" {
+		t.Errorf("obb content = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,143 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"adb-tool-wails/util"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PackageSize 应用存储占用（字节），DataSize 与 StorageStats.dataBytes 一致，包含缓存
+type PackageSize struct {
+	AppSize   int64 `json:"appSize"`
+	DataSize  int64 `json:"dataSize"`
+	CacheSize int64 `json:"cacheSize"`
+}
+
+// GetPackageSizes 通过 dumpsys diskstats 获取所有应用的存储占用
+// diskstats 的数据由系统定期采集，不是实时值，只作为 StorageStatsManager 不可用时的回退
+func GetPackageSizes(param ExecuteParams) (map[string]PackageSize, error) {
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys diskstats")
+	res, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiskStatsPackageSizes(res)
+}
+
+// parseDiskStatsPackageSizes 解析 dumpsys diskstats 中的应用大小列表
+// 格式示例:
+//
+//	Package Names: ["com.android.chrome","com.example"]
+//	App Sizes: [123456,7890]
+//	App Data Sizes: [2345,678]
+//	Cache Sizes: [100,20]
+func parseDiskStatsPackageSizes(content string) (map[string]PackageSize, error) {
+	var names []string
+	var appSizes, dataSizes, cacheSizes []int64
+
+	for _, line := range util.MultiLine(content) {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "Package Names":
+			err = json.Unmarshal([]byte(value), &names)
+		case "App Sizes":
+			appSizes, err = parseDiskStatsSizeList(value)
+		case "App Data Sizes":
+			dataSizes, err = parseDiskStatsSizeList(value)
+		case "Cache Sizes":
+			cacheSizes, err = parseDiskStatsSizeList(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s failed: %w", key, err)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("diskstats 中没有应用大小信息")
+	}
+
+	sizeAt := func(list []int64, i int) int64 {
+		if i < len(list) {
+			return list[i]
+		}
+		return 0
+	}
+
+	result := make(map[string]PackageSize, len(names))
+	for i, name := range names {
+		cacheSize := sizeAt(cacheSizes, i)
+		result[name] = PackageSize{
+			AppSize: sizeAt(appSizes, i),
+			// diskstats 的 App Data Sizes 不含缓存，补齐后与 StorageStats 口径一致
+			DataSize:  sizeAt(dataSizes, i) + cacheSize,
+			CacheSize: cacheSize,
+		}
+	}
+	return result, nil
+}
+
+func parseDiskStatsSizeList(value string) ([]int64, error) {
+	var sizes []int64
+	if err := json.Unmarshal([]byte(value), &sizes); err != nil {
+		return nil, err
+	}
+	return sizes, nil
+}
+
+// GetSdkVersion 获取设备 SDK 版本号，获取失败时返回 0
+func GetSdkVersion(param ExecuteParams) int {
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "getprop ro.build.version.sdk")
+	result := execCmd(cmd)
+	if result.Error != "" {
+		return 0
+	}
+	sdk, err := strconv.Atoi(strings.TrimSpace(result.Res))
+	if err != nil {
+		return 0
+	}
+	return sdk
+}
+
+// ClearAppCache 清除应用缓存（保留数据）
+// Android 14 起 pm clear 支持 --cache-only；更早的版本在 root 或 debuggable 时直接删除缓存目录
+func ClearAppCache(param ExecuteParams) types.ExecResult {
+	externalCacheCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm -rf /sdcard/Android/data/%s/cache/*", param.PackageName))
+
+	if GetSdkVersion(param) >= 34 {
+		cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm clear --cache-only%s %s", param.userArg(), param.PackageName))
+		result := execCmd(cmd)
+		if result.Error != "" {
+			return result
+		}
+		if !strings.Contains(result.Res, "Success") {
+			return types.NewExecResultErrorString(cmd, result.Res)
+		}
+		return types.NewExecResultSuccess(cmd, "缓存已清除")
+	}
+
+	var cmd string
+	if isRoot(param) {
+		cmd = BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm -rf /data/data/%s/cache/* /data/data/%s/code_cache/*", param.PackageName, param.PackageName))
+	} else {
+		cmd = BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("run-as %s rm -rf cache code_cache", param.PackageName))
+	}
+	result := execCmd(cmd)
+	finalCmd := cmd + "\n" + externalCacheCmd
+	execCmd(externalCacheCmd)
+
+	if result.Error != "" {
+		return types.NewExecResultErrorString(finalCmd, result.Error)
+	}
+	if strings.Contains(result.Res, "not debuggable") {
+		return types.NewExecResultErrorString(finalCmd, "应用不是 debuggable，且设备未 root，仅清除了外部存储缓存\n"+result.Res)
+	}
+	return types.NewExecResultSuccess(finalCmd, "缓存已清除")
+}
@@ -0,0 +1,133 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sqliteSidecars WAL 模式下数据库的附属文件，需要与主文件一起拉取
+var sqliteSidecars = []string{"-wal", "-shm"}
+
+// AppDataFiles 应用的 SharedPreferences 与数据库文件
+type AppDataFiles struct {
+	Prefs     []RemoteFileEntry `json:"prefs"`
+	Databases []RemoteFileEntry `json:"databases"`
+}
+
+// ListAppDataFiles 列出 shared_prefs/*.xml 与 databases 下的数据库，目录不存在时返回空列表
+func ListAppDataFiles(param ExecuteParams, access PackageAccess) (AppDataFiles, error) {
+	files := AppDataFiles{Prefs: []RemoteFileEntry{}, Databases: []RemoteFileEntry{}}
+
+	prefs, _ := listDirectory(param, access, joinRemotePath(access.DataDir, "shared_prefs"))
+	for _, e := range prefs {
+		if e.Type == RemoteFileTypeFile && strings.HasSuffix(e.Name, ".xml") {
+			files.Prefs = append(files.Prefs, e)
+		}
+	}
+
+	databases, _ := listDirectory(param, access, joinRemotePath(access.DataDir, "databases"))
+	for _, e := range databases {
+		if e.Type != RemoteFileTypeFile || isSqliteSidecar(e.Name) {
+			continue
+		}
+		files.Databases = append(files.Databases, e)
+	}
+
+	if len(prefs) == 0 && len(databases) == 0 {
+		// 两个目录都读不到时确认一下是否有权限访问数据目录
+		if _, err := listDirectory(param, access, access.DataDir); err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+func isSqliteSidecar(name string) bool {
+	if strings.HasSuffix(name, "-journal") {
+		return true
+	}
+	for _, suffix := range sqliteSidecars {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// PullAppDataFile 以应用身份将文件拉取到本地目录，withSidecars 时一并拉取存在的 -wal/-shm，返回主文件的本地路径
+func PullAppDataFile(param ExecuteParams, access PackageAccess, remotePath string, localDir string, withSidecars bool) (string, error) {
+	remotePaths := []string{remotePath}
+	if withSidecars {
+		existing := make(map[string]bool)
+		entries, _ := listDirectory(param, access, path.Dir(remotePath))
+		for _, e := range entries {
+			existing[e.Name] = true
+		}
+		for _, suffix := range sqliteSidecars {
+			if existing[path.Base(remotePath)+suffix] {
+				remotePaths = append(remotePaths, remotePath+suffix)
+			}
+		}
+	}
+
+	staging, staged, err := StageDownloadAs(param, access, remotePaths)
+	if err != nil {
+		return "", err
+	}
+	defer RemoveStaging(param, staging)
+
+	for _, p := range staged {
+		local := filepath.Join(localDir, path.Base(p))
+		pullCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", quoteHostArg(p), quoteHostArg(local)))
+		if res, err := util.Exec(pullCmd, false, nil); err != nil {
+			return "", fmt.Errorf("拉取 %s 失败: %v, 输出: %s", path.Base(p), err, res)
+		}
+	}
+	return filepath.Join(localDir, path.Base(remotePath)), nil
+}
+
+// PushAppDataFile 先强制停止应用再以应用身份覆盖 remotePath，避免应用运行中把内存里的旧数据写回
+// removeSidecars 时删除设备上的 -wal/-shm，本地文件需已合并 WAL
+func PushAppDataFile(param ExecuteParams, access PackageAccess, localPath string, remotePath string, removeSidecars bool) error {
+	if err := checkPackagePath(access, remotePath); err != nil {
+		return err
+	}
+
+	killParam := param
+	killParam.PackageName = access.Package
+	if res := KillApp(killParam); res.Error != "" {
+		return fmt.Errorf("停止应用失败: %s", res.Error)
+	}
+
+	staging, err := PrepareUploadStaging(param)
+	if err != nil {
+		return err
+	}
+	defer RemoveStaging(param, staging)
+
+	name := path.Base(remotePath)
+	pushCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("push %s %s", quoteHostArg(localPath), quoteHostArg(joinRemotePath(staging, name))))
+	if res, err := util.Exec(pushCmd, false, nil); err != nil {
+		return fmt.Errorf("推送文件失败: %v, 输出: %s", err, res)
+	}
+
+	if _, _, err := CommitUploadAs(param, access, staging, []string{name}, path.Dir(remotePath), ConflictOverwrite); err != nil {
+		return err
+	}
+
+	// 写入成功后才删除旧的 WAL，写入失败时设备上的数据库与 WAL 保持原样
+	if removeSidecars {
+		targets := make([]string, 0, len(sqliteSidecars))
+		for _, suffix := range sqliteSidecars {
+			targets = append(targets, deviceQuote(remotePath+suffix))
+		}
+		res := execSilent(buildRemoteScriptCmd(param, access.wrap("rm -f "+strings.Join(targets, " "))), "")
+		if res.Error != "" {
+			return fmt.Errorf("删除 WAL 文件失败: %s", res.Error)
+		}
+	}
+	return nil
+}
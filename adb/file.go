@@ -0,0 +1,243 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 远程文件类型
+const (
+	RemoteFileTypeFile      = "file"
+	RemoteFileTypeDirectory = "directory"
+	RemoteFileTypeSymlink   = "symlink"
+	RemoteFileTypeChar      = "char"
+	RemoteFileTypeBlock     = "block"
+	RemoteFileTypeFifo      = "fifo"
+	RemoteFileTypeSocket    = "socket"
+)
+
+// RemoteFileEntry 设备目录中的一项
+type RemoteFileEntry struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`
+	Mode       string `json:"mode"`
+	Links      int    `json:"links"`
+	Owner      string `json:"owner"`
+	Group      string `json:"group"`
+	ModTime    string `json:"modTime"` // 设备本地时间，格式 2006-01-02 15:04[:05]
+	LinkTarget string `json:"linkTarget,omitempty"`
+	LinkIsDir  bool   `json:"linkIsDir,omitempty"`
+}
+
+// IsDir 目录或指向目录的软链接
+func (e RemoteFileEntry) IsDir() bool {
+	return e.Type == RemoteFileTypeDirectory || (e.Type == RemoteFileTypeSymlink && e.LinkIsDir)
+}
+
+// reLsLine 匹配一行 ls -l 输出，兼容 toybox、busybox 与 Android 6 之前的 toolbox:
+//
+//	toybox:  drwxrwx--x 4 system sdcard_rw 3452 2024-05-01 10:20 sdcard
+//	busybox: -rw-r--r--    1 root     root           123 Jan  1 00:00 name
+//	toolbox: drwxr-xr-x root     root              2015-01-01 00:00 acct
+//	device:  crw-rw-rw- 1 root root 1,   3 2024-05-01 10:20 null
+var reLsLine = regexp.MustCompile(`^([-dlcbps][-rwxsStT]{9})[.+@]?\s+(?:(\d+)\s+)?(\S+)\s+(\S+)\s+(?:(\d+),\s*\d+\s+|(\d+)\s+)?` +
+	`(\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}(?::\d{2})?(?:\.\d+)?(?:\s+[+-]\d{4})?|[A-Z][a-z]{2}\s+\d{1,2}\s+(?:\d{1,2}:\d{2}|\d{4}))\s(.*)$`)
+
+var (
+	reIsoTime   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+(\d{2}:\d{2}(?::\d{2})?)`)
+	reMonthTime = regexp.MustCompile(`^([A-Z][a-z]{2})\s+(\d{1,2})\s+(?:(\d{1,2}):(\d{2})|(\d{4}))$`)
+)
+
+// lsFileTypes 权限位首字母对应的类型
+var lsFileTypes = map[byte]string{
+	'-': RemoteFileTypeFile,
+	'd': RemoteFileTypeDirectory,
+	'l': RemoteFileTypeSymlink,
+	'c': RemoteFileTypeChar,
+	'b': RemoteFileTypeBlock,
+	'p': RemoteFileTypeFifo,
+	's': RemoteFileTypeSocket,
+}
+
+// deviceQuote 按设备端 shell 的规则用单引号引用参数
+func deviceQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteRemotePath 为设备端 shell 引用路径
+// 外层双引号让本地 shell 把单引号原样交给 adb，设备端再按单引号解析，路径中的空格和特殊字符都不会被展开
+func quoteRemotePath(remotePath string) string {
+	return quoteHostArg(deviceQuote(remotePath))
+}
+
+// quoteHostArg 为本地 shell 引用参数，用于 push/pull 这类不经过设备 shell 的路径，以及整段设备端脚本
+func quoteHostArg(arg string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + arg + `"`
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(arg) + `"`
+}
+
+// joinRemotePath 拼接设备路径，设备端始终使用 /
+func joinRemotePath(dir string, name string) string {
+	return path.Join(dir, name)
+}
+
+// dirArg 目录参数末尾补上 /，目录本身是软链接（如 /sdcard）时 ls、find 才会进入链接指向的目录，而不是只输出链接本身
+func dirArg(dir string) string {
+	if strings.HasSuffix(dir, "/") {
+		return dir
+	}
+	return dir + "/"
+}
+
+// ListDirectory 列出设备目录，目录在前，按名称排序
+func ListDirectory(param ExecuteParams, dir string) ([]RemoteFileEntry, error) {
+	return listDirectory(param, PackageAccess{}, dir)
+}
+
+// listDirectory 以 access 指定的身份列出目录
+func listDirectory(param ExecuteParams, access PackageAccess, dir string) ([]RemoteFileEntry, error) {
+	cmd := buildRemoteScriptCmd(param, access.wrap(fmt.Sprintf("ls -la %s", deviceQuote(dirArg(dir)))))
+	res, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, errLines := parseLsOutput(res, dir, time.Now().Year())
+	if len(entries) == 0 && len(errLines) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errLines, "\n"))
+	}
+
+	resolveSymlinkDirs(param, access, dir, entries)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries, nil
+}
+
+// resolveSymlinkDirs 用 ls -laL 跟随软链接，标记指向目录的链接，便于前端展开
+func resolveSymlinkDirs(param ExecuteParams, access PackageAccess, dir string, entries []RemoteFileEntry) {
+	hasLink := false
+	for _, e := range entries {
+		if e.Type == RemoteFileTypeSymlink {
+			hasLink = true
+			break
+		}
+	}
+	if !hasLink {
+		return
+	}
+
+	cmd := buildRemoteScriptCmd(param, access.wrap(fmt.Sprintf("ls -laL %s", deviceQuote(dirArg(dir)))))
+	res, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return
+	}
+	resolved, _ := parseLsOutput(res, dir, time.Now().Year())
+	dirs := make(map[string]bool, len(resolved))
+	for _, e := range resolved {
+		if e.Type == RemoteFileTypeDirectory {
+			dirs[e.Name] = true
+		}
+	}
+	for i := range entries {
+		if entries[i].Type == RemoteFileTypeSymlink {
+			entries[i].LinkIsDir = dirs[entries[i].Name]
+		}
+	}
+}
+
+// parseLsOutput 解析 ls -la 的输出，返回条目以及无法识别的错误行（如 Permission denied）
+// currentYear 用于补全 busybox 最近半年内文件省略的年份
+func parseLsOutput(content string, dir string, currentYear int) ([]RemoteFileEntry, []string) {
+	entries := []RemoteFileEntry{}
+	var errLines []string
+
+	for _, line := range util.MultiLine(content) {
+		line = strings.TrimRight(line, " \r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "total ") {
+			continue
+		}
+
+		match := reLsLine.FindStringSubmatch(trimmed)
+		if match == nil {
+			errLines = append(errLines, trimmed)
+			continue
+		}
+
+		entry := RemoteFileEntry{
+			Mode:  match[1],
+			Type:  lsFileTypes[match[1][0]],
+			Owner: match[3],
+			Group: match[4],
+		}
+		if match[2] != "" {
+			entry.Links, _ = strconv.Atoi(match[2])
+		}
+		if match[6] != "" {
+			entry.Size, _ = strconv.ParseInt(match[6], 10, 64)
+		}
+		entry.ModTime = normalizeLsTime(match[7], currentYear)
+
+		name := match[8]
+		if entry.Type == RemoteFileTypeSymlink {
+			if linkName, target, found := strings.Cut(name, " -> "); found {
+				name = linkName
+				entry.LinkTarget = target
+			}
+		}
+		// Android 6 之前的 toolbox 在 -a 时也不输出 . 和 ..
+		if name == "." || name == ".." || name == "" {
+			continue
+		}
+		// 对单个文件执行 ls 时输出的是完整路径
+		if strings.HasPrefix(name, "/") {
+			name = path.Base(name)
+		}
+
+		entry.Name = name
+		entry.Path = joinRemotePath(dir, name)
+		entries = append(entries, entry)
+	}
+
+	return entries, errLines
+}
+
+// normalizeLsTime 将 ls 的时间统一为 2006-01-02 15:04[:05]
+func normalizeLsTime(value string, currentYear int) string {
+	if match := reIsoTime.FindStringSubmatch(value); match != nil {
+		return match[1] + " " + match[2]
+	}
+
+	match := reMonthTime.FindStringSubmatch(strings.Join(strings.Fields(value), " "))
+	if match == nil {
+		return value
+	}
+	month, err := time.Parse("Jan", match[1])
+	if err != nil {
+		return value
+	}
+	day, _ := strconv.Atoi(match[2])
+	if match[5] != "" {
+		year, _ := strconv.Atoi(match[5])
+		return fmt.Sprintf("%04d-%02d-%02d 00:00", year, int(month.Month()), day)
+	}
+	hour, _ := strconv.Atoi(match[3])
+	minute, _ := strconv.Atoi(match[4])
+	return fmt.Sprintf("%04d-%02d-%02d %02d:%02d", currentYear, int(month.Month()), day, hour, minute)
+}
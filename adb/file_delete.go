@@ -0,0 +1,246 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"adb-tool-wails/util"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrashDir 设备端回收站目录，每次删除在其中创建一个子目录保存被删除的文件和原始路径
+const TrashDir = "/sdcard/.adbtool-trash"
+
+// trashOriginFile 记录原始路径的文件名
+const trashOriginFile = ".origin"
+
+// DeletePreviewItem 待删除的单个路径
+type DeletePreviewItem struct {
+	Path  string `json:"path"`
+	Type  string `json:"type"`
+	Files int    `json:"files"`
+	Dirs  int    `json:"dirs"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
+
+// DeletePreview 删除预览，确认删除时需要带上 Token
+type DeletePreview struct {
+	Token          string              `json:"token"`
+	Items          []DeletePreviewItem `json:"items"`
+	Files          int                 `json:"files"`
+	Dirs           int                 `json:"dirs"`
+	Bytes          int64               `json:"bytes"`
+	TrashAvailable bool                `json:"trashAvailable"`
+	ExpiresAt      int64               `json:"expiresAt"`
+}
+
+// DeleteResult 确认删除后的结果
+type DeleteResult struct {
+	Deleted []string `json:"deleted"`
+	Trashed []string `json:"trashed"`
+	Errors  []string `json:"errors"`
+}
+
+// TrashEntry 回收站中的一项
+type TrashEntry struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	OriginalPath string `json:"originalPath"`
+	DeletedAt    int64  `json:"deletedAt"`
+}
+
+// isTrashable 回收站与被删除的文件需要在同一存储上，mv 才是原子且不复制数据的
+func isTrashable(p string) bool {
+	return isUnder(p, "/sdcard") || isUnder(p, "/storage/emulated") || isUnder(p, "/storage/self/primary")
+}
+
+// PreviewDelete 统计每个路径将被删除的文件数、目录数和大小，受保护路径直接返回错误
+func PreviewDelete(param ExecuteParams, paths []string, force bool) (DeletePreview, error) {
+	return previewDelete(param, PackageAccess{}, paths, force)
+}
+
+// previewDelete access 不为零值时以应用身份统计，路径必须位于应用数据目录之下
+func previewDelete(param ExecuteParams, access PackageAccess, paths []string, force bool) (DeletePreview, error) {
+	preview := DeletePreview{Items: []DeletePreviewItem{}, TrashAvailable: len(paths) > 0}
+
+	for _, p := range paths {
+		p = path.Clean(p)
+		if access.Mode != "" {
+			if err := checkPackagePath(access, p); err != nil {
+				return preview, err
+			}
+		} else if err := checkProtectedPath(param, p, force); err != nil {
+			return preview, err
+		}
+		if isUnder(p, TrashDir) {
+			return preview, fmt.Errorf("请通过回收站管理删除: %s", p)
+		}
+
+		item := previewDeleteItem(param, access, p)
+		preview.Items = append(preview.Items, item)
+		preview.Files += item.Files
+		preview.Dirs += item.Dirs
+		preview.Bytes += item.Bytes
+		if !isTrashable(p) {
+			preview.TrashAvailable = false
+		}
+	}
+	return preview, nil
+}
+
+// previewDeleteItem 统计单个路径，find 在目录上会包含目录自身
+func previewDeleteItem(param ExecuteParams, access PackageAccess, p string) DeletePreviewItem {
+	item := DeletePreviewItem{Path: p}
+
+	script := fmt.Sprintf("if [ -L %[1]s ]; then echo symlink; elif [ -d %[1]s ]; then echo directory; elif [ -e %[1]s ]; then echo file; else echo missing; fi; find %[1]s -type d 2>/dev/null | wc -l",
+		deviceQuote(p))
+	res := execCmd(buildRemoteScriptCmd(param, access.wrap(script)))
+	if res.Error != "" {
+		item.Error = res.Error
+		return item
+	}
+	lines := util.MultiLine(res.Res)
+	item.Type = strings.TrimSpace(lines[0])
+	if item.Type == "missing" {
+		item.Error = "文件不存在"
+		return item
+	}
+	if len(lines) > 1 {
+		item.Dirs, _ = strconv.Atoi(strings.TrimSpace(lines[len(lines)-1]))
+	}
+
+	if item.Type == "symlink" {
+		// 只删除链接本身
+		item.Files = 1
+		item.Dirs = 0
+		return item
+	}
+
+	files, err := statRemoteFilesAs(param, access, p)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.Files = len(files)
+	for _, f := range files {
+		item.Bytes += f.Size
+	}
+	return item
+}
+
+// DeletePaths 永久删除，或移动到回收站
+func DeletePaths(param ExecuteParams, paths []string, useTrash bool) DeleteResult {
+	return deletePaths(param, PackageAccess{}, paths, useTrash)
+}
+
+// deletePaths access 不为零值时以应用身份删除，应用私有目录不在内部存储上，不支持回收站
+func deletePaths(param ExecuteParams, access PackageAccess, paths []string, useTrash bool) DeleteResult {
+	result := DeleteResult{Deleted: []string{}, Trashed: []string{}, Errors: []string{}}
+
+	for _, p := range paths {
+		var res types.ExecResult
+		if useTrash {
+			res = moveToTrash(param, p)
+		} else {
+			res = execSilent(buildRemoteScriptCmd(param, access.wrap(fmt.Sprintf("rm -rf %s", deviceQuote(p)))), "已删除")
+		}
+		if res.Error != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", p, res.Error))
+			continue
+		}
+		if useTrash {
+			result.Trashed = append(result.Trashed, p)
+		} else {
+			result.Deleted = append(result.Deleted, p)
+		}
+	}
+	return result
+}
+
+// moveToTrash 将文件移动到 TrashDir/<时间戳>-<序号>/ 下并记录原始路径
+func moveToTrash(param ExecuteParams, p string) types.ExecResult {
+	now := time.Now()
+	id := fmt.Sprintf("%d-%s", now.UnixMilli(), strconv.FormatInt(now.UnixNano()%1e6, 36))
+	entryDir := joinRemotePath(TrashDir, id)
+	script := fmt.Sprintf("mkdir -p %[1]s && mv %[2]s %[3]s && echo -n %[4]s > %[5]s",
+		deviceQuote(entryDir),
+		deviceQuote(p),
+		deviceQuote(joinRemotePath(entryDir, path.Base(p))),
+		deviceQuote(p),
+		deviceQuote(joinRemotePath(entryDir, trashOriginFile)))
+	return execSilent(buildRemoteScriptCmd(param, script), "已移到回收站")
+}
+
+// ListTrash 列出回收站中的条目，按删除时间倒序
+func ListTrash(param ExecuteParams) ([]TrashEntry, error) {
+	script := fmt.Sprintf("for d in %s/*; do [ -f \"$d/%s\" ] && echo \"$(basename \"$d\")|$(cat \"$d/%s\")\"; done 2>/dev/null",
+		TrashDir, trashOriginFile, trashOriginFile)
+	res := execCmd(buildRemoteScriptCmd(param, script))
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s", res.Error)
+	}
+
+	entries := []TrashEntry{}
+	for _, line := range util.MultiLine(res.Res) {
+		id, origin, found := strings.Cut(strings.TrimSpace(line), "|")
+		if !found || origin == "" {
+			continue
+		}
+		entry := TrashEntry{Id: id, Name: path.Base(origin), OriginalPath: origin}
+		if ts, _, ok := strings.Cut(id, "-"); ok {
+			entry.DeletedAt, _ = strconv.ParseInt(ts, 10, 64)
+		}
+		entries = append([]TrashEntry{entry}, entries...)
+	}
+	return entries, nil
+}
+
+// validTrashId 防止通过 id 访问回收站以外的路径
+func validTrashId(id string) bool {
+	return id != "" && !strings.ContainsAny(id, "/'\"") && id != "." && id != ".."
+}
+
+// RestoreTrashEntry 将回收站中的条目恢复到原始路径，原路径已存在时拒绝覆盖
+func RestoreTrashEntry(param ExecuteParams, id string) types.ExecResult {
+	if !validTrashId(id) {
+		return types.NewExecResultErrorString("restore", fmt.Sprintf("无效的回收站条目: %s", id))
+	}
+	entries, err := ListTrash(param)
+	if err != nil {
+		return types.NewExecResultError("restore", err)
+	}
+	var entry *TrashEntry
+	for i := range entries {
+		if entries[i].Id == id {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return types.NewExecResultErrorString("restore", fmt.Sprintf("回收站中没有该条目: %s", id))
+	}
+
+	entryDir := joinRemotePath(TrashDir, id)
+	script := fmt.Sprintf("if [ -e %[1]s ]; then echo '原路径已存在'; else mkdir -p %[2]s && mv %[3]s %[1]s && rm -rf %[4]s; fi",
+		deviceQuote(entry.OriginalPath),
+		deviceQuote(path.Dir(entry.OriginalPath)),
+		deviceQuote(joinRemotePath(entryDir, entry.Name)),
+		deviceQuote(entryDir))
+	return execSilent(buildRemoteScriptCmd(param, script), fmt.Sprintf("已恢复到 %s", entry.OriginalPath))
+}
+
+// PurgeTrash 永久删除回收站中的条目，id 为空时清空回收站
+func PurgeTrash(param ExecuteParams, id string) types.ExecResult {
+	target := TrashDir
+	if id != "" {
+		if !validTrashId(id) {
+			return types.NewExecResultErrorString("purge", fmt.Sprintf("无效的回收站条目: %s", id))
+		}
+		target = joinRemotePath(TrashDir, id)
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm -rf %s", quoteRemotePath(target)))
+	return execSilent(cmd, "已永久删除")
+}
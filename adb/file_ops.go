@@ -0,0 +1,124 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// reChmodMode 八进制（755）或符号（u+x,go-w）形式的权限
+var reChmodMode = regexp.MustCompile(`^(?:[0-7]{3,4}|[ugoa]*[-+=][rwxXst]*(?:,[ugoa]*[-+=][rwxXst]*)*)$`)
+
+// writableDataPaths /data 下 shell 用户本来就可写的目录，不需要 root
+var writableDataPaths = []string{"/data/local/tmp"}
+
+// checkProtectedPath 拒绝操作受保护的路径：/、/system 及其子路径、未 root 时的 /data
+// force 为 true 时只检查路径是否为非空的绝对路径，其余由调用方确认风险
+func checkProtectedPath(param ExecuteParams, remotePath string, force bool) error {
+	// 空路径与相对路径无法确定实际操作的位置，强制模式下同样拒绝
+	if remotePath == "" {
+		return fmt.Errorf("路径不能为空")
+	}
+	p := path.Clean(remotePath)
+	if !strings.HasPrefix(p, "/") {
+		return fmt.Errorf("必须使用绝对路径: %s", remotePath)
+	}
+	if force {
+		return nil
+	}
+	if p == "/" {
+		return fmt.Errorf("拒绝操作根目录 /")
+	}
+	if isUnder(p, "/system") {
+		return fmt.Errorf("拒绝操作系统目录: %s", p)
+	}
+	if isUnder(p, "/data") {
+		for _, writable := range writableDataPaths {
+			if isUnder(p, writable) {
+				return nil
+			}
+		}
+		if !isRoot(param) {
+			return fmt.Errorf("设备未 root，拒绝操作 /data 下的路径: %s", p)
+		}
+	}
+	return nil
+}
+
+// isUnder 判断 p 是否为 root 或其子路径
+func isUnder(p string, root string) bool {
+	return p == root || strings.HasPrefix(p, root+"/")
+}
+
+// buildRemoteScriptCmd 将整段脚本交给设备端 shell 执行，脚本中的路径需先用 deviceQuote 引用
+func buildRemoteScriptCmd(param ExecuteParams, script string) string {
+	return BuildAdbShellCmd(param.AdbPath, param.DeviceId, quoteHostArg(script))
+}
+
+// execSilent 执行成功时没有输出的命令（mkdir/mv/cp/chmod/touch），有输出即视为失败
+func execSilent(cmd string, successMsg string) types.ExecResult {
+	result := execCmd(cmd)
+	if result.Error != "" {
+		return result
+	}
+	if result.Res != "" {
+		return types.NewExecResultErrorString(cmd, result.Res)
+	}
+	return types.NewExecResultSuccess(cmd, successMsg)
+}
+
+// MakeRemoteDir 创建目录（包含不存在的上级目录）
+func MakeRemoteDir(param ExecuteParams, dir string, force bool) types.ExecResult {
+	if err := checkProtectedPath(param, dir, force); err != nil {
+		return types.NewExecResultError("mkdir", err)
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("mkdir -p %s", quoteRemotePath(dir)))
+	return execSilent(cmd, "目录已创建")
+}
+
+// RenameRemoteFile 重命名或移动文件/目录，目标已存在时拒绝覆盖
+func RenameRemoteFile(param ExecuteParams, src string, dst string, force bool) types.ExecResult {
+	for _, p := range []string{src, dst} {
+		if err := checkProtectedPath(param, p, force); err != nil {
+			return types.NewExecResultError("mv", err)
+		}
+	}
+	script := fmt.Sprintf("if [ -e %s ]; then echo '目标已存在'; else mv %s %s; fi", deviceQuote(dst), deviceQuote(src), deviceQuote(dst))
+	return execSilent(buildRemoteScriptCmd(param, script), "已移动")
+}
+
+// CopyRemoteFile 在设备上复制文件或目录（递归），目标已存在时拒绝覆盖
+func CopyRemoteFile(param ExecuteParams, src string, dst string, force bool) types.ExecResult {
+	if err := checkProtectedPath(param, dst, force); err != nil {
+		return types.NewExecResultError("cp", err)
+	}
+	script := fmt.Sprintf("if [ -e %s ]; then echo '目标已存在'; else cp -R %s %s; fi", deviceQuote(dst), deviceQuote(src), deviceQuote(dst))
+	return execSilent(buildRemoteScriptCmd(param, script), "已复制")
+}
+
+// ChmodRemoteFile 修改权限，mode 支持八进制与符号形式，recursive 对目录递归生效
+func ChmodRemoteFile(param ExecuteParams, remotePath string, mode string, recursive bool, force bool) types.ExecResult {
+	if !reChmodMode.MatchString(mode) {
+		return types.NewExecResultErrorString("chmod", fmt.Sprintf("无效的权限: %s", mode))
+	}
+	if err := checkProtectedPath(param, remotePath, force); err != nil {
+		return types.NewExecResultError("chmod", err)
+	}
+	flag := ""
+	if recursive {
+		flag = "-R "
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("chmod %s%s %s", flag, mode, quoteRemotePath(remotePath)))
+	return execSilent(cmd, "权限已修改")
+}
+
+// TouchRemoteFile 创建空文件，文件已存在时只更新修改时间
+func TouchRemoteFile(param ExecuteParams, remotePath string, force bool) types.ExecResult {
+	if err := checkProtectedPath(param, remotePath, force); err != nil {
+		return types.NewExecResultError("touch", err)
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("touch %s", quoteRemotePath(remotePath)))
+	return execSilent(cmd, "文件已创建")
+}
@@ -0,0 +1,394 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 预览类型
+const (
+	PreviewKindEmpty  = "empty"
+	PreviewKindText   = "text"
+	PreviewKindJSON   = "json"
+	PreviewKindXML    = "xml"
+	PreviewKindImage  = "image"
+	PreviewKindSQLite = "sqlite"
+	PreviewKindBinary = "binary"
+)
+
+// 读取模式，翻页时由前端带上首次预览确定的模式
+const (
+	PreviewModeAuto = ""
+	PreviewModeText = "text"
+	PreviewModeHex  = "hex"
+)
+
+const (
+	// DefaultPreviewBytes 单次读取的默认字节数
+	DefaultPreviewBytes = 128 * 1024
+	// MaxPreviewBytes 单次读取的上限，超过的部分通过 NextOffset 继续加载
+	MaxPreviewBytes = 1024 * 1024
+	// maxHexPreviewBytes 十六进制展开后约为原始数据的 4 倍，单独限制
+	maxHexPreviewBytes = 64 * 1024
+	// maxImagePreviewBytes 超过该大小的图片按二进制预览
+	maxImagePreviewBytes = 8 * 1024 * 1024
+	// previewBlockSize dd 读取的块大小，偏移量按块对齐后再在本地裁剪
+	previewBlockSize = 4096
+)
+
+// FilePreview 设备文件的一段内容
+type FilePreview struct {
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Mode       string `json:"mode"`
+	MimeType   string `json:"mimeType,omitempty"`
+	Size       int64  `json:"size"`
+	Offset     int64  `json:"offset"`
+	Length     int64  `json:"length"`
+	NextOffset int64  `json:"nextOffset"`
+	HasMore    bool   `json:"hasMore"`
+	Content    string `json:"content"`
+	DataUrl    string `json:"dataUrl,omitempty"`
+	Summary    string `json:"summary,omitempty"`
+}
+
+// imageSignatures 文件头与图片 MIME 类型
+var imageSignatures = []struct {
+	magic []byte
+	mime  string
+}{
+	{[]byte("\x89PNG\r\n\x1a\n"), "image/png"},
+	{[]byte("\xff\xd8\xff"), "image/jpeg"},
+	{[]byte("GIF87a"), "image/gif"},
+	{[]byte("GIF89a"), "image/gif"},
+}
+
+var (
+	sqliteMagic     = []byte("SQLite format 3\x00")
+	binaryXMLMagic  = []byte{0x03, 0x00, 0x08, 0x00}
+	riffMagic       = []byte("RIFF")
+	webpFormatMagic = []byte("WEBP")
+)
+
+// ReadRemoteFile 从 offset 开始读取最多 maxBytes 字节，按内容识别类型
+// mode 为空时自动识别，翻页时传入首次预览返回的 Mode，保证同一文件的展示方式一致
+func ReadRemoteFile(param ExecuteParams, remotePath string, offset int64, maxBytes int64, mode string) (FilePreview, error) {
+	return readRemoteFile(param, PackageAccess{}, remotePath, offset, maxBytes, mode)
+}
+
+// readRemoteFile 以 access 指定的身份读取文件
+func readRemoteFile(param ExecuteParams, access PackageAccess, remotePath string, offset int64, maxBytes int64, mode string) (FilePreview, error) {
+	preview := FilePreview{Path: remotePath, Name: path.Base(remotePath), Offset: offset, Mode: mode}
+
+	size, err := remoteFileSize(param, access, remotePath)
+	if err != nil {
+		return preview, err
+	}
+	preview.Size = size
+	if size == 0 {
+		preview.Kind = PreviewKindEmpty
+		return preview, nil
+	}
+	if offset < 0 || offset >= size {
+		return preview, fmt.Errorf("偏移量超出文件大小: %d / %d", offset, size)
+	}
+
+	if maxBytes <= 0 {
+		maxBytes = DefaultPreviewBytes
+	}
+	if maxBytes > MaxPreviewBytes {
+		maxBytes = MaxPreviewBytes
+	}
+	if mode == PreviewModeHex && maxBytes > maxHexPreviewBytes {
+		maxBytes = maxHexPreviewBytes
+	}
+
+	data, err := readRemoteRange(param, access, remotePath, offset, maxBytes)
+	if err != nil {
+		return preview, err
+	}
+
+	if mode == PreviewModeAuto {
+		if offset != 0 {
+			return preview, fmt.Errorf("从中间位置读取时必须指定读取模式")
+		}
+		detectPreviewKind(&preview, data)
+		if preview.Kind == PreviewKindImage && size <= maxImagePreviewBytes {
+			if int64(len(data)) < size {
+				if data, err = readRemoteRange(param, access, remotePath, 0, size); err != nil {
+					return preview, err
+				}
+			}
+			fillImagePreview(&preview, data)
+			return preview, nil
+		}
+		if preview.Kind == PreviewKindImage {
+			preview.Summary = fmt.Sprintf("图片超过 %d MB，按十六进制显示", maxImagePreviewBytes>>20)
+		}
+		if preview.Mode == PreviewModeHex && int64(len(data)) > maxHexPreviewBytes {
+			data = data[:maxHexPreviewBytes]
+		}
+	}
+
+	if preview.Mode == PreviewModeHex {
+		if preview.Kind == "" {
+			preview.Kind = PreviewKindBinary
+		}
+		preview.Content = hexDump(data, offset)
+		preview.Length = int64(len(data))
+	} else {
+		if preview.Kind == "" {
+			preview.Kind = PreviewKindText
+		}
+		atEOF := offset+int64(len(data)) >= size
+		text := trimTextChunk(data, atEOF)
+		preview.Length = int64(len(text))
+		preview.Content = strings.ToValidUTF8(string(text), "�")
+		if preview.Kind == PreviewKindJSON && offset == 0 && atEOF {
+			var pretty bytes.Buffer
+			if json.Indent(&pretty, text, "", "  ") == nil {
+				preview.Content = pretty.String()
+			}
+		}
+	}
+	preview.NextOffset = offset + preview.Length
+	preview.HasMore = preview.NextOffset < size
+	return preview, nil
+}
+
+// remoteFileSize 用 ls -lL 获取文件大小，兼容没有 stat 命令的旧设备
+func remoteFileSize(param ExecuteParams, access PackageAccess, remotePath string) (int64, error) {
+	cmd := buildRemoteScriptCmd(param, access.wrap(fmt.Sprintf("ls -lL %s", deviceQuote(remotePath))))
+	res, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return 0, err
+	}
+	entries, errLines := parseLsOutput(res, path.Dir(remotePath), time.Now().Year())
+	if len(entries) != 1 {
+		if len(errLines) > 0 {
+			return 0, fmt.Errorf("%s", strings.Join(errLines, "\n"))
+		}
+		return 0, fmt.Errorf("无法读取文件信息: %s", remotePath)
+	}
+	if entries[0].Type == RemoteFileTypeDirectory {
+		return 0, fmt.Errorf("不能预览目录: %s", remotePath)
+	}
+	if entries[0].Type != RemoteFileTypeFile {
+		return 0, fmt.Errorf("不支持预览该类型的文件: %s", entries[0].Type)
+	}
+	return entries[0].Size, nil
+}
+
+// readRemoteRange 读取 [offset, offset+length) 区间的原始字节
+// 设备端用 dd 按块截取到临时文件再 pull，避免经过 shell 标准输出时破坏二进制数据
+// 重定向由 shell 身份完成，run-as 下应用不能写 /data/local/tmp 也能中转
+func readRemoteRange(param ExecuteParams, access PackageAccess, remotePath string, offset int64, length int64) ([]byte, error) {
+	skip := offset / previewBlockSize
+	count := (offset + length - skip*previewBlockSize + previewBlockSize - 1) / previewBlockSize
+
+	stamp := time.Now().UnixNano()
+	devicePath := fmt.Sprintf("/data/local/tmp/adbtool_preview_%d.bin", stamp)
+	localPath := filepath.Join(os.TempDir(), fmt.Sprintf("adbtool_preview_%d.bin", stamp))
+	defer os.Remove(localPath)
+
+	dd := fmt.Sprintf("dd if=%s bs=%d skip=%d count=%d 2>/dev/null", deviceQuote(remotePath), previewBlockSize, skip, count)
+	script := fmt.Sprintf("%s > %s || echo '读取文件失败'", access.wrap(dd), deviceQuote(devicePath))
+	res := execSilent(buildRemoteScriptCmd(param, script), "")
+
+	if res.Error == "" {
+		pullCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", quoteHostArg(devicePath), quoteHostArg(localPath)))
+		if _, err := util.Exec(pullCmd, false, nil); err != nil {
+			res.Error = err.Error()
+		}
+	}
+	rmCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm -f %s", devicePath))
+	_, _ = util.Exec(rmCmd, true, nil)
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s", res.Error)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, err
+	}
+	start := offset - skip*previewBlockSize
+	if start >= int64(len(data)) {
+		return []byte{}, nil
+	}
+	data = data[start:]
+	if int64(len(data)) > length {
+		data = data[:length]
+	}
+	return data, nil
+}
+
+// detectPreviewKind 根据文件头和扩展名确定类型与读取模式
+func detectPreviewKind(preview *FilePreview, data []byte) {
+	for _, sig := range imageSignatures {
+		if bytes.HasPrefix(data, sig.magic) {
+			preview.Kind, preview.MimeType, preview.Mode = PreviewKindImage, sig.mime, PreviewModeHex
+			return
+		}
+	}
+	if bytes.HasPrefix(data, riffMagic) && len(data) >= 12 && bytes.Equal(data[8:12], webpFormatMagic) {
+		preview.Kind, preview.MimeType, preview.Mode = PreviewKindImage, "image/webp", PreviewModeHex
+		return
+	}
+	// BMP 只有两字节魔数，额外要求保留字段为 0，避免误判以 BM 开头的文本
+	if bytes.HasPrefix(data, []byte("BM")) && len(data) >= 26 && binary.LittleEndian.Uint32(data[6:10]) == 0 {
+		preview.Kind, preview.MimeType, preview.Mode = PreviewKindImage, "image/bmp", PreviewModeHex
+		return
+	}
+	if bytes.HasPrefix(data, sqliteMagic) {
+		preview.Kind, preview.Mode = PreviewKindSQLite, PreviewModeHex
+		preview.Summary = sqliteSummary(data)
+		return
+	}
+	if bytes.HasPrefix(data, binaryXMLMagic) {
+		preview.Kind, preview.Mode = PreviewKindBinary, PreviewModeHex
+		preview.Summary = "Android 二进制 XML（AXML）"
+		return
+	}
+	if !looksLikeText(data) {
+		preview.Kind, preview.Mode = PreviewKindBinary, PreviewModeHex
+		return
+	}
+
+	preview.Mode = PreviewModeText
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch ext := strings.ToLower(path.Ext(preview.Path)); {
+	case ext == ".json" || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")):
+		preview.Kind = PreviewKindJSON
+	case ext == ".xml" || bytes.HasPrefix(trimmed, []byte("<?xml")):
+		preview.Kind = PreviewKindXML
+	default:
+		preview.Kind = PreviewKindText
+	}
+}
+
+// looksLikeText 不含 NUL、是合法 UTF-8（允许结尾被截断的字符）且控制字符不超过 10%
+func looksLikeText(data []byte) bool {
+	sample := data
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return false
+	}
+	if !utf8.Valid(trimIncompleteRune(sample)) {
+		return false
+	}
+	control := 0
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != 0x1b {
+			control++
+		}
+	}
+	return control*10 <= len(sample)
+}
+
+// trimIncompleteRune 去掉末尾被截断的多字节字符
+func trimIncompleteRune(data []byte) []byte {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(data); i++ {
+		b := data[len(data)-i]
+		if b < 0x80 {
+			return data
+		}
+		if utf8.RuneStart(b) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			return data
+		}
+	}
+	return data
+}
+
+// trimTextChunk 未读到文件末尾时在最后一个换行处截断，下一页从完整的行开始
+func trimTextChunk(data []byte, atEOF bool) []byte {
+	if atEOF {
+		return data
+	}
+	if newline := bytes.LastIndexByte(data, '\n'); newline >= 0 {
+		return data[:newline+1]
+	}
+	return trimIncompleteRune(data)
+}
+
+// fillImagePreview 图片以 data URL 返回，并在摘要中给出尺寸
+func fillImagePreview(preview *FilePreview, data []byte) {
+	preview.DataUrl = "data:" + preview.MimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	preview.Length = int64(len(data))
+	preview.NextOffset = int64(len(data))
+	if config, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		preview.Summary = fmt.Sprintf("%s %dx%d", strings.ToUpper(format), config.Width, config.Height)
+	}
+}
+
+// sqliteSummary 解析 SQLite 文件头（前 100 字节）
+func sqliteSummary(data []byte) string {
+	if len(data) < 100 {
+		return "SQLite 数据库"
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	pageCount := binary.BigEndian.Uint32(data[28:32])
+	encodings := map[uint32]string{1: "UTF-8", 2: "UTF-16le", 3: "UTF-16be"}
+	encoding := encodings[binary.BigEndian.Uint32(data[56:60])]
+	if encoding == "" {
+		encoding = "UTF-8"
+	}
+	userVersion := binary.BigEndian.Uint32(data[60:64])
+	journal := "rollback"
+	if data[18] == 2 {
+		journal = "WAL"
+	}
+	return fmt.Sprintf("SQLite 数据库，页大小 %d，页数 %d，编码 %s，user_version %d，日志模式 %s",
+		pageSize, pageCount, encoding, userVersion, journal)
+}
+
+// hexDump 每行 16 字节，左侧为文件中的绝对偏移量
+func hexDump(data []byte, offset int64) string {
+	var sb strings.Builder
+	for i := 0; i < len(data); i += 16 {
+		line := data[i:min(i+16, len(data))]
+		fmt.Fprintf(&sb, "%08x  ", offset+int64(i))
+		for j := 0; j < 16; j++ {
+			if j < len(line) {
+				fmt.Fprintf(&sb, "%02x ", line[j])
+			} else {
+				sb.WriteString("   ")
+			}
+			if j == 7 {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(" |")
+		for _, b := range line {
+			if b >= 0x20 && b < 0x7f {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
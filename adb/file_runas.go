@@ -0,0 +1,223 @@
+package adb
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 访问应用私有目录的方式
+const (
+	AccessModeRoot  = "root"   // adbd 以 root 运行，直接访问
+	AccessModeSu    = "su"     // 通过设备上的 su 提权
+	AccessModeRunAs = "run-as" // 以可调试应用自身的身份访问
+)
+
+// rePackageName 合法的应用包名，同时防止包名被拼进 shell 命令时注入
+var rePackageName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*(\.[a-zA-Z0-9_]+)+$`)
+
+// IsValidPackageName 检查包名格式，拼进路径或命令前使用
+func IsValidPackageName(name string) bool {
+	return rePackageName.MatchString(name)
+}
+
+// PackageAccess 以指定应用身份访问文件，零值表示普通 shell 身份
+type PackageAccess struct {
+	Package string `json:"package"`
+	Mode    string `json:"mode"`
+	DataDir string `json:"dataDir"`
+}
+
+// wrap 将设备端脚本包装为以对应身份执行，零值与 root 模式原样返回
+func (a PackageAccess) wrap(script string) string {
+	switch a.Mode {
+	case AccessModeRunAs:
+		return fmt.Sprintf("run-as %s sh -c %s", deviceQuote(a.Package), deviceQuote(script))
+	case AccessModeSu:
+		return fmt.Sprintf("su -c %s", deviceQuote(script))
+	default:
+		return script
+	}
+}
+
+// ResolvePackageAccess 确定访问应用私有目录的方式：adbd root 时直接访问，否则优先 run-as，最后尝试 su
+func ResolvePackageAccess(param ExecuteParams, packageName string) (PackageAccess, error) {
+	if !rePackageName.MatchString(packageName) {
+		return PackageAccess{}, fmt.Errorf("无效的包名: %s", packageName)
+	}
+	access := PackageAccess{Package: packageName, DataDir: "/data/data/" + packageName}
+
+	if isRoot(param) {
+		access.Mode = AccessModeRoot
+		return access, nil
+	}
+
+	runAs := execCmd(buildRemoteScriptCmd(param, fmt.Sprintf("run-as %s id -u", deviceQuote(packageName))))
+	if runAs.Error == "" && isNumeric(runAs.Res) {
+		access.Mode = AccessModeRunAs
+		return access, nil
+	}
+
+	su := execCmd(buildRemoteScriptCmd(param, "su -c 'id -u' 2>/dev/null"))
+	if su.Error == "" && strings.TrimSpace(su.Res) == "0" {
+		access.Mode = AccessModeSu
+		return access, nil
+	}
+
+	reason := strings.TrimSpace(runAs.Res + runAs.Error)
+	return PackageAccess{}, fmt.Errorf("无法访问 %s 的私有目录，应用不可调试且设备未 root: %s", packageName, reason)
+}
+
+func isNumeric(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ListDirectoryAs 以应用身份列出目录
+func ListDirectoryAs(param ExecuteParams, access PackageAccess, dir string) ([]RemoteFileEntry, error) {
+	return listDirectory(param, access, dir)
+}
+
+// ReadRemoteFileAs 以应用身份分段读取文件，参数含义同 ReadRemoteFile
+func ReadRemoteFileAs(param ExecuteParams, access PackageAccess, remotePath string, offset int64, maxBytes int64, mode string) (FilePreview, error) {
+	return readRemoteFile(param, access, remotePath, offset, maxBytes, mode)
+}
+
+// checkPackagePath 以应用身份写入或删除时，路径必须位于应用数据目录之下且不能是数据目录本身
+func checkPackagePath(access PackageAccess, remotePath string) error {
+	p := path.Clean(remotePath)
+	if access.DataDir == "" || p == access.DataDir || !isUnder(p, access.DataDir) {
+		return fmt.Errorf("拒绝操作应用数据目录以外的路径: %s", p)
+	}
+	return nil
+}
+
+// PreviewDeleteAs 以应用身份统计将被删除的内容，参数含义同 PreviewDelete
+func PreviewDeleteAs(param ExecuteParams, access PackageAccess, paths []string) (DeletePreview, error) {
+	return previewDelete(param, access, paths, false)
+}
+
+// DeletePathsAs 以应用身份永久删除，路径需先经过 PreviewDeleteAs 检查
+func DeletePathsAs(param ExecuteParams, access PackageAccess, paths []string) DeleteResult {
+	return deletePaths(param, access, paths, false)
+}
+
+// newStagingDir /data/local/tmp 下的中转目录，shell 与 root 都可读写
+func newStagingDir() string {
+	return fmt.Sprintf("/data/local/tmp/adbtool_runas_%d", time.Now().UnixNano())
+}
+
+// StageDownloadAs 以应用身份打包 remotePaths，在 shell 身份下解包到中转目录，返回中转目录与其中对应的路径
+// 之后可以按普通文件下载中转路径，完成后调用 RemoveStaging 清理
+func StageDownloadAs(param ExecuteParams, access PackageAccess, remotePaths []string) (string, []string, error) {
+	staging := newStagingDir()
+	steps := []string{fmt.Sprintf("mkdir -p %s", deviceQuote(staging))}
+	staged := make([]string, 0, len(remotePaths))
+	for _, p := range remotePaths {
+		p = path.Clean(p)
+		tarCmd := fmt.Sprintf("tar -cf - -C %s %s", deviceQuote(path.Dir(p)), deviceQuote(path.Base(p)))
+		steps = append(steps, fmt.Sprintf("%s | tar -xf - -C %s", access.wrap(tarCmd), deviceQuote(staging)))
+		staged = append(staged, joinRemotePath(staging, path.Base(p)))
+	}
+
+	res := execSilent(buildRemoteScriptCmd(param, strings.Join(steps, " && ")), "")
+	if res.Error != "" {
+		RemoveStaging(param, staging)
+		return "", nil, fmt.Errorf("复制到中转目录失败: %s", res.Error)
+	}
+	return staging, staged, nil
+}
+
+// PrepareUploadStaging 创建上传用的中转目录，文件先按普通方式 push 到这里
+func PrepareUploadStaging(param ExecuteParams) (string, error) {
+	staging := newStagingDir()
+	res := execSilent(buildRemoteScriptCmd(param, fmt.Sprintf("mkdir -p %s", deviceQuote(staging))), "")
+	if res.Error != "" {
+		return "", fmt.Errorf("创建中转目录失败: %s", res.Error)
+	}
+	return staging, nil
+}
+
+// CommitUploadAs 将中转目录中的顶层条目 names 以应用身份解包到 remoteDir
+// 按冲突策略处理 remoteDir 中已存在的同名条目，返回实际写入的路径与跳过的数量
+func CommitUploadAs(param ExecuteParams, access PackageAccess, staging string, names []string, remoteDir string, policy string) ([]string, int, error) {
+	// remoteDir 可以是数据目录本身，写入的每个条目仍需位于数据目录之下
+	remoteDir = path.Clean(remoteDir)
+	if access.DataDir == "" || !isUnder(remoteDir, access.DataDir) {
+		return nil, 0, fmt.Errorf("拒绝写入应用数据目录以外的路径: %s", remoteDir)
+	}
+	for _, name := range names {
+		if name != path.Base(name) {
+			return nil, 0, fmt.Errorf("无效的文件名: %s", name)
+		}
+		if err := checkPackagePath(access, joinRemotePath(remoteDir, name)); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	existing := make(map[string]bool)
+	if entries, err := listDirectory(param, access, remoteDir); err == nil {
+		for _, e := range entries {
+			existing[e.Name] = true
+		}
+	}
+
+	var steps []string
+	var written []string
+	skipped := 0
+	for _, name := range names {
+		target := name
+		if existing[name] {
+			switch policy {
+			case ConflictSkip:
+				skipped++
+				continue
+			case ConflictRename:
+				target = path.Base(uniqueName(name, func(p string) bool { return existing[p] }))
+				steps = append(steps, fmt.Sprintf("mv %s %s", deviceQuote(joinRemotePath(staging, name)), deviceQuote(joinRemotePath(staging, target))))
+			case ConflictOverwrite:
+				steps = append(steps, access.wrap(fmt.Sprintf("rm -rf %s", deviceQuote(joinRemotePath(remoteDir, name)))))
+			}
+		}
+		if err := checkPackagePath(access, joinRemotePath(remoteDir, target)); err != nil {
+			return nil, skipped, err
+		}
+		existing[target] = true
+		untar := fmt.Sprintf("tar -xf - -C %s", deviceQuote(remoteDir))
+		steps = append(steps, fmt.Sprintf("tar -cf - -C %s %s | %s", deviceQuote(staging), deviceQuote(target), access.wrap(untar)))
+		// root 解包会保留 shell 的属主，需要改回应用的 uid 并恢复 SELinux 上下文，否则应用自身无法读写
+		if access.Mode == AccessModeRoot || access.Mode == AccessModeSu {
+			dest := deviceQuote(joinRemotePath(remoteDir, target))
+			fix := fmt.Sprintf("chown -R $(stat -c %%u:%%g %s) %s && (restorecon -R %s >/dev/null 2>&1; true)", deviceQuote(access.DataDir), dest, dest)
+			steps = append(steps, access.wrap(fix))
+		}
+		written = append(written, joinRemotePath(remoteDir, target))
+	}
+	if len(steps) == 0 {
+		return written, skipped, nil
+	}
+
+	res := execSilent(buildRemoteScriptCmd(param, strings.Join(steps, " && ")), "")
+	if res.Error != "" {
+		return nil, skipped, fmt.Errorf("从中转目录写入失败: %s", res.Error)
+	}
+	return written, skipped, nil
+}
+
+// RemoveStaging 删除中转目录
+func RemoveStaging(param ExecuteParams, staging string) {
+	if !strings.HasPrefix(staging, "/data/local/tmp/adbtool_runas_") {
+		return
+	}
+	_ = execSilent(buildRemoteScriptCmd(param, fmt.Sprintf("rm -rf %s", deviceQuote(staging))), "")
+}
@@ -0,0 +1,308 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 搜索的文件类型
+const (
+	SearchTypeAny  = ""
+	SearchTypeFile = "f"
+	SearchTypeDir  = "d"
+)
+
+// 搜索结果数量限制
+const (
+	DefaultSearchResults = 1000
+	MaxSearchResults     = 10000
+)
+
+// searchStatFormat 每个匹配输出一行：大小 修改时间 权限 路径
+const searchStatFormat = "%s %Y %A %n"
+
+// SearchOptions 设备文件搜索条件，数值条件为 0 表示不限制
+type SearchOptions struct {
+	Roots          []string `json:"roots"`
+	Name           string   `json:"name"`           // 文件名通配符，不含 * ? [ 时按包含匹配
+	IgnoreCase     bool     `json:"ignoreCase"`     // 文件名与内容都忽略大小写
+	Type           string   `json:"type"`           // f/d，空为不限
+	MinSize        int64    `json:"minSize"`        // 字节
+	MaxSize        int64    `json:"maxSize"`        // 字节
+	ModifiedAfter  int64    `json:"modifiedAfter"`  // Unix 秒
+	ModifiedBefore int64    `json:"modifiedBefore"` // Unix 秒
+	Content        string   `json:"content"`        // 文件内容包含的文本；设置内容或大小条件后只搜索普通文件
+	MaxResults     int      `json:"maxResults"`
+	InBookmarks    bool     `json:"inBookmarks"` // 同时搜索收藏的目录，由 App 展开到 Roots
+}
+
+// SearchMatch 一个匹配的文件或目录
+type SearchMatch struct {
+	Path        string `json:"path"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ModTime     int64  `json:"modTime"`
+	Permissions string `json:"permissions"`
+	IsDir       bool   `json:"isDir"`
+}
+
+// SearchSummary 搜索结束后的汇总，Errors 为不存在或无法搜索的目录
+type SearchSummary struct {
+	Matches   int      `json:"matches"`
+	Truncated bool     `json:"truncated"`
+	Errors    []string `json:"errors"`
+}
+
+// searchFeatures 设备端 find 支持的能力
+type searchFeatures struct {
+	filters bool  // 支持 -size Nc 与 -mmin，不支持时只在本地过滤
+	now     int64 // 设备当前时间，用于换算 -mmin
+}
+
+// errSearchLimit 结果数达到上限时用于结束设备端命令
+var errSearchLimit = errors.New("search limit reached")
+
+// SearchRemoteFiles 在设备上按名称、大小、修改时间与内容搜索，每找到一个匹配调用一次 onMatch
+// ctx 取消时结束设备端命令并返回 ctx.Err()
+func SearchRemoteFiles(ctx context.Context, param ExecuteParams, options SearchOptions, onMatch func(SearchMatch)) (SearchSummary, error) {
+	summary := SearchSummary{Errors: []string{}}
+
+	roots, err := normalizeSearchRoots(options.Roots)
+	if err != nil {
+		return summary, err
+	}
+	if options.MaxSize > 0 && options.MinSize > options.MaxSize {
+		return summary, fmt.Errorf("最小大小不能超过最大大小")
+	}
+	if options.ModifiedAfter > 0 && options.ModifiedBefore > 0 && options.ModifiedAfter > options.ModifiedBefore {
+		return summary, fmt.Errorf("开始时间不能晚于结束时间")
+	}
+	limit := options.MaxResults
+	if limit <= 0 {
+		limit = DefaultSearchResults
+	}
+	limit = min(limit, MaxSearchResults)
+
+	features, err := probeSearchFeatures(param)
+	if err != nil {
+		return summary, err
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var parseErr []string
+	cmd := buildRemoteScriptCmd(param, buildSearchScript(roots, options, features))
+	err = util.ExecStream(runCtx, cmd, func(line string) {
+		if summary.Truncated {
+			return
+		}
+		if missing, ok := strings.CutPrefix(line, "missing "); ok {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: 不存在或无权访问", missing))
+			return
+		}
+		match, ok := parseSearchLine(line)
+		if !ok {
+			if strings.TrimSpace(line) != "" {
+				parseErr = append(parseErr, strings.TrimSpace(line))
+			}
+			return
+		}
+		// find 会输出搜索目录自身
+		if slices.Contains(roots, match.Path) || !matchSearchFilters(match, options) {
+			return
+		}
+		summary.Matches++
+		onMatch(match)
+		if summary.Matches >= limit {
+			summary.Truncated = true
+			cancel(errSearchLimit)
+		}
+	})
+	if ctx.Err() != nil {
+		return summary, ctx.Err()
+	}
+	if err != nil && !errors.Is(context.Cause(runCtx), errSearchLimit) {
+		return summary, err
+	}
+	if summary.Matches == 0 && len(parseErr) > 0 {
+		return summary, fmt.Errorf("%s", strings.Join(parseErr, "\n"))
+	}
+	return summary, nil
+}
+
+// normalizeSearchRoots 清理搜索目录，去掉重复的以及位于其他目录之下的目录
+func normalizeSearchRoots(roots []string) ([]string, error) {
+	var cleaned []string
+	for _, root := range roots {
+		if strings.TrimSpace(root) == "" {
+			continue
+		}
+		p := path.Clean(root)
+		if !strings.HasPrefix(p, "/") {
+			return nil, fmt.Errorf("必须使用绝对路径: %s", root)
+		}
+		cleaned = append(cleaned, p)
+	}
+	if len(cleaned) == 0 {
+		return nil, fmt.Errorf("请指定搜索目录")
+	}
+
+	var result []string
+	for i, p := range cleaned {
+		covered := false
+		for j, other := range cleaned {
+			if i != j && isUnder(p, other) && (p != other || j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// probeSearchFeatures 检查设备是否有 find，以及 find 是否支持 -size Nc 与 -mmin
+// Android 6 之前的 toolbox 没有 find，早期 toybox 的 find 不支持部分条件
+func probeSearchFeatures(param ExecuteParams) (searchFeatures, error) {
+	script := "if ! command -v find >/dev/null 2>&1; then echo nofind; " +
+		"elif find / -maxdepth 0 -size +0c -mmin +0 >/dev/null 2>&1; then echo full; " +
+		"else echo basic; fi; date +%s"
+	res, err := util.Exec(buildRemoteScriptCmd(param, script), true, nil)
+	if err != nil {
+		return searchFeatures{}, err
+	}
+	lines := util.MultiLine(strings.TrimSpace(res))
+	if len(lines) == 0 {
+		return searchFeatures{}, fmt.Errorf("无法检测设备的 find 命令")
+	}
+	switch strings.TrimSpace(lines[0]) {
+	case "nofind":
+		return searchFeatures{}, fmt.Errorf("设备没有 find 命令，无法搜索")
+	case "full", "basic":
+	default:
+		return searchFeatures{}, fmt.Errorf("%s", strings.TrimSpace(res))
+	}
+
+	features := searchFeatures{filters: strings.TrimSpace(lines[0]) == "full"}
+	if len(lines) > 1 {
+		features.now, _ = strconv.ParseInt(strings.TrimSpace(lines[1]), 10, 64)
+	}
+	if features.now <= 0 {
+		features.filters = false
+	}
+	return features, nil
+}
+
+// buildSearchScript 生成设备端搜索脚本，每个目录一条 find，不存在的目录输出 missing 行
+// 内容搜索用 -exec grep -q {} \; 作为条件，避免 while read 循环对引号的依赖
+func buildSearchScript(roots []string, options SearchOptions, features searchFeatures) string {
+	var conditions []string
+	fileType := options.Type
+	if options.Content != "" || options.MinSize > 0 || options.MaxSize > 0 {
+		fileType = SearchTypeFile
+	}
+	if fileType == SearchTypeFile || fileType == SearchTypeDir {
+		conditions = append(conditions, "-type "+fileType)
+	}
+	if options.Name != "" {
+		flag := "-name"
+		if options.IgnoreCase {
+			flag = "-iname"
+		}
+		conditions = append(conditions, flag+" "+deviceQuote(searchGlob(options.Name)))
+	}
+	if features.filters {
+		if options.MinSize > 0 {
+			conditions = append(conditions, fmt.Sprintf("-size +%dc", options.MinSize-1))
+		}
+		if options.MaxSize > 0 {
+			conditions = append(conditions, fmt.Sprintf("-size -%dc", options.MaxSize+1))
+		}
+		// -mmin 按分钟取整，条件放宽一分钟，精确比较在本地完成
+		if options.ModifiedAfter > 0 {
+			if minutes := (features.now-options.ModifiedAfter)/60 + 2; minutes > 0 {
+				conditions = append(conditions, fmt.Sprintf("-mmin -%d", minutes))
+			}
+		}
+		if options.ModifiedBefore > 0 {
+			if minutes := (features.now-options.ModifiedBefore)/60 - 1; minutes > 0 {
+				conditions = append(conditions, fmt.Sprintf("-mmin +%d", minutes))
+			}
+		}
+	}
+	if options.Content != "" {
+		grep := "grep -q -s -F"
+		if options.IgnoreCase {
+			grep += " -i"
+		}
+		conditions = append(conditions, fmt.Sprintf(`-exec %s -e %s {} \;`, grep, deviceQuote(options.Content)))
+	}
+	conditions = append(conditions, fmt.Sprintf("-exec stat -c %s {} +", deviceQuote(searchStatFormat)))
+
+	var parts []string
+	for _, root := range roots {
+		// 从 root/ 开始，搜索目录本身是软链接（如 /sdcard）时 find 才会进入链接指向的目录
+		quoted := deviceQuote(root)
+		parts = append(parts, fmt.Sprintf("if [ -e %s ]; then find %s %s 2>/dev/null; else echo missing %s; fi",
+			quoted, deviceQuote(dirArg(root)), strings.Join(conditions, " "), quoted))
+	}
+	// 部分子目录无权访问时 find 退出码非 0，结果仍然有效
+	return strings.Join(parts, "; ") + "; true"
+}
+
+// searchGlob 不含通配符的名称按包含匹配
+func searchGlob(name string) string {
+	if strings.ContainsAny(name, "*?[") {
+		return name
+	}
+	return "*" + name + "*"
+}
+
+// parseSearchLine 解析 stat 输出的一行：大小 修改时间 权限 路径
+// 从 root/ 开始的 find 会输出 root/ 自身，部分实现还会输出 root//name，路径统一清理
+func parseSearchLine(line string) (SearchMatch, bool) {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) != 4 || !strings.HasPrefix(fields[3], "/") || len(fields[2]) < 10 {
+		return SearchMatch{}, false
+	}
+	fields[3] = path.Clean(fields[3])
+	size, err1 := strconv.ParseInt(fields[0], 10, 64)
+	mtime, err2 := strconv.ParseInt(fields[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return SearchMatch{}, false
+	}
+	return SearchMatch{
+		Path:        fields[3],
+		Name:        path.Base(fields[3]),
+		Size:        size,
+		ModTime:     mtime,
+		Permissions: fields[2],
+		IsDir:       fields[2][0] == 'd',
+	}, true
+}
+
+// matchSearchFilters 在本地精确检查大小与时间条件，设备端 find 不支持这些条件或按分钟取整时以此为准
+func matchSearchFilters(match SearchMatch, options SearchOptions) bool {
+	if options.MinSize > 0 && match.Size < options.MinSize {
+		return false
+	}
+	if options.MaxSize > 0 && match.Size > options.MaxSize {
+		return false
+	}
+	if options.ModifiedAfter > 0 && match.ModTime < options.ModifiedAfter {
+		return false
+	}
+	if options.ModifiedBefore > 0 && match.ModTime > options.ModifiedBefore {
+		return false
+	}
+	return true
+}
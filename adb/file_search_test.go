@@ -0,0 +1,45 @@
+package adb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildSearchScriptFollowsRootSymlink(t *testing.T) {
+	script := buildSearchScript([]string{"/sdcard", "/"}, SearchOptions{Name: "a.txt"}, searchFeatures{})
+	for _, want := range []string{
+		"if [ -e '/sdcard' ]; then find '/sdcard/' ",
+		"else echo missing '/sdcard'; fi",
+		"if [ -e '/' ]; then find '/' ",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestParseSearchLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		path  string
+		isDir bool
+		ok    bool
+	}{
+		{"3452 1714530000 drwxrwx--x /sdcard/", "/sdcard", true, true},
+		{"12 1714530000 -rw-rw---- /sdcard//Download/a b.txt", "/sdcard/Download/a b.txt", false, true},
+		{"12 1714530000 -rw-rw---- /sdcard/Download/a.txt", "/sdcard/Download/a.txt", false, true},
+		{"find: /sdcard/x: Permission denied", "", false, false},
+		{"12 abc -rw-rw---- /sdcard/a", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			match, ok := parseSearchLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && (match.Path != tt.path || match.IsDir != tt.isDir) {
+				t.Errorf("path %q dir %v, want %q dir %v", match.Path, match.IsDir, tt.path, tt.isDir)
+			}
+		})
+	}
+}
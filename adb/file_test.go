@@ -0,0 +1,163 @@
+package adb
+
+import "testing"
+
+// Android 6 起的 toybox ls -la
+const lsToybox = `total 52
+drwxrwx--x  4 system  sdcard_rw 3452 2024-05-01 10:20 .
+drwxr-xr-x  3 root    root        60 2024-05-01 10:19 ..
+drwxrwx--x  2 u0_a123 sdcard_rw 3452 2024-05-01 10:20 Download
+-rw-rw----  1 u0_a123 sdcard_rw 1234 2024-05-02 08:03 my notes.txt
+lrwxrwxrwx  1 root    root        21 2024-05-01 10:20 primary -> /storage/self/primary
+crw-rw-rw-  1 root    root      1,   3 2024-05-01 10:20 null
+ls: ./secret: Permission denied
+`
+
+// Android 6 之前的 toolbox ls -la：没有链接数，目录和链接没有大小，也不输出 . 和 ..
+const lsToolbox = `drwxr-xr-x root     root              2015-01-01 00:00 acct
+-rw-r--r-- root     root         1234 2015-01-01 00:00 default.prop
+lrwxrwxrwx root     root              2015-01-01 00:00 sdcard -> /storage/emulated/legacy
+-rw-r--r-- root     root           16 2015-03-04 05:06 file with  spaces
+`
+
+// busybox ls -la：月份格式，半年内的文件省略年份
+const lsBusybox = `total 8
+drwxr-xr-x    2 root     root          4096 Jan  1 00:00 .
+drwxr-xr-x    3 root     root          4096 Jan  1 00:00 ..
+-rw-r--r--    1 root     root           123 Mar  9 14:05 build log.txt
+lrwxrwxrwx    1 root     root            21 Dec 31  2020 sdcard -> /storage/self/primary
+drwxr-xr-x    2 root     root          4096 Feb 29  2020 old
+`
+
+func TestParseLsOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		dir      string
+		want     []RemoteFileEntry
+		errLines int
+	}{
+		{
+			name:    "toybox",
+			content: lsToybox,
+			dir:     "/sdcard",
+			want: []RemoteFileEntry{
+				{Name: "Download", Path: "/sdcard/Download", Type: RemoteFileTypeDirectory, Size: 3452, Mode: "drwxrwx--x", Links: 2, Owner: "u0_a123", Group: "sdcard_rw", ModTime: "2024-05-01 10:20"},
+				{Name: "my notes.txt", Path: "/sdcard/my notes.txt", Type: RemoteFileTypeFile, Size: 1234, Mode: "-rw-rw----", Links: 1, Owner: "u0_a123", Group: "sdcard_rw", ModTime: "2024-05-02 08:03"},
+				{Name: "primary", Path: "/sdcard/primary", Type: RemoteFileTypeSymlink, Size: 21, Mode: "lrwxrwxrwx", Links: 1, Owner: "root", Group: "root", ModTime: "2024-05-01 10:20", LinkTarget: "/storage/self/primary"},
+				{Name: "null", Path: "/sdcard/null", Type: RemoteFileTypeChar, Mode: "crw-rw-rw-", Links: 1, Owner: "root", Group: "root", ModTime: "2024-05-01 10:20"},
+			},
+			errLines: 1,
+		},
+		{
+			name:    "toolbox",
+			content: lsToolbox,
+			dir:     "/",
+			want: []RemoteFileEntry{
+				{Name: "acct", Path: "/acct", Type: RemoteFileTypeDirectory, Mode: "drwxr-xr-x", Owner: "root", Group: "root", ModTime: "2015-01-01 00:00"},
+				{Name: "default.prop", Path: "/default.prop", Type: RemoteFileTypeFile, Size: 1234, Mode: "-rw-r--r--", Owner: "root", Group: "root", ModTime: "2015-01-01 00:00"},
+				{Name: "sdcard", Path: "/sdcard", Type: RemoteFileTypeSymlink, Mode: "lrwxrwxrwx", Owner: "root", Group: "root", ModTime: "2015-01-01 00:00", LinkTarget: "/storage/emulated/legacy"},
+				{Name: "file with  spaces", Path: "/file with  spaces", Type: RemoteFileTypeFile, Size: 16, Mode: "-rw-r--r--", Owner: "root", Group: "root", ModTime: "2015-03-04 05:06"},
+			},
+		},
+		{
+			name:    "busybox",
+			content: lsBusybox,
+			dir:     "/data/local/tmp/",
+			want: []RemoteFileEntry{
+				{Name: "build log.txt", Path: "/data/local/tmp/build log.txt", Type: RemoteFileTypeFile, Size: 123, Mode: "-rw-r--r--", Links: 1, Owner: "root", Group: "root", ModTime: "2024-03-09 14:05"},
+				{Name: "sdcard", Path: "/data/local/tmp/sdcard", Type: RemoteFileTypeSymlink, Size: 21, Mode: "lrwxrwxrwx", Links: 1, Owner: "root", Group: "root", ModTime: "2020-12-31 00:00", LinkTarget: "/storage/self/primary"},
+				{Name: "old", Path: "/data/local/tmp/old", Type: RemoteFileTypeDirectory, Size: 4096, Mode: "drwxr-xr-x", Links: 2, Owner: "root", Group: "root", ModTime: "2020-02-29 00:00"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, errLines := parseLsOutput(tt.content, tt.dir, 2024)
+			if len(errLines) != tt.errLines {
+				t.Errorf("errLines = %q, want %d lines", errLines, tt.errLines)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("got %d entries %+v, want %d", len(entries), entries, len(tt.want))
+			}
+			for i := range tt.want {
+				if entries[i] != tt.want[i] {
+					t.Errorf("entry %d:\n got %+v\nwant %+v", i, entries[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLsOutputErrorOnly(t *testing.T) {
+	entries, errLines := parseLsOutput("ls: /data: Permission denied\n", "/data", 2024)
+	if len(entries) != 0 || len(errLines) != 1 {
+		t.Fatalf("entries = %+v errLines = %q", entries, errLines)
+	}
+}
+
+func TestDirArg(t *testing.T) {
+	// 不带 / 时 ls -la /sdcard 只输出链接本身，会被当作 /sdcard/sdcard
+	tests := map[string]string{
+		"/":                     "/",
+		"/sdcard":               "/sdcard/",
+		"/sdcard/":              "/sdcard/",
+		"/storage/my dir":       "/storage/my dir/",
+		"/data/data/com.a.b/db": "/data/data/com.a.b/db/",
+	}
+	for dir, want := range tests {
+		if got := dirArg(dir); got != want {
+			t.Errorf("dirArg(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestCommitUploadAsRejectsOutsidePaths(t *testing.T) {
+	// root 模式下覆盖会执行 rm -rf，路径检查必须在执行任何命令之前完成
+	access := PackageAccess{Package: "com.example", Mode: AccessModeRoot, DataDir: "/data/data/com.example"}
+	tests := []struct {
+		name      string
+		remoteDir string
+		names     []string
+	}{
+		{"outside data dir", "/data/system", []string{"packages.xml"}},
+		{"escape by dot dot", "/data/data/com.example/../com.other", []string{"a.db"}},
+		{"sibling prefix", "/data/data/com.example2", []string{"a.db"}},
+		{"name escapes", "/data/data/com.example/files", []string{"../../com.other"}},
+		{"data dir itself", "/data/data", []string{"com.example"}},
+		{"name is parent", "/data/data/com.example/files", []string{".."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := CommitUploadAs(ExecuteParams{}, access, "/data/local/tmp/adbtool_runas_1", tt.names, tt.remoteDir, ConflictOverwrite); err == nil {
+				t.Errorf("CommitUploadAs(%q, %q) should be rejected", tt.remoteDir, tt.names)
+			}
+		})
+	}
+}
+
+func TestCheckProtectedPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		force bool
+		ok    bool
+	}{
+		{"/sdcard/Download/a.txt", false, true},
+		{"/data/local/tmp/a", false, true},
+		{"/", false, false},
+		{"/system/app", false, false},
+		{"/system/app", true, true},
+		// 强制模式也不接受空路径与相对路径
+		{"", false, false},
+		{"", true, false},
+		{"sdcard/a.txt", true, false},
+		{"../a", true, false},
+		{".", true, false},
+	}
+	for _, tt := range tests {
+		// 非 /data 路径不会查询设备是否 root
+		if err := checkProtectedPath(ExecuteParams{}, tt.path, tt.force); (err == nil) != tt.ok {
+			t.Errorf("checkProtectedPath(%q, force=%v) err = %v, want ok %v", tt.path, tt.force, err, tt.ok)
+		}
+	}
+}
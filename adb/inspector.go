@@ -0,0 +1,536 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ActivityRecord 任务栈中的一个 Activity
+type ActivityRecord struct {
+	Component   string `json:"component"`
+	PackageName string `json:"packageName"`
+	ProcessName string `json:"processName,omitempty"`
+	UserId      int    `json:"userId"`
+	Intent      string `json:"intent,omitempty"`
+	LaunchMode  string `json:"launchMode"`
+	State       string `json:"state,omitempty"`
+	Resumed     bool   `json:"resumed"`
+}
+
+// TaskRecord 一个任务（back stack），Activities 从栈顶到栈底排列
+type TaskRecord struct {
+	TaskId       int              `json:"taskId"`
+	Affinity     string           `json:"affinity,omitempty"`
+	ActivityType string           `json:"activityType,omitempty"`
+	WindowMode   string           `json:"windowMode,omitempty"`
+	Visible      bool             `json:"visible"`
+	Activities   []ActivityRecord `json:"activities"`
+}
+
+// DisplayStack 单个屏幕上的任务列表，Tasks 从上到下排列
+type DisplayStack struct {
+	DisplayId int          `json:"displayId"`
+	Tasks     []TaskRecord `json:"tasks"`
+}
+
+// ActivityStack dumpsys activity activities 的结构化结果
+type ActivityStack struct {
+	ResumedActivity string         `json:"resumedActivity"`
+	Displays        []DisplayStack `json:"displays"`
+}
+
+// FragmentNode Fragment 树中的一个节点，Children 来自其 Child FragmentManager
+type FragmentNode struct {
+	Name     string          `json:"name"`
+	Hash     string          `json:"hash"`
+	Who      string          `json:"who,omitempty"`
+	Id       string          `json:"id,omitempty"`
+	Tag      string          `json:"tag,omitempty"`
+	State    int             `json:"state"`
+	Added    bool            `json:"added"`
+	Hidden   bool            `json:"hidden"`
+	Children []*FragmentNode `json:"children"`
+}
+
+// FragmentTree 栈顶 Activity 的 Fragment 树
+type FragmentTree struct {
+	Activity  string          `json:"activity"`
+	Fragments []*FragmentNode `json:"fragments"`
+}
+
+// ViewBounds 控件在屏幕上的像素坐标，与截图坐标一致
+type ViewBounds struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+}
+
+// ViewNode uiautomator dump 中的一个控件
+type ViewNode struct {
+	Index       int         `json:"index"`
+	Class       string      `json:"class"`
+	Package     string      `json:"package"`
+	ResourceId  string      `json:"resourceId"`
+	Text        string      `json:"text"`
+	ContentDesc string      `json:"contentDesc"`
+	Bounds      ViewBounds  `json:"bounds"`
+	Clickable   bool        `json:"clickable"`
+	Enabled     bool        `json:"enabled"`
+	Focused     bool        `json:"focused"`
+	Scrollable  bool        `json:"scrollable"`
+	Selected    bool        `json:"selected"`
+	Checked     bool        `json:"checked"`
+	Children    []*ViewNode `json:"children"`
+}
+
+// ViewHierarchy 当前界面的控件树
+type ViewHierarchy struct {
+	Rotation int         `json:"rotation"`
+	Nodes    []*ViewNode `json:"nodes"`
+}
+
+var (
+	reDisplayHeader = regexp.MustCompile(`^Display #(\d+)`)
+	reTaskHeader    = regexp.MustCompile(`^\* (?:Task|TaskRecord)\{[0-9a-f]+ #(\d+)(.*)\}`)
+	reHistRecord    = regexp.MustCompile(`^\* Hist #\d+: ActivityRecord\{[0-9a-f]+ u(\d+) (\S+) t(-?\d+)`)
+	reAffinity      = regexp.MustCompile(`\bA=(?:\d+:)?(\S+)`)
+	reActivityType  = regexp.MustCompile(`\btype=(\w+)`)
+	reWindowMode    = regexp.MustCompile(`\bmode=([\w-]+)`)
+	reTaskVisible   = regexp.MustCompile(`\bvisible=(true|false)`)
+	reLaunchMode    = regexp.MustCompile(`\blaunchMode=(\d+)`)
+	reActivityState = regexp.MustCompile(`\bstate=([A-Z_]+)`)
+	reProcessName   = regexp.MustCompile(`\bprocessName=(\S+)`)
+	reFragmentEntry = regexp.MustCompile(`^(?:#\d+:\s*)?([\w.$]+)\{([0-9a-f]+)([^}]*)\}(.*)$`)
+	reFragmentWho   = regexp.MustCompile(`\(([0-9a-f-]{8,})`)
+	reFragmentId    = regexp.MustCompile(`\bid=(0x[0-9a-f]+)`)
+	reFragmentTag   = regexp.MustCompile(`\btag=([^)\s]+)`)
+	reFragmentState = regexp.MustCompile(`\bmState=(-?\d+)`)
+	reFragmentAdded = regexp.MustCompile(`\bmAdded=(true|false)`)
+	reFragmentHide  = regexp.MustCompile(`\bmHidden=(true|false)`)
+	reViewBounds    = regexp.MustCompile(`^\[(-?\d+),(-?\d+)\]\[(-?\d+),(-?\d+)\]$`)
+)
+
+// launchModes ActivityInfo.launchMode 对应的名称
+var launchModes = map[string]string{
+	"0": "standard",
+	"1": "singleTop",
+	"2": "singleTask",
+	"3": "singleInstance",
+	"4": "singleInstancePerTask",
+}
+
+// GetActivityStack 获取每个屏幕的任务栈
+func GetActivityStack(param ExecuteParams) (ActivityStack, error) {
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys activity activities")
+	result, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return ActivityStack{}, err
+	}
+	return parseActivityStack(result), nil
+}
+
+// parseActivityStack 解析 dumpsys activity activities
+// 兼容 Android 9 及以下的 Stack/TaskRecord 与 Android 10 起的 Task 格式，格式示例:
+//
+//	Display #0 (activities from top to bottom):
+//	  * Task{d5f1b2c #123 type=standard A=10123:com.example U=0 visible=true mode=fullscreen sz=1}
+//	    * Hist #0: ActivityRecord{4e0a2b u0 com.example/.MainActivity t123}
+//	      packageName=com.example processName=com.example
+//	      Intent { act=android.intent.action.MAIN flg=0x10200000 cmp=com.example/.MainActivity }
+//	      state=RESUMED stopped=false delayedResume=false finishing=false
+func parseActivityStack(content string) ActivityStack {
+	stack := ActivityStack{Displays: []DisplayStack{}}
+	var display *DisplayStack
+	var task *TaskRecord
+	var activity *ActivityRecord
+	activityIndent := 0
+
+	for _, line := range util.MultiLine(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if match := reDisplayHeader.FindStringSubmatch(trimmed); match != nil {
+			id, _ := strconv.Atoi(match[1])
+			stack.Displays = append(stack.Displays, DisplayStack{DisplayId: id, Tasks: []TaskRecord{}})
+			display = &stack.Displays[len(stack.Displays)-1]
+			task, activity = nil, nil
+			continue
+		}
+
+		if stack.ResumedActivity == "" && isResumedActivityLine(trimmed) {
+			stack.ResumedActivity = extractActivityComponent(trimmed)
+			continue
+		}
+
+		if display == nil {
+			continue
+		}
+
+		if match := reTaskHeader.FindStringSubmatch(trimmed); match != nil {
+			id, _ := strconv.Atoi(match[1])
+			display.Tasks = append(display.Tasks, TaskRecord{TaskId: id, Visible: true, Activities: []ActivityRecord{}})
+			task = &display.Tasks[len(display.Tasks)-1]
+			applyTaskAttributes(task, match[2])
+			activity = nil
+			continue
+		}
+
+		if match := reHistRecord.FindStringSubmatch(trimmed); match != nil {
+			taskId, _ := strconv.Atoi(match[3])
+			// Android 12 起根任务下嵌套叶子任务，按 t<id> 找到 Activity 真正所属的任务
+			if owner := findTask(display, taskId); owner != nil {
+				task = owner
+			}
+			if task == nil {
+				continue
+			}
+			userId, _ := strconv.Atoi(match[1])
+			packageName, _, _ := strings.Cut(match[2], "/")
+			task.Activities = append(task.Activities, ActivityRecord{
+				Component:   match[2],
+				PackageName: packageName,
+				UserId:      userId,
+				LaunchMode:  launchModes["0"],
+			})
+			activity = &task.Activities[len(task.Activities)-1]
+			activityIndent = indent
+			continue
+		}
+
+		if activity == nil {
+			continue
+		}
+		// 缩进回退说明当前 Activity 的属性段已经结束
+		if indent <= activityIndent {
+			activity = nil
+			continue
+		}
+		if strings.HasPrefix(trimmed, "Intent {") && activity.Intent == "" {
+			activity.Intent = trimmed
+		}
+		if match := reProcessName.FindStringSubmatch(trimmed); match != nil {
+			activity.ProcessName = match[1]
+		}
+		if match := reLaunchMode.FindStringSubmatch(trimmed); match != nil {
+			if mode, ok := launchModes[match[1]]; ok {
+				activity.LaunchMode = mode
+			}
+		}
+		if match := reActivityState.FindStringSubmatch(trimmed); match != nil && strings.HasPrefix(trimmed, "state=") {
+			activity.State = match[1]
+		}
+	}
+
+	// 去掉只包含子任务的根任务
+	for i := range stack.Displays {
+		tasks := stack.Displays[i].Tasks[:0]
+		for _, t := range stack.Displays[i].Tasks {
+			if len(t.Activities) > 0 {
+				tasks = append(tasks, t)
+			}
+		}
+		stack.Displays[i].Tasks = tasks
+	}
+
+	markResumedActivity(&stack)
+	return stack
+}
+
+func isResumedActivityLine(line string) bool {
+	return strings.HasPrefix(line, "mResumedActivity") ||
+		strings.HasPrefix(line, "ResumedActivity") ||
+		strings.HasPrefix(line, "topResumedActivity")
+}
+
+func applyTaskAttributes(task *TaskRecord, attrs string) {
+	if match := reAffinity.FindStringSubmatch(attrs); match != nil {
+		task.Affinity = match[1]
+	}
+	if match := reActivityType.FindStringSubmatch(attrs); match != nil {
+		task.ActivityType = match[1]
+	}
+	if match := reWindowMode.FindStringSubmatch(attrs); match != nil {
+		task.WindowMode = match[1]
+	}
+	if match := reTaskVisible.FindStringSubmatch(attrs); match != nil {
+		task.Visible = match[1] == "true"
+	}
+}
+
+func findTask(display *DisplayStack, taskId int) *TaskRecord {
+	for i := len(display.Tasks) - 1; i >= 0; i-- {
+		if display.Tasks[i].TaskId == taskId {
+			return &display.Tasks[i]
+		}
+	}
+	return nil
+}
+
+func markResumedActivity(stack *ActivityStack) {
+	for i := range stack.Displays {
+		for j := range stack.Displays[i].Tasks {
+			activities := stack.Displays[i].Tasks[j].Activities
+			for k := range activities {
+				if activities[k].State == "RESUMED" || (stack.ResumedActivity != "" && activities[k].Component == stack.ResumedActivity) {
+					activities[k].Resumed = true
+				}
+			}
+		}
+	}
+}
+
+// GetFragmentTree 获取栈顶 Activity 的 Fragment 树，activity 为空时取 dumpsys activity top 的第一个 Activity
+func GetFragmentTree(param ExecuteParams, activity string) (FragmentTree, error) {
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys activity top")
+	result, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return FragmentTree{}, err
+	}
+	return parseFragmentTree(result, activity), nil
+}
+
+// parseFragmentTree 解析 dumpsys activity top 中的 Active Fragments 段
+// 子 Fragment 出现在父 Fragment 的 Child FragmentManager 中，按缩进确定父子关系，格式示例:
+//
+//	ACTIVITY com.example/.MainActivity 4e0a2b pid=1234
+//	    Active Fragments:
+//	      HomeFragment{8f2d1c3} (2c6e5a10-... id=0x7f0a00d2 tag=home)
+//	        mState=7 mWho=2c6e-... mBackStackNesting=0
+//	        mAdded=true mRemoving=false mFromLayout=false mInLayout=false
+//	        Child FragmentManager{...}:
+//	          Active Fragments:
+//	            ListFragment{1a2b3c} (7d41c2e8-... id=0x7f0a00d5)
+func parseFragmentTree(content string, activity string) FragmentTree {
+	tree := FragmentTree{Activity: activity, Fragments: []*FragmentNode{}}
+
+	type stackEntry struct {
+		indent int
+		node   *FragmentNode
+	}
+	var nodes []stackEntry
+	var sections []int
+	inActivity := false
+	var current *FragmentNode
+	currentIndent := 0
+
+	for _, line := range util.MultiLine(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		if strings.HasPrefix(trimmed, "ACTIVITY ") {
+			fields := strings.Fields(trimmed)
+			component := ""
+			if len(fields) > 1 {
+				component = fields[1]
+			}
+			if inActivity {
+				break
+			}
+			if tree.Activity == "" || component == tree.Activity {
+				tree.Activity = component
+				inActivity = true
+			}
+			continue
+		}
+		if !inActivity {
+			continue
+		}
+
+		for len(sections) > 0 && indent <= sections[len(sections)-1] {
+			sections = sections[:len(sections)-1]
+		}
+		if strings.HasPrefix(trimmed, "Active Fragments") {
+			sections = append(sections, indent)
+			current = nil
+			continue
+		}
+		if len(sections) == 0 {
+			current = nil
+			continue
+		}
+
+		if match := reFragmentEntry.FindStringSubmatch(trimmed); match != nil {
+			if match[1] == "ReportFragment" {
+				current = nil
+				continue
+			}
+			node := &FragmentNode{
+				Name:     match[1],
+				Hash:     match[2],
+				Children: []*FragmentNode{},
+			}
+			// androidx: Name{hash} (who id=0x.. tag=..)；framework: Name{hash #0 id=0x.. tag}
+			attrs := match[3] + match[4]
+			if m := reFragmentWho.FindStringSubmatch(attrs); m != nil {
+				node.Who = m[1]
+			}
+			if m := reFragmentId.FindStringSubmatch(attrs); m != nil {
+				node.Id = m[1]
+			}
+			if m := reFragmentTag.FindStringSubmatch(attrs); m != nil {
+				node.Tag = m[1]
+			}
+			for len(nodes) > 0 && nodes[len(nodes)-1].indent >= indent {
+				nodes = nodes[:len(nodes)-1]
+			}
+			if len(nodes) > 0 {
+				parent := nodes[len(nodes)-1].node
+				parent.Children = append(parent.Children, node)
+			} else {
+				tree.Fragments = append(tree.Fragments, node)
+			}
+			nodes = append(nodes, stackEntry{indent: indent, node: node})
+			current, currentIndent = node, indent
+			continue
+		}
+
+		if current == nil || indent <= currentIndent {
+			continue
+		}
+		if match := reFragmentState.FindStringSubmatch(trimmed); match != nil {
+			current.State, _ = strconv.Atoi(match[1])
+		}
+		if match := reFragmentAdded.FindStringSubmatch(trimmed); match != nil {
+			current.Added = match[1] == "true"
+		}
+		if match := reFragmentHide.FindStringSubmatch(trimmed); match != nil {
+			current.Hidden = match[1] == "true"
+		}
+	}
+
+	return tree
+}
+
+// uiautomatorNode uiautomator dump 的 XML 节点
+type uiautomatorNode struct {
+	Index       int               `xml:"index,attr"`
+	Text        string            `xml:"text,attr"`
+	ResourceId  string            `xml:"resource-id,attr"`
+	Class       string            `xml:"class,attr"`
+	Package     string            `xml:"package,attr"`
+	ContentDesc string            `xml:"content-desc,attr"`
+	Checked     bool              `xml:"checked,attr"`
+	Clickable   bool              `xml:"clickable,attr"`
+	Enabled     bool              `xml:"enabled,attr"`
+	Focused     bool              `xml:"focused,attr"`
+	Scrollable  bool              `xml:"scrollable,attr"`
+	Selected    bool              `xml:"selected,attr"`
+	Bounds      string            `xml:"bounds,attr"`
+	Nodes       []uiautomatorNode `xml:"node"`
+}
+
+type uiautomatorHierarchy struct {
+	Rotation int               `xml:"rotation,attr"`
+	Nodes    []uiautomatorNode `xml:"node"`
+}
+
+// GetViewHierarchy 通过 uiautomator dump 获取当前界面的控件树
+func GetViewHierarchy(param ExecuteParams) (ViewHierarchy, error) {
+	devicePath := "/sdcard/adbtool_window_dump.xml"
+	dumpCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("uiautomator dump %s", devicePath))
+	res, err := util.Exec(dumpCmd, true, nil)
+	if err != nil {
+		return ViewHierarchy{}, err
+	}
+	if !strings.Contains(res, "dumped to") {
+		return ViewHierarchy{}, fmt.Errorf("uiautomator dump 失败: %s", strings.TrimSpace(res))
+	}
+
+	catCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("cat %s", devicePath))
+	content, err := util.Exec(catCmd, true, nil)
+	rmCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm %s", devicePath))
+	_, _ = util.Exec(rmCmd, true, nil)
+	if err != nil {
+		return ViewHierarchy{}, err
+	}
+
+	return parseViewHierarchy(content)
+}
+
+// parseViewHierarchy 解析 uiautomator dump 的 XML
+func parseViewHierarchy(content string) (ViewHierarchy, error) {
+	start := strings.Index(content, "<hierarchy")
+	if start < 0 {
+		return ViewHierarchy{}, fmt.Errorf("未找到控件树")
+	}
+
+	var raw uiautomatorHierarchy
+	if err := xml.Unmarshal([]byte(content[start:]), &raw); err != nil {
+		return ViewHierarchy{}, fmt.Errorf("解析控件树失败: %w", err)
+	}
+
+	hierarchy := ViewHierarchy{Rotation: raw.Rotation, Nodes: convertViewNodes(raw.Nodes)}
+	return hierarchy, nil
+}
+
+func convertViewNodes(raw []uiautomatorNode) []*ViewNode {
+	nodes := make([]*ViewNode, 0, len(raw))
+	for _, r := range raw {
+		node := &ViewNode{
+			Index:       r.Index,
+			Class:       r.Class,
+			Package:     r.Package,
+			ResourceId:  r.ResourceId,
+			Text:        r.Text,
+			ContentDesc: r.ContentDesc,
+			Clickable:   r.Clickable,
+			Enabled:     r.Enabled,
+			Focused:     r.Focused,
+			Scrollable:  r.Scrollable,
+			Selected:    r.Selected,
+			Checked:     r.Checked,
+			Children:    convertViewNodes(r.Nodes),
+		}
+		// bounds 格式: [0,0][1080,2400]
+		if match := reViewBounds.FindStringSubmatch(r.Bounds); match != nil {
+			node.Bounds.Left, _ = strconv.Atoi(match[1])
+			node.Bounds.Top, _ = strconv.Atoi(match[2])
+			node.Bounds.Right, _ = strconv.Atoi(match[3])
+			node.Bounds.Bottom, _ = strconv.Atoi(match[4])
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// CaptureScreenshot 截图并返回 PNG 的 data URL，供前端叠加控件边框
+// 通过文件中转而不是 exec-out 读取标准输出，避免 Windows 上的输出转码破坏二进制数据
+func CaptureScreenshot(param ExecuteParams) (string, error) {
+	devicePath := "/sdcard/adbtool_inspector.png"
+	localPath := filepath.Join(os.TempDir(), fmt.Sprintf("adbtool_inspector_%d.png", time.Now().UnixNano()))
+	defer os.Remove(localPath)
+
+	screencapCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("screencap -p %s", devicePath))
+	if res, err := util.Exec(screencapCmd, false, nil); err != nil {
+		return "", fmt.Errorf("截图失败: %v, 输出: %s", err, res)
+	}
+
+	pullCmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", devicePath, quoteHostArg(localPath)))
+	res, err := util.Exec(pullCmd, false, nil)
+	rmCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm %s", devicePath))
+	_, _ = util.Exec(rmCmd, true, nil)
+	if err != nil {
+		return "", fmt.Errorf("拉取截图失败: %v, 输出: %s", err, res)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
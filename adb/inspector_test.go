@@ -0,0 +1,180 @@
+package adb
+
+import "testing"
+
+// Android 9：Stack 下的 TaskRecord
+const activitiesAndroid9 = `ACTIVITY MANAGER ACTIVITIES (dumpsys activity activities)
+Display #0 (activities from top to bottom):
+  Stack #12: type=standard mode=fullscreen
+  isSleeping=false
+  mBounds=Rect(0, 0 - 0, 0)
+    Task id #57
+    mBounds=Rect(0, 0 - 0, 0)
+    * TaskRecord{3b1e2a9 #57 A=com.example U=0 StackId=12 sz=2}
+      userId=0 effectiveUid=u0a123 mCallingUid=2000 mUserSetupComplete=true mCallingPackage=null
+      * Hist #1: ActivityRecord{7c2d1f0 u0 com.example/.DetailActivity t57}
+          packageName=com.example processName=com.example:ui
+          launchedFromUid=10123 launchedFromPackage=com.example userId=0
+          Intent { cmp=com.example/.DetailActivity }
+          launchMode=1 lockTaskLaunchMode=LOCK_TASK_LAUNCH_MODE_DEFAULT
+          state=RESUMED stopped=false delayedResume=false finishing=false
+      * Hist #0: ActivityRecord{5a0b9e1 u0 com.example/.MainActivity t57}
+          packageName=com.example processName=com.example
+          Intent { act=android.intent.action.MAIN cat=[android.intent.category.LAUNCHER] flg=0x10200000 cmp=com.example/.MainActivity }
+          launchMode=2 lockTaskLaunchMode=LOCK_TASK_LAUNCH_MODE_DEFAULT
+          state=STOPPED stopped=true delayedResume=false finishing=false
+
+  Stack #0: type=home mode=fullscreen
+    * TaskRecord{1f2e3d4 #2 I=com.android.launcher3/.Launcher U=0 StackId=0 sz=1}
+      * Hist #0: ActivityRecord{9a8b7c6 u0 com.android.launcher3/.Launcher t2}
+          packageName=com.android.launcher3 processName=com.android.launcher3
+          state=STOPPED stopped=true delayedResume=false finishing=false
+
+ mResumedActivity: ActivityRecord{7c2d1f0 u0 com.example/.DetailActivity t57}
+`
+
+// Android 12：根任务下嵌套叶子任务，Activity 通过 t<id> 归属到叶子任务
+const activitiesAndroid12 = `ACTIVITY MANAGER ACTIVITIES (dumpsys activity activities)
+Display #0 (activities from top to bottom):
+  * Task{a1b2c3d #31 type=standard A=10150:com.example.split U=0 visible=true mode=fullscreen translucent=false sz=2}
+    mResumedActivity: ActivityRecord{44aa11 u10 com.example.split/.LeftActivity t32}
+    * Task{e4f5a6b #32 type=standard A=10150:com.example.split U=10 visible=true mode=multi-window translucent=false sz=1}
+      * Hist #0: ActivityRecord{44aa11 u10 com.example.split/.LeftActivity t32}
+        packageName=com.example.split processName=com.example.split
+        launchMode=0
+        state=RESUMED stopped=false delayedResume=false finishing=false
+    * Task{c7d8e9f #33 type=standard A=10150:com.example.split U=10 visible=false mode=multi-window translucent=false sz=1}
+      * Hist #0: ActivityRecord{55bb22 u10 com.example.split/.RightActivity t33}
+        packageName=com.example.split processName=com.example.split
+        launchMode=3
+        state=PAUSED stopped=false delayedResume=false finishing=false
+`
+
+func TestParseActivityStackAndroid9(t *testing.T) {
+	stack := parseActivityStack(activitiesAndroid9)
+	if stack.ResumedActivity != "com.example/.DetailActivity" {
+		t.Errorf("resumed = %q", stack.ResumedActivity)
+	}
+	if len(stack.Displays) != 1 || len(stack.Displays[0].Tasks) != 2 {
+		t.Fatalf("displays = %+v", stack.Displays)
+	}
+	task := stack.Displays[0].Tasks[0]
+	if task.TaskId != 57 || task.Affinity != "com.example" || len(task.Activities) != 2 {
+		t.Fatalf("task = %+v", task)
+	}
+	want := []ActivityRecord{
+		{Component: "com.example/.DetailActivity", PackageName: "com.example", ProcessName: "com.example:ui",
+			Intent: "Intent { cmp=com.example/.DetailActivity }", LaunchMode: "singleTop", State: "RESUMED", Resumed: true},
+		{Component: "com.example/.MainActivity", PackageName: "com.example", ProcessName: "com.example",
+			Intent:     "Intent { act=android.intent.action.MAIN cat=[android.intent.category.LAUNCHER] flg=0x10200000 cmp=com.example/.MainActivity }",
+			LaunchMode: "singleTask", State: "STOPPED"},
+	}
+	for i := range want {
+		if task.Activities[i] != want[i] {
+			t.Errorf("activity %d:\n got %+v\nwant %+v", i, task.Activities[i], want[i])
+		}
+	}
+	if home := stack.Displays[0].Tasks[1]; home.TaskId != 2 || home.Activities[0].Component != "com.android.launcher3/.Launcher" {
+		t.Errorf("home task = %+v", home)
+	}
+}
+
+func TestParseActivityStackNestedTasks(t *testing.T) {
+	stack := parseActivityStack(activitiesAndroid12)
+	if stack.ResumedActivity != "com.example.split/.LeftActivity" {
+		t.Errorf("resumed = %q", stack.ResumedActivity)
+	}
+	// 只包含子任务的根任务 #31 被去掉
+	tasks := stack.Displays[0].Tasks
+	if len(tasks) != 2 || tasks[0].TaskId != 32 || tasks[1].TaskId != 33 {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+	left, right := tasks[0].Activities[0], tasks[1].Activities[0]
+	if left.UserId != 10 || !left.Resumed || left.LaunchMode != "standard" || tasks[0].WindowMode != "multi-window" {
+		t.Errorf("left = %+v task %+v", left, tasks[0])
+	}
+	if right.Resumed || right.LaunchMode != "singleInstance" || right.State != "PAUSED" || tasks[1].Visible {
+		t.Errorf("right = %+v task %+v", right, tasks[1])
+	}
+}
+
+const activityTop = `TASK 10123:com.example id=57 userId=0
+  ACTIVITY com.example/.MainActivity 5a0b9e1 pid=4321
+    Local FragmentActivity 3c4d5e6 State:
+      mCreated=true mResumed=true mStopped=false
+    Active Fragments:
+      ReportFragment{1111111 #0 androidx.lifecycle.LifecycleDispatcher.report_fragment_tag}
+        mFragmentId=#0 mContainerId=#0 mTag=androidx.lifecycle.LifecycleDispatcher.report_fragment_tag
+      HomeFragment{8f2d1c3} (2c6e5a10-1b2c-4d5e-8f90-123456789abc id=0x7f0a00d2 tag=home)
+        mFragmentId=#7f0a00d2 mContainerId=#7f0a00d2 mTag=home
+        mState=7 mWho=2c6e5a10-1b2c-4d5e-8f90-123456789abc mBackStackNesting=0
+        mAdded=true mRemoving=false mFromLayout=false mInLayout=false
+        mHidden=false mDetached=false mMenuVisible=true mHasMenu=false
+        Child FragmentManager{4a5b6c7 in HomeFragment{8f2d1c3}}:
+          Active Fragments:
+            ListFragment{1a2b3c} (7d41c2e8-aaaa-bbbb-cccc-ddddeeeeffff id=0x7f0a00d5)
+              mState=5 mWho=7d41c2e8-aaaa-bbbb-cccc-ddddeeeeffff
+              mAdded=true mRemoving=false
+              mHidden=true mDetached=false
+      SettingsFragment{9e8d7c6} (0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0 tag=settings)
+        mState=1 mWho=0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0
+        mAdded=false mRemoving=false
+  ACTIVITY com.example/.DetailActivity 7c2d1f0 pid=4321
+    Active Fragments:
+      DetailFragment{abcdef0} (11111111-2222-3333-4444-555555555555 id=0x7f0a0001)
+`
+
+func TestParseFragmentTree(t *testing.T) {
+	tree := parseFragmentTree(activityTop, "")
+	if tree.Activity != "com.example/.MainActivity" {
+		t.Errorf("activity = %q", tree.Activity)
+	}
+	if len(tree.Fragments) != 2 {
+		t.Fatalf("fragments = %d, want 2", len(tree.Fragments))
+	}
+	home, settings := tree.Fragments[0], tree.Fragments[1]
+	if home.Name != "HomeFragment" || home.Hash != "8f2d1c3" || home.Id != "0x7f0a00d2" || home.Tag != "home" ||
+		home.Who != "2c6e5a10-1b2c-4d5e-8f90-123456789abc" || home.State != 7 || !home.Added || home.Hidden {
+		t.Errorf("home = %+v", home)
+	}
+	if len(home.Children) != 1 {
+		t.Fatalf("home children = %d, want 1", len(home.Children))
+	}
+	if list := home.Children[0]; list.Name != "ListFragment" || list.Id != "0x7f0a00d5" || list.State != 5 || !list.Hidden {
+		t.Errorf("list = %+v", list)
+	}
+	if settings.Name != "SettingsFragment" || settings.Tag != "settings" || settings.State != 1 || settings.Added || len(settings.Children) != 0 {
+		t.Errorf("settings = %+v", settings)
+	}
+
+	detail := parseFragmentTree(activityTop, "com.example/.DetailActivity")
+	if len(detail.Fragments) != 1 || detail.Fragments[0].Name != "DetailFragment" {
+		t.Errorf("detail fragments = %+v", detail.Fragments)
+	}
+}
+
+const windowDump = `UI hierchary dumped to: /dev/tty
+<?xml version='1.0' encoding='UTF-8' standalone='yes' ?><hierarchy rotation="1"><node index="0" text="" resource-id="" class="android.widget.FrameLayout" package="com.example" content-desc="" checkable="false" checked="false" clickable="false" enabled="true" focusable="false" focused="false" scrollable="false" long-clickable="false" password="false" selected="false" bounds="[0,0][2400,1080]"><node index="0" text="确定" resource-id="com.example:id/ok" class="android.widget.Button" package="com.example" content-desc="ok button" checkable="false" checked="false" clickable="true" enabled="true" focusable="true" focused="true" scrollable="false" long-clickable="false" password="false" selected="false" bounds="[100,-20][300,120]" /></node></hierarchy>`
+
+func TestParseViewHierarchy(t *testing.T) {
+	hierarchy, err := parseViewHierarchy(windowDump)
+	if err != nil {
+		t.Fatalf("parseViewHierarchy: %v", err)
+	}
+	if hierarchy.Rotation != 1 || len(hierarchy.Nodes) != 1 {
+		t.Fatalf("hierarchy = %+v", hierarchy)
+	}
+	root := hierarchy.Nodes[0]
+	if root.Class != "android.widget.FrameLayout" || root.Bounds != (ViewBounds{0, 0, 2400, 1080}) || len(root.Children) != 1 {
+		t.Errorf("root = %+v", root)
+	}
+	button := root.Children[0]
+	if button.Text != "确定" || button.ResourceId != "com.example:id/ok" || button.ContentDesc != "ok button" ||
+		!button.Clickable || !button.Focused || button.Bounds != (ViewBounds{100, -20, 300, 120}) {
+		t.Errorf("button = %+v", button)
+	}
+
+	if _, err := parseViewHierarchy("ERROR: null root node returned by UiTestAutomationBridge."); err == nil {
+		t.Error("expected error for dump without hierarchy")
+	}
+}
@@ -0,0 +1,89 @@
+package adb
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 安装失败时可以自动尝试的修复方式
+const (
+	InstallFixUninstall = "uninstall"  // 卸载后重新安装
+	InstallFixDowngrade = "downgrade"  // 加 -d 允许降级
+	InstallFixTestOnly  = "test-only"  // 加 -t 允许 testOnly 应用
+	InstallFixReplace   = "replace"    // 加 -r 覆盖安装
+	InstallFixFreeSpace = "free-space" // 需要用户清理存储，无法自动处理
+)
+
+var (
+	reInstallFailure = regexp.MustCompile(`(?:Failure|Failed to (?:install|commit)[^\[]*)\s*\[?(INSTALL_[A-Z_]+|DELETE_FAILED_[A-Z_]+)(?::\s*([^\]\r\n]*))?\]?`)
+	reFailurePackage = regexp.MustCompile(`(?:Package|package|Existing package)\s+([a-zA-Z][\w]*(?:\.[\w]+)+)`)
+)
+
+// InstallFailure 解析后的安装失败原因
+type InstallFailure struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	Explanation string `json:"explanation"`
+	Suggestion  string `json:"suggestion"`
+	Fix         string `json:"fix"`     // 见 InstallFix*，为空表示没有可自动执行的修复
+	Package     string `json:"package"` // 能从输出中识别出的包名
+}
+
+// installFailureInfo 错误码对应的说明、建议与修复方式
+type installFailureInfo struct {
+	explanation string
+	suggestion  string
+	fix         string
+}
+
+var installFailures = map[string]installFailureInfo{
+	"INSTALL_FAILED_UPDATE_INCOMPATIBLE":             {"已安装的版本签名与当前安装包不一致", "卸载已安装的应用后重新安装（会清除应用数据）", InstallFixUninstall},
+	"INSTALL_PARSE_FAILED_INCONSISTENT_CERTIFICATES": {"安装包的签名与已安装版本不一致", "卸载已安装的应用后重新安装（会清除应用数据）", InstallFixUninstall},
+	"INSTALL_FAILED_SHARED_USER_INCOMPATIBLE":        {"sharedUserId 与已安装应用的签名不一致", "卸载已安装的应用后重新安装（会清除应用数据）", InstallFixUninstall},
+	"INSTALL_FAILED_VERSION_DOWNGRADE":               {"安装包的 versionCode 低于已安装版本", "使用 -d 允许降级安装（仅 debuggable 应用或 userdebug 系统可用）", InstallFixDowngrade},
+	"INSTALL_FAILED_ALREADY_EXISTS":                  {"应用已存在", "使用 -r 覆盖安装", InstallFixReplace},
+	"INSTALL_FAILED_TEST_ONLY":                       {"应用声明了 android:testOnly", "使用 -t 允许安装测试应用", InstallFixTestOnly},
+	"INSTALL_FAILED_INSUFFICIENT_STORAGE":            {"设备存储空间不足", "清理设备存储后重试", InstallFixFreeSpace},
+	"INSTALL_FAILED_OLDER_SDK":                       {"设备系统版本低于应用要求的 minSdkVersion", "换用系统版本更高的设备，或重新构建降低 minSdkVersion", ""},
+	"INSTALL_FAILED_NEWER_SDK":                       {"设备系统版本高于应用支持的 maxSdkVersion", "换用系统版本较低的设备", ""},
+	"INSTALL_FAILED_DEPRECATED_SDK_VERSION":          {"应用的 targetSdkVersion 过低，新系统拒绝安装", "提高 targetSdkVersion 重新构建，或使用 --bypass-low-target-sdk-block 安装", ""},
+	"INSTALL_FAILED_NO_MATCHING_ABIS":                {"安装包中没有设备 CPU 架构可用的 so 库", "使用包含设备 ABI 的安装包，或安装 universal 包", ""},
+	"INSTALL_FAILED_CPU_ABI_INCOMPATIBLE":            {"安装包中没有设备 CPU 架构可用的 so 库", "使用包含设备 ABI 的安装包，或安装 universal 包", ""},
+	"INSTALL_FAILED_MISSING_SPLIT":                   {"缺少应用需要的拆分包", "同时安装基础包与所有需要的拆分包", ""},
+	"INSTALL_FAILED_MISSING_SHARED_LIBRARY":          {"设备上缺少应用依赖的共享库", "检查 uses-library 声明，或在包含该库的设备上安装", ""},
+	"INSTALL_FAILED_DUPLICATE_PERMISSION":            {"应用定义的权限已被另一个签名不同的应用定义", "卸载定义了同名权限的应用后重试", ""},
+	"INSTALL_FAILED_CONFLICTING_PROVIDER":            {"ContentProvider authority 与已安装应用冲突", "卸载冲突的应用，或修改 authority", ""},
+	"INSTALL_FAILED_INVALID_APK":                     {"安装包无效或已损坏", "重新构建或重新下载安装包", ""},
+	"INSTALL_PARSE_FAILED_NOT_APK":                   {"文件不是 APK", "确认选择的是 APK 文件", ""},
+	"INSTALL_PARSE_FAILED_NO_CERTIFICATES":           {"安装包没有签名或签名无效", "使用 apksigner 对安装包签名后重试", ""},
+	"INSTALL_PARSE_FAILED_MANIFEST_MALFORMED":        {"AndroidManifest.xml 格式有误", "检查清单文件，例如 Android 12 起含 intent-filter 的组件必须声明 android:exported", ""},
+	"INSTALL_FAILED_USER_RESTRICTED":                 {"设备禁止通过 USB 安装应用", "在开发者选项中打开“USB 安装”，或在设备上确认安装", ""},
+	"INSTALL_FAILED_VERIFICATION_FAILURE":            {"安装包验证失败", "关闭“通过 USB 验证应用”后重试", ""},
+	"INSTALL_FAILED_ABORTED":                         {"安装被用户或系统取消", "在设备上确认安装后重试", ""},
+	"INSTALL_FAILED_INTERNAL_ERROR":                  {"系统内部错误", "重启设备后重试，或查看 logcat 中 PackageManager 的日志", ""},
+	"INSTALL_FAILED_PERMISSION_MODEL_DOWNGRADE":      {"新版本的 targetSdkVersion 低于 23，而已安装版本使用运行时权限", "卸载已安装的应用后重新安装", InstallFixUninstall},
+	"INSTALL_FAILED_SESSION_INVALID":                 {"安装会话已失效", "重新安装", ""},
+	"INSTALL_FAILED_MULTIPACKAGE_INCONSISTENCY":      {"拆分包之间的包名或版本不一致", "确认所有拆分包来自同一次构建", ""},
+	"INSTALL_FAILED_UID_CHANGED":                     {"系统中残留了该应用的旧数据", "卸载后重试，仍失败时删除 /data/data 下残留的目录", InstallFixUninstall},
+}
+
+// ParseInstallFailure 从 adb install 的输出中解析 INSTALL_FAILED_* 等错误码，没有识别到时返回 nil
+func ParseInstallFailure(output string) *InstallFailure {
+	match := reInstallFailure.FindStringSubmatch(output)
+	if match == nil {
+		return nil
+	}
+	failure := &InstallFailure{Code: match[1], Message: strings.TrimSpace(match[2])}
+	if info, ok := installFailures[failure.Code]; ok {
+		failure.Explanation = info.explanation
+		failure.Suggestion = info.suggestion
+		failure.Fix = info.fix
+	} else {
+		failure.Explanation = "未收录的错误码"
+		failure.Suggestion = "查看完整输出或 logcat 中 PackageManager 的日志"
+	}
+	if m := reFailurePackage.FindStringSubmatch(output); m != nil {
+		failure.Package = m[1]
+	}
+	return failure
+}
@@ -0,0 +1,102 @@
+package adb
+
+import "testing"
+
+func TestParseInstallFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		code    string
+		message string
+		fix     string
+		pkg     string
+		known   bool
+	}{
+		{
+			name: "streamed install",
+			output: `Performing Streamed Install
+adb: failed to install app-debug.apk: Failure [INSTALL_FAILED_UPDATE_INCOMPATIBLE: Package com.example.app signatures do not match previously installed version; ignoring!]`,
+			code:    "INSTALL_FAILED_UPDATE_INCOMPATIBLE",
+			message: "Package com.example.app signatures do not match previously installed version; ignoring!",
+			fix:     InstallFixUninstall,
+			pkg:     "com.example.app",
+			known:   true,
+		},
+		{
+			// 旧版 adb 先 push 到 /data/local/tmp 再调用 pm install
+			name: "push then pm install",
+			output: `app-debug.apk: 1 file pushed, 0 skipped. 45.2 MB/s (2458624 bytes in 0.052s)
+	pkg: /data/local/tmp/app-debug.apk
+Failure [INSTALL_FAILED_VERSION_DOWNGRADE]`,
+			code:  "INSTALL_FAILED_VERSION_DOWNGRADE",
+			fix:   InstallFixDowngrade,
+			known: true,
+		},
+		{
+			name:    "inconsistent certificates",
+			output:  `adb: failed to install app-release.apk: Failure [INSTALL_PARSE_FAILED_INCONSISTENT_CERTIFICATES: Existing package com.example.app signatures do not match newer version; ignoring!]`,
+			code:    "INSTALL_PARSE_FAILED_INCONSISTENT_CERTIFICATES",
+			message: "Existing package com.example.app signatures do not match newer version; ignoring!",
+			fix:     InstallFixUninstall,
+			pkg:     "com.example.app",
+			known:   true,
+		},
+		{
+			name:    "test only",
+			output:  "Performing Streamed Install\nadb: failed to install app-debug.apk: Failure [INSTALL_FAILED_TEST_ONLY: installPackageLI]\r\n",
+			code:    "INSTALL_FAILED_TEST_ONLY",
+			message: "installPackageLI",
+			fix:     InstallFixTestOnly,
+			known:   true,
+		},
+		{
+			name:    "install-multiple commit",
+			output:  `Failed to commit install session 1842364955 with command cmd package install-commit 1842364955: Failure [INSTALL_FAILED_MISSING_SPLIT: Missing split for com.example.app]`,
+			code:    "INSTALL_FAILED_MISSING_SPLIT",
+			message: "Missing split for com.example.app",
+			known:   true,
+		},
+		{
+			name:    "install-multiple finalize",
+			output:  "adb: failed to finalize session\nFailure [INSTALL_FAILED_NO_MATCHING_ABIS: INSTALL_FAILED_NO_MATCHING_ABIS: Failed to extract native libraries, res=-113]",
+			code:    "INSTALL_FAILED_NO_MATCHING_ABIS",
+			message: "INSTALL_FAILED_NO_MATCHING_ABIS: Failed to extract native libraries, res=-113",
+			known:   true,
+		},
+		{
+			name:    "unknown code",
+			output:  "Performing Streamed Install\nadb: failed to install app.apk: Failure [INSTALL_FAILED_BAD_DEX_METADATA: Invalid dex metadata file]",
+			code:    "INSTALL_FAILED_BAD_DEX_METADATA",
+			message: "Invalid dex metadata file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := ParseInstallFailure(tt.output)
+			if failure == nil {
+				t.Fatal("failure not recognized")
+			}
+			if failure.Code != tt.code || failure.Message != tt.message || failure.Fix != tt.fix || failure.Package != tt.pkg {
+				t.Errorf("failure = %+v", *failure)
+			}
+			if known := failure.Explanation != "未收录的错误码"; known != tt.known {
+				t.Errorf("known = %v, explanation %q", known, failure.Explanation)
+			}
+			if failure.Suggestion == "" {
+				t.Error("empty suggestion")
+			}
+		})
+	}
+}
+
+func TestParseInstallFailureSuccess(t *testing.T) {
+	for _, output := range []string{
+		"Performing Streamed Install\nSuccess\n",
+		"Success",
+		"",
+	} {
+		if failure := ParseInstallFailure(output); failure != nil {
+			t.Errorf("ParseInstallFailure(%q) = %+v, want nil", output, *failure)
+		}
+	}
+}
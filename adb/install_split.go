@@ -0,0 +1,630 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 安装包格式
+const (
+	InstallFormatApk  = "apk"
+	InstallFormatApks = "apks"
+	InstallFormatXapk = "xapk"
+	InstallFormatAab  = "aab"
+)
+
+// 拆分包的类型
+const (
+	SplitKindMaster   = "master"
+	SplitKindAbi      = "abi"
+	SplitKindDensity  = "density"
+	SplitKindLanguage = "language"
+	SplitKindOther    = "other"
+)
+
+// installWorkDirPrefix 解压安装包使用的临时目录前缀
+const installWorkDirPrefix = "adbtool-install-"
+
+// densityBuckets 资源密度限定符对应的 dpi
+var densityBuckets = map[string]int{
+	"ldpi":    120,
+	"mdpi":    160,
+	"tvdpi":   213,
+	"hdpi":    240,
+	"xhdpi":   320,
+	"xxhdpi":  480,
+	"xxxhdpi": 640,
+}
+
+// DeviceSpec 选择拆分包需要的设备配置，与 GetDeviceInfo 读取的属性一致
+type DeviceSpec struct {
+	Abis    []string `json:"abis"`    // 按优先级排列，如 arm64-v8a、armeabi-v7a
+	Density int      `json:"density"` // 当前生效的 dpi
+	Locales []string `json:"locales"` // 如 zh-CN、en-US
+	Sdk     int      `json:"sdk"`
+}
+
+// ApkSplit 安装计划中的一个 APK
+type ApkSplit struct {
+	File      string `json:"file"` // 本地路径
+	Name      string `json:"name"`
+	Module    string `json:"module"`
+	Qualifier string `json:"qualifier"`
+	Kind      string `json:"kind"`
+	Size      int64  `json:"size"`
+	Selected  bool   `json:"selected"`
+	Reason    string `json:"reason"`
+}
+
+// ObbFile XAPK 附带的数据包，安装后推送到 /sdcard/Android/obb
+type ObbFile struct {
+	File       string `json:"file"`
+	RemotePath string `json:"remotePath"`
+	Size       int64  `json:"size"`
+}
+
+// InstallPlan 待安装的 APK 集合，Splits 中 Selected 的项会一起通过 install-multiple 安装
+type InstallPlan struct {
+	Source  string     `json:"source"`
+	Format  string     `json:"format"`
+	Package string     `json:"package"` // 仅 XAPK 的 manifest 中能直接取得
+	Device  DeviceSpec `json:"device"`
+	Splits  []ApkSplit `json:"splits"`
+	Obbs    []ObbFile  `json:"obbs"`
+	WorkDir string     `json:"workDir"` // 解压目录，安装后删除
+}
+
+// InstallResult 安装结果，Installed 为实际安装的 APK 名称
+type InstallResult struct {
+	Success   bool     `json:"success"`
+	Installed []string `json:"installed"`
+	Obbs      []string `json:"obbs"`
+	Output    string   `json:"output"`
+	Cmd       string   `json:"cmd"`
+	// Failure 安装失败且能识别出错误码时的原因与修复建议
+	Failure *InstallFailure `json:"failure"`
+}
+
+// InstallOptions adb install 的选项
+type InstallOptions struct {
+	Replace          bool   `json:"replace"`          // -r 覆盖安装
+	Downgrade        bool   `json:"downgrade"`        // -d 允许降级
+	AllowTest        bool   `json:"allowTest"`        // -t 允许 testOnly 应用
+	GrantPermissions bool   `json:"grantPermissions"` // -g 授予清单中的运行时权限
+	UninstallPackage string `json:"uninstallPackage"` // 非空时先卸载该包再安装
+	User             string `json:"user"`             // 安装到指定用户，为空时由 adb 决定（通常为所有用户）
+}
+
+func (o InstallOptions) flags() string {
+	var flags []string
+	if o.Replace {
+		flags = append(flags, "-r")
+	}
+	if o.Downgrade {
+		flags = append(flags, "-d")
+	}
+	if o.AllowTest {
+		flags = append(flags, "-t")
+	}
+	if o.GrantPermissions {
+		flags = append(flags, "-g")
+	}
+	if o.User != "" {
+		flags = append(flags, "--user "+o.User)
+	}
+	return strings.Join(flags, " ")
+}
+
+// GetDeviceSpec 读取设备 ABI 列表、屏幕密度、语言与 SDK 版本
+func GetDeviceSpec(param ExecuteParams) (DeviceSpec, error) {
+	script := "getprop ro.product.cpu.abilist; echo ---; getprop ro.product.cpu.abi; echo ---; wm density; echo ---; " +
+		"settings get system system_locales; getprop persist.sys.locale; getprop ro.product.locale; echo ---; getprop ro.build.version.sdk"
+	res, err := util.Exec(buildRemoteScriptCmd(param, script), true, nil)
+	if err != nil {
+		return DeviceSpec{}, err
+	}
+	sections := strings.Split(res, "---")
+	if len(sections) < 5 {
+		return DeviceSpec{}, fmt.Errorf("读取设备配置失败: %s", strings.TrimSpace(res))
+	}
+
+	spec := DeviceSpec{}
+	abiList := strings.TrimSpace(sections[0])
+	if abiList == "" {
+		abiList = strings.TrimSpace(sections[1])
+	}
+	for _, abi := range strings.Split(abiList, ",") {
+		if abi = strings.TrimSpace(abi); abi != "" {
+			spec.Abis = append(spec.Abis, abi)
+		}
+	}
+
+	densityRes, _ := parseWmDensity(strings.TrimSpace(sections[2]))
+	spec.Density, _ = strconv.Atoi(densityRes)
+
+	for _, line := range util.MultiLine(sections[3]) {
+		for _, locale := range strings.Split(line, ",") {
+			locale = strings.TrimSpace(locale)
+			if locale != "" && locale != "null" && !slices.Contains(spec.Locales, locale) {
+				spec.Locales = append(spec.Locales, locale)
+			}
+		}
+	}
+	spec.Sdk, _ = strconv.Atoi(strings.TrimSpace(sections[4]))
+	return spec, nil
+}
+
+// PlanInstall 分析待安装的文件：多个 .apk 视为同一应用的拆分包，.apks/.xapk 解压后按设备配置选择拆分包，
+// .aab 需要本机有 bundletool，先生成 .apks 再处理
+func PlanInstall(param ExecuteParams, paths []string) (InstallPlan, error) {
+	plan := InstallPlan{Splits: []ApkSplit{}, Obbs: []ObbFile{}}
+	if len(paths) == 0 {
+		return plan, fmt.Errorf("请选择安装包")
+	}
+
+	spec, err := GetDeviceSpec(param)
+	if err != nil {
+		return plan, err
+	}
+	plan.Device = spec
+
+	format := installFormat(paths[0])
+	for _, p := range paths[1:] {
+		if installFormat(p) != InstallFormatApk || format != InstallFormatApk {
+			return plan, fmt.Errorf("一次只能安装一个 .apks/.xapk/.aab，或同一应用的多个 .apk")
+		}
+	}
+	plan.Format = format
+	plan.Source = paths[0]
+
+	switch format {
+	case InstallFormatApk:
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return plan, err
+			}
+			plan.Splits = append(plan.Splits, newApkSplit(p, info.Size()))
+		}
+	case InstallFormatApks, InstallFormatXapk, InstallFormatAab:
+		workDir, err := os.MkdirTemp("", installWorkDirPrefix)
+		if err != nil {
+			return plan, err
+		}
+		plan.WorkDir = workDir
+		archive := paths[0]
+		if format == InstallFormatAab {
+			archive = filepath.Join(workDir, "bundle.apks")
+			if err := buildApksFromBundle(param, paths[0], archive); err != nil {
+				DiscardInstallPlan(plan)
+				return plan, err
+			}
+		}
+		if err := extractInstallArchive(archive, format, &plan); err != nil {
+			DiscardInstallPlan(plan)
+			return plan, err
+		}
+	default:
+		return plan, fmt.Errorf("不支持的安装包格式: %s", filepath.Base(paths[0]))
+	}
+
+	if len(plan.Splits) == 0 {
+		DiscardInstallPlan(plan)
+		return plan, fmt.Errorf("安装包中没有 APK")
+	}
+	selectPlanSplits(plan.Splits, spec)
+	return plan, nil
+}
+
+// selectPlanSplits 只有一个 APK 时它就是完整安装包，始终安装，不按文件名猜测拆分配置（如 my-app.apk）；
+// 多个 APK 时才按文件名识别拆分包并选择
+func selectPlanSplits(splits []ApkSplit, spec DeviceSpec) {
+	if len(splits) == 1 {
+		s := &splits[0]
+		s.Module = strings.TrimSuffix(s.Name, filepath.Ext(s.Name))
+		s.Qualifier = ""
+		s.Kind = SplitKindMaster
+		s.Selected, s.Reason = true, "完整安装包"
+		return
+	}
+	selectSplits(splits, spec)
+}
+
+// RunInstall 用 install-multiple 安装选中的 APK，只有一个时使用 install，成功后推送 OBB
+func RunInstall(param ExecuteParams, plan InstallPlan, options InstallOptions) InstallResult {
+	result := InstallResult{Installed: []string{}, Obbs: []string{}}
+
+	var files []string
+	for _, s := range plan.Splits {
+		if s.Selected {
+			files = append(files, quoteHostArg(s.File))
+			result.Installed = append(result.Installed, s.Name)
+		}
+	}
+	if len(files) == 0 {
+		result.Output = "没有选中要安装的 APK"
+		result.Installed = []string{}
+		return result
+	}
+	if !IsValidUserId(options.User) {
+		result.Output = fmt.Sprintf("无效的用户: %s", options.User)
+		result.Installed = []string{}
+		return result
+	}
+
+	var uninstallOutput string
+	if options.UninstallPackage != "" {
+		if !IsValidPackageName(options.UninstallPackage) {
+			result.Output = fmt.Sprintf("无效的包名: %s", options.UninstallPackage)
+			result.Installed = []string{}
+			return result
+		}
+		// 包不存在时卸载会失败，不影响后续安装
+		uninstallArgs := "uninstall "
+		if options.User != "" {
+			uninstallArgs += "--user " + options.User + " "
+		}
+		res, _ := util.Exec(BuildAdbCmd(param.AdbPath, param.DeviceId, uninstallArgs+options.UninstallPackage), true, nil)
+		uninstallOutput = fmt.Sprintf("uninstall %s: %s\n", options.UninstallPackage, strings.TrimSpace(res))
+	}
+
+	verb := "install-multiple"
+	if len(files) == 1 {
+		verb = "install"
+	}
+	args := verb
+	if flags := options.flags(); flags != "" {
+		args += " " + flags
+	}
+	result.Cmd = BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("%s %s", args, strings.Join(files, " ")))
+	res, err := util.Exec(result.Cmd, true, nil)
+	result.Output = strings.TrimSpace(res)
+	if err != nil {
+		result.Output = err.Error()
+	}
+	installed := strings.Contains(result.Output, "Success")
+	if !installed {
+		result.Failure = ParseInstallFailure(result.Output)
+	}
+	// 卸载的输出同样是 Success，放在判断之后拼接
+	result.Output = uninstallOutput + result.Output
+	if !installed {
+		result.Installed = []string{}
+		return result
+	}
+	result.Success = true
+
+	for _, obb := range plan.Obbs {
+		cmd := BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("push %s %s", quoteHostArg(obb.File), quoteHostArg(obb.RemotePath)))
+		res, err := util.Exec(cmd, true, nil)
+		if err != nil || strings.Contains(res, "adb: error") {
+			result.Output += fmt.Sprintf("\nOBB 推送失败 %s: %s", obb.RemotePath, strings.TrimSpace(res))
+			continue
+		}
+		result.Obbs = append(result.Obbs, obb.RemotePath)
+	}
+	return result
+}
+
+// DiscardInstallPlan 删除安装计划的解压目录，只处理本工具创建的临时目录
+func DiscardInstallPlan(plan InstallPlan) {
+	if plan.WorkDir == "" {
+		return
+	}
+	dir := filepath.Clean(plan.WorkDir)
+	if filepath.Dir(dir) != filepath.Clean(os.TempDir()) || !strings.HasPrefix(filepath.Base(dir), installWorkDirPrefix) {
+		return
+	}
+	_ = os.RemoveAll(dir)
+}
+
+func installFormat(p string) string {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".apk":
+		return InstallFormatApk
+	case ".apks":
+		return InstallFormatApks
+	case ".xapk":
+		return InstallFormatXapk
+	case ".aab":
+		return InstallFormatAab
+	}
+	return ""
+}
+
+// buildApksFromBundle 调用 bundletool 为已连接的设备生成 .apks，bundletool 使用默认的 debug 签名
+func buildApksFromBundle(param ExecuteParams, bundle string, output string) error {
+	var command []string
+	if p, err := exec.LookPath("bundletool"); err == nil {
+		command = []string{quoteHostArg(p)}
+	} else if jar := os.Getenv("BUNDLETOOL_JAR"); jar != "" {
+		command = []string{"java", "-jar", quoteHostArg(jar)}
+	} else {
+		return fmt.Errorf("安装 .aab 需要 bundletool，请将 bundletool 加入 PATH 或设置环境变量 BUNDLETOOL_JAR")
+	}
+	command = append(command, "build-apks", "--bundle", quoteHostArg(bundle), "--output", quoteHostArg(output),
+		"--connected-device", "--adb", quoteHostArg(param.AdbPath))
+	if param.DeviceId != "" {
+		command = append(command, "--device-id", quoteHostArg(param.DeviceId))
+	}
+	res, err := util.Exec(strings.Join(command, " "), true, nil)
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(output); statErr != nil {
+		return fmt.Errorf("bundletool 生成 .apks 失败: %s", strings.TrimSpace(res))
+	}
+	return nil
+}
+
+// xapkManifest XAPK 中 manifest.json 用到的字段
+type xapkManifest struct {
+	PackageName string `json:"package_name"`
+	SplitApks   []struct {
+		File string `json:"file"`
+		Id   string `json:"id"`
+	} `json:"split_apks"`
+	Expansions []struct {
+		File        string `json:"file"`
+		InstallPath string `json:"install_path"`
+	} `json:"expansions"`
+}
+
+// extractInstallArchive 解压 .apks/.xapk 中的 APK 与 OBB 到 plan.WorkDir
+// 带 manifest.json 时按其中的 split_apks 与 expansions 安装；
+// 否则 .apks 只取 splits/ 下的拆分包，没有时退回 universal.apk 或 standalones/ 下的完整包
+func extractInstallArchive(archive string, format string, plan *InstallPlan) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("无法打开安装包: %w", err)
+	}
+	defer reader.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, f := range reader.File {
+		entries[path.Clean(f.Name)] = f
+	}
+
+	// 本工具导出的 .apks 也带有 XAPK 格式的 manifest.json
+	_, hasManifest := entries["manifest.json"]
+	var apkNames []string
+	if format == InstallFormatXapk || hasManifest {
+		if f, ok := entries["manifest.json"]; ok {
+			var manifest xapkManifest
+			if err := readZipJSON(f, &manifest); err == nil {
+				plan.Package = manifest.PackageName
+				for _, s := range manifest.SplitApks {
+					apkNames = append(apkNames, path.Clean(s.File))
+				}
+				if err := extractObbs(entries, manifest, plan); err != nil {
+					return err
+				}
+			}
+		}
+		if len(apkNames) == 0 {
+			for name := range entries {
+				if !strings.Contains(name, "/") && strings.HasSuffix(strings.ToLower(name), ".apk") {
+					apkNames = append(apkNames, name)
+				}
+			}
+		}
+	} else {
+		for name := range entries {
+			if strings.HasPrefix(name, "splits/") && strings.HasSuffix(name, ".apk") {
+				apkNames = append(apkNames, name)
+			}
+		}
+		if len(apkNames) == 0 {
+			if _, ok := entries["universal.apk"]; ok {
+				apkNames = []string{"universal.apk"}
+			} else {
+				apkNames = pickStandalone(entries, plan.Device)
+			}
+		}
+	}
+	slices.Sort(apkNames)
+
+	for _, name := range apkNames {
+		f, ok := entries[name]
+		if !ok {
+			return fmt.Errorf("安装包缺少 %s", name)
+		}
+		// 只用文件名落盘，避免条目中的 ../ 写到解压目录之外
+		local := filepath.Join(plan.WorkDir, path.Base(name))
+		if err := extractZipFile(f, local); err != nil {
+			return err
+		}
+		plan.Splits = append(plan.Splits, newApkSplit(local, int64(f.UncompressedSize64)))
+	}
+	return nil
+}
+
+// extractObbs 解压 XAPK 中的 OBB，目标路径必须位于 Android/obb 下
+func extractObbs(entries map[string]*zip.File, manifest xapkManifest, plan *InstallPlan) error {
+	for i, e := range manifest.Expansions {
+		f, ok := entries[path.Clean(e.File)]
+		if !ok {
+			continue
+		}
+		installPath := path.Clean("/" + e.InstallPath)
+		if !isUnder(installPath, "/Android/obb") {
+			continue
+		}
+		local := filepath.Join(plan.WorkDir, fmt.Sprintf("obb-%d-%s", i, path.Base(installPath)))
+		if err := extractZipFile(f, local); err != nil {
+			return err
+		}
+		plan.Obbs = append(plan.Obbs, ObbFile{
+			File:       local,
+			RemotePath: "/sdcard" + installPath,
+			Size:       int64(f.UncompressedSize64),
+		})
+	}
+	return nil
+}
+
+// pickStandalone 从 standalones/ 中选出与设备 ABI 匹配的完整包
+func pickStandalone(entries map[string]*zip.File, spec DeviceSpec) []string {
+	var standalones []string
+	for name := range entries {
+		if strings.HasPrefix(name, "standalones/") && strings.HasSuffix(name, ".apk") {
+			standalones = append(standalones, name)
+		}
+	}
+	slices.Sort(standalones)
+	for _, abi := range spec.Abis {
+		qualifier := strings.ReplaceAll(abi, "-", "_")
+		for _, name := range standalones {
+			if strings.Contains(path.Base(name), qualifier) {
+				return []string{name}
+			}
+		}
+	}
+	if len(standalones) > 0 {
+		return standalones[:1]
+	}
+	return nil
+}
+
+func readZipJSON(f *zip.File, dest interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(dest)
+}
+
+func extractZipFile(f *zip.File, local string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.Create(local)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// newApkSplit 按文件名识别模块与配置限定符：
+// bundletool 输出的 base-master.apk、base-arm64_v8a.apk，设备上导出的 split_config.xxhdpi.apk，
+// 以及 XAPK 中的 config.zh.apk、feature.config.arm64_v8a.apk
+func newApkSplit(file string, size int64) ApkSplit {
+	name := filepath.Base(file)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	split := ApkSplit{File: file, Name: name, Size: size, Module: stem, Kind: SplitKindMaster}
+
+	switch {
+	case strings.Contains(stem, "config."):
+		module, qualifier, _ := strings.Cut(stem, "config.")
+		module = strings.TrimSuffix(strings.TrimPrefix(module, "split_"), ".")
+		if module == "" {
+			module = "base"
+		}
+		split.Module, split.Qualifier = module, qualifier
+	case strings.Contains(stem, "-"):
+		module, qualifier, _ := strings.Cut(stem, "-")
+		split.Module = module
+		if qualifier != "master" {
+			split.Qualifier = qualifier
+		}
+	case strings.HasPrefix(stem, "split_"):
+		split.Module = strings.TrimPrefix(stem, "split_")
+	}
+	if split.Qualifier != "" {
+		split.Kind = qualifierKind(split.Qualifier)
+	}
+	return split
+}
+
+func qualifierKind(qualifier string) string {
+	switch {
+	case slices.Contains([]string{"armeabi", "armeabi_v7a", "arm64_v8a", "x86", "x86_64", "mips", "mips64", "riscv64"}, qualifier):
+		return SplitKindAbi
+	case densityBuckets[qualifier] > 0:
+		return SplitKindDensity
+	case len(qualifier) >= 2 && len(qualifier) <= 3 && strings.ToLower(qualifier) == qualifier && !strings.ContainsAny(qualifier, "0123456789_"):
+		return SplitKindLanguage
+	}
+	return SplitKindOther
+}
+
+// selectSplits 按模块分别选择：主包全部安装，ABI 取设备最优先的一个，密度取最接近的一个（优先更高的），语言取设备使用的语言
+func selectSplits(splits []ApkSplit, spec DeviceSpec) {
+	languages := map[string]bool{}
+	for _, locale := range spec.Locales {
+		lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+		languages[strings.ToLower(lang)] = true
+	}
+
+	byModule := map[string][]int{}
+	for i := range splits {
+		byModule[splits[i].Module] = append(byModule[splits[i].Module], i)
+	}
+
+	for _, indexes := range byModule {
+		bestAbi, bestAbiRank := -1, len(spec.Abis)
+		bestDensity, bestDensityScore := -1, 0
+		for _, i := range indexes {
+			s := &splits[i]
+			switch s.Kind {
+			case SplitKindMaster:
+				s.Selected, s.Reason = true, "主包"
+			case SplitKindAbi:
+				s.Reason = "设备不支持该 ABI"
+				rank := slices.Index(spec.Abis, strings.ReplaceAll(s.Qualifier, "_", "-"))
+				if rank >= 0 && rank < bestAbiRank {
+					bestAbi, bestAbiRank = i, rank
+				}
+			case SplitKindDensity:
+				s.Reason = "非最接近的屏幕密度"
+				if score := densityScore(densityBuckets[s.Qualifier], spec.Density); bestDensity < 0 || score < bestDensityScore {
+					bestDensity, bestDensityScore = i, score
+				}
+			case SplitKindLanguage:
+				s.Selected = languages[s.Qualifier]
+				s.Reason = "非设备语言"
+				if s.Selected {
+					s.Reason = "设备语言"
+				}
+			default:
+				s.Selected, s.Reason = true, "无法识别的配置，默认安装"
+			}
+		}
+		if bestAbi >= 0 {
+			splits[bestAbi].Selected = true
+			splits[bestAbi].Reason = fmt.Sprintf("匹配设备 ABI %s", spec.Abis[bestAbiRank])
+		}
+		if bestDensity >= 0 {
+			splits[bestDensity].Selected = true
+			splits[bestDensity].Reason = fmt.Sprintf("最接近设备密度 %ddpi", spec.Density)
+		}
+	}
+}
+
+// densityScore 越小越合适：密度不低于设备时按差值，低于设备时额外加权，与系统选择资源的倾向一致
+func densityScore(dpi int, device int) int {
+	if device <= 0 {
+		return -dpi
+	}
+	if dpi >= device {
+		return dpi - device
+	}
+	return (device-dpi)*2 + 1000
+}
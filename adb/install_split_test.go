@@ -0,0 +1,118 @@
+package adb
+
+import "testing"
+
+func TestNewApkSplit(t *testing.T) {
+	tests := []struct {
+		file      string
+		module    string
+		qualifier string
+		kind      string
+	}{
+		{"base.apk", "base", "", SplitKindMaster},
+		{"base-master.apk", "base", "", SplitKindMaster},
+		{"base-arm64_v8a.apk", "base", "arm64_v8a", SplitKindAbi},
+		{"base-xxhdpi.apk", "base", "xxhdpi", SplitKindDensity},
+		{"base-zh.apk", "base", "zh", SplitKindLanguage},
+		{"split_config.xxhdpi.apk", "base", "xxhdpi", SplitKindDensity},
+		{"split_config.arm64_v8a.apk", "base", "arm64_v8a", SplitKindAbi},
+		{"config.zh.apk", "base", "zh", SplitKindLanguage},
+		{"feature.config.arm64_v8a.apk", "feature", "arm64_v8a", SplitKindAbi},
+		{"split_feature.apk", "feature", "", SplitKindMaster},
+		{"feature-master.apk", "feature", "", SplitKindMaster},
+		{"base-sw600dp.apk", "base", "sw600dp", SplitKindOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			s := newApkSplit("/tmp/"+tt.file, 1)
+			if s.Name != tt.file || s.Module != tt.module || s.Qualifier != tt.qualifier || s.Kind != tt.kind {
+				t.Errorf("newApkSplit(%q) = name %q module %q qualifier %q kind %q, want module %q qualifier %q kind %q",
+					tt.file, s.Name, s.Module, s.Qualifier, s.Kind, tt.module, tt.qualifier, tt.kind)
+			}
+		})
+	}
+}
+
+func testDeviceSpec() DeviceSpec {
+	return DeviceSpec{Abis: []string{"arm64-v8a", "armeabi-v7a"}, Density: 440, Locales: []string{"zh-CN", "en-US"}, Sdk: 33}
+}
+
+func newTestSplits(files ...string) []ApkSplit {
+	splits := make([]ApkSplit, len(files))
+	for i, f := range files {
+		splits[i] = newApkSplit("/tmp/"+f, 1)
+	}
+	return splits
+}
+
+func selectedNames(splits []ApkSplit) map[string]bool {
+	selected := map[string]bool{}
+	for _, s := range splits {
+		if s.Selected {
+			selected[s.Name] = true
+		}
+	}
+	return selected
+}
+
+func TestSelectSplits(t *testing.T) {
+	splits := newTestSplits(
+		"base-master.apk",
+		"base-arm64_v8a.apk",
+		"base-armeabi_v7a.apk",
+		"base-x86_64.apk",
+		"base-hdpi.apk",
+		"base-xxhdpi.apk",
+		"base-xxxhdpi.apk",
+		"base-zh.apk",
+		"base-en.apk",
+		"base-ja.apk",
+		"base-sw600dp.apk",
+		"feature-master.apk",
+		"feature-armeabi_v7a.apk",
+	)
+	selectSplits(splits, testDeviceSpec())
+
+	want := map[string]bool{
+		"base-master.apk":         true,
+		"base-arm64_v8a.apk":      true,
+		"base-xxhdpi.apk":         true,
+		"base-zh.apk":             true,
+		"base-en.apk":             true,
+		"base-sw600dp.apk":        true,
+		"feature-master.apk":      true,
+		"feature-armeabi_v7a.apk": true, // 模块中只有这一个 ABI，按设备的次优先 ABI 选择
+	}
+	got := selectedNames(splits)
+	for _, s := range splits {
+		if got[s.Name] != want[s.Name] {
+			t.Errorf("%s selected = %v, want %v (reason %q)", s.Name, got[s.Name], want[s.Name], s.Reason)
+		}
+	}
+}
+
+func TestSelectPlanSplitsStandalone(t *testing.T) {
+	// 单个 APK 的文件名即使形如拆分包也必须安装
+	for _, file := range []string{"my-app.apk", "demo-dev.apk", "shop-qa.apk", "app-x86.apk", "base-hdpi.apk", "config.zh.apk"} {
+		t.Run(file, func(t *testing.T) {
+			splits := newTestSplits(file)
+			selectPlanSplits(splits, testDeviceSpec())
+			s := splits[0]
+			if !s.Selected || s.Kind != SplitKindMaster || s.Qualifier != "" {
+				t.Errorf("selected %v kind %q qualifier %q, want standalone master", s.Selected, s.Kind, s.Qualifier)
+			}
+		})
+	}
+}
+
+func TestSelectPlanSplitsMultiple(t *testing.T) {
+	splits := newTestSplits("base.apk", "split_config.arm64_v8a.apk", "split_config.x86.apk", "split_config.ja.apk")
+	selectPlanSplits(splits, testDeviceSpec())
+	got := selectedNames(splits)
+	want := map[string]bool{"base.apk": true, "split_config.arm64_v8a.apk": true}
+	for _, s := range splits {
+		if got[s.Name] != want[s.Name] {
+			t.Errorf("%s selected = %v, want %v (reason %q)", s.Name, got[s.Name], want[s.Name], s.Reason)
+		}
+	}
+}
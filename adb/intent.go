@@ -0,0 +1,430 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 发送 Intent 的方式
+const (
+	IntentModeActivity          = "activity"           // am start
+	IntentModeBroadcast         = "broadcast"          // am broadcast
+	IntentModeService           = "service"            // am startservice
+	IntentModeForegroundService = "foreground-service" // am start-foreground-service，Android 8.0 起后台应用需要
+)
+
+var intentModeCommands = map[string]string{
+	IntentModeActivity:          "am start",
+	IntentModeBroadcast:         "am broadcast",
+	IntentModeService:           "am startservice",
+	IntentModeForegroundService: "am start-foreground-service",
+}
+
+// Extra 的类型与对应的 am 参数，数组类型的值以逗号分隔
+var intentExtraOptions = map[string]string{
+	"string":       "--es",
+	"int":          "--ei",
+	"long":         "--el",
+	"float":        "--ef",
+	"bool":         "--ez",
+	"uri":          "--eu",
+	"component":    "--ecn",
+	"null":         "--esn",
+	"string-array": "--esa",
+	"int-array":    "--eia",
+	"long-array":   "--ela",
+}
+
+// IntentFlags 可选的 Intent.FLAG_*，按位或后通过 -f 传给 am
+var IntentFlags = map[string]int64{
+	"FLAG_GRANT_READ_URI_PERMISSION":     0x00000001,
+	"FLAG_GRANT_WRITE_URI_PERMISSION":    0x00000002,
+	"FLAG_INCLUDE_STOPPED_PACKAGES":      0x00000020,
+	"FLAG_ACTIVITY_CLEAR_TASK":           0x00008000,
+	"FLAG_ACTIVITY_NO_ANIMATION":         0x00010000,
+	"FLAG_ACTIVITY_REORDER_TO_FRONT":     0x00020000,
+	"FLAG_ACTIVITY_EXCLUDE_FROM_RECENTS": 0x00800000,
+	"FLAG_ACTIVITY_CLEAR_TOP":            0x04000000,
+	"FLAG_ACTIVITY_MULTIPLE_TASK":        0x08000000,
+	"FLAG_ACTIVITY_NEW_TASK":             0x10000000,
+	"FLAG_RECEIVER_FOREGROUND":           0x10000000,
+	"FLAG_ACTIVITY_SINGLE_TOP":           0x20000000,
+	"FLAG_ACTIVITY_NO_HISTORY":           0x40000000,
+}
+
+var (
+	reIntentComponent = regexp.MustCompile(`^[A-Za-z0-9_.]+/[A-Za-z0-9_.$]+$`)
+	reIntentWaitField = regexp.MustCompile(`^(Status|LaunchState|Activity|ThisTime|TotalTime|WaitTime):\s*(.*)$`)
+
+	reResolverEntry     = regexp.MustCompile(`^[0-9a-f]+ ([\w.$]+/[\w.$]+)(?: filter ([0-9a-f]+))?`)
+	reResolverValue     = regexp.MustCompile(`^(Action|Category|Scheme): "(.*)"`)
+	reResolverAuthority = regexp.MustCompile(`^Authority: "(.*)": (-?\d+)`)
+)
+
+// IntentExtra 一个附加参数
+type IntentExtra struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"` // 见 intentExtraOptions
+	Value string `json:"value"`
+}
+
+// IntentSpec 要发送的 Intent，字段为空表示不指定
+type IntentSpec struct {
+	Mode       string        `json:"mode"` // 见 IntentMode* 常量
+	Action     string        `json:"action"`
+	Data       string        `json:"data"` // 数据 URI，如深链接 https://example.com/path
+	MimeType   string        `json:"mimeType"`
+	Package    string        `json:"package"`
+	Component  string        `json:"component"` // 包名/类名，类名可用 . 开头的简写
+	Categories []string      `json:"categories"`
+	Extras     []IntentExtra `json:"extras"`
+	Flags      []string      `json:"flags"`     // IntentFlags 中的名称
+	Wait       bool          `json:"wait"`      // -W，等待启动完成并返回耗时，只对 Activity 有效
+	ForceStop  bool          `json:"forceStop"` // -S，启动前先停止应用，只对 Activity 有效
+}
+
+// IntentResult am 的输出，Wait 时附带解析出的启动耗时
+type IntentResult struct {
+	Cmd         string `json:"cmd"`
+	Output      string `json:"output"`
+	Status      string `json:"status"`      // -W 输出的 Status，如 ok、timeout
+	LaunchState string `json:"launchState"` // COLD/WARM/HOT，Android 10 起才有
+	Activity    string `json:"activity"`    // 实际启动的 Activity
+	ThisTime    int64  `json:"thisTime"`    // 毫秒，-1 表示没有该字段
+	TotalTime   int64  `json:"totalTime"`
+	WaitTime    int64  `json:"waitTime"`
+	Warning     string `json:"warning"` // 如应用已在前台，只是被带到前面
+}
+
+// IntentComponent 声明了 intent-filter 的组件，用于补全
+// 来源是 dumpsys package 的 Resolver Table，其中没有 exported 信息，因此不提供该字段
+type IntentComponent struct {
+	Kind       string   `json:"kind"` // activity/receiver/service
+	Name       string   `json:"name"`
+	Actions    []string `json:"actions"`
+	Categories []string `json:"categories"`
+	Schemes    []string `json:"schemes"` // intent-filter 中 data 的 scheme://host 组合
+}
+
+// buildIntentArgs 把 IntentSpec 转为 am 命令，所有值都经过设备端引用；userArg 需要紧跟在子命令后面
+func buildIntentArgs(spec IntentSpec, userArg string) (string, error) {
+	base, ok := intentModeCommands[spec.Mode]
+	if !ok {
+		return "", fmt.Errorf("不支持的方式: %s", spec.Mode)
+	}
+	if spec.Action == "" && spec.Data == "" && spec.Component == "" && spec.Package == "" {
+		return "", fmt.Errorf("至少需要指定 action、data、包名或组件中的一项")
+	}
+	args := []string{base + userArg}
+	if spec.Mode == IntentModeActivity {
+		if spec.Wait {
+			args = append(args, "-W")
+		}
+		if spec.ForceStop {
+			args = append(args, "-S")
+		}
+	}
+
+	if spec.Action != "" {
+		args = append(args, "-a", deviceQuote(spec.Action))
+	}
+	if spec.Data != "" {
+		args = append(args, "-d", deviceQuote(spec.Data))
+	}
+	if spec.MimeType != "" {
+		args = append(args, "-t", deviceQuote(spec.MimeType))
+	}
+	for _, c := range spec.Categories {
+		if c = strings.TrimSpace(c); c != "" {
+			args = append(args, "-c", deviceQuote(c))
+		}
+	}
+
+	var flags int64
+	for _, name := range spec.Flags {
+		value, ok := IntentFlags[name]
+		if !ok {
+			return "", fmt.Errorf("未知的 flag: %s", name)
+		}
+		flags |= value
+	}
+	if flags != 0 {
+		args = append(args, "-f", fmt.Sprintf("0x%08x", flags))
+	}
+
+	for _, extra := range spec.Extras {
+		arg, err := buildIntentExtra(extra)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+	}
+
+	// 组件或包名必须放在最后，am 把剩余的参数当作目标
+	if spec.Component != "" {
+		if !reIntentComponent.MatchString(spec.Component) {
+			return "", fmt.Errorf("无效的组件: %s", spec.Component)
+		}
+		args = append(args, "-n", spec.Component)
+	} else if spec.Package != "" {
+		if !IsValidPackageName(spec.Package) {
+			return "", fmt.Errorf("无效的包名: %s", spec.Package)
+		}
+		args = append(args, "-p", spec.Package)
+	}
+	return strings.Join(args, " "), nil
+}
+
+func buildIntentExtra(extra IntentExtra) (string, error) {
+	option, ok := intentExtraOptions[extra.Type]
+	if !ok {
+		return "", fmt.Errorf("不支持的参数类型: %s", extra.Type)
+	}
+	key := strings.TrimSpace(extra.Key)
+	if key == "" {
+		return "", fmt.Errorf("参数名不能为空")
+	}
+	if extra.Type == "null" {
+		return option + " " + deviceQuote(key), nil
+	}
+
+	value := extra.Value
+	var check func(string) error
+	switch extra.Type {
+	case "int", "int-array":
+		check = func(s string) error { _, err := strconv.ParseInt(s, 10, 32); return err }
+	case "long", "long-array":
+		check = func(s string) error { _, err := strconv.ParseInt(s, 10, 64); return err }
+	case "float":
+		check = func(s string) error { _, err := strconv.ParseFloat(s, 32); return err }
+	case "bool":
+		check = func(s string) error { _, err := strconv.ParseBool(s); return err }
+	case "component":
+		check = func(s string) error {
+			if !reIntentComponent.MatchString(s) {
+				return fmt.Errorf("invalid component")
+			}
+			return nil
+		}
+	}
+	if check != nil {
+		parts := []string{value}
+		if strings.HasSuffix(extra.Type, "-array") {
+			parts = strings.Split(value, ",")
+		}
+		for i, p := range parts {
+			parts[i] = strings.TrimSpace(p)
+			if err := check(parts[i]); err != nil {
+				return "", fmt.Errorf("参数 %s 的值 %q 不是有效的 %s", key, p, extra.Type)
+			}
+		}
+		value = strings.Join(parts, ",")
+	}
+	return fmt.Sprintf("%s %s %s", option, deviceQuote(key), deviceQuote(value)), nil
+}
+
+// SendIntent 按 spec 启动 Activity、发送广播或启动服务
+// am 出错时退出码仍为 0，需要检查输出中的 Error/Exception
+func SendIntent(param ExecuteParams, spec IntentSpec) (IntentResult, error) {
+	args, err := buildIntentArgs(spec, param.userArg())
+	if err != nil {
+		return IntentResult{}, err
+	}
+	cmd := buildRemoteScriptCmd(param, args)
+	res := execCmd(cmd)
+	result := parseIntentOutput(res.Res)
+	result.Cmd = cmd
+	if res.Error != "" {
+		return result, fmt.Errorf("%s", res.Error)
+	}
+	for _, line := range strings.Split(res.Res, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Error") || strings.Contains(line, "Exception") {
+			return result, fmt.Errorf("%s", line)
+		}
+	}
+	return result, nil
+}
+
+// parseIntentOutput 解析 am start -W 的输出；未使用 -W 时只保留原始输出
+func parseIntentOutput(output string) IntentResult {
+	result := IntentResult{Output: output, ThisTime: -1, TotalTime: -1, WaitTime: -1}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Warning:") {
+			result.Warning = strings.TrimSpace(strings.TrimPrefix(line, "Warning:"))
+			continue
+		}
+		m := reIntentWaitField.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "Status":
+			result.Status = value
+		case "LaunchState":
+			result.LaunchState = value
+		case "Activity":
+			result.Activity = value
+		case "ThisTime":
+			result.ThisTime, _ = strconv.ParseInt(value, 10, 64)
+		case "TotalTime":
+			result.TotalTime, _ = strconv.ParseInt(value, 10, 64)
+		case "WaitTime":
+			result.WaitTime, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return result
+}
+
+// ListIntentComponents 解析 dumpsys package 的 Activity/Receiver/Service Resolver Table，列出声明了 intent-filter 的组件
+// -f 才会输出 intent-filter 的内容；没有 intent-filter 的组件只能显式启动，不在表中，需要手动填写
+func ListIntentComponents(param ExecuteParams, packageName string) ([]IntentComponent, error) {
+	if !IsValidPackageName(packageName) {
+		return nil, fmt.Errorf("无效的包名: %s", packageName)
+	}
+	dump, err := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys package -f "+packageName), true, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(dump, "Package ["+packageName+"]") {
+		return nil, fmt.Errorf("未找到应用: %s", packageName)
+	}
+	return parseResolverTables(dump, packageName), nil
+}
+
+// resolverFilter 一个 intent-filter 中的 scheme 与 authority，全部读完后再组合
+type resolverFilter struct {
+	component *IntentComponent
+	schemes   []string
+	hosts     []string
+}
+
+// parseResolverTables 同一个 intent-filter 会在每个 action、scheme、MIME 类型下重复出现，按 filter 地址去重，格式示例:
+//
+//	Activity Resolver Table:
+//	  Schemes:
+//	      https:
+//	        4f0a8e1 com.example/.DeepLinkActivity filter 7a8b9c0
+//	          Action: "android.intent.action.VIEW"
+//	          Category: "android.intent.category.BROWSABLE"
+//	          Scheme: "https"
+//	          Authority: "example.com": -1
+func parseResolverTables(content string, pkg string) []IntentComponent {
+	tables := map[string]string{
+		"Activity Resolver Table:": "activity",
+		"Receiver Resolver Table:": "receiver",
+		"Service Resolver Table:":  "service",
+	}
+	byName := map[string]*IntentComponent{}
+	var order []*IntentComponent
+	seen := map[string]bool{}
+	var filters []*resolverFilter
+	var filter *resolverFilter
+	kind := ""
+	entryIndent := 0
+
+	for _, line := range util.MultiLine(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			kind = tables[trimmed]
+			filter = nil
+			continue
+		}
+		if kind == "" {
+			continue
+		}
+
+		if m := reResolverEntry.FindStringSubmatch(trimmed); m != nil {
+			filter = nil
+			pkgName, class, _ := strings.Cut(m[1], "/")
+			if pkgName != pkg {
+				continue
+			}
+			name := componentClassName(pkg, class)
+			key := kind + " " + name + " " + m[2]
+			if m[2] != "" && seen[key] {
+				continue
+			}
+			seen[key] = true
+			c, ok := byName[kind+" "+name]
+			if !ok {
+				c = &IntentComponent{Kind: kind, Name: name, Actions: []string{}, Categories: []string{}, Schemes: []string{}}
+				byName[kind+" "+name] = c
+				order = append(order, c)
+			}
+			filter = &resolverFilter{component: c}
+			filters = append(filters, filter)
+			entryIndent = indent
+			continue
+		}
+		if filter == nil {
+			continue
+		}
+		if indent <= entryIndent {
+			filter = nil
+			continue
+		}
+		if m := reResolverValue.FindStringSubmatch(trimmed); m != nil {
+			switch m[1] {
+			case "Action":
+				filter.component.Actions = appendUnique(filter.component.Actions, m[2])
+			case "Category":
+				filter.component.Categories = appendUnique(filter.component.Categories, m[2])
+			case "Scheme":
+				filter.schemes = appendUnique(filter.schemes, m[2])
+			}
+		} else if m := reResolverAuthority.FindStringSubmatch(trimmed); m != nil {
+			host := m[1]
+			if m[2] != "-1" {
+				host += ":" + m[2]
+			}
+			filter.hosts = appendUnique(filter.hosts, host)
+		}
+	}
+
+	// 同一个 intent-filter 中的 scheme 与 host 互相组合，与系统匹配 data 的规则一致
+	for _, f := range filters {
+		for _, scheme := range f.schemes {
+			if len(f.hosts) == 0 {
+				f.component.Schemes = appendUnique(f.component.Schemes, scheme+"://")
+			}
+			for _, host := range f.hosts {
+				f.component.Schemes = appendUnique(f.component.Schemes, scheme+"://"+host)
+			}
+		}
+	}
+
+	components := make([]IntentComponent, 0, len(order))
+	for _, c := range order {
+		components = append(components, *c)
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		if components[i].Kind != components[j].Kind {
+			return components[i].Kind < components[j].Kind
+		}
+		return components[i].Name < components[j].Name
+	})
+	return components
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,103 @@
+package adb
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dumpsys package -f：同一个 filter 在每个 scheme、action 下各出现一次
+const dumpsysResolvers = `Activity Resolver Table:
+  Schemes:
+      https:
+        4f0a8e1 com.example/.DeepLinkActivity filter 7a8b9c0
+          Action: "android.intent.action.VIEW"
+          Category: "android.intent.category.DEFAULT"
+          Category: "android.intent.category.BROWSABLE"
+          Scheme: "https"
+          Scheme: "example"
+          Authority: "example.com": -1
+          Authority: "m.example.com": 8443
+          Path: "PatternMatcher{PREFIX: /item}"
+          AutoVerify=true
+        4f0a8e1 com.example/.DeepLinkActivity filter 1c2d3e4
+          Action: "android.intent.action.VIEW"
+          Scheme: "https"
+          Authority: "share.example.com": -1
+      example:
+        4f0a8e1 com.example/.DeepLinkActivity filter 7a8b9c0
+          Action: "android.intent.action.VIEW"
+          Category: "android.intent.category.DEFAULT"
+          Category: "android.intent.category.BROWSABLE"
+          Scheme: "https"
+          Scheme: "example"
+          Authority: "example.com": -1
+          Authority: "m.example.com": 8443
+      geo:
+        5b6c7d8 com.example/com.example.map.MapActivity filter 9e0f1a2
+          Action: "android.intent.action.VIEW"
+          Scheme: "geo"
+
+  Full MIME Types:
+      image/png:
+        6c7d8e9 com.example/.ShareActivity filter 2b3c4d5
+          Action: "android.intent.action.SEND"
+          Category: "android.intent.category.DEFAULT"
+          Type: "image/png"
+
+  Non-Data Actions:
+      android.intent.action.MAIN:
+        8e9f0a1 com.example/.MainActivity filter 3d4e5f6
+          Action: "android.intent.action.MAIN"
+          Category: "android.intent.category.LAUNCHER"
+
+Receiver Resolver Table:
+  Non-Data Actions:
+      android.intent.action.BOOT_COMPLETED:
+        a1b2c3d com.example/.BootReceiver filter 4e5f6a7
+          Action: "android.intent.action.BOOT_COMPLETED"
+
+Service Resolver Table:
+  Non-Data Actions:
+      com.example.action.SYNC:
+        b2c3d4e com.example/.SyncService filter 5f6a7b8
+          Action: "com.example.action.SYNC"
+
+Provider Resolver Table:
+  Non-Data Actions:
+      com.example.action.PROVIDE:
+        c3d4e5f com.example/.DataProvider filter 6a7b8c9
+          Action: "com.example.action.PROVIDE"
+
+Packages:
+  Package [com.example] (d4e5f6a):
+    userId=10123
+`
+
+func TestParseResolverTables(t *testing.T) {
+	got := parseResolverTables(dumpsysResolvers, "com.example")
+	want := []IntentComponent{
+		{Kind: "activity", Name: "com.example.DeepLinkActivity",
+			Actions:    []string{"android.intent.action.VIEW"},
+			Categories: []string{"android.intent.category.DEFAULT", "android.intent.category.BROWSABLE"},
+			// scheme 与 host 只在同一个 filter 内组合，share.example.com 不会与 example 组合
+			Schemes: []string{"https://example.com", "https://m.example.com:8443", "example://example.com", "example://m.example.com:8443", "https://share.example.com"}},
+		{Kind: "activity", Name: "com.example.MainActivity",
+			Actions: []string{"android.intent.action.MAIN"}, Categories: []string{"android.intent.category.LAUNCHER"}, Schemes: []string{}},
+		{Kind: "activity", Name: "com.example.ShareActivity",
+			Actions: []string{"android.intent.action.SEND"}, Categories: []string{"android.intent.category.DEFAULT"}, Schemes: []string{}},
+		{Kind: "activity", Name: "com.example.map.MapActivity",
+			Actions: []string{"android.intent.action.VIEW"}, Categories: []string{}, Schemes: []string{"geo://"}},
+		{Kind: "receiver", Name: "com.example.BootReceiver",
+			Actions: []string{"android.intent.action.BOOT_COMPLETED"}, Categories: []string{}, Schemes: []string{}},
+		{Kind: "service", Name: "com.example.SyncService",
+			Actions: []string{"com.example.action.SYNC"}, Categories: []string{}, Schemes: []string{}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d components %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("component %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
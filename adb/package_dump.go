@@ -0,0 +1,125 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"adb-tool-wails/util"
+)
+
+var (
+	reDumpUserState = regexp.MustCompile(`^User (\d+):.*\binstalled=(true|false)\b.*\benabled=(\d+)`)
+	reDumpCodePath  = regexp.MustCompile(`codePath=(\S+)`)
+	reDumpInstallAt = regexp.MustCompile(`firstInstallTime=(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+	reDumpUpdateAt  = regexp.MustCompile(`lastUpdateTime=(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+	reDumpPkgFlags  = regexp.MustCompile(`pkgFlags=\[([^\]]*)\]`)
+)
+
+// DumpsysPackage dumpsys package 中应用在某个用户下的基本信息，Aya 无法读取时（如只安装在工作资料中）作为后备
+type DumpsysPackage struct {
+	Package          string
+	VersionName      string
+	VersionCode      int
+	MinSdk           int
+	TargetSdk        int
+	ApkPath          string
+	FirstInstallTime int64 // Unix 毫秒，按本机时区换算
+	LastUpdateTime   int64
+	System           bool
+	Installed        bool // 在目标用户下已安装
+	Enabled          bool
+}
+
+// GetDumpsysPackage 读取应用在 param.UserId 下的信息，未指定用户时使用主用户
+func GetDumpsysPackage(param ExecuteParams, packageName string) (DumpsysPackage, error) {
+	if !IsValidPackageName(packageName) && packageName != "android" {
+		return DumpsysPackage{}, fmt.Errorf("无效的包名: %s", packageName)
+	}
+	dump, err := util.Exec(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "dumpsys package "+packageName), true, nil)
+	if err != nil {
+		return DumpsysPackage{}, err
+	}
+	userId := param.UserId
+	if userId == "" {
+		userId = "0"
+	}
+	info, ok := parseDumpsysPackage(dump, packageName, userId)
+	if !ok {
+		return info, fmt.Errorf("应用 %s 未安装", packageName)
+	}
+	return info, nil
+}
+
+// parseDumpsysPackage 只解析 Packages: 中该应用的段落，Hidden system packages: 中的同名段落是被更新前的系统版本
+func parseDumpsysPackage(content string, packageName string, userId string) (DumpsysPackage, bool) {
+	info := DumpsysPackage{Package: packageName}
+	header := "Package [" + packageName + "]"
+	var block []string
+	indent := -1
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r", ""), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if indent < 0 {
+			if strings.HasPrefix(trimmed, header) {
+				indent = len(line) - len(strings.TrimLeft(line, " "))
+			}
+			continue
+		}
+		if trimmed != "" && len(line)-len(strings.TrimLeft(line, " ")) <= indent {
+			break
+		}
+		block = append(block, trimmed)
+	}
+	if indent < 0 {
+		return info, false
+	}
+
+	text := strings.Join(block, "\n")
+	if m := reDumpVersionCode.FindStringSubmatch(text); m != nil {
+		info.VersionCode, _ = strconv.Atoi(m[1])
+		info.MinSdk, _ = strconv.Atoi(m[2])
+		info.TargetSdk, _ = strconv.Atoi(m[3])
+	}
+	if m := reDumpVersionName.FindStringSubmatch(text); m != nil {
+		info.VersionName = m[1]
+	}
+	if m := reDumpCodePath.FindStringSubmatch(text); m != nil {
+		info.ApkPath = m[1]
+		if !strings.HasSuffix(info.ApkPath, ".apk") {
+			info.ApkPath = strings.TrimSuffix(info.ApkPath, "/") + "/base.apk"
+		}
+	}
+	info.FirstInstallTime = parseDumpTime(reDumpInstallAt, text)
+	info.LastUpdateTime = parseDumpTime(reDumpUpdateAt, text)
+	if m := reDumpPkgFlags.FindStringSubmatch(text); m != nil {
+		info.System = strings.Contains(" "+m[1]+" ", " SYSTEM ")
+	}
+
+	// enabled: 0 默认、1 启用、2 停用、3 用户停用、4 使用前停用
+	for _, line := range block {
+		m := reDumpUserState.FindStringSubmatch(line)
+		if m == nil || m[1] != userId {
+			continue
+		}
+		info.Installed = m[2] == "true"
+		info.Enabled = m[3] == "0" || m[3] == "1"
+		return info, info.Installed
+	}
+	// Android 4.2 之前没有多用户，段落中没有 User 行
+	info.Installed, info.Enabled = userId == "0", true
+	return info, info.Installed
+}
+
+func parseDumpTime(re *regexp.Regexp, text string) int64 {
+	m := re.FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", m[1], time.Local)
+	if err != nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,120 @@
+package adb
+
+import (
+	"testing"
+	"time"
+)
+
+// 更新过的系统应用：Packages: 与 Hidden system packages: 中各有一段
+const dumpsysPackageUpdated = `Packages:
+  Package [com.example.app] (4f2a1c3):
+    userId=10123
+    pkg=Package{8d1e2f0 com.example.app}
+    codePath=/data/app/~~abc==/com.example.app-xyz==
+    resourcePath=/data/app/~~abc==/com.example.app-xyz==
+    versionCode=42 minSdk=24 targetSdk=34
+    versionName=2.1.0
+    pkgFlags=[ SYSTEM HAS_CODE ALLOW_CLEAR_USER_DATA UPDATED_SYSTEM_APP ]
+    timeStamp=2024-05-02 08:03:00
+    firstInstallTime=2024-05-01 10:20:30
+    lastUpdateTime=2024-05-02 08:03:00
+    User 0: ceDataInode=1234 installed=true hidden=false suspended=false distractionFlags=0 stopped=false notLaunched=false enabled=3 instant=false virtual=false
+    User 10: ceDataInode=5678 installed=true hidden=false suspended=false distractionFlags=0 stopped=false notLaunched=false enabled=0 instant=false virtual=false
+    User 11: ceDataInode=0 installed=false hidden=false suspended=false distractionFlags=0 stopped=true notLaunched=true enabled=0 instant=false virtual=false
+
+Hidden system packages:
+  Package [com.example.app] (1b2c3d4):
+    codePath=/system/app/Example/Example.apk
+    versionCode=1 minSdk=24 targetSdk=30
+    versionName=1.0
+`
+
+// Android 4.x：codePath 直接是 APK，没有 User 行
+const dumpsysPackageLegacy = `Packages:
+  Package [com.example.old] 41a2b3c4:
+    userId=10050 gids=[3003]
+    codePath=/data/app/com.example.old-1.apk
+    versionCode=7 targetSdk=19
+    versionName=0.7
+    pkgFlags=[ HAS_CODE ALLOW_CLEAR_USER_DATA ]
+    firstInstallTime=2015-01-01 00:00:00
+    lastUpdateTime=2015-01-01 00:00:00
+`
+
+func TestParseDumpsysPackage(t *testing.T) {
+	installed := time.Date(2024, 5, 1, 10, 20, 30, 0, time.Local).UnixMilli()
+	tests := []struct {
+		name    string
+		content string
+		pkg     string
+		userId  string
+		want    DumpsysPackage
+		ok      bool
+	}{
+		{
+			name:    "user 0 disabled by user",
+			content: dumpsysPackageUpdated,
+			pkg:     "com.example.app",
+			userId:  "0",
+			want: DumpsysPackage{
+				Package: "com.example.app", VersionName: "2.1.0", VersionCode: 42, MinSdk: 24, TargetSdk: 34,
+				ApkPath:          "/data/app/~~abc==/com.example.app-xyz==/base.apk",
+				FirstInstallTime: installed, LastUpdateTime: time.Date(2024, 5, 2, 8, 3, 0, 0, time.Local).UnixMilli(),
+				System: true, Installed: true, Enabled: false,
+			},
+			ok: true,
+		},
+		{
+			name:    "work profile",
+			content: dumpsysPackageUpdated,
+			pkg:     "com.example.app",
+			userId:  "10",
+			want: DumpsysPackage{
+				Package: "com.example.app", VersionName: "2.1.0", VersionCode: 42, MinSdk: 24, TargetSdk: 34,
+				ApkPath:          "/data/app/~~abc==/com.example.app-xyz==/base.apk",
+				FirstInstallTime: installed, LastUpdateTime: time.Date(2024, 5, 2, 8, 3, 0, 0, time.Local).UnixMilli(),
+				System: true, Installed: true, Enabled: true,
+			},
+			ok: true,
+		},
+		{
+			name:    "not installed for user",
+			content: dumpsysPackageUpdated,
+			pkg:     "com.example.app",
+			userId:  "11",
+			ok:      false,
+		},
+		{
+			name:    "missing package",
+			content: dumpsysPackageUpdated,
+			pkg:     "com.example.other",
+			userId:  "0",
+			ok:      false,
+		},
+		{
+			name:    "legacy without users",
+			content: dumpsysPackageLegacy,
+			pkg:     "com.example.old",
+			userId:  "0",
+			want: DumpsysPackage{
+				Package: "com.example.old", VersionName: "0.7", VersionCode: 7, TargetSdk: 19,
+				ApkPath:          "/data/app/com.example.old-1.apk",
+				FirstInstallTime: time.Date(2015, 1, 1, 0, 0, 0, 0, time.Local).UnixMilli(),
+				LastUpdateTime:   time.Date(2015, 1, 1, 0, 0, 0, 0, time.Local).UnixMilli(),
+				Installed:        true, Enabled: true,
+			},
+			ok: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDumpsysPackage(tt.content, tt.pkg, tt.userId)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"fmt"
+	"strings"
+)
+
+// 修改应用状态的操作，每个操作都有对应的撤销操作
+const (
+	PackageOpDisable   = "disable"   // pm disable-user
+	PackageOpEnable    = "enable"    // pm enable
+	PackageOpSuspend   = "suspend"   // pm suspend，应用保留在桌面但无法打开
+	PackageOpUnsuspend = "unsuspend" // pm unsuspend
+	PackageOpUninstall = "uninstall" // pm uninstall -k --user，保留数据与系统分区中的 APK
+	PackageOpReinstall = "reinstall" // cmd package install-existing，恢复为用户卸载的应用
+)
+
+var packageOpCommands = map[string]string{
+	PackageOpDisable:   "pm disable-user%s %s",
+	PackageOpEnable:    "pm enable%s %s",
+	PackageOpSuspend:   "pm suspend%s %s",
+	PackageOpUnsuspend: "pm unsuspend%s %s",
+	PackageOpUninstall: "pm uninstall -k%s %s",
+	PackageOpReinstall: "cmd package install-existing%s %s",
+}
+
+var packageOpInverse = map[string]string{
+	PackageOpDisable:   PackageOpEnable,
+	PackageOpEnable:    PackageOpDisable,
+	PackageOpSuspend:   PackageOpUnsuspend,
+	PackageOpUnsuspend: PackageOpSuspend,
+	PackageOpUninstall: PackageOpReinstall,
+	PackageOpReinstall: PackageOpUninstall,
+}
+
+// ProtectedPackage 停用或卸载后可能导致无法开机、无法操作或无法恢复的系统应用
+type ProtectedPackage struct {
+	Package string `json:"package"`
+	Reason  string `json:"reason"`
+}
+
+var protectedPackages = []ProtectedPackage{
+	{"android", "系统框架"},
+	{"com.android.systemui", "状态栏、导航栏与锁屏"},
+	{"com.android.settings", "系统设置，停用后无法在设备上恢复"},
+	{"com.android.shell", "adb shell 身份，停用后工具无法工作"},
+	{"com.android.phone", "电话与移动网络"},
+	{"com.android.server.telecom", "通话服务"},
+	{"com.android.providers.settings", "系统设置存储"},
+	{"com.android.providers.telephony", "短信与运营商配置"},
+	{"com.android.providers.media", "媒体库与存储访问"},
+	{"com.android.providers.media.module", "媒体库与存储访问"},
+	{"com.android.externalstorage", "外部存储访问"},
+	{"com.android.packageinstaller", "安装与卸载应用"},
+	{"com.google.android.packageinstaller", "安装与卸载应用"},
+	{"com.android.permissioncontroller", "运行时权限"},
+	{"com.google.android.permissioncontroller", "运行时权限"},
+	{"com.android.networkstack", "网络连接"},
+	{"com.google.android.networkstack", "网络连接"},
+	{"com.android.inputdevices", "输入设备"},
+	{"com.android.keychain", "证书存储"},
+	{"com.android.launcher3", "桌面，停用后可能没有可用的桌面"},
+	{"com.google.android.apps.nexuslauncher", "桌面，停用后可能没有可用的桌面"},
+}
+
+// ProtectedPackages 默认禁止停用、挂起或卸载的系统应用
+func ProtectedPackages() []ProtectedPackage {
+	return protectedPackages
+}
+
+// PackageProtection 返回应用受保护的原因，不受保护时返回空
+func PackageProtection(packageName string) string {
+	for _, p := range protectedPackages {
+		if p.Package == packageName {
+			return p.Reason
+		}
+	}
+	return ""
+}
+
+// IsRestrictivePackageOp 停用、挂起与卸载会让应用不可用，需要检查保护列表
+func IsRestrictivePackageOp(op string) bool {
+	return op == PackageOpDisable || op == PackageOpSuspend || op == PackageOpUninstall
+}
+
+// InversePackageOp 返回撤销 op 的操作
+func InversePackageOp(op string) (string, bool) {
+	inverse, ok := packageOpInverse[op]
+	return inverse, ok
+}
+
+// SetPackageState 在目标用户下执行 Package* 操作，未指定用户时作用于当前用户
+// pm 出错时多数情况下退出码仍为 0，需要检查输出
+func SetPackageState(param ExecuteParams, packageName string, op string) types.ExecResult {
+	format, ok := packageOpCommands[op]
+	if !ok {
+		return types.NewExecResultErrorString(op, fmt.Sprintf("不支持的操作: %s", op))
+	}
+	if !IsValidPackageName(packageName) && packageName != "android" {
+		return types.NewExecResultErrorString(op, fmt.Sprintf("无效的包名: %s", packageName))
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf(format, param.userArgOrCurrent(), packageName))
+	result := execCmd(cmd)
+	if result.Error != "" {
+		return result
+	}
+	if isPackageOpFailure(result.Res) {
+		return types.NewExecResultErrorString(cmd, result.Res)
+	}
+	return types.NewExecResultSuccess(cmd, result.Res)
+}
+
+// isPackageOpFailure 按行首识别 pm 的错误输出，成功时的输出包含包名（如 Package com.error.app new state: disabled-user），
+// 不能在整段输出中查找 error 等单词；带空格的短语不会出现在包名中，可以直接查找
+func isPackageOpFailure(out string) bool {
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Error") || strings.HasPrefix(line, "Failure") || strings.HasPrefix(line, "Exception") ||
+			strings.HasPrefix(line, "java.lang.") || strings.HasPrefix(line, "Security exception") {
+			return true
+		}
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "unknown package") || strings.Contains(lower, " not installed") || strings.Contains(lower, "doesn't exist") {
+			return true
+		}
+	}
+	return false
+}
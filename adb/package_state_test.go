@@ -0,0 +1,45 @@
+package adb
+
+import "testing"
+
+func TestIsPackageOpFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want bool
+	}{
+		// 成功时的输出，包名中可能包含 error、failure 等单词
+		{"disable-user", "Package com.example.app new state: disabled-user", false},
+		{"package name contains error", "Package com.error.app new state: disabled-user", false},
+		{"package name contains failure", "Package com.Failure.app new state: enabled", false},
+		{"package name contains exception", "Package com.exception.tracker new state: default", false},
+		{"enable", "Package com.example.app new state: enabled\n", false},
+		{"suspend", "Package com.example.app new suspended state: true", false},
+		{"unsuspend", "Package com.error.app new suspended state: false", false},
+		{"uninstall keep data", "Success", false},
+		{"install existing", "Package com.example.app installed for user: 10", false},
+		{"empty", "", false},
+		// Android 7 及以前 pm 直接打印 Error:
+		{"legacy unknown package", "Error: java.lang.IllegalArgumentException: Unknown package: com.foo", true},
+		{"uninstall not installed", "Failure [not installed for 10]", true},
+		{"uninstall internal error", "Failure [DELETE_FAILED_INTERNAL_ERROR]", true},
+		// Android 8 起通过 cmd package 执行，异常带堆栈
+		{"exception with stack", `Exception occurred while executing 'disable-user':
+java.lang.IllegalArgumentException: Unknown package: com.foo
+	at com.android.server.pm.PackageManagerService.setEnabledSetting(PackageManagerService.java:3649)
+	at com.android.server.pm.PackageManagerShellCommand.runSetEnabledSetting(PackageManagerShellCommand.java:2391)`, true},
+		{"security exception in stack", `Exception occurred while executing 'suspend':
+java.lang.SecurityException: Shell cannot suspend packages for user 10`, true},
+		{"java exception", "java.lang.IllegalArgumentException: Unknown package: com.foo", true},
+		{"security exception", "Security exception: Shell does not have permission to access user 10", true},
+		{"not installed", "Package com.foo is not installed for user 10", true},
+		{"does not exist", "Package com.foo doesn't exist", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPackageOpFailure(tt.out); got != tt.want {
+				t.Errorf("isPackageOpFailure(%q) = %v, want %v", tt.out, got, tt.want)
+			}
+		})
+	}
+}
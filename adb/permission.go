@@ -0,0 +1,328 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"adb-tool-wails/util"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AppPermission 单个权限的状态
+type AppPermission struct {
+	Name      string   `json:"name"`
+	Requested bool     `json:"requested"`
+	Runtime   bool     `json:"runtime"`
+	Granted   bool     `json:"granted"`
+	Flags     []string `json:"flags"`
+	AppOp     string   `json:"appOp,omitempty"`
+	AppOpMode string   `json:"appOpMode,omitempty"`
+}
+
+// AppOpEntry appops get 输出中的一项
+type AppOpEntry struct {
+	Op      string `json:"op"`
+	Mode    string `json:"mode"`
+	UidMode bool   `json:"uidMode"`
+}
+
+// AppPermissionInfo 应用的权限与 appops 状态
+type AppPermissionInfo struct {
+	PackageName string          `json:"packageName"`
+	UserId      string          `json:"userId"` // 运行时权限状态所属的用户
+	Permissions []AppPermission `json:"permissions"`
+	AppOps      []AppOpEntry    `json:"appOps"`
+}
+
+var (
+	rePermissionState = regexp.MustCompile(`^([\w.]+):\s*granted=(true|false)(?:,\s*flags=\[\s*(.*?)\s*\])?`)
+	reDumpUser        = regexp.MustCompile(`^User (\d+):`)
+	reAppOpLine       = regexp.MustCompile(`^(Uid mode:\s*)?([A-Z_0-9]+):\s*([a-z_]+)`)
+	rePermissionName  = regexp.MustCompile(`^[\w.]+$`)
+	reAppOpName       = regexp.MustCompile(`^[A-Z_0-9]+$`)
+)
+
+// appOpModes appops set 支持的模式
+var appOpModes = map[string]bool{
+	"allow":      true,
+	"ignore":     true,
+	"deny":       true,
+	"default":    true,
+	"foreground": true,
+}
+
+// permissionAppOps 常见权限对应的 appop 名称
+var permissionAppOps = map[string]string{
+	"android.permission.ACCESS_COARSE_LOCATION":   "COARSE_LOCATION",
+	"android.permission.ACCESS_FINE_LOCATION":     "FINE_LOCATION",
+	"android.permission.CAMERA":                   "CAMERA",
+	"android.permission.RECORD_AUDIO":             "RECORD_AUDIO",
+	"android.permission.READ_CONTACTS":            "READ_CONTACTS",
+	"android.permission.WRITE_CONTACTS":           "WRITE_CONTACTS",
+	"android.permission.GET_ACCOUNTS":             "GET_ACCOUNTS",
+	"android.permission.READ_CALENDAR":            "READ_CALENDAR",
+	"android.permission.WRITE_CALENDAR":           "WRITE_CALENDAR",
+	"android.permission.READ_CALL_LOG":            "READ_CALL_LOG",
+	"android.permission.WRITE_CALL_LOG":           "WRITE_CALL_LOG",
+	"android.permission.CALL_PHONE":               "CALL_PHONE",
+	"android.permission.READ_PHONE_STATE":         "READ_PHONE_STATE",
+	"android.permission.READ_PHONE_NUMBERS":       "READ_PHONE_NUMBERS",
+	"android.permission.ANSWER_PHONE_CALLS":       "ANSWER_PHONE_CALLS",
+	"android.permission.READ_SMS":                 "READ_SMS",
+	"android.permission.SEND_SMS":                 "SEND_SMS",
+	"android.permission.RECEIVE_SMS":              "RECEIVE_SMS",
+	"android.permission.RECEIVE_MMS":              "RECEIVE_MMS",
+	"android.permission.BODY_SENSORS":             "BODY_SENSORS",
+	"android.permission.ACTIVITY_RECOGNITION":     "ACTIVITY_RECOGNITION",
+	"android.permission.READ_EXTERNAL_STORAGE":    "READ_EXTERNAL_STORAGE",
+	"android.permission.WRITE_EXTERNAL_STORAGE":   "WRITE_EXTERNAL_STORAGE",
+	"android.permission.READ_MEDIA_IMAGES":        "READ_MEDIA_IMAGES",
+	"android.permission.READ_MEDIA_VIDEO":         "READ_MEDIA_VIDEO",
+	"android.permission.READ_MEDIA_AUDIO":         "READ_MEDIA_AUDIO",
+	"android.permission.ACCESS_MEDIA_LOCATION":    "ACCESS_MEDIA_LOCATION",
+	"android.permission.POST_NOTIFICATIONS":       "POST_NOTIFICATION",
+	"android.permission.SYSTEM_ALERT_WINDOW":      "SYSTEM_ALERT_WINDOW",
+	"android.permission.WRITE_SETTINGS":           "WRITE_SETTINGS",
+	"android.permission.MANAGE_EXTERNAL_STORAGE":  "MANAGE_EXTERNAL_STORAGE",
+	"android.permission.REQUEST_INSTALL_PACKAGES": "REQUEST_INSTALL_PACKAGES",
+	"android.permission.PACKAGE_USAGE_STATS":      "GET_USAGE_STATS",
+	"android.permission.SCHEDULE_EXACT_ALARM":     "SCHEDULE_EXACT_ALARM",
+}
+
+// GetAppPermissions 解析 dumpsys package 与 appops get 的输出，得到应用的权限列表
+func GetAppPermissions(param ExecuteParams) (AppPermissionInfo, error) {
+	if err := checkPackageTarget(param); err != nil {
+		return AppPermissionInfo{}, err
+	}
+	// 运行时权限按用户区分，未指定用户时读取当前用户，appops 与之保持一致
+	if param.UserId == "" {
+		param.UserId = CurrentUserId(param)
+	}
+	info := AppPermissionInfo{PackageName: param.PackageName, UserId: param.UserId}
+
+	dumpCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("dumpsys package %s", param.PackageName))
+	dumpPackage := execCmd(dumpCmd)
+	if dumpPackage.Error != "" {
+		return info, fmt.Errorf("%s", dumpPackage.Error)
+	}
+	if !strings.Contains(dumpPackage.Res, "Package ["+param.PackageName+"]") {
+		return info, fmt.Errorf("未找到应用: %s", param.PackageName)
+	}
+
+	appOpsCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("appops get%s %s", param.userArg(), param.PackageName))
+	appOpsRes := execCmd(appOpsCmd)
+	if appOpsRes.Error == "" {
+		info.AppOps = parseAppOps(appOpsRes.Res)
+	}
+
+	info.Permissions = parsePackagePermissions(dumpPackage.Res, param.UserId)
+	applyAppOpModes(info.Permissions, info.AppOps)
+	return info, nil
+}
+
+// parsePackagePermissions 解析 dumpsys package 中的 requested/install/runtime permissions 段
+// 运行时权限按用户分段列在 User N: 下，只取 userId 的状态，userId 为空时取第一个用户
+func parsePackagePermissions(content string, userId string) []AppPermission {
+	permissions := make(map[string]*AppPermission)
+	get := func(name string) *AppPermission {
+		if p, ok := permissions[name]; ok {
+			return p
+		}
+		p := &AppPermission{Name: name, Flags: []string{}}
+		permissions[name] = p
+		return p
+	}
+
+	section := ""
+	sectionIndent := 0
+	user := ""
+	for _, line := range util.MultiLine(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			section = ""
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if m := reDumpUser.FindStringSubmatch(trimmed); m != nil {
+			user = m[1]
+		}
+
+		switch trimmed {
+		case "requested permissions:", "install permissions:", "runtime permissions:":
+			section = trimmed
+			sectionIndent = indent
+			continue
+		}
+		if section == "" {
+			continue
+		}
+		// 缩进回退说明当前段已经结束
+		if indent <= sectionIndent {
+			section = ""
+			continue
+		}
+
+		switch section {
+		case "requested permissions:":
+			name, _, _ := strings.Cut(trimmed, ":")
+			get(strings.TrimSpace(name)).Requested = true
+		case "install permissions:", "runtime permissions:":
+			if section == "runtime permissions:" {
+				if userId == "" {
+					userId = user
+				}
+				if user != userId {
+					continue
+				}
+			}
+			match := rePermissionState.FindStringSubmatch(trimmed)
+			if match == nil {
+				continue
+			}
+			p := get(match[1])
+			p.Runtime = section == "runtime permissions:"
+			p.Granted = match[2] == "true"
+			if match[3] != "" {
+				p.Flags = strings.Split(match[3], "|")
+			}
+		}
+	}
+
+	result := make([]AppPermission, 0, len(permissions))
+	for _, p := range permissions {
+		// 未出现在 runtime 段但属于危险权限的，同样视为运行时权限
+		if !p.Runtime && dangerousPermissions[p.Name] {
+			p.Runtime = true
+		}
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Runtime != result[j].Runtime {
+			return result[i].Runtime
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// parseAppOps 解析 appops get 的输出
+// 格式示例:
+//
+//	CAMERA: allow; time=+1h2m3s ago
+//	Uid mode: MANAGE_EXTERNAL_STORAGE: default
+func parseAppOps(content string) []AppOpEntry {
+	var ops []AppOpEntry
+	for _, line := range util.MultiLine(content) {
+		match := reAppOpLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		ops = append(ops, AppOpEntry{
+			Op:      match[2],
+			Mode:    match[3],
+			UidMode: match[1] != "",
+		})
+	}
+	return ops
+}
+
+// applyAppOpModes 将 appops 的模式关联到对应的权限上，包级模式优先于 uid 模式
+func applyAppOpModes(permissions []AppPermission, ops []AppOpEntry) {
+	modes := make(map[string]string, len(ops))
+	for _, op := range ops {
+		if _, ok := modes[op.Op]; ok && op.UidMode {
+			continue
+		}
+		modes[op.Op] = op.Mode
+	}
+	for i := range permissions {
+		op, ok := permissionAppOps[permissions[i].Name]
+		if !ok {
+			continue
+		}
+		permissions[i].AppOp = op
+		permissions[i].AppOpMode = modes[op]
+	}
+}
+
+// GrantAppPermission 授予单个运行时权限
+func GrantAppPermission(param ExecuteParams, permission string) types.ExecResult {
+	if err := checkPermissionTarget(param, permission); err != nil {
+		return types.NewExecResultError("pm grant", err)
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm grant%s %s %s", param.userArg(), param.PackageName, permission))
+	return checkPmResult(execCmd(cmd))
+}
+
+// RevokeAppPermission 撤销单个运行时权限
+func RevokeAppPermission(param ExecuteParams, permission string) types.ExecResult {
+	if err := checkPermissionTarget(param, permission); err != nil {
+		return types.NewExecResultError("pm revoke", err)
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm revoke%s %s %s", param.userArg(), param.PackageName, permission))
+	return checkPmResult(execCmd(cmd))
+}
+
+// ResetAppPermission 将单个权限恢复到未询问状态：撤销授权并清除 user-set/user-fixed 标记
+func ResetAppPermission(param ExecuteParams, permission string) types.ExecResult {
+	revokeRes := RevokeAppPermission(param, permission)
+	if revokeRes.Error != "" {
+		return revokeRes
+	}
+	clearCmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pm clear-permission-flags%s %s %s user-set user-fixed", param.userArg(), param.PackageName, permission))
+	clearRes := checkPmResult(execCmd(clearCmd))
+	finalCmd := revokeRes.Cmd + "\n" + clearCmd
+	if clearRes.Error != "" {
+		return types.NewExecResultErrorString(finalCmd, clearRes.Error)
+	}
+	return types.NewExecResultSuccess(finalCmd, "")
+}
+
+// SetAppOp 设置应用的 appop 模式
+func SetAppOp(param ExecuteParams, op string, mode string) types.ExecResult {
+	if err := checkPackageTarget(param); err != nil {
+		return types.NewExecResultError("appops set", err)
+	}
+	if !reAppOpName.MatchString(op) {
+		return types.NewExecResultErrorString("appops set", fmt.Sprintf("无效的 appop: %s", op))
+	}
+	if !appOpModes[mode] {
+		return types.NewExecResultErrorString("appops set", fmt.Sprintf("不支持的 appops 模式: %s", mode))
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("appops set%s %s %s %s", param.userArg(), param.PackageName, op, mode))
+	return checkPmResult(execCmd(cmd))
+}
+
+// checkPackageTarget 包名与用户会拼进 shell 命令，执行前检查格式
+func checkPackageTarget(param ExecuteParams) error {
+	if !IsValidPackageName(param.PackageName) {
+		return fmt.Errorf("无效的包名: %s", param.PackageName)
+	}
+	if !IsValidUserId(param.UserId) {
+		return fmt.Errorf("无效的用户: %s", param.UserId)
+	}
+	return nil
+}
+
+// checkPermissionTarget 在 checkPackageTarget 的基础上检查权限名
+func checkPermissionTarget(param ExecuteParams, permission string) error {
+	if err := checkPackageTarget(param); err != nil {
+		return err
+	}
+	if !rePermissionName.MatchString(permission) {
+		return fmt.Errorf("无效的权限名: %s", permission)
+	}
+	return nil
+}
+
+// checkPmResult pm/appops 出错时只在输出中打印异常，需要转换为错误
+func checkPmResult(result types.ExecResult) types.ExecResult {
+	if result.Error != "" {
+		return result
+	}
+	if strings.Contains(result.Res, "Exception") || strings.HasPrefix(result.Res, "Error") || strings.Contains(result.Res, "Unknown option") {
+		return types.NewExecResultErrorString(result.Cmd, result.Res)
+	}
+	return result
+}
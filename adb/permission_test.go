@@ -0,0 +1,98 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"strings"
+	"testing"
+)
+
+// 主用户拒绝了相机，工作资料授予了相机
+const dumpsysPermissions = `Packages:
+  Package [com.example.app] (4f2a1c3):
+    requested permissions:
+      android.permission.INTERNET
+      android.permission.CAMERA
+    install permissions:
+      android.permission.INTERNET: granted=true
+    User 0: ceDataInode=1234 installed=true hidden=false enabled=0
+      gids=[3003]
+      runtime permissions:
+        android.permission.CAMERA: granted=false, flags=[ USER_SET|USER_SENSITIVE_WHEN_GRANTED ]
+    User 10: ceDataInode=5678 installed=true hidden=false enabled=0
+      gids=[3003]
+      runtime permissions:
+        android.permission.CAMERA: granted=true, flags=[ USER_SET ]
+`
+
+func TestParsePackagePermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		userId  string
+		granted bool
+		flags   []string
+	}{
+		{"primary user", "0", false, []string{"USER_SET", "USER_SENSITIVE_WHEN_GRANTED"}},
+		{"work profile", "10", true, []string{"USER_SET"}},
+		// 未指定用户时不能把各用户的状态合并，取第一个用户
+		{"unspecified user", "", false, []string{"USER_SET", "USER_SENSITIVE_WHEN_GRANTED"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perms := parsePackagePermissions(dumpsysPermissions, tt.userId)
+			byName := map[string]AppPermission{}
+			for _, p := range perms {
+				byName[p.Name] = p
+			}
+			camera, ok := byName["android.permission.CAMERA"]
+			if !ok || !camera.Requested || !camera.Runtime {
+				t.Fatalf("camera = %+v", camera)
+			}
+			if camera.Granted != tt.granted || len(camera.Flags) != len(tt.flags) {
+				t.Fatalf("camera granted %v flags %q, want %v %q", camera.Granted, camera.Flags, tt.granted, tt.flags)
+			}
+			for i := range tt.flags {
+				if camera.Flags[i] != tt.flags[i] {
+					t.Errorf("flags = %q, want %q", camera.Flags, tt.flags)
+				}
+			}
+			internet := byName["android.permission.INTERNET"]
+			if !internet.Granted || internet.Runtime {
+				t.Errorf("internet = %+v", internet)
+			}
+		})
+	}
+}
+
+func TestPermissionCommandValidation(t *testing.T) {
+	// 参数无效时在执行命令之前返回错误
+	valid := ExecuteParams{PackageName: "com.example.app", UserId: "10"}
+	tests := []struct {
+		name string
+		run  func() types.ExecResult
+	}{
+		{"package injection", func() types.ExecResult {
+			return GrantAppPermission(ExecuteParams{PackageName: "com.example.app; reboot"}, "android.permission.CAMERA")
+		}},
+		{"user injection", func() types.ExecResult {
+			return RevokeAppPermission(ExecuteParams{PackageName: "com.example.app", UserId: "0;reboot"}, "android.permission.CAMERA")
+		}},
+		{"permission injection", func() types.ExecResult {
+			return GrantAppPermission(valid, "android.permission.CAMERA && reboot")
+		}},
+		{"empty permission", func() types.ExecResult { return ResetAppPermission(valid, "") }},
+		{"appop package injection", func() types.ExecResult {
+			return SetAppOp(ExecuteParams{PackageName: "$(reboot)"}, "CAMERA", "allow")
+		}},
+		{"appop injection", func() types.ExecResult { return SetAppOp(valid, "CAMERA;reboot", "allow") }},
+		{"lowercase appop", func() types.ExecResult { return SetAppOp(valid, "camera", "allow") }},
+		{"unknown mode", func() types.ExecResult { return SetAppOp(valid, "CAMERA", "always") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.run()
+			if !strings.Contains(res.Error, "无效") && !strings.Contains(res.Error, "不支持") {
+				t.Errorf("expected validation error, got %+v", res)
+			}
+		})
+	}
+}
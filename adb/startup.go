@@ -0,0 +1,244 @@
+package adb
+
+import (
+	"adb-tool-wails/types"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 启动类型
+const (
+	StartupCold = "cold" // 进程不存在，每次启动前 force-stop
+	StartupWarm = "warm" // 进程存在但 Activity 已销毁，启动前按返回键
+	StartupHot  = "hot"  // Activity 仍在后台，启动前回到桌面
+)
+
+const (
+	startupMaxRuns      = 50
+	startupDefaultRuns  = 5
+	startupSettleDelay  = 1500 * time.Millisecond // 热/温启动前让应用完成首次启动
+	startupDropCachesSh = "sync; echo 3 > /proc/sys/vm/drop_caches"
+)
+
+// StartupOptions 启动耗时测量的参数
+type StartupOptions struct {
+	Mode       string `json:"mode"` // 见 Startup* 常量
+	Runs       int    `json:"runs"`
+	DropCaches bool   `json:"dropCaches"` // 冷启动前清空页缓存，需要 root
+	IntervalMs int    `json:"intervalMs"` // 两次启动之间的等待，让设备回到空闲
+}
+
+// StartupRun 一次启动的结果，耗时为毫秒，-1 表示 am 没有输出该字段
+type StartupRun struct {
+	Index       int    `json:"index"` // 从 1 开始
+	Status      string `json:"status"`
+	LaunchState string `json:"launchState"` // 系统判断的实际启动类型，与请求的类型可能不同
+	TotalTime   int64  `json:"totalTime"`
+	WaitTime    int64  `json:"waitTime"`
+	Error       string `json:"error"`
+}
+
+// StartupStats 成功的启动中某项耗时的统计
+type StartupStats struct {
+	Count  int   `json:"count"`
+	Min    int64 `json:"min"`
+	Max    int64 `json:"max"`
+	Mean   int64 `json:"mean"`
+	Median int64 `json:"median"`
+	P90    int64 `json:"p90"`
+}
+
+// StartupReport 一次测量的全部结果
+type StartupReport struct {
+	Package    string       `json:"package"`
+	Activity   string       `json:"activity"`
+	Mode       string       `json:"mode"`
+	DropCaches bool         `json:"dropCaches"` // 实际是否清空了页缓存
+	Runs       []StartupRun `json:"runs"`
+	TotalTime  StartupStats `json:"totalTime"`
+	WaitTime   StartupStats `json:"waitTime"`
+	Warnings   []string     `json:"warnings"`
+}
+
+// MeasureStartup 重复启动应用的入口 Activity，用 am start -W 统计 TotalTime 与 WaitTime
+// 每次启动完成后调用 onRun；ctx 取消时返回已完成的部分与 context.Canceled
+func MeasureStartup(ctx context.Context, param ExecuteParams, packageName string, options StartupOptions, onRun func(StartupRun)) (StartupReport, error) {
+	report := StartupReport{Package: packageName, Mode: options.Mode, Runs: []StartupRun{}, Warnings: []string{}}
+	if !IsValidPackageName(packageName) {
+		return report, fmt.Errorf("无效的包名: %s", packageName)
+	}
+	if options.Mode != StartupCold && options.Mode != StartupWarm && options.Mode != StartupHot {
+		return report, fmt.Errorf("不支持的启动类型: %s", options.Mode)
+	}
+	if options.Runs <= 0 {
+		options.Runs = startupDefaultRuns
+	}
+	if options.Runs > startupMaxRuns {
+		options.Runs = startupMaxRuns
+	}
+	interval := time.Duration(max(options.IntervalMs, 0)) * time.Millisecond
+
+	param.PackageName = packageName
+	resolved := ResolveLauncherActivity(param)
+	if resolved.Error != "" {
+		return report, fmt.Errorf("%s", resolved.Error)
+	}
+	report.Activity = resolved.Res
+	spec := IntentSpec{Mode: IntentModeActivity, Component: resolved.Res, Wait: true, ForceStop: options.Mode == StartupCold}
+
+	var dropCaches PackageAccess
+	if options.Mode == StartupCold && options.DropCaches {
+		if access, ok := resolveRootAccess(param); ok {
+			dropCaches = access
+			report.DropCaches = true
+		} else {
+			report.Warnings = append(report.Warnings, "设备未 root，已跳过清空页缓存")
+		}
+	}
+
+	// 温/热启动需要进程已经存在，先完整启动一次
+	if options.Mode != StartupCold {
+		if _, err := SendIntent(param, spec); err != nil {
+			return report, err
+		}
+		if err := sleepContext(ctx, startupSettleDelay); err != nil {
+			return report, err
+		}
+	}
+
+	for i := 1; i <= options.Runs; i++ {
+		if err := ctx.Err(); err != nil {
+			report.finish()
+			return report, err
+		}
+		run := StartupRun{Index: i, TotalTime: -1, WaitTime: -1}
+		prepareErr := prepareStartupRun(ctx, param, options.Mode, dropCaches, report.DropCaches)
+		if err := ctx.Err(); err != nil {
+			report.finish()
+			return report, err
+		}
+		if prepareErr != nil {
+			run.Error = prepareErr.Error()
+		} else if result, err := SendIntent(param, spec); err != nil {
+			run.Error = err.Error()
+		} else {
+			run.Status = result.Status
+			run.LaunchState = result.LaunchState
+			run.TotalTime = result.TotalTime
+			run.WaitTime = result.WaitTime
+			if result.Status != "" && result.Status != "ok" {
+				run.Error = fmt.Sprintf("Status: %s", result.Status)
+			} else if result.Warning != "" && result.TotalTime < 0 {
+				run.Error = result.Warning
+			}
+		}
+		report.Runs = append(report.Runs, run)
+		if onRun != nil {
+			onRun(run)
+		}
+		if i < options.Runs {
+			// 温/热启动每次至少等待应用完成绘制，否则返回键或 Home 可能打断启动
+			wait := interval
+			if options.Mode != StartupCold {
+				wait = max(wait, startupSettleDelay)
+			}
+			if err := sleepContext(ctx, wait); err != nil {
+				report.finish()
+				return report, err
+			}
+		}
+	}
+	report.finish()
+	return report, nil
+}
+
+// prepareStartupRun 把应用置于对应启动类型的初始状态；冷启动时 am start -S 会再停止一次，确保进程不存在
+func prepareStartupRun(ctx context.Context, param ExecuteParams, mode string, access PackageAccess, dropCaches bool) error {
+	var res types.ExecResult
+	switch mode {
+	case StartupCold:
+		res = KillApp(param)
+		if res.Error == "" && dropCaches {
+			res = execCmd(buildRemoteScriptCmd(param, access.wrap(startupDropCachesSh)))
+		}
+	case StartupWarm:
+		// Android 12 起入口 Activity 按返回键只会移到后台，此时实际是热启动，以 LaunchState 为准
+		res = SendKeyEvent(param, "KEYCODE_BACK")
+	case StartupHot:
+		res = SendKeyEvent(param, "KEYCODE_HOME")
+	}
+	if res.Error != "" {
+		return fmt.Errorf("%s", res.Error)
+	}
+	// 等待窗口动画结束，避免计入上一次的过渡
+	return sleepContext(ctx, 500*time.Millisecond)
+}
+
+// resolveRootAccess adbd 以 root 运行或可以 su 时返回对应的执行方式
+func resolveRootAccess(param ExecuteParams) (PackageAccess, bool) {
+	if isRoot(param) {
+		return PackageAccess{Mode: AccessModeRoot}, true
+	}
+	su := execCmd(buildRemoteScriptCmd(param, "su -c 'id -u' 2>/dev/null"))
+	if su.Error == "" && strings.TrimSpace(su.Res) == "0" {
+		return PackageAccess{Mode: AccessModeSu}, true
+	}
+	return PackageAccess{}, false
+}
+
+func (r *StartupReport) finish() {
+	var total, wait []int64
+	for _, run := range r.Runs {
+		if run.Error != "" {
+			continue
+		}
+		if run.TotalTime >= 0 {
+			total = append(total, run.TotalTime)
+		}
+		if run.WaitTime >= 0 {
+			wait = append(wait, run.WaitTime)
+		}
+	}
+	r.TotalTime = newStartupStats(total)
+	r.WaitTime = newStartupStats(wait)
+}
+
+// newStartupStats 分位数使用最近秩法，样本少时 p90 即为最大值附近的实际样本
+func newStartupStats(values []int64) StartupStats {
+	stats := StartupStats{Count: len(values)}
+	if len(values) == 0 {
+		return stats
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	n := len(sorted)
+	stats.Min = sorted[0]
+	stats.Max = sorted[n-1]
+	stats.Mean = int64(math.Round(float64(sum) / float64(n)))
+	if n%2 == 1 {
+		stats.Median = sorted[n/2]
+	} else {
+		stats.Median = int64(math.Round(float64(sorted[n/2-1]+sorted[n/2]) / 2))
+	}
+	stats.P90 = sorted[int(math.Ceil(0.9*float64(n)))-1]
+	return stats
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package adb
+
+import "testing"
+
+func TestNewStartupStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int64
+		want   StartupStats
+	}{
+		{"empty", nil, StartupStats{}},
+		{"single", []int64{420}, StartupStats{Count: 1, Min: 420, Max: 420, Mean: 420, Median: 420, P90: 420}},
+		// 奇数个样本取中间值，p90 按最近秩取第 ceil(0.9*5)=5 个
+		{"odd", []int64{530, 410, 480, 900, 450}, StartupStats{Count: 5, Min: 410, Max: 900, Mean: 554, Median: 480, P90: 900}},
+		// 偶数个样本取中间两个的平均并四舍五入
+		{"even", []int64{400, 401, 500, 450}, StartupStats{Count: 4, Min: 400, Max: 500, Mean: 438, Median: 426, P90: 500}},
+		// ceil(0.9*10)=9，第 9 个样本
+		{"ten", []int64{10, 9, 8, 7, 6, 5, 4, 3, 2, 100}, StartupStats{Count: 10, Min: 2, Max: 100, Mean: 15, Median: 7, P90: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newStartupStats(tt.values); got != tt.want {
+				t.Errorf("newStartupStats(%v) = %+v, want %+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartupReportFinish(t *testing.T) {
+	report := StartupReport{Runs: []StartupRun{
+		{Index: 1, Status: "ok", TotalTime: 500, WaitTime: 520},
+		// 失败的启动不计入统计，即使有耗时
+		{Index: 2, Status: "timeout", TotalTime: 5000, WaitTime: 5010, Error: "Status: timeout"},
+		{Index: 3, Status: "ok", TotalTime: 300, WaitTime: 330},
+		{Index: 4, Error: "error: device offline", TotalTime: -1, WaitTime: -1},
+		// 旧系统没有 WaitTime，只统计 TotalTime
+		{Index: 5, Status: "ok", TotalTime: 400, WaitTime: -1},
+	}}
+	report.finish()
+	if want := (StartupStats{Count: 3, Min: 300, Max: 500, Mean: 400, Median: 400, P90: 500}); report.TotalTime != want {
+		t.Errorf("total = %+v, want %+v", report.TotalTime, want)
+	}
+	if want := (StartupStats{Count: 2, Min: 330, Max: 520, Mean: 425, Median: 425, P90: 520}); report.WaitTime != want {
+		t.Errorf("wait = %+v, want %+v", report.WaitTime, want)
+	}
+}
@@ -0,0 +1,425 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 同步方向
+const (
+	SyncPush = "push" // 以本地为准
+	SyncPull = "pull" // 以设备为准
+	SyncBoth = "both" // 双向，较新的一方覆盖另一方
+)
+
+// 比较方式
+const (
+	SyncCompareMtime = "mtime" // 大小与修改时间
+	SyncCompareHash  = "hash"  // MD5，较慢但不受时间戳影响
+)
+
+// 单个文件的同步动作
+const (
+	SyncActionSame         = "same"
+	SyncActionPush         = "push"
+	SyncActionPull         = "pull"
+	SyncActionDeleteRemote = "delete-remote"
+	SyncActionDeleteLocal  = "delete-local"
+	SyncActionKeep         = "keep"     // 只存在于目标端且未开启删除
+	SyncActionConflict     = "conflict" // 双向同步时两端内容不同但时间相同
+)
+
+// syncMtimeTolerance 修改时间允许的误差（秒），FAT 类文件系统的时间精度为 2 秒
+const syncMtimeTolerance = 2
+
+// SyncOptions 同步选项
+type SyncOptions struct {
+	Direction    string `json:"direction"`
+	Compare      string `json:"compare"`
+	DeleteExtras bool   `json:"deleteExtras"`
+}
+
+// SyncItem 一个文件的比较结果，Path 为相对同步根目录的路径
+type SyncItem struct {
+	Path          string `json:"path"`
+	Action        string `json:"action"`
+	LocalSize     int64  `json:"localSize"`
+	RemoteSize    int64  `json:"remoteSize"`
+	LocalModTime  int64  `json:"localModTime"`
+	RemoteModTime int64  `json:"remoteModTime"`
+	LocalExists   bool   `json:"localExists"`
+	RemoteExists  bool   `json:"remoteExists"`
+}
+
+// SyncDiff 两个目录的比较结果
+type SyncDiff struct {
+	LocalDir     string      `json:"localDir"`
+	RemoteDir    string      `json:"remoteDir"`
+	Options      SyncOptions `json:"options"`
+	Items        []SyncItem  `json:"items"`
+	Push         int         `json:"push"`
+	Pull         int         `json:"pull"`
+	DeleteRemote int         `json:"deleteRemote"`
+	DeleteLocal  int         `json:"deleteLocal"`
+	Same         int         `json:"same"`
+	Keep         int         `json:"keep"`
+	Conflict     int         `json:"conflict"`
+	PushBytes    int64       `json:"pushBytes"`
+	PullBytes    int64       `json:"pullBytes"`
+}
+
+// syncFileState 一端的文件状态
+type syncFileState struct {
+	size  int64
+	mtime int64
+	hash  string
+}
+
+// CompareDirectories 比较本地目录与设备目录，按同步方向计算每个文件的动作
+func CompareDirectories(param ExecuteParams, localDir string, remoteDir string, options SyncOptions) (SyncDiff, error) {
+	remoteDir = path.Clean(remoteDir)
+	diff := SyncDiff{LocalDir: localDir, RemoteDir: remoteDir, Options: options, Items: []SyncItem{}}
+
+	switch options.Direction {
+	case SyncPush, SyncPull, SyncBoth:
+	default:
+		return diff, fmt.Errorf("无效的同步方向: %s", options.Direction)
+	}
+	if options.Direction != SyncPull || options.DeleteExtras {
+		if err := CheckSyncTarget(param, remoteDir); err != nil {
+			return diff, err
+		}
+	}
+	useHash := options.Compare == SyncCompareHash
+
+	local, err := scanLocalFiles(localDir, useHash)
+	if err != nil {
+		return diff, err
+	}
+	remote, err := scanRemoteFiles(param, remoteDir, useHash)
+	if err != nil {
+		return diff, err
+	}
+
+	names := make(map[string]bool, len(local)+len(remote))
+	for name := range local {
+		names[name] = true
+	}
+	for name := range remote {
+		names[name] = true
+	}
+
+	for name := range names {
+		l, inLocal := local[name]
+		r, inRemote := remote[name]
+		item := SyncItem{Path: name, LocalExists: inLocal, RemoteExists: inRemote}
+		if inLocal {
+			item.LocalSize, item.LocalModTime = l.size, l.mtime
+		}
+		if inRemote {
+			item.RemoteSize, item.RemoteModTime = r.size, r.mtime
+		}
+		item.Action = syncAction(options, l, inLocal, r, inRemote, useHash)
+
+		switch item.Action {
+		case SyncActionPush:
+			diff.Push++
+			diff.PushBytes += item.LocalSize
+		case SyncActionPull:
+			diff.Pull++
+			diff.PullBytes += item.RemoteSize
+		case SyncActionDeleteRemote:
+			diff.DeleteRemote++
+		case SyncActionDeleteLocal:
+			diff.DeleteLocal++
+		case SyncActionSame:
+			diff.Same++
+		case SyncActionKeep:
+			diff.Keep++
+		case SyncActionConflict:
+			diff.Conflict++
+		}
+		diff.Items = append(diff.Items, item)
+	}
+
+	sort.Slice(diff.Items, func(i, j int) bool { return diff.Items[i].Path < diff.Items[j].Path })
+	return diff, nil
+}
+
+// CheckSyncTarget 检查设备目录是否允许同步写入或删除
+func CheckSyncTarget(param ExecuteParams, remoteDir string) error {
+	return checkProtectedPath(param, path.Clean(remoteDir), false)
+}
+
+// CheckSyncDiff 检查来自前端的比较结果：设备目录允许写入，且每个条目都位于两端的同步根目录之下
+func CheckSyncDiff(param ExecuteParams, diff SyncDiff) error {
+	if diff.LocalDir == "" || !filepath.IsAbs(diff.LocalDir) {
+		return fmt.Errorf("无效的本地目录: %s", diff.LocalDir)
+	}
+	if err := CheckSyncTarget(param, diff.RemoteDir); err != nil {
+		return err
+	}
+	for _, item := range diff.Items {
+		if err := checkSyncItemPath(diff, item.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSyncItemPath 条目路径必须是相对路径，拼接后不能通过 .. 离开同步根目录
+func checkSyncItemPath(diff SyncDiff, p string) error {
+	if p == "" || path.IsAbs(p) || filepath.IsAbs(filepath.FromSlash(p)) {
+		return fmt.Errorf("无效的同步路径: %s", p)
+	}
+	remoteDir := path.Clean(diff.RemoteDir)
+	if remote := joinRemotePath(remoteDir, p); remote == remoteDir || !isUnder(remote, remoteDir) {
+		return fmt.Errorf("同步路径超出设备目录: %s", p)
+	}
+	rel, err := filepath.Rel(diff.LocalDir, filepath.Join(diff.LocalDir, filepath.FromSlash(p)))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("同步路径超出本地目录: %s", p)
+	}
+	return nil
+}
+
+// syncAction 根据两端状态决定动作
+func syncAction(options SyncOptions, l syncFileState, inLocal bool, r syncFileState, inRemote bool, useHash bool) string {
+	if inLocal && inRemote {
+		same := l.size == r.size && abs64(l.mtime-r.mtime) <= syncMtimeTolerance
+		if useHash {
+			same = l.hash == r.hash
+		}
+		if same {
+			return SyncActionSame
+		}
+		switch options.Direction {
+		case SyncPush:
+			return SyncActionPush
+		case SyncPull:
+			return SyncActionPull
+		}
+		switch {
+		case l.mtime-r.mtime > syncMtimeTolerance:
+			return SyncActionPush
+		case r.mtime-l.mtime > syncMtimeTolerance:
+			return SyncActionPull
+		default:
+			return SyncActionConflict
+		}
+	}
+
+	if inLocal {
+		if options.Direction == SyncPull {
+			if options.DeleteExtras {
+				return SyncActionDeleteLocal
+			}
+			return SyncActionKeep
+		}
+		return SyncActionPush
+	}
+
+	if options.Direction == SyncPush {
+		if options.DeleteExtras {
+			return SyncActionDeleteRemote
+		}
+		return SyncActionKeep
+	}
+	return SyncActionPull
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// scanLocalFiles 递归列出本地目录下的普通文件，key 为使用 / 分隔的相对路径
+func scanLocalFiles(localDir string, withHash bool) (map[string]syncFileState, error) {
+	files := make(map[string]syncFileState)
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("不是目录: %s", localDir)
+	}
+
+	err = filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return err
+		}
+		fileInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		state := syncFileState{size: fileInfo.Size(), mtime: fileInfo.ModTime().Unix()}
+		if withHash {
+			if state.hash, err = md5File(p); err != nil {
+				return err
+			}
+		}
+		files[filepath.ToSlash(rel)] = state
+		return nil
+	})
+	return files, err
+}
+
+func md5File(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// scanRemoteFiles 递归列出设备目录下的文件大小与修改时间，目录不存在时返回空
+func scanRemoteFiles(param ExecuteParams, remoteDir string, withHash bool) (map[string]syncFileState, error) {
+	files := make(map[string]syncFileState)
+	// 从 remoteDir/ 开始 find，目录本身是软链接（如 /sdcard）时才会进入链接指向的目录；输出的路径统一清理后再去掉前缀
+	prefix := strings.TrimSuffix(path.Clean(remoteDir), "/") + "/"
+
+	exists := execCmd(buildRemoteScriptCmd(param, fmt.Sprintf("[ -d %s ] && echo yes", deviceQuote(remoteDir))))
+	if exists.Error != "" {
+		return nil, fmt.Errorf("%s", exists.Error)
+	}
+	if exists.Res != "yes" {
+		return files, nil
+	}
+
+	cmd := buildRemoteScriptCmd(param, fmt.Sprintf("find %s -type f -exec stat -c '%%s %%Y %%n' {} +", deviceQuote(dirArg(remoteDir))))
+	res, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	var errLines []string
+	for _, line := range util.MultiLine(res) {
+		line = strings.TrimRight(line, "\r")
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) == 3 {
+			fields[2] = path.Clean(fields[2])
+		}
+		if len(fields) != 3 || !strings.HasPrefix(fields[2], prefix) {
+			if strings.TrimSpace(line) != "" {
+				errLines = append(errLines, strings.TrimSpace(line))
+			}
+			continue
+		}
+		size, err1 := strconv.ParseInt(fields[0], 10, 64)
+		mtime, err2 := strconv.ParseInt(fields[1], 10, 64)
+		if err1 != nil || err2 != nil {
+			errLines = append(errLines, strings.TrimSpace(line))
+			continue
+		}
+		files[strings.TrimPrefix(fields[2], prefix)] = syncFileState{size: size, mtime: mtime}
+	}
+	if len(files) == 0 && len(errLines) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errLines, "\n"))
+	}
+
+	if withHash && len(files) > 0 {
+		cmd := buildRemoteScriptCmd(param, fmt.Sprintf("find %s -type f -exec md5sum {} +", deviceQuote(dirArg(remoteDir))))
+		res, err := util.Exec(cmd, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range util.MultiLine(res) {
+			hash, name, found := strings.Cut(strings.TrimRight(line, "\r"), "  ")
+			name = path.Clean(name)
+			if !found || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			rel := strings.TrimPrefix(name, prefix)
+			if state, ok := files[rel]; ok {
+				state.hash = hash
+				files[rel] = state
+			}
+		}
+	}
+	return files, nil
+}
+
+// SyncTransferPlans 将比较结果中需要传输的文件转换为上传与下载计划
+func SyncTransferPlans(diff SyncDiff) (TransferPlan, TransferPlan) {
+	upload := TransferPlan{Direction: TransferUpload}
+	download := TransferPlan{Direction: TransferDownload}
+	for _, item := range diff.Items {
+		f := TransferFile{
+			LocalPath:  filepath.Join(diff.LocalDir, filepath.FromSlash(item.Path)),
+			RemotePath: joinRemotePath(diff.RemoteDir, item.Path),
+		}
+		switch item.Action {
+		case SyncActionPush:
+			f.Size = item.LocalSize
+			upload.Files = append(upload.Files, f)
+		case SyncActionPull:
+			f.Size = item.RemoteSize
+			f.ModTime = item.RemoteModTime
+			download.Files = append(download.Files, f)
+		}
+	}
+	return upload, download
+}
+
+// DeleteSyncExtras 删除比较结果中标记为删除的文件，返回已删除的路径与错误
+func DeleteSyncExtras(ctx context.Context, param ExecuteParams, diff SyncDiff) ([]string, []string) {
+	var deleted, errs []string
+	var remoteTargets []string
+	for _, item := range diff.Items {
+		if ctx.Err() != nil {
+			break
+		}
+		switch item.Action {
+		case SyncActionDeleteLocal:
+			p := filepath.Join(diff.LocalDir, filepath.FromSlash(item.Path))
+			if err := os.Remove(p); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", p, err))
+				continue
+			}
+			deleted = append(deleted, p)
+		case SyncActionDeleteRemote:
+			remoteTargets = append(remoteTargets, joinRemotePath(diff.RemoteDir, item.Path))
+		}
+	}
+	if len(remoteTargets) == 0 || ctx.Err() != nil {
+		return deleted, errs
+	}
+
+	// 分批删除，避免命令行过长
+	const batch = 100
+	for i := 0; i < len(remoteTargets); i += batch {
+		group := remoteTargets[i:min(i+batch, len(remoteTargets))]
+		quoted := make([]string, len(group))
+		for j, p := range group {
+			quoted[j] = deviceQuote(p)
+		}
+		res := execSilent(buildRemoteScriptCmd(param, "rm -f "+strings.Join(quoted, " ")), "")
+		if res.Error != "" {
+			errs = append(errs, res.Error)
+			continue
+		}
+		deleted = append(deleted, group...)
+	}
+	return deleted, errs
+}
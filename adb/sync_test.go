@@ -0,0 +1,26 @@
+package adb
+
+import "testing"
+
+func TestCheckSyncItemPath(t *testing.T) {
+	diff := SyncDiff{LocalDir: "/home/user/photos", RemoteDir: "/sdcard/DCIM"}
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"a.jpg", true},
+		{"2024/05/a b.jpg", true},
+		{"..a.jpg", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../Download/a.jpg", false},
+		{"2024/../../a.jpg", false},
+		{"/sdcard/DCIM/a.jpg", false},
+	}
+	for _, tt := range tests {
+		if err := checkSyncItemPath(diff, tt.path); (err == nil) != tt.ok {
+			t.Errorf("checkSyncItemPath(%q) err = %v, want ok %v", tt.path, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package adb
+
+import (
+	"adb-tool-wails/util"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 传输方向
+const (
+	TransferUpload   = "upload"
+	TransferDownload = "download"
+)
+
+// 目标已存在时的处理方式
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+// 传输状态
+const (
+	TransferRunning   = "running"
+	TransferCompleted = "completed"
+	TransferCancelled = "cancelled"
+	TransferFailed    = "failed"
+)
+
+// TransferFile 一次传输中的单个文件
+type TransferFile struct {
+	LocalPath  string `json:"localPath"`
+	RemotePath string `json:"remotePath"`
+	Size       int64  `json:"size"`
+	ModTime    int64  `json:"modTime,omitempty"` // 下载后设置到本地文件的修改时间（Unix 秒），0 表示不设置
+}
+
+// TransferProgress 传输进度，按文件和整体分别统计
+type TransferProgress struct {
+	TaskId      string  `json:"taskId"`
+	Direction   string  `json:"direction"`
+	Status      string  `json:"status"`
+	File        string  `json:"file"`
+	FileIndex   int     `json:"fileIndex"`
+	FileCount   int     `json:"fileCount"`
+	FileBytes   int64   `json:"fileBytes"`
+	FileTotal   int64   `json:"fileTotal"`
+	Bytes       int64   `json:"bytes"`
+	Total       int64   `json:"total"`
+	BytesPerSec float64 `json:"bytesPerSec"`
+	EtaSeconds  int64   `json:"etaSeconds"`
+	Skipped     int     `json:"skipped"`
+	Failed      int     `json:"failed"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// TransferResult 传输完成后的汇总
+type TransferResult struct {
+	TaskId      string   `json:"taskId"`
+	Status      string   `json:"status"`
+	Transferred int      `json:"transferred"`
+	Skipped     int      `json:"skipped"`
+	Failed      int      `json:"failed"`
+	Bytes       int64    `json:"bytes"`
+	Errors      []string `json:"errors"`
+}
+
+// TransferPlan 规划好的传输任务，Skipped 为按冲突策略跳过的文件数
+type TransferPlan struct {
+	Direction string
+	Files     []TransferFile
+	Skipped   int
+}
+
+// PlanUpload 展开本地文件与目录，按冲突策略计算每个文件在设备上的目标路径
+func PlanUpload(param ExecuteParams, localPaths []string, remoteDir string, policy string) (TransferPlan, error) {
+	plan := TransferPlan{Direction: TransferUpload}
+
+	var files []TransferFile
+	for _, localPath := range localPaths {
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return plan, err
+		}
+		base := filepath.Base(localPath)
+		if !info.IsDir() {
+			files = append(files, TransferFile{LocalPath: localPath, RemotePath: joinRemotePath(remoteDir, base), Size: info.Size()})
+			continue
+		}
+		err = filepath.WalkDir(localPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !d.Type().IsRegular() {
+				return err
+			}
+			fileInfo, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(localPath, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, TransferFile{
+				LocalPath:  p,
+				RemotePath: joinRemotePath(remoteDir, path.Join(base, filepath.ToSlash(rel))),
+				Size:       fileInfo.Size(),
+			})
+			return nil
+		})
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	existing := listRemoteFiles(param, remoteDir)
+	exists := func(p string) bool { return existing[p] }
+	plan.Files, plan.Skipped = applyConflictPolicy(files, policy, func(f *TransferFile) *string { return &f.RemotePath }, exists)
+	return plan, nil
+}
+
+// PlanDownload 展开设备上的文件与目录，按冲突策略计算每个文件的本地路径
+func PlanDownload(param ExecuteParams, remotePaths []string, localDir string, policy string) (TransferPlan, error) {
+	plan := TransferPlan{Direction: TransferDownload}
+
+	var files []TransferFile
+	for _, remotePath := range remotePaths {
+		remotePath = path.Clean(remotePath)
+		sizes, err := statRemoteFiles(param, remotePath)
+		if err != nil {
+			return plan, err
+		}
+		parent := path.Dir(remotePath)
+		for _, f := range sizes {
+			rel := strings.TrimPrefix(strings.TrimPrefix(f.RemotePath, parent), "/")
+			f.LocalPath = filepath.Join(localDir, filepath.FromSlash(rel))
+			files = append(files, f)
+		}
+	}
+
+	exists := func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	}
+	plan.Files, plan.Skipped = applyConflictPolicy(files, policy, func(f *TransferFile) *string { return &f.LocalPath }, exists)
+	return plan, nil
+}
+
+// applyConflictPolicy 处理目标已存在的文件：跳过、覆盖或改名为 name (1).ext
+func applyConflictPolicy(files []TransferFile, policy string, target func(*TransferFile) *string, exists func(string) bool) ([]TransferFile, int) {
+	result := make([]TransferFile, 0, len(files))
+	skipped := 0
+	taken := make(map[string]bool)
+	for i := range files {
+		f := files[i]
+		dest := target(&f)
+		if exists(*dest) || taken[*dest] {
+			switch policy {
+			case ConflictSkip:
+				skipped++
+				continue
+			case ConflictRename:
+				*dest = uniqueName(*dest, func(p string) bool { return exists(p) || taken[p] })
+			}
+		}
+		taken[*dest] = true
+		result = append(result, f)
+	}
+	return result, skipped
+}
+
+// uniqueName 生成不冲突的文件名，本地与设备路径都按 / 或 \ 分隔处理
+func uniqueName(p string, exists func(string) bool) string {
+	dir, name := "", p
+	if i := strings.LastIndexAny(p, `/\`); i >= 0 {
+		dir, name = p[:i+1], p[i+1:]
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s%s (%d)%s", dir, stem, n, ext)
+		if !exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// listRemoteFiles 列出设备目录下已有的文件，用于上传前的冲突检查
+func listRemoteFiles(param ExecuteParams, remoteDir string) map[string]bool {
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("find %s -type f 2>/dev/null", quoteRemotePath(dirArg(remoteDir))))
+	res, _ := util.Exec(cmd, true, nil)
+	existing := make(map[string]bool)
+	for _, line := range util.MultiLine(res) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "/") {
+			existing[path.Clean(line)] = true
+		}
+	}
+	return existing
+}
+
+// statRemoteFiles 递归获取设备路径下所有普通文件及大小，remotePath 为文件时返回其自身
+func statRemoteFiles(param ExecuteParams, remotePath string) ([]TransferFile, error) {
+	return statRemoteFilesAs(param, PackageAccess{}, remotePath)
+}
+
+// statRemoteFilesAs 以应用身份执行 statRemoteFiles，目录（包括指向目录的软链接）从 remotePath/ 开始查找
+func statRemoteFilesAs(param ExecuteParams, access PackageAccess, remotePath string) ([]TransferFile, error) {
+	script := fmt.Sprintf("if [ -d %[1]s ]; then find %[2]s -type f -exec stat -c %[3]s {} +; else find %[1]s -type f -exec stat -c %[3]s {} +; fi",
+		deviceQuote(remotePath), deviceQuote(dirArg(remotePath)), deviceQuote("%s %n"))
+	res, err := util.Exec(buildRemoteScriptCmd(param, access.wrap(script)), true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []TransferFile
+	var errLines []string
+	for _, line := range util.MultiLine(res) {
+		line = strings.TrimRight(line, "\r")
+		sizeStr, name, found := strings.Cut(line, " ")
+		size, convErr := strconv.ParseInt(sizeStr, 10, 64)
+		if !found || convErr != nil || !strings.HasPrefix(name, "/") {
+			if strings.TrimSpace(line) != "" {
+				errLines = append(errLines, strings.TrimSpace(line))
+			}
+			continue
+		}
+		files = append(files, TransferFile{RemotePath: path.Clean(name), Size: size})
+	}
+	if len(files) == 0 && len(errLines) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errLines, "\n"))
+	}
+	return files, nil
+}
+
+// RunTransfer 逐个文件执行 push/pull，定期通过 onProgress 汇报进度；ctx 取消时结束当前 adb 进程并清理不完整的文件
+func RunTransfer(ctx context.Context, param ExecuteParams, taskId string, plan TransferPlan, onProgress func(TransferProgress)) TransferResult {
+	result := TransferResult{TaskId: taskId, Status: TransferRunning, Skipped: plan.Skipped, Errors: []string{}}
+
+	var total int64
+	for _, f := range plan.Files {
+		total += f.Size
+	}
+
+	progress := TransferProgress{
+		TaskId:    taskId,
+		Direction: plan.Direction,
+		Status:    TransferRunning,
+		FileCount: len(plan.Files),
+		Total:     total,
+		Skipped:   plan.Skipped,
+	}
+	start := time.Now()
+	report := func(fileBytes int64) {
+		progress.FileBytes = fileBytes
+		current := result.Bytes + fileBytes
+		progress.Bytes = current
+		elapsed := time.Since(start).Seconds()
+		if elapsed > 0 {
+			progress.BytesPerSec = float64(current) / elapsed
+		}
+		if progress.BytesPerSec > 0 {
+			progress.EtaSeconds = int64(float64(total-current) / progress.BytesPerSec)
+		}
+		progress.Failed = result.Failed
+		onProgress(progress)
+	}
+	report(0)
+
+	for i, f := range plan.Files {
+		if ctx.Err() != nil {
+			break
+		}
+		progress.FileIndex = i
+		progress.File = f.RemotePath
+		progress.FileTotal = f.Size
+		if plan.Direction == TransferDownload {
+			progress.File = f.LocalPath
+		}
+
+		err := transferOne(ctx, param, plan.Direction, f, report)
+		if err != nil {
+			if ctx.Err() != nil {
+				removePartial(param, plan.Direction, f)
+				break
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", f.LocalPath, err))
+			continue
+		}
+		result.Transferred++
+		result.Bytes += f.Size
+		report(0)
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		result.Status = TransferCancelled
+	case result.Failed > 0 && result.Transferred == 0:
+		result.Status = TransferFailed
+	default:
+		result.Status = TransferCompleted
+	}
+
+	progress.Status = result.Status
+	if len(result.Errors) > 0 {
+		progress.Error = strings.Join(result.Errors, "\n")
+	}
+	report(0)
+	return result
+}
+
+// transferOne 传输单个文件，传输期间每 500ms 查询一次已传输的大小
+func transferOne(ctx context.Context, param ExecuteParams, direction string, f TransferFile, report func(int64)) error {
+	var cmd string
+	if direction == TransferUpload {
+		cmd = BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("push %s %s", quoteHostArg(f.LocalPath), quoteHostArg(f.RemotePath)))
+	} else {
+		if err := os.MkdirAll(filepath.Dir(f.LocalPath), 0755); err != nil {
+			return err
+		}
+		cmd = BuildAdbCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("pull %s %s", quoteHostArg(f.RemotePath), quoteHostArg(f.LocalPath)))
+	}
+
+	// 轮询协程在命令结束后退出，report 不会与调用方并发执行
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if size := transferredSize(param, direction, f); size > 0 && size <= f.Size {
+					report(size)
+				}
+			}
+		}
+	}()
+
+	res, err := util.ExecContext(ctx, cmd)
+	close(done)
+	<-stopped
+	if err != nil {
+		return err
+	}
+	// push/pull 出错时退出码不一定非 0，需要检查输出
+	if strings.Contains(res, "adb: error") || strings.Contains(res, "failed to copy") {
+		return fmt.Errorf("%s", strings.TrimSpace(res))
+	}
+	// pull 不保留设备上的修改时间，同步时需要两端一致才能在下次比较中判定为未变化
+	if direction == TransferDownload && f.ModTime > 0 {
+		mtime := time.Unix(f.ModTime, 0)
+		return os.Chtimes(f.LocalPath, mtime, mtime)
+	}
+	return nil
+}
+
+// transferredSize 当前文件已传输的字节数：下载看本地文件大小，上传看设备文件大小
+func transferredSize(param ExecuteParams, direction string, f TransferFile) int64 {
+	if direction == TransferDownload {
+		info, err := os.Stat(f.LocalPath)
+		if err != nil {
+			return 0
+		}
+		return info.Size()
+	}
+
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("stat -c %%s %s", quoteRemotePath(f.RemotePath)))
+	res, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return 0
+	}
+	size, _ := strconv.ParseInt(strings.TrimSpace(res), 10, 64)
+	return size
+}
+
+// removePartial 删除取消时未传输完成的文件
+func removePartial(param ExecuteParams, direction string, f TransferFile) {
+	if direction == TransferDownload {
+		_ = os.Remove(f.LocalPath)
+		return
+	}
+	cmd := BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm -f %s", quoteRemotePath(f.RemotePath)))
+	_, _ = util.Exec(cmd, true, nil)
+}
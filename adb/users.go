@@ -0,0 +1,129 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 用户类型，由 UserInfo 的 flags 推断
+const (
+	UserTypeFull    = "full"    // 主用户或次要用户
+	UserTypeWork    = "work"    // 工作资料
+	UserTypeGuest   = "guest"   // 访客
+	UserTypeProfile = "profile" // 其他资料，如应用分身、私密空间
+)
+
+// UserInfo.flags 中用到的位
+const (
+	userFlagGuest          = 0x4
+	userFlagManagedProfile = 0x20
+	userFlagProfile        = 0x1000
+)
+
+// reUserInfo 匹配 pm list users 的一行：UserInfo{10:Work profile:1030} running
+var reUserInfo = regexp.MustCompile(`UserInfo\{(\d+):(.*):([0-9a-fA-F]+)\}(.*)`)
+
+// DeviceUser 设备上的用户或资料
+type DeviceUser struct {
+	Id      int    `json:"id"`
+	Name    string `json:"name"`
+	Flags   int    `json:"flags"`
+	Type    string `json:"type"` // 见 UserType* 常量
+	Running bool   `json:"running"`
+	Current bool   `json:"current"` // 前台用户
+}
+
+// userArg 目标用户对应的 --user 参数，未指定用户时为空，命令作用于当前用户
+func (p ExecuteParams) userArg() string {
+	if p.UserId == "" {
+		return ""
+	}
+	return " --user " + p.UserId
+}
+
+// userArgOrCurrent 与 userArg 相同，但未指定时显式使用 current；pm uninstall 等命令缺省作用于所有用户
+func (p ExecuteParams) userArgOrCurrent() string {
+	if p.UserId == "" {
+		return " --user current"
+	}
+	return p.userArg()
+}
+
+// IsValidUserId 用户 id 为非负整数，拼进命令前检查
+func IsValidUserId(userId string) bool {
+	return userId == "" || isNumeric(userId)
+}
+
+// ListDeviceUsers 解析 pm list users，并用 am get-current-user 标记前台用户
+func ListDeviceUsers(param ExecuteParams) ([]DeviceUser, error) {
+	res := execCmd(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "pm list users"))
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s", res.Error)
+	}
+	users := parseDeviceUsers(res.Res)
+	if len(users) == 0 {
+		return nil, fmt.Errorf("读取用户列表失败: %s", res.Res)
+	}
+
+	current, _ := strconv.Atoi(CurrentUserId(param))
+	for i := range users {
+		users[i].Current = users[i].Id == current
+	}
+	return users, nil
+}
+
+// CurrentUserId 返回前台用户的 id，Android 8.0 以下没有 get-current-user，此时视为主用户 0
+func CurrentUserId(param ExecuteParams) string {
+	if cur := execCmd(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "am get-current-user")); cur.Error == "" && isNumeric(cur.Res) {
+		return strings.TrimSpace(cur.Res)
+	}
+	return "0"
+}
+
+func parseDeviceUsers(content string) []DeviceUser {
+	users := []DeviceUser{}
+	for _, line := range strings.Split(content, "\n") {
+		m := reUserInfo.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(m[1])
+		flags, _ := strconv.ParseInt(m[3], 16, 64)
+		user := DeviceUser{
+			Id:      id,
+			Name:    m[2],
+			Flags:   int(flags),
+			Type:    UserTypeFull,
+			Running: strings.Contains(m[4], "running"),
+		}
+		switch {
+		case flags&userFlagManagedProfile != 0:
+			user.Type = UserTypeWork
+		case flags&userFlagGuest != 0:
+			user.Type = UserTypeGuest
+		case flags&userFlagProfile != 0:
+			user.Type = UserTypeProfile
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+	return users
+}
+
+// ListDisabledPackages 返回目标用户下已停用的应用
+func ListDisabledPackages(param ExecuteParams) (map[string]bool, error) {
+	res := execCmd(BuildAdbShellCmd(param.AdbPath, param.DeviceId, "pm list packages -d"+param.userArg()))
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s", res.Error)
+	}
+	disabled := make(map[string]bool)
+	for _, line := range strings.Split(res.Res, "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "package:"); ok {
+			disabled[name] = true
+		}
+	}
+	return disabled, nil
+}
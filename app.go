@@ -38,12 +38,49 @@ type App struct {
 	appListCancel context.CancelFunc
 	appListMutex  sync.Mutex
 	appListDone   chan struct{} // 新增：用于等待任务完成
+
+	// 用于取消文件传输任务，key 为任务 id
+	transferCancels map[string]context.CancelFunc
+	transferMutex   sync.Mutex
+
+	// 用于取消设备文件搜索，key 为任务 id
+	searchCancels map[string]context.CancelFunc
+	searchMutex   sync.Mutex
+
+	// 用于取消批量安装队列，key 为队列 id
+	installQueueCancels map[string]context.CancelFunc
+	installQueueMutex   sync.Mutex
+
+	// 用于取消启动耗时测量，key 为任务 id
+	startupCancels map[string]context.CancelFunc
+	startupMutex   sync.Mutex
+
+	// 收藏的读改写需要串行
+	bookmarkMutex sync.Mutex
+
+	// 应用状态操作记录的读改写需要串行
+	packageHistoryMutex sync.Mutex
+
+	// Intent 预设的读改写需要串行
+	intentPresetMutex sync.Mutex
+
+	// 应用快照列表的读改写需要串行
+	packageSnapshotMutex sync.Mutex
+
+	// 删除预览生成的确认令牌，key 为 token
+	deleteTokens map[string]deleteRequest
+	deleteMutex  sync.Mutex
+
+	// 本地打开的应用数据库副本，key 为会话 id
+	appDatabases     map[string]*appDatabaseSession
+	appDatabaseMutex sync.Mutex
 }
 
 type Action struct {
 	Action            string `json:"action"`
 	TargetPackageName string `json:"targetPackageName"`
 	DeviceId          string `json:"deviceId"`
+	UserId            string `json:"userId"` // 目标用户，为空时作用于当前用户
 }
 
 // NewApp creates a new App application struct
@@ -118,6 +155,8 @@ func (a *App) shutdown(ctx context.Context) {
 		a.ayaClient = nil
 	}
 
+	a.closeAllAppDatabases()
+
 	if a.store != nil {
 		if err := a.store.Close(); err != nil {
 			applog.Warnf(applog.CategoryStartup, "storage_close_failed err=%q", err.Error())
@@ -189,7 +228,7 @@ var keyActionMap = map[string]string{
 func (a *App) ExecuteAction(ac Action) (result types.ExecResult) {
 	action := ac.Action
 	start := time.Now()
-	applog.Infof(applog.CategoryAction, "action_started action=%s device=%s package=%s", action, ac.DeviceId, ac.TargetPackageName)
+	applog.Infof(applog.CategoryAction, "action_started action=%s device=%s user=%s package=%s", action, ac.DeviceId, ac.UserId, ac.TargetPackageName)
 	defer func() {
 		duration := time.Since(start).Milliseconds()
 		if result.Error != "" {
@@ -205,12 +244,17 @@ func (a *App) ExecuteAction(ac Action) (result types.ExecResult) {
 		return
 	}
 
+	if !adb.IsValidUserId(ac.UserId) {
+		result = types.NewExecResultErrorString(action, fmt.Sprintf("无效的用户: %s", ac.UserId))
+		return
+	}
 	param := adb.ExecuteParams{
 		Action:      action,
 		PackageName: ac.TargetPackageName,
 		Ctxt:        a.ctx,
 		DeviceId:    ac.DeviceId,
 		AdbPath:     a.adbPath,
+		UserId:      ac.UserId,
 	}
 
 	// 按键事件统一处理
@@ -236,6 +280,8 @@ func (a *App) ExecuteAction(ac Action) (result types.ExecResult) {
 		result = adb.KillApp(param)
 	case "clear-data":
 		result = adb.ClearApp(param)
+	case "clear-cache":
+		result = adb.ClearAppCache(param)
 	case "restart-app":
 		result = adb.RestartApp(param)
 	case "reboot-device":
@@ -386,30 +432,11 @@ func (a *App) GetPackageInfoFromAya(param adb.ExecuteParams, packageNames []stri
 	}
 	defer client.Close()
 
-	result, err := client.SendMessage("getPackageInfos", map[string]interface{}{
-		"packageNames": packageNames,
-	})
+	packageInfos, err := client.GetPackageInfos(packageNames)
 	if err != nil {
 		return types.NewExecResultErrorString("aya_send_message", fmt.Sprintf("发送消息失败: %v", err))
 	}
 
-	// 从 result 中提取 packageInfos
-	packageInfosRaw, ok := result["packageInfos"]
-	if !ok {
-		return types.NewExecResultErrorString("aya_parse_response", "响应中缺少 packageInfos 字段")
-	}
-
-	// 通过 JSON 序列化和反序列化来转换类型
-	jsonBytes, err := json.Marshal(packageInfosRaw)
-	if err != nil {
-		return types.NewExecResultErrorString("aya_marshal", fmt.Sprintf("序列化失败: %v", err))
-	}
-
-	var packageInfos []aya.PackageInfo
-	if err := json.Unmarshal(jsonBytes, &packageInfos); err != nil {
-		return types.NewExecResultErrorString("aya_unmarshal", fmt.Sprintf("反序列化失败: %v", err))
-	}
-
 	// 格式化为 JSON 输出
 	jsonData, err := json.MarshalIndent(packageInfos, "", "  ")
 	if err != nil {
@@ -422,6 +449,30 @@ func (a *App) GetPackageInfoFromAya(param adb.ExecuteParams, packageNames []stri
 	)
 }
 
+// GetAyaServerHealth 检查 Aya 服务的运行状态、心跳延迟与 dex 校验结果，未运行时会自动部署
+func (a *App) GetAyaServerHealth(deviceId string) aya.ServerHealth {
+	client := aya.NewClient(a.buildParam(deviceId))
+	if err := client.Connect(a.ayaDexPath); err != nil {
+		health := client.Health()
+		health.Error = fmt.Sprintf("连接 Aya 服务失败: %v", err)
+		return health
+	}
+	defer client.Close()
+
+	health := client.Health()
+	applog.Infof(applog.CategoryAya, "server_health device=%s running=%t latency_ms=%d checksum_matched=%t", deviceId, health.Running, health.LatencyMs, health.ChecksumMatched)
+	return health
+}
+
+// StopAyaServer 关闭设备上的 Aya 服务
+func (a *App) StopAyaServer(deviceId string) types.ExecResult {
+	client := aya.NewClient(a.buildParam(deviceId))
+	if err := client.Shutdown(); err != nil {
+		return types.NewExecResultErrorString("aya_shutdown", err.Error())
+	}
+	return types.NewExecResultSuccess("aya_shutdown", "Aya 服务已关闭")
+}
+
 func (a *App) extractAyaDex() error {
 	// 1. 获取临时目录
 	tmpDir := os.TempDir()
@@ -444,8 +495,12 @@ func (a *App) extractAyaDex() error {
 	return nil
 }
 
-// GetApplicationListWithProgress 获取应用列表（带进度回调和取消支持）
-func (a *App) GetApplicationListWithProgress(deviceId string) ([]aya.PackageInfo, error) {
+// GetApplicationListWithProgress 分批读取应用列表，userId 非空时只列出该用户下安装的应用
+func (a *App) GetApplicationListWithProgress(deviceId string, userId string) ([]aya.PackageInfo, error) {
+	if !adb.IsValidUserId(userId) {
+		return nil, fmt.Errorf("无效的用户: %s", userId)
+	}
+
 	// 先取消之前的任务并等待其完成
 	a.CancelApplicationListLoading()
 
@@ -508,6 +563,7 @@ func (a *App) GetApplicationListWithProgress(deviceId string) ([]aya.PackageInfo
 		Ctxt:     ctx,
 		AdbPath:  a.adbPath,
 		DeviceId: deviceId,
+		UserId:   userId,
 	}
 
 	// 检查是否已取消
@@ -585,7 +641,7 @@ func (a *App) GetApplicationListWithProgress(deviceId string) ([]aya.PackageInfo
 			continue
 		}
 
-		allApps = append(allApps, batchApps...)
+		allApps = append(allApps, fillMissingPackages(param, batch, batchApps)...)
 
 		// 发送进度更新
 		emitProgress(totalPackages, len(allApps), false)
@@ -598,6 +654,19 @@ func (a *App) GetApplicationListWithProgress(deviceId string) ([]aya.PackageInfo
 		return nil, context.Canceled
 	}
 
+	a.fillPackageSizes(param, allApps)
+
+	// Aya 读到的启用状态属于主用户，其他用户以该用户下的停用列表为准
+	if userId != "" {
+		if disabled, err := adb.ListDisabledPackages(param); err == nil {
+			for i := range allApps {
+				allApps[i].Enabled = !disabled[allApps[i].PackageName]
+			}
+		} else {
+			applog.Warnf(applog.CategoryAction, "app_list_disabled_failed device=%s user=%s err=%q", deviceId, userId, err.Error())
+		}
+	}
+
 	// 发送完成事件
 	emitProgress(totalPackages, len(allApps), true)
 
@@ -606,6 +675,39 @@ func (a *App) GetApplicationListWithProgress(deviceId string) ([]aya.PackageInfo
 	return allApps, nil
 }
 
+// fillPackageSizes 使用 dumpsys diskstats 补齐 Aya 未能查询到的应用大小
+func (a *App) fillPackageSizes(param adb.ExecuteParams, apps []aya.PackageInfo) {
+	missing := 0
+	for _, app := range apps {
+		if app.AppSize == 0 && app.DataSize == 0 && app.CacheSize == 0 {
+			missing++
+		}
+	}
+	if missing == 0 {
+		return
+	}
+
+	sizes, err := adb.GetPackageSizes(param)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "app_size_fallback_failed device=%s missing=%d err=%q", param.DeviceId, missing, err.Error())
+		return
+	}
+
+	filled := 0
+	for i := range apps {
+		if apps[i].AppSize != 0 || apps[i].DataSize != 0 || apps[i].CacheSize != 0 {
+			continue
+		}
+		if size, ok := sizes[apps[i].PackageName]; ok {
+			apps[i].AppSize = size.AppSize
+			apps[i].DataSize = size.DataSize
+			apps[i].CacheSize = size.CacheSize
+			filled++
+		}
+	}
+	applog.Infof(applog.CategoryAction, "app_size_fallback_applied device=%s missing=%d filled=%d", param.DeviceId, missing, filled)
+}
+
 // CancelApplicationListLoading 取消当前正在进行的应用列表加载任务
 func (a *App) CancelApplicationListLoading() {
 	a.appListMutex.Lock()
@@ -771,37 +873,34 @@ func (a *App) buildParam(deviceId string) adb.ExecuteParams {
 	}
 }
 
-// ListDirectory 列出设备目录内容
-func (a *App) ListDirectory(deviceId string, path string) types.ExecResult {
-	param := a.buildParam(deviceId)
-	cmd := adb.BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("ls -la %s", path))
-	res, err := util.Exec(cmd, true, nil)
-	if err != nil {
-		return types.NewExecResultFromError(cmd, "", err)
+// buildUserParam 与 buildParam 相同，并指定 pm/am 命令的目标用户
+func (a *App) buildUserParam(deviceId string, userId string) (adb.ExecuteParams, error) {
+	if !adb.IsValidUserId(userId) {
+		return adb.ExecuteParams{}, fmt.Errorf("无效的用户: %s", userId)
 	}
-	return types.NewExecResultSuccess(cmd, strings.TrimSpace(res))
+	param := a.buildParam(deviceId)
+	param.UserId = userId
+	return param, nil
 }
 
-// ReadFileContent 读取设备文件内容
-func (a *App) ReadFileContent(deviceId string, path string) types.ExecResult {
-	param := a.buildParam(deviceId)
-	cmd := adb.BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("cat %s", path))
-	res, err := util.Exec(cmd, true, nil)
+// ListDirectory 列出设备目录内容，返回解析后的条目
+func (a *App) ListDirectory(deviceId string, path string) ([]adb.RemoteFileEntry, error) {
+	entries, err := adb.ListDirectory(a.buildParam(deviceId), path)
 	if err != nil {
-		return types.NewExecResultFromError(cmd, "", err)
+		applog.Warnf(applog.CategoryAction, "list_directory_failed device=%s path=%s err=%q", deviceId, path, err.Error())
+		return nil, err
 	}
-	return types.NewExecResultSuccess(cmd, res)
+	return entries, nil
 }
 
-// DeleteRemoteFile 删除设备文件
-func (a *App) DeleteRemoteFile(deviceId string, path string) types.ExecResult {
-	param := a.buildParam(deviceId)
-	cmd := adb.BuildAdbShellCmd(param.AdbPath, param.DeviceId, fmt.Sprintf("rm -rf %s", path))
-	res, err := util.Exec(cmd, true, nil)
+// PreviewRemoteFile 分段预览设备文件，offset 与 mode 取自上一页的 NextOffset 与 Mode
+func (a *App) PreviewRemoteFile(deviceId string, path string, offset int64, maxBytes int64, mode string) (adb.FilePreview, error) {
+	preview, err := adb.ReadRemoteFile(a.buildParam(deviceId), path, offset, maxBytes, mode)
 	if err != nil {
-		return types.NewExecResultFromError(cmd, "", err)
+		applog.Warnf(applog.CategoryAction, "file_preview_failed path=%q offset=%d err=%q", path, offset, err.Error())
+		return preview, err
 	}
-	return types.NewExecResultSuccess(cmd, strings.TrimSpace(res))
+	return preview, nil
 }
 
 // UploadFile 上传本地文件到设备（adb push）
@@ -854,24 +953,3 @@ func (a *App) DownloadFile(deviceId string, remotePath string) types.ExecResult
 func (a *App) GetVersion() string {
 	return Version
 }
-
-// GetBookmarkPaths 获取收藏的路径列表
-func (a *App) GetBookmarkPaths() []string {
-	if a.store == nil {
-		return []string{}
-	}
-	var paths []string
-	err := a.store.Get(storage.KeyBookmarkPaths, &paths)
-	if err != nil {
-		return []string{}
-	}
-	return paths
-}
-
-// SetBookmarkPaths 保存收藏的路径列表
-func (a *App) SetBookmarkPaths(paths []string) error {
-	if a.store == nil {
-		return fmt.Errorf("storage is not initialized")
-	}
-	return a.store.Set(storage.KeyBookmarkPaths, paths)
-}
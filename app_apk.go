@@ -0,0 +1,154 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/aya"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 与已安装版本比较的结果
+const (
+	ApkNotInstalled = "not-installed"
+	ApkUpgrade      = "upgrade"
+	ApkSameVersion  = "same"
+	ApkDowngrade    = "downgrade"
+	ApkUnknown      = "unknown" // 无法读取已安装版本
+)
+
+// ApkInspection 本地 APK 信息以及与设备上已安装版本的对比
+type ApkInspection struct {
+	Apk           adb.ApkInfo      `json:"apk"`
+	Installed     *aya.PackageInfo `json:"installed"`
+	InstalledErr  string           `json:"installedErr,omitempty"`
+	Version       string           `json:"version"`       // 见 Apk* 常量
+	SameSignature *bool            `json:"sameSignature"` // 未安装或无法比较时为空
+	Device        adb.DeviceSpec   `json:"device"`
+	Warnings      []string         `json:"warnings"`
+}
+
+// SelectApkFile 选择单个 APK 文件
+func (a *App) SelectApkFile() (string, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择 APK 文件",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Android APK (*.apk)",
+				Pattern:     "*.apk",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("选择文件失败: %w", err)
+	}
+	if path == "" {
+		return "", errDialogCancelled
+	}
+	return path, nil
+}
+
+// InspectApk 解析本地 APK，并与设备上已安装的同名应用比较版本与签名
+// deviceId 为空时只解析 APK
+func (a *App) InspectApk(deviceId string, path string) (ApkInspection, error) {
+	inspection := ApkInspection{Version: ApkUnknown, Warnings: []string{}}
+	info, err := adb.ParseApk(path)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "apk_inspect_failed path=%q err=%q", path, err.Error())
+		return inspection, err
+	}
+	inspection.Apk = info
+	if deviceId == "" {
+		return inspection, nil
+	}
+
+	param := a.buildParam(deviceId)
+	if spec, err := adb.GetDeviceSpec(param); err == nil {
+		inspection.Device = spec
+	}
+
+	param.PackageName = info.Package
+	if res := adb.GetAppInstallPath(param); res.Error == "" && !strings.Contains(res.Res, "package:") {
+		inspection.Version = ApkNotInstalled
+	} else {
+		client := aya.NewClient(param)
+		if err := client.Connect(a.ayaDexPath); err != nil {
+			inspection.InstalledErr = fmt.Sprintf("连接 Aya 服务失败: %v", err)
+		} else {
+			installed, err := client.GetPackageInfo(info.Package)
+			client.Close()
+			if err != nil {
+				inspection.InstalledErr = fmt.Sprintf("读取已安装版本失败: %v", err)
+			} else {
+				inspection.Installed = installed
+			}
+		}
+	}
+	compareInstalledApk(&inspection)
+
+	applog.Infof(applog.CategoryAction, "apk_inspected device=%s package=%s version_code=%d compare=%s warnings=%d",
+		deviceId, info.Package, info.VersionCode, inspection.Version, len(inspection.Warnings))
+	return inspection, nil
+}
+
+func compareInstalledApk(inspection *ApkInspection) {
+	apk := inspection.Apk
+	device := inspection.Device
+
+	if installed := inspection.Installed; installed != nil {
+		switch {
+		case apk.VersionCode > int64(installed.VersionCode):
+			inspection.Version = ApkUpgrade
+		case apk.VersionCode == int64(installed.VersionCode):
+			inspection.Version = ApkSameVersion
+		default:
+			inspection.Version = ApkDowngrade
+			inspection.Warnings = append(inspection.Warnings,
+				fmt.Sprintf("versionCode %d 低于已安装的 %d，需要 -d 降级安装，非 debuggable 应用在 user 版系统上会失败", apk.VersionCode, installed.VersionCode))
+		}
+
+		if len(installed.SignatureSha256s) > 0 && len(apk.Certificates) > 0 {
+			same := false
+			for _, cert := range apk.Certificates {
+				for _, digest := range installed.SignatureSha256s {
+					if adb.NormalizeCertDigest(digest) == cert.Sha256 {
+						same = true
+					}
+				}
+			}
+			inspection.SameSignature = &same
+			if !same {
+				inspection.Warnings = append(inspection.Warnings, "签名与已安装版本不一致，直接覆盖安装会失败，需要先卸载（会清除应用数据）")
+			}
+		}
+	}
+
+	if device.Sdk > 0 && apk.MinSdkVersion > device.Sdk {
+		inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("minSdkVersion %d 高于设备 SDK %d", apk.MinSdkVersion, device.Sdk))
+	}
+	if len(apk.Abis) > 0 && len(device.Abis) > 0 {
+		matched := false
+		for _, abi := range apk.Abis {
+			matched = matched || slices.Contains(device.Abis, abi)
+		}
+		if !matched {
+			inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("APK 只包含 %s 的 so 库，设备支持 %s", strings.Join(apk.Abis, ", "), strings.Join(device.Abis, ", ")))
+		}
+	}
+	if apk.Split != "" {
+		inspection.Warnings = append(inspection.Warnings, fmt.Sprintf("这是拆分包 %s，需要与基础包一起安装", apk.Split))
+	}
+	if apk.TestOnly {
+		inspection.Warnings = append(inspection.Warnings, "应用声明了 testOnly，需要 -t 安装")
+	}
+	if len(apk.Certificates) == 0 {
+		inspection.Warnings = append(inspection.Warnings, "APK 没有签名，无法安装")
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/appdata"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/types"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// appDatabaseSession 拉取到本地临时目录的数据库副本
+type appDatabaseSession struct {
+	deviceId   string
+	access     adb.PackageAccess
+	remotePath string
+	localDir   string
+	localPath  string
+	db         *appdata.Database
+	writable   bool
+	dirty      bool
+}
+
+// AppDatabaseInfo 打开数据库后返回给前端的信息
+type AppDatabaseInfo struct {
+	SessionId string              `json:"sessionId"`
+	Name      string              `json:"name"`
+	Writable  bool                `json:"writable"`
+	Tables    []appdata.TableInfo `json:"tables"`
+}
+
+// ListAppDataFiles 列出应用的 SharedPreferences 与数据库文件
+func (a *App) ListAppDataFiles(deviceId string, access adb.PackageAccess) (adb.AppDataFiles, error) {
+	param := a.buildParam(deviceId)
+	access, err := a.reresolveAccess(param, access)
+	if err != nil {
+		return adb.AppDataFiles{}, err
+	}
+	files, err := adb.ListAppDataFiles(param, access)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "app_data_list_failed device=%s package=%s err=%q", deviceId, access.Package, err.Error())
+		return files, err
+	}
+	return files, nil
+}
+
+// ReadSharedPrefs 拉取并解析 SharedPreferences 文件
+func (a *App) ReadSharedPrefs(deviceId string, access adb.PackageAccess, remotePath string) ([]appdata.PrefEntry, error) {
+	param := a.buildParam(deviceId)
+	access, err := a.reresolveAccess(param, access)
+	if err != nil {
+		return nil, err
+	}
+	localDir, err := os.MkdirTemp("", "adbtool_prefs_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(localDir)
+
+	localPath, err := adb.PullAppDataFile(param, access, remotePath, localDir, false)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "shared_prefs_read_failed package=%s path=%q err=%q", access.Package, remotePath, err.Error())
+		return nil, err
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, err
+	}
+	return appdata.ParseSharedPrefs(data)
+}
+
+// WriteSharedPrefs 生成 XML 并写回设备，写入前会强制停止应用
+func (a *App) WriteSharedPrefs(deviceId string, access adb.PackageAccess, remotePath string, entries []appdata.PrefEntry) types.ExecResult {
+	data, err := appdata.BuildSharedPrefs(entries)
+	if err != nil {
+		return types.NewExecResultError("write_prefs", err)
+	}
+	localDir, err := os.MkdirTemp("", "adbtool_prefs_")
+	if err != nil {
+		return types.NewExecResultError("write_prefs", err)
+	}
+	defer os.RemoveAll(localDir)
+
+	localPath := filepath.Join(localDir, path.Base(remotePath))
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		return types.NewExecResultError("write_prefs", err)
+	}
+	param := a.buildParam(deviceId)
+	access, err = a.reresolveAccess(param, access)
+	if err != nil {
+		return types.NewExecResultError("write_prefs", err)
+	}
+	if err := adb.PushAppDataFile(param, access, localPath, remotePath, false); err != nil {
+		applog.Warnf(applog.CategoryAction, "shared_prefs_write_failed package=%s path=%q err=%q", access.Package, remotePath, err.Error())
+		return types.NewExecResultError("write_prefs", err)
+	}
+	applog.Infof(applog.CategoryAction, "shared_prefs_written package=%s path=%q entries=%d", access.Package, remotePath, len(entries))
+	return types.NewExecResultSuccess("write_prefs", "已写回，应用已停止")
+}
+
+// OpenAppDatabase 拉取数据库（含 WAL 文件）到本地并打开，writable 为 false 时只允许查询
+func (a *App) OpenAppDatabase(deviceId string, access adb.PackageAccess, remotePath string, writable bool) (AppDatabaseInfo, error) {
+	param := a.buildParam(deviceId)
+	access, err := a.reresolveAccess(param, access)
+	if err != nil {
+		return AppDatabaseInfo{}, err
+	}
+	localDir, err := os.MkdirTemp("", "adbtool_db_")
+	if err != nil {
+		return AppDatabaseInfo{}, err
+	}
+	localPath, err := adb.PullAppDataFile(param, access, remotePath, localDir, true)
+	if err != nil {
+		os.RemoveAll(localDir)
+		applog.Warnf(applog.CategoryAction, "app_database_pull_failed package=%s path=%q err=%q", access.Package, remotePath, err.Error())
+		return AppDatabaseInfo{}, err
+	}
+	db, err := appdata.OpenDatabase(localPath, !writable)
+	if err != nil {
+		os.RemoveAll(localDir)
+		return AppDatabaseInfo{}, err
+	}
+	tables, err := db.Tables()
+	if err != nil {
+		db.Close()
+		os.RemoveAll(localDir)
+		return AppDatabaseInfo{}, err
+	}
+
+	session := &appDatabaseSession{
+		deviceId:   deviceId,
+		access:     access,
+		remotePath: remotePath,
+		localDir:   localDir,
+		localPath:  localPath,
+		db:         db,
+		writable:   writable,
+	}
+	sessionId := uuid.New().String()
+	a.appDatabaseMutex.Lock()
+	if a.appDatabases == nil {
+		a.appDatabases = make(map[string]*appDatabaseSession)
+	}
+	a.appDatabases[sessionId] = session
+	a.appDatabaseMutex.Unlock()
+
+	applog.Infof(applog.CategoryAction, "app_database_opened session=%s package=%s path=%q writable=%t tables=%d", sessionId, access.Package, remotePath, writable, len(tables))
+	return AppDatabaseInfo{SessionId: sessionId, Name: path.Base(remotePath), Writable: writable, Tables: tables}, nil
+}
+
+// QueryAppDatabase 在本地副本上执行查询，最多返回 limit 行
+func (a *App) QueryAppDatabase(sessionId string, statement string, limit int) (appdata.QueryResult, error) {
+	session, err := a.appDatabaseSession(sessionId)
+	if err != nil {
+		return appdata.QueryResult{}, err
+	}
+	return session.db.Query(statement, limit)
+}
+
+// ExecAppDatabase 在本地副本上执行写语句，需以可写方式打开，调用 CommitAppDatabase 后才会写回设备
+func (a *App) ExecAppDatabase(sessionId string, statement string) (appdata.QueryResult, error) {
+	session, err := a.appDatabaseSession(sessionId)
+	if err != nil {
+		return appdata.QueryResult{}, err
+	}
+	result, err := session.db.Exec(statement)
+	if err != nil {
+		return result, err
+	}
+	session.dirty = true
+	return result, nil
+}
+
+// CommitAppDatabase 合并 WAL 后强制停止应用并把本地副本写回设备
+func (a *App) CommitAppDatabase(sessionId string) types.ExecResult {
+	session, err := a.appDatabaseSession(sessionId)
+	if err != nil {
+		return types.NewExecResultError("commit_database", err)
+	}
+	if !session.writable {
+		return types.NewExecResultErrorString("commit_database", "数据库以只读方式打开")
+	}
+	if !session.dirty {
+		return types.NewExecResultSuccess("commit_database", "没有需要写回的修改")
+	}
+	if err := session.db.Checkpoint(); err != nil {
+		return types.NewExecResultError("commit_database", fmt.Errorf("合并 WAL 失败: %w", err))
+	}
+	if err := adb.PushAppDataFile(a.buildParam(session.deviceId), session.access, session.localPath, session.remotePath, true); err != nil {
+		applog.Warnf(applog.CategoryAction, "app_database_commit_failed session=%s package=%s path=%q err=%q", sessionId, session.access.Package, session.remotePath, err.Error())
+		return types.NewExecResultError("commit_database", err)
+	}
+	session.dirty = false
+	applog.Infof(applog.CategoryAction, "app_database_committed session=%s package=%s path=%q", sessionId, session.access.Package, session.remotePath)
+	return types.NewExecResultSuccess("commit_database", "已写回，应用已停止")
+}
+
+// CloseAppDatabase 关闭数据库并删除本地副本，未写回的修改会丢弃
+func (a *App) CloseAppDatabase(sessionId string) {
+	a.appDatabaseMutex.Lock()
+	session, ok := a.appDatabases[sessionId]
+	delete(a.appDatabases, sessionId)
+	a.appDatabaseMutex.Unlock()
+	if ok {
+		session.close()
+	}
+}
+
+func (a *App) appDatabaseSession(sessionId string) (*appDatabaseSession, error) {
+	a.appDatabaseMutex.Lock()
+	defer a.appDatabaseMutex.Unlock()
+	session, ok := a.appDatabases[sessionId]
+	if !ok {
+		return nil, fmt.Errorf("数据库已关闭，请重新打开")
+	}
+	return session, nil
+}
+
+func (a *App) closeAllAppDatabases() {
+	a.appDatabaseMutex.Lock()
+	sessions := a.appDatabases
+	a.appDatabases = nil
+	a.appDatabaseMutex.Unlock()
+	for _, session := range sessions {
+		session.close()
+	}
+}
+
+func (s *appDatabaseSession) close() {
+	if err := s.db.Close(); err != nil {
+		applog.Warnf(applog.CategoryAction, "app_database_close_failed path=%q err=%q", s.remotePath, err.Error())
+	}
+	os.RemoveAll(s.localDir)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// BackupAppData 备份应用的私有数据与外部数据到选择的 zip 文件
+func (a *App) BackupAppData(deviceId string, packageName string, options adb.AppBackupOptions) (adb.AppBackupResult, error) {
+	output, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("%s-backup-%s.zip", packageName, time.Now().Format("20060102-150405")),
+		Title:           "保存应用数据备份",
+	})
+	if err != nil {
+		return adb.AppBackupResult{}, fmt.Errorf("选择保存路径失败: %w", err)
+	}
+	if output == "" {
+		return adb.AppBackupResult{}, errDialogCancelled
+	}
+
+	result, err := adb.BackupAppData(a.buildParam(deviceId), packageName, options, output)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "app_backup_failed device=%s package=%s err=%q", deviceId, packageName, err.Error())
+		return result, err
+	}
+	applog.Infof(applog.CategoryAction, "app_backup_created device=%s package=%s version_code=%d method=%s access=%s external=%t bytes=%d file=%q warnings=%q",
+		deviceId, packageName, result.Meta.VersionCode, result.Meta.Method, result.Meta.Access, result.Meta.External, result.Bytes, output, strings.Join(result.Warnings, "; "))
+	return result, nil
+}
+
+// SelectAppBackup 选择备份文件并检查能否恢复到设备
+func (a *App) SelectAppBackup(deviceId string) (adb.AppBackupCheck, error) {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择应用数据备份",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "应用数据备份 (*.zip)",
+				Pattern:     "*.zip",
+			},
+		},
+	})
+	if err != nil {
+		return adb.AppBackupCheck{}, fmt.Errorf("选择文件失败: %w", err)
+	}
+	if file == "" {
+		return adb.AppBackupCheck{}, errDialogCancelled
+	}
+	return adb.CheckAppBackup(a.buildParam(deviceId), file)
+}
+
+// RestoreAppData 停止应用并用备份替换其数据，force 时允许恢复到低于备份时的版本
+func (a *App) RestoreAppData(deviceId string, file string, force bool) (adb.AppBackupCheck, error) {
+	check, err := adb.RestoreAppData(a.buildParam(deviceId), file, force)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "app_restore_failed device=%s package=%s file=%q err=%q", deviceId, check.Meta.Package, file, err.Error())
+		return check, err
+	}
+	applog.Infof(applog.CategoryAction, "app_restored device=%s package=%s backup_version=%d installed_version=%d method=%s access=%s force=%t file=%q",
+		deviceId, check.Meta.Package, check.Meta.VersionCode, check.VersionCode, check.Meta.Method, check.Access, force, file)
+	return check, nil
+}
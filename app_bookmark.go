@@ -0,0 +1,243 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/storage"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// bookmarkPackagePlaceholder 收藏路径中的包名占位符
+const bookmarkPackagePlaceholder = "{package}"
+
+// Bookmark 收藏的设备路径
+type Bookmark struct {
+	Id        string `json:"id"`
+	Label     string `json:"label"`
+	Path      string `json:"path"`     // 可包含 {package}，如 /sdcard/Android/data/{package}/files
+	Package   string `json:"package"`  // 展开 {package} 使用的包名，为空时使用打开时指定的包名
+	DeviceId  string `json:"deviceId"` // 为空表示所有设备可见
+	Order     int    `json:"order"`
+	LastUsed  int64  `json:"lastUsed"` // Unix 毫秒，0 表示未使用过
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// expand 替换 {package}，收藏自带包名时优先使用，否则使用 packageName
+func (b Bookmark) expand(packageName string) (string, error) {
+	if !strings.Contains(b.Path, bookmarkPackagePlaceholder) {
+		return b.Path, nil
+	}
+	if b.Package != "" {
+		packageName = b.Package
+	}
+	if packageName == "" {
+		return "", fmt.Errorf("收藏 %s 需要指定包名", b.Label)
+	}
+	if !adb.IsValidPackageName(packageName) {
+		return "", fmt.Errorf("无效的包名: %s", packageName)
+	}
+	return strings.ReplaceAll(b.Path, bookmarkPackagePlaceholder, packageName), nil
+}
+
+// loadBookmarks 读取收藏，首次读取时把旧版的路径列表迁移为收藏对象；调用方需持有 bookmarkMutex
+func (a *App) loadBookmarks() ([]Bookmark, error) {
+	if a.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+	var bookmarks []Bookmark
+	if a.store.Has(storage.KeyBookmarks) {
+		if err := a.store.Get(storage.KeyBookmarks, &bookmarks); err != nil {
+			return nil, err
+		}
+		return bookmarks, nil
+	}
+
+	var paths []string
+	if err := a.store.Get(storage.KeyBookmarkPaths, &paths); err != nil {
+		return []Bookmark{}, nil
+	}
+	now := time.Now().UnixMilli()
+	for i, p := range paths {
+		bookmarks = append(bookmarks, Bookmark{
+			Id:        uuid.New().String(),
+			Label:     bookmarkLabel(p),
+			Path:      p,
+			Order:     i,
+			CreatedAt: now,
+		})
+	}
+	if err := a.store.Set(storage.KeyBookmarks, bookmarks); err != nil {
+		return nil, err
+	}
+	_ = a.store.Delete(storage.KeyBookmarkPaths)
+	applog.Infof(applog.CategoryAction, "bookmarks_migrated count=%d", len(bookmarks))
+	return bookmarks, nil
+}
+
+// saveBookmarks 按 Order 排序后重新编号并保存；调用方需持有 bookmarkMutex
+func (a *App) saveBookmarks(bookmarks []Bookmark) error {
+	slices.SortStableFunc(bookmarks, func(x, y Bookmark) int { return x.Order - y.Order })
+	for i := range bookmarks {
+		bookmarks[i].Order = i
+	}
+	return a.store.Set(storage.KeyBookmarks, bookmarks)
+}
+
+// bookmarkLabel 默认使用路径最后一级作为名称
+func bookmarkLabel(p string) string {
+	if base := path.Base(p); base != "/" && base != "." {
+		return base
+	}
+	return p
+}
+
+// ListBookmarks 获取设备可见的收藏，deviceId 为空时返回全部，按顺序排列
+func (a *App) ListBookmarks(deviceId string) ([]Bookmark, error) {
+	a.bookmarkMutex.Lock()
+	defer a.bookmarkMutex.Unlock()
+
+	bookmarks, err := a.loadBookmarks()
+	if err != nil {
+		return nil, err
+	}
+	result := []Bookmark{}
+	for _, b := range bookmarks {
+		if deviceId == "" || b.DeviceId == "" || b.DeviceId == deviceId {
+			result = append(result, b)
+		}
+	}
+	slices.SortStableFunc(result, func(x, y Bookmark) int { return x.Order - y.Order })
+	return result, nil
+}
+
+// SaveBookmark 新增（Id 为空）或更新收藏，新增的收藏排在最后
+func (a *App) SaveBookmark(bookmark Bookmark) (Bookmark, error) {
+	bookmark.Path = strings.TrimSpace(bookmark.Path)
+	bookmark.Label = strings.TrimSpace(bookmark.Label)
+	bookmark.Package = strings.TrimSpace(bookmark.Package)
+	if !strings.HasPrefix(bookmark.Path, "/") {
+		return bookmark, fmt.Errorf("必须使用绝对路径: %s", bookmark.Path)
+	}
+	if bookmark.Path != "/" {
+		bookmark.Path = strings.TrimSuffix(bookmark.Path, "/")
+	}
+	if bookmark.Package != "" && !adb.IsValidPackageName(bookmark.Package) {
+		return bookmark, fmt.Errorf("无效的包名: %s", bookmark.Package)
+	}
+	if bookmark.Label == "" {
+		bookmark.Label = bookmarkLabel(bookmark.Path)
+	}
+
+	a.bookmarkMutex.Lock()
+	defer a.bookmarkMutex.Unlock()
+
+	bookmarks, err := a.loadBookmarks()
+	if err != nil {
+		return bookmark, err
+	}
+	index := slices.IndexFunc(bookmarks, func(b Bookmark) bool { return b.Id == bookmark.Id })
+	if index < 0 {
+		bookmark.Id = uuid.New().String()
+		bookmark.Order = len(bookmarks)
+		bookmark.CreatedAt = time.Now().UnixMilli()
+		bookmark.LastUsed = 0
+		bookmarks = append(bookmarks, bookmark)
+	} else {
+		// 顺序、使用时间与创建时间不由编辑修改
+		bookmark.Order = bookmarks[index].Order
+		bookmark.LastUsed = bookmarks[index].LastUsed
+		bookmark.CreatedAt = bookmarks[index].CreatedAt
+		bookmarks[index] = bookmark
+	}
+	if err := a.saveBookmarks(bookmarks); err != nil {
+		return bookmark, err
+	}
+	applog.Infof(applog.CategoryAction, "bookmark_saved id=%s path=%q device=%s package=%s", bookmark.Id, bookmark.Path, bookmark.DeviceId, bookmark.Package)
+	return bookmark, nil
+}
+
+// DeleteBookmark 删除收藏
+func (a *App) DeleteBookmark(id string) error {
+	a.bookmarkMutex.Lock()
+	defer a.bookmarkMutex.Unlock()
+
+	bookmarks, err := a.loadBookmarks()
+	if err != nil {
+		return err
+	}
+	bookmarks = slices.DeleteFunc(bookmarks, func(b Bookmark) bool { return b.Id == id })
+	if err := a.saveBookmarks(bookmarks); err != nil {
+		return err
+	}
+	applog.Infof(applog.CategoryAction, "bookmark_deleted id=%s", id)
+	return nil
+}
+
+// ReorderBookmarks 按 ids 的顺序排列收藏，未列出的收藏保持原有相对顺序排在后面
+func (a *App) ReorderBookmarks(ids []string) error {
+	a.bookmarkMutex.Lock()
+	defer a.bookmarkMutex.Unlock()
+
+	bookmarks, err := a.loadBookmarks()
+	if err != nil {
+		return err
+	}
+	for i := range bookmarks {
+		if pos := slices.Index(ids, bookmarks[i].Id); pos >= 0 {
+			bookmarks[i].Order = pos
+		} else {
+			bookmarks[i].Order = len(ids) + bookmarks[i].Order
+		}
+	}
+	return a.saveBookmarks(bookmarks)
+}
+
+// OpenBookmark 展开收藏的路径并记录使用时间，收藏没有设置包名时用 packageName 替换 {package}
+func (a *App) OpenBookmark(id string, packageName string) (string, error) {
+	a.bookmarkMutex.Lock()
+	defer a.bookmarkMutex.Unlock()
+
+	bookmarks, err := a.loadBookmarks()
+	if err != nil {
+		return "", err
+	}
+	index := slices.IndexFunc(bookmarks, func(b Bookmark) bool { return b.Id == id })
+	if index < 0 {
+		return "", fmt.Errorf("收藏不存在")
+	}
+	resolved, err := bookmarks[index].expand(strings.TrimSpace(packageName))
+	if err != nil {
+		return "", err
+	}
+	bookmarks[index].LastUsed = time.Now().UnixMilli()
+	if err := a.saveBookmarks(bookmarks); err != nil {
+		applog.Warnf(applog.CategoryAction, "bookmark_touch_failed id=%s err=%q", id, err.Error())
+	}
+	return resolved, nil
+}
+
+// bookmarkPaths 设备可见且能直接展开的收藏路径，需要包名的收藏会被跳过
+func (a *App) bookmarkPaths(deviceId string) []string {
+	bookmarks, err := a.ListBookmarks(deviceId)
+	if err != nil {
+		return []string{}
+	}
+	paths := []string{}
+	for _, b := range bookmarks {
+		if p, err := b.expand(""); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// GetBookmarkPaths 获取全部收藏中可以直接展开的路径
+func (a *App) GetBookmarkPaths() []string {
+	return a.bookmarkPaths("")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/types"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// deleteTokenTTL 删除确认令牌的有效期
+const deleteTokenTTL = 5 * time.Minute
+
+// deleteRequest 预览时记录的待删除路径，确认时只能删除这些路径
+type deleteRequest struct {
+	deviceId  string
+	access    adb.PackageAccess // 零值表示以 shell 身份删除
+	preview   adb.DeletePreview
+	expiresAt time.Time
+}
+
+// PrepareDeleteRemoteFiles 删除第一步：统计将被删除的内容并生成确认令牌
+func (a *App) PrepareDeleteRemoteFiles(deviceId string, paths []string, force bool) (adb.DeletePreview, error) {
+	if len(paths) == 0 {
+		return adb.DeletePreview{}, fmt.Errorf("没有选择要删除的文件")
+	}
+	preview, err := adb.PreviewDelete(a.buildParam(deviceId), paths, force)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "file_delete_refused device=%s paths=%q err=%q", deviceId, paths, err.Error())
+		return preview, err
+	}
+	return a.issueDeleteToken(deviceId, adb.PackageAccess{}, preview), nil
+}
+
+// PrepareDeleteRemoteFilesAs 以应用身份删除的第一步，访问方式在后端重新确定，路径必须位于应用数据目录之下
+func (a *App) PrepareDeleteRemoteFilesAs(deviceId string, packageName string, paths []string) (adb.DeletePreview, error) {
+	if len(paths) == 0 {
+		return adb.DeletePreview{}, fmt.Errorf("没有选择要删除的文件")
+	}
+	param := a.buildParam(deviceId)
+	access, err := adb.ResolvePackageAccess(param, packageName)
+	if err != nil {
+		return adb.DeletePreview{}, err
+	}
+	preview, err := adb.PreviewDeleteAs(param, access, paths)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "file_delete_refused device=%s package=%s paths=%q err=%q", deviceId, packageName, paths, err.Error())
+		return preview, err
+	}
+	return a.issueDeleteToken(deviceId, access, preview), nil
+}
+
+// issueDeleteToken 为预览生成确认令牌，同时清理过期的令牌
+func (a *App) issueDeleteToken(deviceId string, access adb.PackageAccess, preview adb.DeletePreview) adb.DeletePreview {
+	expiresAt := time.Now().Add(deleteTokenTTL)
+	preview.Token = uuid.New().String()
+	preview.ExpiresAt = expiresAt.UnixMilli()
+
+	a.deleteMutex.Lock()
+	if a.deleteTokens == nil {
+		a.deleteTokens = make(map[string]deleteRequest)
+	}
+	for token, req := range a.deleteTokens {
+		if time.Now().After(req.expiresAt) {
+			delete(a.deleteTokens, token)
+		}
+	}
+	a.deleteTokens[preview.Token] = deleteRequest{deviceId: deviceId, access: access, preview: preview, expiresAt: expiresAt}
+	a.deleteMutex.Unlock()
+
+	applog.Infof(applog.CategoryAction, "file_delete_prepared device=%s package=%s paths=%d files=%d dirs=%d bytes=%d",
+		deviceId, access.Package, len(preview.Items), preview.Files, preview.Dirs, preview.Bytes)
+	return preview
+}
+
+// ConfirmDeleteRemoteFiles 删除第二步：凭令牌删除预览中的路径，令牌只能使用一次
+func (a *App) ConfirmDeleteRemoteFiles(token string, useTrash bool) (adb.DeleteResult, error) {
+	a.deleteMutex.Lock()
+	req, ok := a.deleteTokens[token]
+	delete(a.deleteTokens, token)
+	a.deleteMutex.Unlock()
+
+	if !ok {
+		return adb.DeleteResult{}, fmt.Errorf("删除确认已失效，请重新选择")
+	}
+	if time.Now().After(req.expiresAt) {
+		return adb.DeleteResult{}, fmt.Errorf("删除确认已过期，请重新选择")
+	}
+	if useTrash && !req.preview.TrashAvailable {
+		return adb.DeleteResult{}, fmt.Errorf("所选路径不在内部存储中，无法移到回收站")
+	}
+
+	paths := make([]string, 0, len(req.preview.Items))
+	var skipped []string
+	for _, item := range req.preview.Items {
+		if item.Error == "" {
+			paths = append(paths, item.Path)
+		} else {
+			skipped = append(skipped, fmt.Sprintf("%s: %s", item.Path, item.Error))
+		}
+	}
+	var result adb.DeleteResult
+	if req.access.Mode != "" {
+		result = adb.DeletePathsAs(a.buildParam(req.deviceId), req.access, paths)
+	} else {
+		result = adb.DeletePaths(a.buildParam(req.deviceId), paths, useTrash)
+	}
+	// 预览时就无法读取的路径没有删除，也要告诉用户
+	result.Errors = append(result.Errors, skipped...)
+	a.auditDelete(req.deviceId, req.access, req.preview, result, useTrash)
+	return result, nil
+}
+
+// ListRemoteTrash 列出设备回收站
+func (a *App) ListRemoteTrash(deviceId string) ([]adb.TrashEntry, error) {
+	return adb.ListTrash(a.buildParam(deviceId))
+}
+
+// RestoreRemoteTrash 将回收站中的条目恢复到原始位置
+func (a *App) RestoreRemoteTrash(deviceId string, id string) types.ExecResult {
+	result := adb.RestoreTrashEntry(a.buildParam(deviceId), id)
+	if result.Error != "" {
+		applog.Warnf(applog.CategoryAction, "file_trash_restore_failed device=%s id=%s err=%q", deviceId, id, result.Error)
+		return result
+	}
+	applog.Infof(applog.CategoryAction, "file_trash_restored device=%s id=%s msg=%q", deviceId, id, result.Res)
+	return result
+}
+
+// PurgeRemoteTrash 永久删除回收站中的条目，id 为空时清空回收站
+func (a *App) PurgeRemoteTrash(deviceId string, id string) types.ExecResult {
+	result := adb.PurgeTrash(a.buildParam(deviceId), id)
+	if result.Error != "" {
+		applog.Warnf(applog.CategoryAction, "file_trash_purge_failed device=%s id=%q err=%q", deviceId, id, result.Error)
+		return result
+	}
+	applog.Infof(applog.CategoryAction, "file_delete_audit device=%s mode=purge trash_id=%q", deviceId, id)
+	return result
+}
+
+// auditDelete 每个被删除的路径记录一条审计日志
+func (a *App) auditDelete(deviceId string, access adb.PackageAccess, preview adb.DeletePreview, result adb.DeleteResult, useTrash bool) {
+	mode := "permanent"
+	done := result.Deleted
+	if useTrash {
+		mode = "trash"
+		done = result.Trashed
+	}
+	items := make(map[string]adb.DeletePreviewItem, len(preview.Items))
+	for _, item := range preview.Items {
+		items[item.Path] = item
+	}
+	for _, p := range done {
+		item := items[p]
+		applog.Infof(applog.CategoryAction, "file_delete_audit device=%s mode=%s package=%s access=%s path=%q type=%s files=%d dirs=%d bytes=%d",
+			deviceId, mode, access.Package, access.Mode, p, item.Type, item.Files, item.Dirs, item.Bytes)
+	}
+	for _, e := range result.Errors {
+		applog.Warnf(applog.CategoryAction, "file_delete_failed device=%s mode=%s package=%s err=%q", deviceId, mode, access.Package, e)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// AppExportEvent app-export-progress 事件：Progress 为当前应用的下载进度，Result 在该应用导出结束时给出
+type AppExportEvent struct {
+	TaskId   string               `json:"taskId"`
+	Package  string               `json:"package"`
+	Index    int                  `json:"index"`
+	Count    int                  `json:"count"`
+	Progress adb.TransferProgress `json:"progress"`
+	Result   *adb.AppExportResult `json:"result"`
+}
+
+// ExportApps 把多个应用逐个导出到选择的目录，每个应用一个 .apks/.zip
+// 任务 id 随第一个事件返回给前端，可以用 CancelFileTransfer 取消，取消后剩余的应用不再导出
+func (a *App) ExportApps(deviceId string, packages []string, options adb.AppExportOptions) ([]adb.AppExportResult, error) {
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("请选择要导出的应用")
+	}
+	outDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择导出目录",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("选择导出目录失败: %w", err)
+	}
+	if outDir == "" {
+		return nil, errDialogCancelled
+	}
+
+	taskId := uuid.New().String()
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	a.transferMutex.Lock()
+	if a.transferCancels == nil {
+		a.transferCancels = make(map[string]context.CancelFunc)
+	}
+	a.transferCancels[taskId] = cancel
+	a.transferMutex.Unlock()
+	defer func() {
+		a.transferMutex.Lock()
+		delete(a.transferCancels, taskId)
+		a.transferMutex.Unlock()
+	}()
+
+	applog.Infof(applog.CategoryAction, "app_export_started task=%s device=%s packages=%q obb=%t data=%t format=%s",
+		taskId, deviceId, packages, options.IncludeObb, options.IncludeData, options.Format)
+
+	param := a.buildParam(deviceId)
+	results := make([]adb.AppExportResult, 0, len(packages))
+	for i, pkg := range packages {
+		event := AppExportEvent{TaskId: taskId, Package: pkg, Index: i, Count: len(packages)}
+		var result adb.AppExportResult
+		if ctx.Err() != nil {
+			result = adb.AppExportResult{Package: pkg, Status: adb.TransferCancelled, Errors: []string{}}
+		} else {
+			runtime.EventsEmit(a.ctx, "app-export-progress", event)
+			result = adb.ExportAppBundle(ctx, param, taskId, pkg, outDir, options, func(progress adb.TransferProgress) {
+				event.Progress = progress
+				runtime.EventsEmit(a.ctx, "app-export-progress", event)
+			})
+		}
+		event.Result = &result
+		runtime.EventsEmit(a.ctx, "app-export-progress", event)
+		results = append(results, result)
+
+		if result.Status == adb.TransferCompleted {
+			applog.Infof(applog.CategoryAction, "app_exported task=%s package=%s file=%q splits=%d obbs=%d data_files=%d bytes=%d",
+				taskId, pkg, result.File, result.Splits, result.Obbs, result.DataFiles, result.Bytes)
+		} else {
+			applog.Warnf(applog.CategoryAction, "app_export_failed task=%s package=%s status=%s err=%q",
+				taskId, pkg, result.Status, strings.Join(result.Errors, "; "))
+		}
+	}
+	return results, nil
+}
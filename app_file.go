@@ -0,0 +1,41 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/types"
+)
+
+// MakeRemoteDir 在设备上创建目录，force 为 true 时允许操作受保护路径
+func (a *App) MakeRemoteDir(deviceId string, path string, force bool) types.ExecResult {
+	return a.logFileOpResult("mkdir", path, adb.MakeRemoteDir(a.buildParam(deviceId), path, force))
+}
+
+// RenameRemoteFile 重命名或移动设备上的文件/目录
+func (a *App) RenameRemoteFile(deviceId string, src string, dst string, force bool) types.ExecResult {
+	return a.logFileOpResult("rename", src+" -> "+dst, adb.RenameRemoteFile(a.buildParam(deviceId), src, dst, force))
+}
+
+// CopyRemoteFile 在设备上复制文件/目录
+func (a *App) CopyRemoteFile(deviceId string, src string, dst string, force bool) types.ExecResult {
+	return a.logFileOpResult("copy", src+" -> "+dst, adb.CopyRemoteFile(a.buildParam(deviceId), src, dst, force))
+}
+
+// ChmodRemoteFile 修改设备上文件/目录的权限
+func (a *App) ChmodRemoteFile(deviceId string, path string, mode string, recursive bool, force bool) types.ExecResult {
+	return a.logFileOpResult("chmod", path+" "+mode, adb.ChmodRemoteFile(a.buildParam(deviceId), path, mode, recursive, force))
+}
+
+// TouchRemoteFile 在设备上创建空文件
+func (a *App) TouchRemoteFile(deviceId string, path string, force bool) types.ExecResult {
+	return a.logFileOpResult("touch", path, adb.TouchRemoteFile(a.buildParam(deviceId), path, force))
+}
+
+func (a *App) logFileOpResult(operation string, path string, result types.ExecResult) types.ExecResult {
+	if result.Error != "" {
+		applog.Warnf(applog.CategoryAction, "file_%s_failed path=%q err=%q", operation, path, result.Error)
+		return result
+	}
+	applog.Infof(applog.CategoryAction, "file_%s_succeeded path=%q", operation, path)
+	return result
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+)
+
+// GetActivityStack 获取每个屏幕的任务栈（任务 id、Activity、Intent、启动模式）
+func (a *App) GetActivityStack(deviceId string) (adb.ActivityStack, error) {
+	stack, err := adb.GetActivityStack(a.buildParam(deviceId))
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "activity_stack_failed device=%s err=%q", deviceId, err.Error())
+		return stack, err
+	}
+	applog.Infof(applog.CategoryAction, "activity_stack_loaded device=%s displays=%d resumed=%s", deviceId, len(stack.Displays), stack.ResumedActivity)
+	return stack, nil
+}
+
+// GetFragmentTree 获取 Activity 的 Fragment 树，activity 为空时取栈顶 Activity
+func (a *App) GetFragmentTree(deviceId string, activity string) (adb.FragmentTree, error) {
+	tree, err := adb.GetFragmentTree(a.buildParam(deviceId), activity)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "fragment_tree_failed device=%s activity=%s err=%q", deviceId, activity, err.Error())
+		return tree, err
+	}
+	applog.Infof(applog.CategoryAction, "fragment_tree_loaded device=%s activity=%s fragments=%d", deviceId, tree.Activity, len(tree.Fragments))
+	return tree, nil
+}
+
+// GetViewHierarchy 获取当前界面的控件树（uiautomator dump）
+func (a *App) GetViewHierarchy(deviceId string) (adb.ViewHierarchy, error) {
+	hierarchy, err := adb.GetViewHierarchy(a.buildParam(deviceId))
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "view_hierarchy_failed device=%s err=%q", deviceId, err.Error())
+		return hierarchy, err
+	}
+	applog.Infof(applog.CategoryAction, "view_hierarchy_loaded device=%s roots=%d", deviceId, len(hierarchy.Nodes))
+	return hierarchy, nil
+}
+
+// CaptureScreenshot 截取当前屏幕，返回 PNG data URL，用于叠加显示控件边框
+func (a *App) CaptureScreenshot(deviceId string) (string, error) {
+	image, err := adb.CaptureScreenshot(a.buildParam(deviceId))
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "inspector_screenshot_failed device=%s err=%q", deviceId, err.Error())
+		return "", err
+	}
+	return image, nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// SelectInstallFiles 选择安装包：一个 .apks/.xapk/.aab，或同一应用的多个 .apk
+func (a *App) SelectInstallFiles() ([]string, error) {
+	paths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择安装包",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Android 安装包 (*.apk, *.apks, *.xapk, *.aab)",
+				Pattern:     "*.apk;*.apks;*.xapk;*.aab",
+			},
+			{
+				DisplayName: "所有文件 (*.*)",
+				Pattern:     "*.*",
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("选择文件失败: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, errDialogCancelled
+	}
+	return paths, nil
+}
+
+// PlanInstall 解析安装包并按设备配置预选拆分包，前端确认或调整选择后调用 RunInstall
+func (a *App) PlanInstall(deviceId string, paths []string) (adb.InstallPlan, error) {
+	plan, err := adb.PlanInstall(a.buildParam(deviceId), paths)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "install_plan_failed device=%s paths=%q err=%q", deviceId, paths, err.Error())
+		return plan, err
+	}
+	selected := 0
+	for _, s := range plan.Splits {
+		if s.Selected {
+			selected++
+		}
+	}
+	applog.Infof(applog.CategoryAction, "install_planned device=%s source=%q format=%s splits=%d selected=%d obbs=%d abis=%q density=%d",
+		deviceId, plan.Source, plan.Format, len(plan.Splits), selected, len(plan.Obbs), plan.Device.Abis, plan.Device.Density)
+	return plan, nil
+}
+
+// RunInstall 安装计划中选中的 APK；keepPlan 为 true 时保留解压目录，便于失败后按建议修复再次安装
+func (a *App) RunInstall(deviceId string, plan adb.InstallPlan, options adb.InstallOptions, keepPlan bool) adb.InstallResult {
+	result := adb.RunInstall(a.buildParam(deviceId), plan, options)
+	if result.Success || !keepPlan {
+		adb.DiscardInstallPlan(plan)
+	}
+	logInstallResult(deviceId, plan.Source, result)
+	return result
+}
+
+// DiscardInstallPlan 放弃安装时清理解压目录
+func (a *App) DiscardInstallPlan(plan adb.InstallPlan) {
+	adb.DiscardInstallPlan(plan)
+}
+
+// 批量安装队列中单项的状态，完成状态沿用 adb.Transfer*
+const (
+	InstallItemPending    = "pending"
+	InstallItemInstalling = "installing"
+)
+
+// InstallQueueItem 批量安装队列中的一项：一个安装包安装到一台设备
+type InstallQueueItem struct {
+	Id       string            `json:"id"`
+	DeviceId string            `json:"deviceId"`
+	Path     string            `json:"path"`
+	Status   string            `json:"status"`
+	Result   adb.InstallResult `json:"result"`
+}
+
+// InstallQueueEvent install-queue-progress 事件，每项状态变化时推送一次，Done 表示整个队列结束
+type InstallQueueEvent struct {
+	QueueId string           `json:"queueId"`
+	Item    InstallQueueItem `json:"item"`
+	Done    bool             `json:"done"`
+}
+
+// InstallQueue 把多个安装包依次安装到多台设备，不同设备并行，同一设备按顺序逐个安装
+// 每个安装包单独解析，拆分包按设备配置自动选择；进度通过 install-queue-progress 事件推送，队列 id 随第一个事件返回给前端
+func (a *App) InstallQueue(deviceIds []string, paths []string, options adb.InstallOptions) ([]InstallQueueItem, error) {
+	if len(deviceIds) == 0 {
+		return nil, fmt.Errorf("请选择设备")
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("请选择安装包")
+	}
+
+	queueId := uuid.New().String()
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	a.installQueueMutex.Lock()
+	if a.installQueueCancels == nil {
+		a.installQueueCancels = make(map[string]context.CancelFunc)
+	}
+	a.installQueueCancels[queueId] = cancel
+	a.installQueueMutex.Unlock()
+	defer func() {
+		a.installQueueMutex.Lock()
+		delete(a.installQueueCancels, queueId)
+		a.installQueueMutex.Unlock()
+	}()
+
+	items := make([]InstallQueueItem, 0, len(deviceIds)*len(paths))
+	for _, deviceId := range deviceIds {
+		for _, p := range paths {
+			items = append(items, InstallQueueItem{
+				Id:       fmt.Sprintf("%d", len(items)),
+				DeviceId: deviceId,
+				Path:     p,
+				Status:   InstallItemPending,
+				Result:   adb.InstallResult{Installed: []string{}, Obbs: []string{}},
+			})
+		}
+	}
+	emit := func(item InstallQueueItem) {
+		runtime.EventsEmit(a.ctx, "install-queue-progress", InstallQueueEvent{QueueId: queueId, Item: item})
+	}
+	for _, item := range items {
+		emit(item)
+	}
+
+	applog.Infof(applog.CategoryAction, "install_queue_started queue=%s devices=%q files=%d", queueId, deviceIds, len(paths))
+
+	var wg sync.WaitGroup
+	for d := range deviceIds {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for i := start; i < start+len(paths); i++ {
+				item := &items[i]
+				if ctx.Err() != nil {
+					item.Status = adb.TransferCancelled
+					emit(*item)
+					continue
+				}
+				item.Status = InstallItemInstalling
+				emit(*item)
+				item.Result = a.installPackageFile(item.DeviceId, item.Path, options)
+				if item.Result.Success {
+					item.Status = adb.TransferCompleted
+				} else {
+					item.Status = adb.TransferFailed
+				}
+				emit(*item)
+			}
+		}(d * len(paths))
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, item := range items {
+		if item.Status != adb.TransferCompleted {
+			failed++
+		}
+	}
+	runtime.EventsEmit(a.ctx, "install-queue-progress", InstallQueueEvent{QueueId: queueId, Done: true})
+	applog.Infof(applog.CategoryAction, "install_queue_finished queue=%s total=%d failed=%d cancelled=%t", queueId, len(items), failed, ctx.Err() != nil)
+	return items, nil
+}
+
+// CancelInstallQueue 取消批量安装，正在安装的项会执行完，后续项标记为已取消
+func (a *App) CancelInstallQueue(queueId string) {
+	a.installQueueMutex.Lock()
+	cancel, ok := a.installQueueCancels[queueId]
+	a.installQueueMutex.Unlock()
+	if ok {
+		applog.Infof(applog.CategoryAction, "install_queue_cancel_requested queue=%s", queueId)
+		cancel()
+	}
+}
+
+// InstallPackageFile 按指定选项安装单个安装包，用于批量安装失败后按建议修复并重试
+func (a *App) InstallPackageFile(deviceId string, path string, options adb.InstallOptions) adb.InstallResult {
+	return a.installPackageFile(deviceId, path, options)
+}
+
+func (a *App) installPackageFile(deviceId string, path string, options adb.InstallOptions) adb.InstallResult {
+	param := a.buildParam(deviceId)
+	plan, err := adb.PlanInstall(param, []string{path})
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "install_plan_failed device=%s paths=%q err=%q", deviceId, path, err.Error())
+		return adb.InstallResult{Installed: []string{}, Obbs: []string{}, Output: fmt.Sprintf("解析安装包失败: %s", err.Error())}
+	}
+	defer adb.DiscardInstallPlan(plan)
+
+	result := adb.RunInstall(param, plan, options)
+	logInstallResult(deviceId, plan.Source, result)
+	return result
+}
+
+func logInstallResult(deviceId string, source string, result adb.InstallResult) {
+	if !result.Success {
+		code := ""
+		if result.Failure != nil {
+			code = result.Failure.Code
+		}
+		applog.Warnf(applog.CategoryAction, "install_failed device=%s source=%q code=%s output=%q", deviceId, source, code, result.Output)
+		return
+	}
+	applog.Infof(applog.CategoryAction, "install_succeeded device=%s source=%q installed=%q obbs=%d", deviceId, source, result.Installed, len(result.Obbs))
+}
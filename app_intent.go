@@ -0,0 +1,166 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/storage"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IntentPreset 保存的 Intent，按包名分组
+type IntentPreset struct {
+	Id        string         `json:"id"`
+	Name      string         `json:"name"`
+	Package   string         `json:"package"` // 为空表示不属于某个应用，如系统设置页
+	Spec      adb.IntentSpec `json:"spec"`
+	CreatedAt int64          `json:"createdAt"`
+	LastUsed  int64          `json:"lastUsed"` // Unix 毫秒，0 表示未使用过
+}
+
+// loadIntentPresets 调用方需持有 intentPresetMutex
+func (a *App) loadIntentPresets() ([]IntentPreset, error) {
+	if a.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+	presets := []IntentPreset{}
+	if a.store.Has(storage.KeyIntentPresets) {
+		if err := a.store.Get(storage.KeyIntentPresets, &presets); err != nil {
+			return nil, err
+		}
+	}
+	return presets, nil
+}
+
+// SendIntent 在 userId 下启动 Activity、发送广播或启动服务；presetId 不为空时记录预设的使用时间
+func (a *App) SendIntent(deviceId string, userId string, spec adb.IntentSpec, presetId string) (adb.IntentResult, error) {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return adb.IntentResult{}, err
+	}
+	result, err := adb.SendIntent(param, spec)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "intent_failed device=%s user=%s mode=%s cmd=%q err=%q", deviceId, userId, spec.Mode, result.Cmd, err.Error())
+		return result, err
+	}
+	applog.Infof(applog.CategoryAction, "intent_sent device=%s user=%s mode=%s cmd=%q total_time=%d", deviceId, userId, spec.Mode, result.Cmd, result.TotalTime)
+
+	if presetId != "" {
+		a.intentPresetMutex.Lock()
+		defer a.intentPresetMutex.Unlock()
+		presets, err := a.loadIntentPresets()
+		if err == nil {
+			if index := slices.IndexFunc(presets, func(p IntentPreset) bool { return p.Id == presetId }); index >= 0 {
+				presets[index].LastUsed = time.Now().UnixMilli()
+				err = a.store.Set(storage.KeyIntentPresets, presets)
+			}
+		}
+		if err != nil {
+			applog.Warnf(applog.CategoryAction, "intent_preset_touch_failed id=%s err=%q", presetId, err.Error())
+		}
+	}
+	return result, nil
+}
+
+// ListIntentComponents 列出应用中声明了 intent-filter 的 Activity、Receiver 与 Service，用于补全
+func (a *App) ListIntentComponents(deviceId string, userId string, packageName string) ([]adb.IntentComponent, error) {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return nil, err
+	}
+	components, err := adb.ListIntentComponents(param, packageName)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "intent_components_failed device=%s package=%s err=%q", deviceId, packageName, err.Error())
+		return nil, err
+	}
+	return components, nil
+}
+
+// GetIntentFlags 可选的 Intent flag 名称
+func (a *App) GetIntentFlags() []string {
+	names := make([]string, 0, len(adb.IntentFlags))
+	for name := range adb.IntentFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListIntentPresets 返回包名下的预设与不属于任何应用的预设，packageName 为空时返回全部；最近使用的在前
+func (a *App) ListIntentPresets(packageName string) ([]IntentPreset, error) {
+	a.intentPresetMutex.Lock()
+	defer a.intentPresetMutex.Unlock()
+	presets, err := a.loadIntentPresets()
+	if err != nil {
+		return nil, err
+	}
+	result := []IntentPreset{}
+	for _, p := range presets {
+		if packageName == "" || p.Package == "" || p.Package == packageName {
+			result = append(result, p)
+		}
+	}
+	slices.SortStableFunc(result, func(x, y IntentPreset) int {
+		if x.LastUsed != y.LastUsed {
+			return int(y.LastUsed - x.LastUsed)
+		}
+		return int(y.CreatedAt - x.CreatedAt)
+	})
+	return result, nil
+}
+
+// SaveIntentPreset 新增（Id 为空）或更新预设
+func (a *App) SaveIntentPreset(preset IntentPreset) (IntentPreset, error) {
+	preset.Name = strings.TrimSpace(preset.Name)
+	preset.Package = strings.TrimSpace(preset.Package)
+	if preset.Name == "" {
+		return preset, fmt.Errorf("预设名称不能为空")
+	}
+	if preset.Package != "" && !adb.IsValidPackageName(preset.Package) {
+		return preset, fmt.Errorf("无效的包名: %s", preset.Package)
+	}
+
+	a.intentPresetMutex.Lock()
+	defer a.intentPresetMutex.Unlock()
+	presets, err := a.loadIntentPresets()
+	if err != nil {
+		return preset, err
+	}
+	index := slices.IndexFunc(presets, func(p IntentPreset) bool { return p.Id == preset.Id })
+	if index < 0 {
+		preset.Id = uuid.New().String()
+		preset.CreatedAt = time.Now().UnixMilli()
+		preset.LastUsed = 0
+		presets = append(presets, preset)
+	} else {
+		preset.CreatedAt = presets[index].CreatedAt
+		preset.LastUsed = presets[index].LastUsed
+		presets[index] = preset
+	}
+	if err := a.store.Set(storage.KeyIntentPresets, presets); err != nil {
+		return preset, err
+	}
+	applog.Infof(applog.CategoryAction, "intent_preset_saved id=%s name=%q package=%s mode=%s", preset.Id, preset.Name, preset.Package, preset.Spec.Mode)
+	return preset, nil
+}
+
+// DeleteIntentPreset 删除预设
+func (a *App) DeleteIntentPreset(id string) error {
+	a.intentPresetMutex.Lock()
+	defer a.intentPresetMutex.Unlock()
+	presets, err := a.loadIntentPresets()
+	if err != nil {
+		return err
+	}
+	presets = slices.DeleteFunc(presets, func(p IntentPreset) bool { return p.Id == id })
+	if err := a.store.Set(storage.KeyIntentPresets, presets); err != nil {
+		return err
+	}
+	applog.Infof(applog.CategoryAction, "intent_preset_deleted id=%s", id)
+	return nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/aya"
+	"adb-tool-wails/storage"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 包差异的类型，版本与签名同时变化时记为签名变化
+const (
+	PackageDiffAdded      = "added"      // 只在右侧存在
+	PackageDiffRemoved    = "removed"    // 只在左侧存在
+	PackageDiffUpgraded   = "upgraded"   // 右侧 versionCode 更高
+	PackageDiffDowngraded = "downgraded" // 右侧 versionCode 更低
+	PackageDiffSignature  = "signature"  // 签名证书不同
+)
+
+// snapshotBatchSize 与应用列表相同，每批向 Aya 请求 50 个应用
+const snapshotBatchSize = 50
+
+// PackageSnapshotEntry 快照中一个应用的版本与签名
+type PackageSnapshotEntry struct {
+	Package     string   `json:"package"`
+	VersionCode int      `json:"versionCode"`
+	VersionName string   `json:"versionName"`
+	System      bool     `json:"system"`
+	Enabled     bool     `json:"enabled"`
+	Signatures  []string `json:"signatures"` // 证书 SHA-256，已排序
+}
+
+// PackageSnapshotMeta 快照的描述，列表中不包含应用明细
+type PackageSnapshotMeta struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	DeviceId   string `json:"deviceId"`
+	DeviceName string `json:"deviceName"`
+	UserId     string `json:"userId"`
+	Packages   int    `json:"packages"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+// PackageSnapshot 快照的元数据与全部应用
+type PackageSnapshot struct {
+	PackageSnapshotMeta
+	Entries []PackageSnapshotEntry `json:"entries"`
+}
+
+// PackageDiffSource 比较的一侧：SnapshotId 不为空时使用保存的快照，否则实时读取设备
+type PackageDiffSource struct {
+	SnapshotId string `json:"snapshotId"`
+	DeviceId   string `json:"deviceId"`
+	UserId     string `json:"userId"`
+}
+
+// PackageDiffEntry 一个有差异的应用，Left/Right 为空表示该侧没有安装
+type PackageDiffEntry struct {
+	Package string                `json:"package"`
+	Change  string                `json:"change"` // 见 PackageDiff* 常量
+	Left    *PackageSnapshotEntry `json:"left"`
+	Right   *PackageSnapshotEntry `json:"right"`
+}
+
+// PackageDiff 两侧的比较结果，只列出有差异的应用
+type PackageDiff struct {
+	Left      PackageSnapshotMeta `json:"left"`
+	Right     PackageSnapshotMeta `json:"right"`
+	Entries   []PackageDiffEntry  `json:"entries"`
+	Counts    map[string]int      `json:"counts"`
+	Identical int                 `json:"identical"` // 两侧版本与签名都相同的应用数
+}
+
+func packageSnapshotKey(id string) string {
+	return storage.KeyPackageSnapshots + ":" + id
+}
+
+// loadPackageSnapshotMetas 调用方需持有 packageSnapshotMutex
+func (a *App) loadPackageSnapshotMetas() ([]PackageSnapshotMeta, error) {
+	if a.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+	metas := []PackageSnapshotMeta{}
+	if a.store.Has(storage.KeyPackageSnapshots) {
+		if err := a.store.Get(storage.KeyPackageSnapshots, &metas); err != nil {
+			return nil, err
+		}
+	}
+	return metas, nil
+}
+
+// capturePackageSnapshot 通过 Aya 读取设备上全部应用的版本与签名，不保存
+func (a *App) capturePackageSnapshot(deviceId string, userId string) (PackageSnapshot, error) {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return PackageSnapshot{}, err
+	}
+	snapshot := PackageSnapshot{
+		PackageSnapshotMeta: PackageSnapshotMeta{
+			DeviceId:   deviceId,
+			DeviceName: adb.GetDeviceNameByDeviceId(a.adbPath, deviceId),
+			UserId:     userId,
+			CreatedAt:  time.Now().UnixMilli(),
+		},
+		Entries: []PackageSnapshotEntry{},
+	}
+
+	allPackagesRes := adb.GetAllPackages(param)
+	if allPackagesRes.Error != "" {
+		return snapshot, fmt.Errorf("读取应用列表失败: %s", allPackagesRes.Error)
+	}
+	packageNames := []string{}
+	for _, pkg := range strings.Split(allPackagesRes.Res, "\n") {
+		if pkg = strings.TrimSpace(pkg); pkg != "" {
+			packageNames = append(packageNames, pkg)
+		}
+	}
+
+	client := aya.NewClient(param)
+	if err := client.Connect(a.ayaDexPath); err != nil {
+		return snapshot, fmt.Errorf("连接 Aya 服务失败: %w", err)
+	}
+	defer client.Close()
+
+	// 任何一批失败都会让快照缺少应用，比较结果不可信，直接返回错误
+	for i := 0; i < len(packageNames); i += snapshotBatchSize {
+		batch := packageNames[i:min(i+snapshotBatchSize, len(packageNames))]
+		infos, err := client.GetPackageInfos(batch)
+		if err != nil {
+			return snapshot, fmt.Errorf("读取应用信息失败: %w", err)
+		}
+		// 旧版本 Aya 服务只查询主用户，指定用户时与应用列表一样用 dumpsys package 补齐
+		if userId != "" {
+			infos = fillMissingPackages(param, batch, infos)
+		}
+		// Aya 对查不到的应用只记录日志，缺少的应用会被当作已卸载
+		if len(infos) < len(batch) {
+			return snapshot, fmt.Errorf("读取应用信息失败: %s", strings.Join(missingPackages(batch, infos), ", "))
+		}
+		for _, info := range infos {
+			snapshot.Entries = append(snapshot.Entries, newPackageSnapshotEntry(info))
+		}
+	}
+	sort.Slice(snapshot.Entries, func(i, j int) bool { return snapshot.Entries[i].Package < snapshot.Entries[j].Package })
+	snapshot.Packages = len(snapshot.Entries)
+	return snapshot, nil
+}
+
+// missingPackages 返回 batch 中 Aya 没有返回信息的应用
+func missingPackages(batch []string, infos []aya.PackageInfo) []string {
+	found := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		found[info.PackageName] = true
+	}
+	missing := []string{}
+	for _, pkg := range batch {
+		if !found[pkg] {
+			missing = append(missing, pkg)
+		}
+	}
+	return missing
+}
+
+func newPackageSnapshotEntry(info aya.PackageInfo) PackageSnapshotEntry {
+	signatures := append([]string{}, info.SignatureSha256s...)
+	for i := range signatures {
+		signatures[i] = strings.ToLower(strings.ReplaceAll(signatures[i], ":", ""))
+	}
+	sort.Strings(signatures)
+	return PackageSnapshotEntry{
+		Package:     info.PackageName,
+		VersionCode: info.VersionCode,
+		VersionName: info.VersionName,
+		System:      info.System,
+		Enabled:     info.Enabled,
+		Signatures:  signatures,
+	}
+}
+
+// TakePackageSnapshot 保存设备在 userId 下已安装应用的版本与签名，name 为空时使用设备名与时间
+func (a *App) TakePackageSnapshot(deviceId string, userId string, name string) (PackageSnapshotMeta, error) {
+	snapshot, err := a.capturePackageSnapshot(deviceId, userId)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "package_snapshot_failed device=%s user=%s err=%q", deviceId, userId, err.Error())
+		return PackageSnapshotMeta{}, err
+	}
+	snapshot.Id = uuid.New().String()
+	snapshot.Name = strings.TrimSpace(name)
+	if snapshot.Name == "" {
+		snapshot.Name = fmt.Sprintf("%s %s", snapshot.DeviceName, time.UnixMilli(snapshot.CreatedAt).Format("2006-01-02 15:04"))
+	}
+
+	a.packageSnapshotMutex.Lock()
+	defer a.packageSnapshotMutex.Unlock()
+	metas, err := a.loadPackageSnapshotMetas()
+	if err != nil {
+		return PackageSnapshotMeta{}, err
+	}
+	if err := a.store.Set(packageSnapshotKey(snapshot.Id), snapshot); err != nil {
+		return PackageSnapshotMeta{}, err
+	}
+	metas = append(metas, snapshot.PackageSnapshotMeta)
+	if err := a.store.Set(storage.KeyPackageSnapshots, metas); err != nil {
+		_ = a.store.Delete(packageSnapshotKey(snapshot.Id))
+		return PackageSnapshotMeta{}, err
+	}
+	applog.Infof(applog.CategoryAction, "package_snapshot_saved id=%s device=%s user=%s packages=%d", snapshot.Id, deviceId, userId, snapshot.Packages)
+	return snapshot.PackageSnapshotMeta, nil
+}
+
+// ListPackageSnapshots 返回保存的快照，最新的在前
+func (a *App) ListPackageSnapshots() ([]PackageSnapshotMeta, error) {
+	a.packageSnapshotMutex.Lock()
+	defer a.packageSnapshotMutex.Unlock()
+	metas, err := a.loadPackageSnapshotMetas()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(metas, func(x, y PackageSnapshotMeta) int { return int(y.CreatedAt - x.CreatedAt) })
+	return metas, nil
+}
+
+// DeletePackageSnapshot 删除快照
+func (a *App) DeletePackageSnapshot(id string) error {
+	a.packageSnapshotMutex.Lock()
+	defer a.packageSnapshotMutex.Unlock()
+	metas, err := a.loadPackageSnapshotMetas()
+	if err != nil {
+		return err
+	}
+	metas = slices.DeleteFunc(metas, func(m PackageSnapshotMeta) bool { return m.Id == id })
+	if err := a.store.Set(storage.KeyPackageSnapshots, metas); err != nil {
+		return err
+	}
+	_ = a.store.Delete(packageSnapshotKey(id))
+	applog.Infof(applog.CategoryAction, "package_snapshot_deleted id=%s", id)
+	return nil
+}
+
+// loadDiffSource 读取保存的快照或实时读取设备
+func (a *App) loadDiffSource(source PackageDiffSource) (PackageSnapshot, error) {
+	if source.SnapshotId == "" {
+		if source.DeviceId == "" {
+			return PackageSnapshot{}, fmt.Errorf("请选择设备或快照")
+		}
+		return a.capturePackageSnapshot(source.DeviceId, source.UserId)
+	}
+	a.packageSnapshotMutex.Lock()
+	defer a.packageSnapshotMutex.Unlock()
+	var snapshot PackageSnapshot
+	if err := a.store.GetOrError(packageSnapshotKey(source.SnapshotId), &snapshot); err != nil {
+		return snapshot, fmt.Errorf("快照不存在")
+	}
+	return snapshot, nil
+}
+
+// ComparePackages 比较两台设备或快照上安装的应用，列出新增、移除、升级、降级与签名变化的应用
+func (a *App) ComparePackages(left PackageDiffSource, right PackageDiffSource) (PackageDiff, error) {
+	l, err := a.loadDiffSource(left)
+	if err != nil {
+		return PackageDiff{}, err
+	}
+	r, err := a.loadDiffSource(right)
+	if err != nil {
+		return PackageDiff{}, err
+	}
+	diff := diffPackageSnapshots(l, r)
+	applog.Infof(applog.CategoryAction, "package_diff left=%q right=%q identical=%d changes=%d",
+		l.DeviceName+l.Name, r.DeviceName+r.Name, diff.Identical, len(diff.Entries))
+	return diff, nil
+}
+
+func diffPackageSnapshots(left PackageSnapshot, right PackageSnapshot) PackageDiff {
+	diff := PackageDiff{
+		Left:    left.PackageSnapshotMeta,
+		Right:   right.PackageSnapshotMeta,
+		Entries: []PackageDiffEntry{},
+		Counts:  map[string]int{},
+	}
+	rightByName := make(map[string]*PackageSnapshotEntry, len(right.Entries))
+	for i := range right.Entries {
+		rightByName[right.Entries[i].Package] = &right.Entries[i]
+	}
+
+	for i := range left.Entries {
+		l := &left.Entries[i]
+		r, ok := rightByName[l.Package]
+		delete(rightByName, l.Package)
+		change := ""
+		switch {
+		case !ok:
+			change = PackageDiffRemoved
+		// 任一侧没有读到签名时不比较签名，避免误报
+		case len(l.Signatures) > 0 && len(r.Signatures) > 0 && !slices.Equal(l.Signatures, r.Signatures):
+			change = PackageDiffSignature
+		case r.VersionCode > l.VersionCode:
+			change = PackageDiffUpgraded
+		case r.VersionCode < l.VersionCode:
+			change = PackageDiffDowngraded
+		}
+		if change == "" {
+			diff.Identical++
+			continue
+		}
+		diff.Entries = append(diff.Entries, PackageDiffEntry{Package: l.Package, Change: change, Left: l, Right: r})
+		diff.Counts[change]++
+	}
+	for name, r := range rightByName {
+		diff.Entries = append(diff.Entries, PackageDiffEntry{Package: name, Change: PackageDiffAdded, Right: r})
+		diff.Counts[PackageDiffAdded]++
+	}
+	sort.Slice(diff.Entries, func(i, j int) bool { return diff.Entries[i].Package < diff.Entries[j].Package })
+	return diff
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffPackageSnapshots(t *testing.T) {
+	left := PackageSnapshot{Entries: []PackageSnapshotEntry{
+		{Package: "com.example.same", VersionCode: 10, Signatures: []string{"aa"}},
+		{Package: "com.example.removed", VersionCode: 1},
+		{Package: "com.example.upgraded", VersionCode: 10, Signatures: []string{"aa"}},
+		{Package: "com.example.downgraded", VersionCode: 10, Signatures: []string{"aa"}},
+		// 版本与签名同时变化时记为签名变化
+		{Package: "com.example.resigned", VersionCode: 10, Signatures: []string{"aa"}},
+		// dumpsys 补齐的应用没有签名，只比较版本
+		{Package: "com.example.nosig", VersionCode: 10, Signatures: []string{}},
+	}}
+	right := PackageSnapshot{Entries: []PackageSnapshotEntry{
+		{Package: "com.example.added", VersionCode: 1},
+		{Package: "com.example.same", VersionCode: 10, Signatures: []string{"aa"}},
+		{Package: "com.example.upgraded", VersionCode: 11, Signatures: []string{"aa"}},
+		{Package: "com.example.downgraded", VersionCode: 9, Signatures: []string{"aa"}},
+		{Package: "com.example.resigned", VersionCode: 11, Signatures: []string{"bb"}},
+		{Package: "com.example.nosig", VersionCode: 10, Signatures: []string{"aa"}},
+	}}
+
+	diff := diffPackageSnapshots(left, right)
+	got := map[string]string{}
+	for _, e := range diff.Entries {
+		got[e.Package] = e.Change
+		if (e.Left == nil) != (e.Change == PackageDiffAdded) || (e.Right == nil) != (e.Change == PackageDiffRemoved) {
+			t.Errorf("%s: left %v right %v", e.Package, e.Left, e.Right)
+		}
+	}
+	want := map[string]string{
+		"com.example.added":      PackageDiffAdded,
+		"com.example.removed":    PackageDiffRemoved,
+		"com.example.upgraded":   PackageDiffUpgraded,
+		"com.example.downgraded": PackageDiffDowngraded,
+		"com.example.resigned":   PackageDiffSignature,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+	if diff.Identical != 2 {
+		t.Errorf("identical = %d, want 2", diff.Identical)
+	}
+	for change, count := range map[string]int{PackageDiffAdded: 1, PackageDiffRemoved: 1, PackageDiffUpgraded: 1, PackageDiffDowngraded: 1, PackageDiffSignature: 1} {
+		if diff.Counts[change] != count {
+			t.Errorf("counts[%s] = %d, want %d", change, diff.Counts[change], count)
+		}
+	}
+	for i := 1; i < len(diff.Entries); i++ {
+		if diff.Entries[i-1].Package > diff.Entries[i].Package {
+			t.Errorf("entries not sorted: %s before %s", diff.Entries[i-1].Package, diff.Entries[i].Package)
+		}
+	}
+}
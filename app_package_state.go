@@ -0,0 +1,168 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/storage"
+	"adb-tool-wails/types"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// packageHistoryLimit 最多保留的操作记录数，超出时丢弃最早的
+const packageHistoryLimit = 200
+
+// PackageStateRecord 一次应用状态修改，用于撤销
+type PackageStateRecord struct {
+	Id       string `json:"id"`
+	DeviceId string `json:"deviceId"`
+	UserId   string `json:"userId"` // 操作时实际的用户 id，旧记录为空时撤销作用于当前用户
+	Package  string `json:"package"`
+	Op       string `json:"op"` // 见 adb.PackageOp* 常量
+	Forced   bool   `json:"forced"`
+	Time     int64  `json:"time"`     // Unix 毫秒
+	UndoneAt int64  `json:"undoneAt"` // 0 表示未撤销
+}
+
+// loadPackageHistory 调用方需持有 packageHistoryMutex
+func (a *App) loadPackageHistory() ([]PackageStateRecord, error) {
+	if a.store == nil {
+		return nil, fmt.Errorf("storage is not initialized")
+	}
+	records := []PackageStateRecord{}
+	if a.store.Has(storage.KeyPackageHistory) {
+		if err := a.store.Get(storage.KeyPackageHistory, &records); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// GetProtectedPackages 默认禁止停用、挂起或卸载的系统应用
+func (a *App) GetProtectedPackages() []adb.ProtectedPackage {
+	return adb.ProtectedPackages()
+}
+
+// SetPackageState 在 userId 下停用、启用、挂起、取消挂起、卸载或重新安装应用，成功后记录到操作历史
+// userId 为空时使用当前前台用户，并记录其 id，之后切换用户再撤销仍作用于原来的用户
+// 受保护的系统应用只有 force 时才允许停用、挂起或卸载
+func (a *App) SetPackageState(deviceId string, userId string, packageName string, op string, force bool) types.ExecResult {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return types.NewExecResultError(op, err)
+	}
+	if userId == "" {
+		userId = adb.CurrentUserId(param)
+		param.UserId = userId
+	}
+	if reason := adb.PackageProtection(packageName); reason != "" && adb.IsRestrictivePackageOp(op) && !force {
+		applog.Warnf(applog.CategoryAction, "package_state_blocked device=%s package=%s op=%s reason=%q", deviceId, packageName, op, reason)
+		return types.NewExecResultErrorString(op, fmt.Sprintf("%s 是受保护的系统应用（%s），默认不允许此操作", packageName, reason))
+	}
+
+	result := adb.SetPackageState(param, packageName, op)
+	if result.Error != "" {
+		applog.Warnf(applog.CategoryAction, "package_state_failed device=%s user=%s package=%s op=%s err=%q", deviceId, userId, packageName, op, result.Error)
+		return result
+	}
+	applog.Infof(applog.CategoryAction, "package_state_changed device=%s user=%s package=%s op=%s forced=%t", deviceId, userId, packageName, op, force)
+
+	record := PackageStateRecord{
+		Id:       uuid.New().String(),
+		DeviceId: deviceId,
+		UserId:   userId,
+		Package:  packageName,
+		Op:       op,
+		Forced:   force,
+		Time:     time.Now().UnixMilli(),
+	}
+	a.packageHistoryMutex.Lock()
+	defer a.packageHistoryMutex.Unlock()
+	records, err := a.loadPackageHistory()
+	if err == nil {
+		records = append([]PackageStateRecord{record}, records...)
+		if len(records) > packageHistoryLimit {
+			records = records[:packageHistoryLimit]
+		}
+		err = a.store.Set(storage.KeyPackageHistory, records)
+	}
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "package_history_save_failed package=%s op=%s err=%q", packageName, op, err.Error())
+	}
+	return result
+}
+
+// GetPackageHistory 返回应用状态操作记录，最新的在前；deviceId 为空时返回所有设备的记录
+func (a *App) GetPackageHistory(deviceId string) ([]PackageStateRecord, error) {
+	a.packageHistoryMutex.Lock()
+	defer a.packageHistoryMutex.Unlock()
+	records, err := a.loadPackageHistory()
+	if err != nil || deviceId == "" {
+		return records, err
+	}
+	filtered := []PackageStateRecord{}
+	for _, r := range records {
+		if r.DeviceId == deviceId {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// UndoPackageState 在记录所在的设备上执行相反的操作，撤销本身不再产生新记录
+func (a *App) UndoPackageState(recordId string) types.ExecResult {
+	a.packageHistoryMutex.Lock()
+	defer a.packageHistoryMutex.Unlock()
+	records, err := a.loadPackageHistory()
+	if err != nil {
+		return types.NewExecResultError("undo_package_state", err)
+	}
+	index := -1
+	for i, r := range records {
+		if r.Id == recordId {
+			index = i
+		}
+	}
+	if index < 0 {
+		return types.NewExecResultErrorString("undo_package_state", "操作记录不存在")
+	}
+	record := records[index]
+	if record.UndoneAt != 0 {
+		return types.NewExecResultErrorString("undo_package_state", "该操作已撤销")
+	}
+	inverse, ok := adb.InversePackageOp(record.Op)
+	if !ok {
+		return types.NewExecResultErrorString("undo_package_state", fmt.Sprintf("操作 %s 无法撤销", record.Op))
+	}
+	// 撤销启用、取消挂起或重新安装会让应用不可用，同样受保护列表限制；撤销时不能再确认，只有原操作是强制执行的才允许
+	if reason := adb.PackageProtection(record.Package); reason != "" && adb.IsRestrictivePackageOp(inverse) && !record.Forced {
+		applog.Warnf(applog.CategoryAction, "package_state_undo_blocked device=%s package=%s op=%s reason=%q", record.DeviceId, record.Package, inverse, reason)
+		return types.NewExecResultErrorString("undo_package_state", fmt.Sprintf("%s 是受保护的系统应用（%s），撤销需要执行 %s，默认不允许此操作", record.Package, reason, inverse))
+	}
+
+	param := a.buildParam(record.DeviceId)
+	param.UserId = record.UserId
+	result := adb.SetPackageState(param, record.Package, inverse)
+	if result.Error != "" {
+		applog.Warnf(applog.CategoryAction, "package_state_undo_failed device=%s package=%s op=%s err=%q", record.DeviceId, record.Package, inverse, result.Error)
+		return result
+	}
+	records[index].UndoneAt = time.Now().UnixMilli()
+	if err := a.store.Set(storage.KeyPackageHistory, records); err != nil {
+		applog.Warnf(applog.CategoryAction, "package_history_save_failed package=%s op=%s err=%q", record.Package, inverse, err.Error())
+	}
+	applog.Infof(applog.CategoryAction, "package_state_undone device=%s package=%s op=%s record=%s", record.DeviceId, record.Package, inverse, recordId)
+	return result
+}
+
+// ClearPackageHistory 清空操作记录
+func (a *App) ClearPackageHistory() error {
+	a.packageHistoryMutex.Lock()
+	defer a.packageHistoryMutex.Unlock()
+	if a.store == nil {
+		return fmt.Errorf("storage is not initialized")
+	}
+	return a.store.Delete(storage.KeyPackageHistory)
+}
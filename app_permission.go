@@ -0,0 +1,72 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/types"
+)
+
+// GetAppPermissions 获取应用的权限列表（包含授权状态、标记和 appops 模式），userId 为空时读取当前用户
+func (a *App) GetAppPermissions(deviceId string, userId string, packageName string) (adb.AppPermissionInfo, error) {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return adb.AppPermissionInfo{}, err
+	}
+	param.PackageName = packageName
+	info, err := adb.GetAppPermissions(param)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "permission_list_failed device=%s user=%s package=%s err=%q", deviceId, userId, packageName, err.Error())
+		return info, err
+	}
+	applog.Infof(applog.CategoryAction, "permission_list_loaded device=%s user=%s package=%s permissions=%d app_ops=%d", deviceId, info.UserId, packageName, len(info.Permissions), len(info.AppOps))
+	return info, nil
+}
+
+// GrantAppPermission 授予单个权限
+func (a *App) GrantAppPermission(deviceId string, userId string, packageName string, permission string) types.ExecResult {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return types.NewExecResultError("grant", err)
+	}
+	param.PackageName = packageName
+	return a.logPermissionResult("grant", param, permission, adb.GrantAppPermission(param, permission))
+}
+
+// RevokeAppPermission 撤销单个权限
+func (a *App) RevokeAppPermission(deviceId string, userId string, packageName string, permission string) types.ExecResult {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return types.NewExecResultError("revoke", err)
+	}
+	param.PackageName = packageName
+	return a.logPermissionResult("revoke", param, permission, adb.RevokeAppPermission(param, permission))
+}
+
+// ResetAppPermission 重置单个权限为未询问状态
+func (a *App) ResetAppPermission(deviceId string, userId string, packageName string, permission string) types.ExecResult {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return types.NewExecResultError("reset", err)
+	}
+	param.PackageName = packageName
+	return a.logPermissionResult("reset", param, permission, adb.ResetAppPermission(param, permission))
+}
+
+// SetAppOp 设置应用的 appops 模式（allow/ignore/deny/default/foreground）
+func (a *App) SetAppOp(deviceId string, userId string, packageName string, op string, mode string) types.ExecResult {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return types.NewExecResultError("appops set", err)
+	}
+	param.PackageName = packageName
+	return a.logPermissionResult("appops_"+mode, param, op, adb.SetAppOp(param, op, mode))
+}
+
+func (a *App) logPermissionResult(operation string, param adb.ExecuteParams, permission string, result types.ExecResult) types.ExecResult {
+	if result.Error != "" {
+		applog.Warnf(applog.CategoryAction, "permission_%s_failed user=%s package=%s permission=%s err=%q", operation, param.UserId, param.PackageName, permission, result.Error)
+		return result
+	}
+	applog.Infof(applog.CategoryAction, "permission_%s_succeeded user=%s package=%s permission=%s", operation, param.UserId, param.PackageName, permission)
+	return result
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"fmt"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ResolvePackageAccess 检查能否以应用身份访问其私有目录，后续 *As 调用传回的结果只取包名，访问方式在后端重新检查
+func (a *App) ResolvePackageAccess(deviceId string, packageName string) (adb.PackageAccess, error) {
+	access, err := adb.ResolvePackageAccess(a.buildParam(deviceId), packageName)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "package_access_failed device=%s package=%s err=%q", deviceId, packageName, err.Error())
+		return access, err
+	}
+	applog.Infof(applog.CategoryAction, "package_access_resolved device=%s package=%s mode=%s", deviceId, packageName, access.Mode)
+	return access, nil
+}
+
+// reresolveAccess 只采用前端传回的包名，访问方式与数据目录在后端重新检查，不信任前端传来的 Mode、DataDir
+func (a *App) reresolveAccess(param adb.ExecuteParams, access adb.PackageAccess) (adb.PackageAccess, error) {
+	resolved, err := adb.ResolvePackageAccess(param, access.Package)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "package_access_failed device=%s package=%s err=%q", param.DeviceId, access.Package, err.Error())
+	}
+	return resolved, err
+}
+
+// ListDirectoryAs 以应用身份列出目录
+func (a *App) ListDirectoryAs(deviceId string, access adb.PackageAccess, path string) ([]adb.RemoteFileEntry, error) {
+	param := a.buildParam(deviceId)
+	access, err := a.reresolveAccess(param, access)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := adb.ListDirectoryAs(param, access, path)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "list_directory_failed device=%s package=%s mode=%s path=%s err=%q", deviceId, access.Package, access.Mode, path, err.Error())
+		return nil, err
+	}
+	return entries, nil
+}
+
+// PreviewRemoteFileAs 以应用身份分段预览文件
+func (a *App) PreviewRemoteFileAs(deviceId string, access adb.PackageAccess, path string, offset int64, maxBytes int64, mode string) (adb.FilePreview, error) {
+	param := a.buildParam(deviceId)
+	access, err := a.reresolveAccess(param, access)
+	if err != nil {
+		return adb.FilePreview{}, err
+	}
+	preview, err := adb.ReadRemoteFileAs(param, access, path, offset, maxBytes, mode)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "file_preview_failed package=%s path=%q offset=%d err=%q", access.Package, path, offset, err.Error())
+		return preview, err
+	}
+	return preview, nil
+}
+
+// DownloadFilesAs 以应用身份复制到中转目录后下载到选择的本地目录
+func (a *App) DownloadFilesAs(deviceId string, access adb.PackageAccess, remotePaths []string, conflictPolicy string) (adb.TransferResult, error) {
+	localDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择保存位置",
+	})
+	if err != nil {
+		return adb.TransferResult{}, fmt.Errorf("选择保存路径失败: %w", err)
+	}
+	if localDir == "" {
+		return adb.TransferResult{}, errDialogCancelled
+	}
+
+	param := a.buildParam(deviceId)
+	access, err = a.reresolveAccess(param, access)
+	if err != nil {
+		return adb.TransferResult{}, err
+	}
+	staging, staged, err := adb.StageDownloadAs(param, access, remotePaths)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "download_stage_failed device=%s package=%s err=%q", deviceId, access.Package, err.Error())
+		return adb.TransferResult{}, err
+	}
+	defer adb.RemoveStaging(param, staging)
+
+	plan, err := adb.PlanDownload(param, staged, localDir, conflictPolicy)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "download_plan_failed device=%s paths=%d err=%q", deviceId, len(remotePaths), err.Error())
+		return adb.TransferResult{}, err
+	}
+	return a.runTransfer(param, plan), nil
+}
+
+// UploadFilesAs 选择本地文件，push 到中转目录后以应用身份写入 remoteDir
+func (a *App) UploadFilesAs(deviceId string, access adb.PackageAccess, remoteDir string, conflictPolicy string) (adb.TransferResult, error) {
+	localPaths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择要上传的文件",
+	})
+	if err != nil {
+		return adb.TransferResult{}, fmt.Errorf("选择文件失败: %w", err)
+	}
+	if len(localPaths) == 0 {
+		return adb.TransferResult{}, errDialogCancelled
+	}
+
+	param := a.buildParam(deviceId)
+	access, err = a.reresolveAccess(param, access)
+	if err != nil {
+		return adb.TransferResult{}, err
+	}
+	staging, err := adb.PrepareUploadStaging(param)
+	if err != nil {
+		return adb.TransferResult{}, err
+	}
+	defer adb.RemoveStaging(param, staging)
+
+	// 中转目录是新建的空目录，冲突策略在写入 remoteDir 时处理
+	plan, err := adb.PlanUpload(param, localPaths, staging, adb.ConflictOverwrite)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "upload_plan_failed device=%s remote_dir=%s err=%q", deviceId, remoteDir, err.Error())
+		return adb.TransferResult{}, err
+	}
+	result := a.runTransfer(param, plan)
+	if result.Status != adb.TransferCompleted || result.Failed > 0 {
+		return result, nil
+	}
+
+	names := make([]string, 0, len(localPaths))
+	for _, p := range localPaths {
+		names = append(names, filepath.Base(p))
+	}
+	written, skipped, err := adb.CommitUploadAs(param, access, staging, names, remoteDir, conflictPolicy)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "upload_commit_failed device=%s package=%s remote_dir=%s err=%q", deviceId, access.Package, remoteDir, err.Error())
+		return result, err
+	}
+	result.Transferred = len(written)
+	result.Skipped += skipped
+	applog.Infof(applog.CategoryAction, "upload_commit_succeeded device=%s package=%s mode=%s remote_dir=%s files=%d skipped=%d",
+		deviceId, access.Package, access.Mode, remoteDir, len(written), skipped)
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// searchFlushInterval 搜索结果攒批推送的间隔，避免每个匹配一个事件
+const searchFlushInterval = 300 * time.Millisecond
+
+// RemoteSearchEvent file-search-progress 事件，Matches 为本批新增的结果
+type RemoteSearchEvent struct {
+	TaskId  string            `json:"taskId"`
+	Status  string            `json:"status"`
+	Matches []adb.SearchMatch `json:"matches"`
+	Count   int               `json:"count"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// SearchRemoteFiles 搜索设备文件，结果通过 file-search-progress 事件分批推送，任务 id 随第一个事件返回给前端
+// options.InBookmarks 为 true 时同时搜索该设备可见的收藏目录
+func (a *App) SearchRemoteFiles(deviceId string, options adb.SearchOptions) (adb.SearchSummary, error) {
+	if options.InBookmarks {
+		options.Roots = append(options.Roots, a.bookmarkPaths(deviceId)...)
+	}
+
+	taskId := uuid.New().String()
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	a.searchMutex.Lock()
+	if a.searchCancels == nil {
+		a.searchCancels = make(map[string]context.CancelFunc)
+	}
+	a.searchCancels[taskId] = cancel
+	a.searchMutex.Unlock()
+	defer func() {
+		a.searchMutex.Lock()
+		delete(a.searchCancels, taskId)
+		a.searchMutex.Unlock()
+	}()
+
+	applog.Infof(applog.CategoryAction, "file_search_started task=%s device=%s roots=%q name=%q content=%t type=%s",
+		taskId, deviceId, options.Roots, options.Name, options.Content != "", options.Type)
+
+	var mu sync.Mutex
+	var pending []adb.SearchMatch
+	count := 0
+	emit := func(status string, errMsg string) {
+		mu.Lock()
+		event := RemoteSearchEvent{TaskId: taskId, Status: status, Matches: pending, Count: count, Error: errMsg}
+		pending = nil
+		mu.Unlock()
+		if event.Matches == nil {
+			event.Matches = []adb.SearchMatch{}
+		}
+		runtime.EventsEmit(a.ctx, "file-search-progress", event)
+	}
+	emit(adb.TransferRunning, "")
+
+	done := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ticker := time.NewTicker(searchFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				hasPending := len(pending) > 0
+				mu.Unlock()
+				if hasPending {
+					emit(adb.TransferRunning, "")
+				}
+			}
+		}
+	}()
+
+	summary, err := adb.SearchRemoteFiles(ctx, a.buildParam(deviceId), options, func(match adb.SearchMatch) {
+		mu.Lock()
+		pending = append(pending, match)
+		count++
+		mu.Unlock()
+	})
+	close(done)
+	<-flushed
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		emit(adb.TransferCancelled, "")
+		applog.Infof(applog.CategoryAction, "file_search_cancelled task=%s matches=%d", taskId, summary.Matches)
+		return summary, nil
+	case err != nil:
+		emit(adb.TransferFailed, err.Error())
+		applog.Warnf(applog.CategoryAction, "file_search_failed task=%s err=%q", taskId, err.Error())
+		return summary, err
+	}
+	emit(adb.TransferCompleted, "")
+	applog.Infof(applog.CategoryAction, "file_search_finished task=%s matches=%d truncated=%t errors=%d", taskId, summary.Matches, summary.Truncated, len(summary.Errors))
+	return summary, nil
+}
+
+// CancelRemoteSearch 取消正在进行的搜索
+func (a *App) CancelRemoteSearch(taskId string) {
+	a.searchMutex.Lock()
+	cancel, ok := a.searchCancels[taskId]
+	a.searchMutex.Unlock()
+	if ok {
+		applog.Infof(applog.CategoryAction, "file_search_cancel_requested task=%s", taskId)
+		cancel()
+	}
+}
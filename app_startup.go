@@ -0,0 +1,71 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// StartupMeasureEvent startup-measure-progress 事件：开始时推送一次用于返回任务 id，之后每完成一次启动推送一次 Run
+type StartupMeasureEvent struct {
+	TaskId string          `json:"taskId"`
+	Run    *adb.StartupRun `json:"run"`
+}
+
+// MeasureStartup 在 userId 下重复启动应用并统计耗时，每次结果通过 startup-measure-progress 事件推送，任务 id 随第一个事件返回给前端
+// 取消时返回已完成的部分，不视为错误
+func (a *App) MeasureStartup(deviceId string, userId string, packageName string, options adb.StartupOptions) (adb.StartupReport, error) {
+	param, err := a.buildUserParam(deviceId, userId)
+	if err != nil {
+		return adb.StartupReport{}, err
+	}
+
+	taskId := uuid.New().String()
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	a.startupMutex.Lock()
+	if a.startupCancels == nil {
+		a.startupCancels = make(map[string]context.CancelFunc)
+	}
+	a.startupCancels[taskId] = cancel
+	a.startupMutex.Unlock()
+	defer func() {
+		a.startupMutex.Lock()
+		delete(a.startupCancels, taskId)
+		a.startupMutex.Unlock()
+	}()
+
+	applog.Infof(applog.CategoryAction, "startup_measure_started task=%s device=%s user=%s package=%s mode=%s runs=%d drop_caches=%t",
+		taskId, deviceId, userId, packageName, options.Mode, options.Runs, options.DropCaches)
+	runtime.EventsEmit(a.ctx, "startup-measure-progress", StartupMeasureEvent{TaskId: taskId})
+	report, err := adb.MeasureStartup(ctx, param, packageName, options, func(run adb.StartupRun) {
+		runtime.EventsEmit(a.ctx, "startup-measure-progress", StartupMeasureEvent{TaskId: taskId, Run: &run})
+	})
+	switch {
+	case errors.Is(err, context.Canceled):
+		applog.Infof(applog.CategoryAction, "startup_measure_cancelled task=%s runs=%d", taskId, len(report.Runs))
+		return report, nil
+	case err != nil:
+		applog.Warnf(applog.CategoryAction, "startup_measure_failed task=%s package=%s err=%q", taskId, packageName, err.Error())
+		return report, err
+	}
+	applog.Infof(applog.CategoryAction, "startup_measure_finished task=%s package=%s mode=%s ok=%d median=%d p90=%d",
+		taskId, packageName, options.Mode, report.TotalTime.Count, report.TotalTime.Median, report.TotalTime.P90)
+	return report, nil
+}
+
+// CancelStartupMeasure 取消正在进行的启动耗时测量
+func (a *App) CancelStartupMeasure(taskId string) {
+	a.startupMutex.Lock()
+	cancel, ok := a.startupCancels[taskId]
+	a.startupMutex.Unlock()
+	if ok {
+		applog.Infof(applog.CategoryAction, "startup_measure_cancel_requested task=%s", taskId)
+		cancel()
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// SyncResult 一次同步的结果，上传与下载分别统计
+type SyncResult struct {
+	Upload   adb.TransferResult `json:"upload"`
+	Download adb.TransferResult `json:"download"`
+	Deleted  int                `json:"deleted"`
+	Errors   []string           `json:"errors"`
+}
+
+// SelectSyncLocalDirectory 选择同步用的本地目录
+func (a *App) SelectSyncLocalDirectory() (string, error) {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择要同步的本地目录",
+	})
+	if err != nil {
+		return "", fmt.Errorf("选择目录失败: %w", err)
+	}
+	if dir == "" {
+		return "", errDialogCancelled
+	}
+	return dir, nil
+}
+
+// CompareSyncDirectories 比较本地目录与设备目录，返回每个文件的同步动作
+func (a *App) CompareSyncDirectories(deviceId string, localDir string, remoteDir string, options adb.SyncOptions) (adb.SyncDiff, error) {
+	diff, err := adb.CompareDirectories(a.buildParam(deviceId), localDir, remoteDir, options)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "sync_compare_failed device=%s local=%q remote=%q err=%q", deviceId, localDir, remoteDir, err.Error())
+		return diff, err
+	}
+	applog.Infof(applog.CategoryAction, "sync_compared device=%s local=%q remote=%q direction=%s compare=%s push=%d pull=%d delete_remote=%d delete_local=%d same=%d conflict=%d",
+		deviceId, localDir, remoteDir, options.Direction, options.Compare, diff.Push, diff.Pull, diff.DeleteRemote, diff.DeleteLocal, diff.Same, diff.Conflict)
+	return diff, nil
+}
+
+// ApplySync 按比较结果执行上传、下载与删除，进度通过 file-transfer-progress 事件推送
+func (a *App) ApplySync(deviceId string, diff adb.SyncDiff) (SyncResult, error) {
+	param := a.buildParam(deviceId)
+	result := SyncResult{Errors: []string{}}
+
+	// 比较结果来自前端，执行前重新检查目录与每个条目的路径，动作只按 Items 执行，不使用前端的计数
+	if err := adb.CheckSyncDiff(param, diff); err != nil {
+		applog.Warnf(applog.CategoryAction, "sync_rejected device=%s local=%q remote=%q err=%q", deviceId, diff.LocalDir, diff.RemoteDir, err.Error())
+		return result, err
+	}
+
+	upload, download := adb.SyncTransferPlans(diff)
+	if len(upload.Files) > 0 {
+		result.Upload = a.runTransfer(param, upload)
+		result.Errors = append(result.Errors, result.Upload.Errors...)
+		if result.Upload.Status == adb.TransferCancelled {
+			return result, nil
+		}
+	}
+	if len(download.Files) > 0 {
+		result.Download = a.runTransfer(param, download)
+		result.Errors = append(result.Errors, result.Download.Errors...)
+		if result.Download.Status == adb.TransferCancelled {
+			return result, nil
+		}
+	}
+
+	deleted, errs := adb.DeleteSyncExtras(a.ctx, param, diff)
+	result.Deleted = len(deleted)
+	result.Errors = append(result.Errors, errs...)
+	for _, p := range deleted {
+		applog.Infof(applog.CategoryAction, "file_delete_audit device=%s mode=sync path=%q", deviceId, p)
+	}
+
+	applog.Infof(applog.CategoryAction, "sync_applied device=%s local=%q remote=%q uploaded=%d downloaded=%d deleted=%d errors=%d",
+		deviceId, diff.LocalDir, diff.RemoteDir, result.Upload.Transferred, result.Download.Transferred, result.Deleted, len(result.Errors))
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// errDialogCancelled 用户在文件选择对话框中取消
+var errDialogCancelled = errors.New("已取消")
+
+// UploadFiles 选择多个本地文件上传到设备目录，conflictPolicy 为 skip/overwrite/rename
+func (a *App) UploadFiles(deviceId string, remoteDir string, conflictPolicy string) (adb.TransferResult, error) {
+	localPaths, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择要上传的文件",
+	})
+	if err != nil {
+		return adb.TransferResult{}, fmt.Errorf("选择文件失败: %w", err)
+	}
+	if len(localPaths) == 0 {
+		return adb.TransferResult{}, errDialogCancelled
+	}
+	return a.UploadPaths(deviceId, localPaths, remoteDir, conflictPolicy)
+}
+
+// UploadDirectory 选择本地目录并递归上传到设备目录
+func (a *App) UploadDirectory(deviceId string, remoteDir string, conflictPolicy string) (adb.TransferResult, error) {
+	localDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择要上传的文件夹",
+	})
+	if err != nil {
+		return adb.TransferResult{}, fmt.Errorf("选择文件夹失败: %w", err)
+	}
+	if localDir == "" {
+		return adb.TransferResult{}, errDialogCancelled
+	}
+	return a.UploadPaths(deviceId, []string{localDir}, remoteDir, conflictPolicy)
+}
+
+// UploadPaths 上传本地文件或目录（如拖拽的路径）到设备目录
+func (a *App) UploadPaths(deviceId string, localPaths []string, remoteDir string, conflictPolicy string) (adb.TransferResult, error) {
+	if remoteDir == "" {
+		remoteDir = "/sdcard/"
+	}
+	param := a.buildParam(deviceId)
+	plan, err := adb.PlanUpload(param, localPaths, remoteDir, conflictPolicy)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "upload_plan_failed device=%s remote_dir=%s err=%q", deviceId, remoteDir, err.Error())
+		return adb.TransferResult{}, err
+	}
+	return a.runTransfer(param, plan), nil
+}
+
+// DownloadFiles 下载设备上的多个文件或目录到选择的本地目录
+func (a *App) DownloadFiles(deviceId string, remotePaths []string, conflictPolicy string) (adb.TransferResult, error) {
+	localDir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择保存位置",
+	})
+	if err != nil {
+		return adb.TransferResult{}, fmt.Errorf("选择保存路径失败: %w", err)
+	}
+	if localDir == "" {
+		return adb.TransferResult{}, errDialogCancelled
+	}
+
+	param := a.buildParam(deviceId)
+	plan, err := adb.PlanDownload(param, remotePaths, localDir, conflictPolicy)
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "download_plan_failed device=%s paths=%d err=%q", deviceId, len(remotePaths), err.Error())
+		return adb.TransferResult{}, err
+	}
+	return a.runTransfer(param, plan), nil
+}
+
+// CancelFileTransfer 取消正在进行的传输任务
+func (a *App) CancelFileTransfer(taskId string) {
+	a.transferMutex.Lock()
+	cancel, ok := a.transferCancels[taskId]
+	a.transferMutex.Unlock()
+	if ok {
+		applog.Infof(applog.CategoryAction, "file_transfer_cancel_requested task=%s", taskId)
+		cancel()
+	}
+}
+
+// runTransfer 执行传输并通过 file-transfer-progress 事件推送进度，任务 id 随第一个进度事件返回给前端
+func (a *App) runTransfer(param adb.ExecuteParams, plan adb.TransferPlan) adb.TransferResult {
+	taskId := uuid.New().String()
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+
+	a.transferMutex.Lock()
+	if a.transferCancels == nil {
+		a.transferCancels = make(map[string]context.CancelFunc)
+	}
+	a.transferCancels[taskId] = cancel
+	a.transferMutex.Unlock()
+	defer func() {
+		a.transferMutex.Lock()
+		delete(a.transferCancels, taskId)
+		a.transferMutex.Unlock()
+	}()
+
+	applog.Infof(applog.CategoryAction, "file_transfer_started task=%s device=%s direction=%s files=%d skipped=%d", taskId, param.DeviceId, plan.Direction, len(plan.Files), plan.Skipped)
+
+	result := adb.RunTransfer(ctx, param, taskId, plan, func(progress adb.TransferProgress) {
+		runtime.EventsEmit(a.ctx, "file-transfer-progress", progress)
+	})
+
+	if result.Failed > 0 {
+		applog.Warnf(applog.CategoryAction, "file_transfer_finished task=%s status=%s transferred=%d skipped=%d failed=%d bytes=%d", taskId, result.Status, result.Transferred, result.Skipped, result.Failed, result.Bytes)
+	} else {
+		applog.Infof(applog.CategoryAction, "file_transfer_finished task=%s status=%s transferred=%d skipped=%d bytes=%d", taskId, result.Status, result.Transferred, result.Skipped, result.Bytes)
+	}
+	return result
+}
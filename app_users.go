@@ -0,0 +1,45 @@
+package main
+
+import (
+	"adb-tool-wails/adb"
+	"adb-tool-wails/applog"
+	"adb-tool-wails/aya"
+)
+
+// ListDeviceUsers 列出设备上的用户与资料（主用户、次要用户、工作资料、访客）
+func (a *App) ListDeviceUsers(deviceId string) ([]adb.DeviceUser, error) {
+	users, err := adb.ListDeviceUsers(a.buildParam(deviceId))
+	if err != nil {
+		applog.Warnf(applog.CategoryAction, "device_users_failed device=%s err=%q", deviceId, err.Error())
+		return nil, err
+	}
+	return users, nil
+}
+
+// fillMissingPackages 用 dumpsys package 补齐 Aya 未返回的应用
+// 旧版本 Aya 服务只查询主用户，只安装在其他用户或工作资料中的应用会缺失
+func fillMissingPackages(param adb.ExecuteParams, batch []string, infos []aya.PackageInfo) []aya.PackageInfo {
+	for _, pkg := range missingPackages(batch, infos) {
+		dump, err := adb.GetDumpsysPackage(param, pkg)
+		if err != nil {
+			applog.Warnf(applog.CategoryAction, "package_info_fallback_failed device=%s user=%s package=%s err=%q", param.DeviceId, param.UserId, pkg, err.Error())
+			continue
+		}
+		infos = append(infos, aya.PackageInfo{
+			PackageName:      pkg,
+			Label:            pkg,
+			VersionName:      dump.VersionName,
+			VersionCode:      dump.VersionCode,
+			FirstInstallTime: dump.FirstInstallTime,
+			LastUpdateTime:   dump.LastUpdateTime,
+			ApkPath:          dump.ApkPath,
+			Enabled:          dump.Enabled,
+			System:           dump.System,
+			MinSdkVersion:    dump.MinSdk,
+			TargetSdkVersion: dump.TargetSdk,
+			Signatures:       []string{},
+			SignatureSha256s: []string{},
+		})
+	}
+	return infos
+}
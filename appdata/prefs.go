@@ -0,0 +1,170 @@
+package appdata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SharedPreferences 中的值类型，与 XML 标签名一致
+const (
+	PrefTypeString  = "string"
+	PrefTypeInt     = "int"
+	PrefTypeLong    = "long"
+	PrefTypeFloat   = "float"
+	PrefTypeBoolean = "boolean"
+	PrefTypeSet     = "set"
+	PrefTypeNull    = "null" // putString(key, null) 写入的空值，没有 value
+)
+
+// PrefEntry SharedPreferences 中的一项，Set 类型的值放在 Values 中
+type PrefEntry struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Value  string   `json:"value"`
+	Values []string `json:"values,omitempty"`
+}
+
+// ParseSharedPrefs 解析 shared_prefs 下的 XML 文件，按名称排序
+func ParseSharedPrefs(data []byte) ([]PrefEntry, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	entries := []PrefEntry{}
+	inMap := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 SharedPreferences 失败: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "map" {
+			inMap = true
+			continue
+		}
+		if !inMap {
+			continue
+		}
+
+		entry := PrefEntry{Name: attr(start, "name"), Type: start.Name.Local}
+		switch entry.Type {
+		case PrefTypeString:
+			var text string
+			if err := decoder.DecodeElement(&text, &start); err != nil {
+				return nil, fmt.Errorf("解析 %s 失败: %w", entry.Name, err)
+			}
+			entry.Value = text
+		case PrefTypeSet:
+			var set struct {
+				Values []string `xml:"string"`
+			}
+			if err := decoder.DecodeElement(&set, &start); err != nil {
+				return nil, fmt.Errorf("解析 %s 失败: %w", entry.Name, err)
+			}
+			entry.Values = set.Values
+			if entry.Values == nil {
+				entry.Values = []string{}
+			}
+		case PrefTypeInt, PrefTypeLong, PrefTypeFloat, PrefTypeBoolean:
+			entry.Value = attr(start, "value")
+			if err := decoder.Skip(); err != nil {
+				return nil, err
+			}
+		case PrefTypeNull:
+			if err := decoder.Skip(); err != nil {
+				return nil, err
+			}
+		default:
+			// 未知标签保留名称，值为空
+			if err := decoder.Skip(); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// BuildSharedPrefs 按 Android 的格式生成 XML，写入前校验数值类型
+func BuildSharedPrefs(entries []PrefEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version='1.0' encoding='utf-8' standalone='yes' ?>\n<map>\n")
+	for _, e := range entries {
+		if e.Name == "" {
+			return nil, fmt.Errorf("键名不能为空")
+		}
+		if err := validatePrefValue(e); err != nil {
+			return nil, err
+		}
+		name := escapeXML(e.Name)
+		switch e.Type {
+		case PrefTypeString:
+			fmt.Fprintf(&buf, "    <string name=\"%s\">%s</string>\n", name, escapeXML(e.Value))
+		case PrefTypeSet:
+			if len(e.Values) == 0 {
+				fmt.Fprintf(&buf, "    <set name=\"%s\" />\n", name)
+				continue
+			}
+			fmt.Fprintf(&buf, "    <set name=\"%s\">\n", name)
+			for _, v := range e.Values {
+				fmt.Fprintf(&buf, "        <string>%s</string>\n", escapeXML(v))
+			}
+			buf.WriteString("    </set>\n")
+		case PrefTypeNull:
+			fmt.Fprintf(&buf, "    <null name=\"%s\" />\n", name)
+		default:
+			fmt.Fprintf(&buf, "    <%s name=\"%s\" value=\"%s\" />\n", e.Type, name, escapeXML(e.Value))
+		}
+	}
+	buf.WriteString("</map>\n")
+	return buf.Bytes(), nil
+}
+
+func validatePrefValue(e PrefEntry) error {
+	var err error
+	switch e.Type {
+	case PrefTypeString, PrefTypeSet, PrefTypeNull:
+	case PrefTypeInt:
+		_, err = strconv.ParseInt(e.Value, 10, 32)
+	case PrefTypeLong:
+		_, err = strconv.ParseInt(e.Value, 10, 64)
+	case PrefTypeFloat:
+		_, err = strconv.ParseFloat(e.Value, 32)
+	case PrefTypeBoolean:
+		if e.Value != "true" && e.Value != "false" {
+			err = fmt.Errorf("只能是 true 或 false")
+		}
+	default:
+		return fmt.Errorf("不支持的类型 %s: %s", e.Type, e.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s 的值 %q 不是合法的 %s", e.Name, e.Value, e.Type)
+	}
+	return nil
+}
+
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func escapeXML(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package appdata
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Android 写出的 SharedPreferences，包含所有类型的标签
+const sharedPrefsXML = `<?xml version='1.0' encoding='utf-8' standalone='yes' ?>
+<map>
+    <string name="token">a&amp;b &lt;c&gt; &quot;d&quot;</string>
+    <int name="launch_count" value="42" />
+    <long name="last_sync" value="1714531200000" />
+    <float name="volume" value="0.75" />
+    <boolean name="onboarded" value="true" />
+    <set name="tags">
+        <string>beta</string>
+        <string>vip</string>
+    </set>
+    <set name="empty_set" />
+    <null name="cleared" />
+    <string name="empty"></string>
+</map>
+`
+
+func TestSharedPrefsRoundTrip(t *testing.T) {
+	want := []PrefEntry{
+		{Name: "cleared", Type: PrefTypeNull},
+		{Name: "empty", Type: PrefTypeString},
+		{Name: "empty_set", Type: PrefTypeSet, Values: []string{}},
+		{Name: "last_sync", Type: PrefTypeLong, Value: "1714531200000"},
+		{Name: "launch_count", Type: PrefTypeInt, Value: "42"},
+		{Name: "onboarded", Type: PrefTypeBoolean, Value: "true"},
+		{Name: "tags", Type: PrefTypeSet, Values: []string{"beta", "vip"}},
+		{Name: "token", Type: PrefTypeString, Value: `a&b <c> "d"`},
+		{Name: "volume", Type: PrefTypeFloat, Value: "0.75"},
+	}
+
+	entries, err := ParseSharedPrefs([]byte(sharedPrefsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("parse:\n got %+v\nwant %+v", entries, want)
+	}
+
+	// 生成的 XML 再解析一次，结果不变
+	data, err := BuildSharedPrefs(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseSharedPrefs(data)
+	if err != nil {
+		t.Fatalf("parse built xml: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip:\n got %+v\nwant %+v\n%s", again, want, data)
+	}
+}
+
+func TestBuildSharedPrefsValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry PrefEntry
+	}{
+		{"empty name", PrefEntry{Type: PrefTypeString}},
+		{"int overflow", PrefEntry{Name: "n", Type: PrefTypeInt, Value: "2147483648"}},
+		{"long not number", PrefEntry{Name: "n", Type: PrefTypeLong, Value: "12a"}},
+		{"float not number", PrefEntry{Name: "n", Type: PrefTypeFloat, Value: "fast"}},
+		{"boolean", PrefEntry{Name: "n", Type: PrefTypeBoolean, Value: "1"}},
+		{"unknown type", PrefEntry{Name: "n", Type: "double", Value: "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildSharedPrefs([]PrefEntry{tt.entry}); err == nil {
+				t.Errorf("BuildSharedPrefs(%+v) should fail", tt.entry)
+			}
+		})
+	}
+}
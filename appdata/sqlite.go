@@ -0,0 +1,230 @@
+package appdata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite"
+)
+
+// DefaultQueryLimit 查询结果默认最多返回的行数
+const DefaultQueryLimit = 500
+
+// TableInfo 数据库中的表或视图
+type TableInfo struct {
+	Name     string       `json:"name"`
+	Type     string       `json:"type"`
+	Sql      string       `json:"sql"`
+	Columns  []ColumnInfo `json:"columns"`
+	RowCount int64        `json:"rowCount"`
+}
+
+// ColumnInfo 列定义
+type ColumnInfo struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	NotNull    bool   `json:"notNull"`
+	PrimaryKey bool   `json:"primaryKey"`
+}
+
+// QueryResult 查询结果，值已转换为前端可直接显示的类型
+type QueryResult struct {
+	Columns      []string `json:"columns"`
+	Rows         [][]any  `json:"rows"`
+	Truncated    bool     `json:"truncated"`
+	RowsAffected int64    `json:"rowsAffected"`
+}
+
+// Database 本地打开的 SQLite 文件，readOnly 时开启 query_only，写语句会被 SQLite 拒绝
+type Database struct {
+	db       *sql.DB
+	readOnly bool
+}
+
+// OpenDatabase 打开本地 SQLite 文件
+// 只读模式不使用 mode=ro：WAL 模式的数据库在只读打开时无法创建 -shm 文件，改用 query_only 限制写入
+func OpenDatabase(localPath string, readOnly bool) (*Database, error) {
+	if _, err := os.Stat(localPath); err != nil {
+		return nil, err
+	}
+	dsn := localPath
+	if readOnly {
+		dsn += "?_pragma=query_only(1)"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	return &Database{db: db, readOnly: readOnly}, nil
+}
+
+// Close 关闭数据库
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// Tables 列出表与视图及其列和行数
+func (d *Database) Tables() ([]TableInfo, error) {
+	rows, err := d.db.Query("SELECT name, type, COALESCE(sql, '') FROM sqlite_master WHERE type IN ('table', 'view') ORDER BY type, name")
+	if err != nil {
+		return nil, err
+	}
+	var tables []TableInfo
+	for rows.Next() {
+		var t TableInfo
+		if err := rows.Scan(&t.Name, &t.Type, &t.Sql); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range tables {
+		t := &tables[i]
+		t.Columns, err = d.columns(t.Name)
+		if err != nil {
+			return nil, err
+		}
+		if t.Type == "table" {
+			_ = d.db.QueryRow("SELECT COUNT(*) FROM " + quoteIdent(t.Name)).Scan(&t.RowCount)
+		}
+	}
+	return tables, nil
+}
+
+func (d *Database) columns(table string) ([]ColumnInfo, error) {
+	rows, err := d.db.Query("PRAGMA table_info(" + quoteIdent(table) + ")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []ColumnInfo{}
+	for rows.Next() {
+		var (
+			cid     int
+			c       ColumnInfo
+			notNull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &c.Name, &c.Type, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		c.NotNull = notNull != 0
+		c.PrimaryKey = pk != 0
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// Query 执行一条只读语句，有结果集时最多返回 limit 行
+// 可写打开时查询期间同样开启 query_only，写语句只能通过 Exec 执行，修改才会被记录并写回设备
+func (d *Database) Query(statement string, limit int) (QueryResult, error) {
+	statement = strings.TrimSpace(statement)
+	if statement == "" {
+		return QueryResult{}, fmt.Errorf("SQL 不能为空")
+	}
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer conn.Close()
+	if !d.readOnly {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = 1"); err != nil {
+			return QueryResult{}, err
+		}
+		defer conn.ExecContext(ctx, "PRAGMA query_only = 0")
+	}
+
+	rows, err := conn.QueryContext(ctx, statement)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return QueryResult{}, err
+	}
+	result := QueryResult{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		if len(result.Rows) >= limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return QueryResult{}, err
+		}
+		for i, v := range values {
+			values[i] = displayValue(v)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// Exec 执行写语句，只读打开时拒绝
+func (d *Database) Exec(statement string) (QueryResult, error) {
+	if d.readOnly {
+		return QueryResult{}, fmt.Errorf("数据库以只读方式打开")
+	}
+	res, err := d.db.Exec(statement)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	affected, _ := res.RowsAffected()
+	return QueryResult{Columns: []string{}, Rows: [][]any{}, RowsAffected: affected}, nil
+}
+
+// Checkpoint 将 WAL 合并回主文件，写回设备前调用，保证只推送一个文件即可
+func (d *Database) Checkpoint() error {
+	if d.readOnly {
+		return nil
+	}
+	_, err := d.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// displayValue BLOB 为合法 UTF-8 时按文本显示，否则显示为 X'..' 形式的十六进制
+func displayValue(v any) any {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	if len(b) > 256 {
+		return fmt.Sprintf("X'%s…' (%d bytes)", hex.EncodeToString(b[:256]), len(b))
+	}
+	return fmt.Sprintf("X'%s'", hex.EncodeToString(b))
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,142 @@
+package appdata
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// newTestDatabase 在临时目录创建一个 WAL 模式的数据库，与设备上的应用数据库一致
+func newTestDatabase(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "app.db")
+	db, err := sql.Open("sqlite", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"PRAGMA journal_mode = WAL",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, avatar BLOB)",
+		"CREATE VIEW user_names AS SELECT name FROM users",
+		"INSERT INTO users (name, avatar) VALUES ('alice', x'89504e47'), ('bob', 'text blob'), ('carol', NULL)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return p
+}
+
+func TestDatabaseTables(t *testing.T) {
+	db, err := OpenDatabase(newTestDatabase(t), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tables, err := db.Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("tables = %+v", tables)
+	}
+	users, view := tables[0], tables[1]
+	if users.Name != "users" || users.Type != "table" || users.RowCount != 3 || len(users.Columns) != 3 {
+		t.Errorf("users = %+v", users)
+	}
+	if c := users.Columns[0]; c.Name != "id" || !c.PrimaryKey || c.Type != "INTEGER" {
+		t.Errorf("id column = %+v", c)
+	}
+	if c := users.Columns[1]; c.Name != "name" || !c.NotNull {
+		t.Errorf("name column = %+v", c)
+	}
+	if view.Name != "user_names" || view.Type != "view" || len(view.Columns) != 1 {
+		t.Errorf("view = %+v", view)
+	}
+}
+
+func TestDatabaseQuery(t *testing.T) {
+	db, err := OpenDatabase(newTestDatabase(t), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	result, err := db.Query("SELECT name, avatar FROM users ORDER BY id", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Truncated || len(result.Rows) != 2 || len(result.Columns) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	// 非 UTF-8 的 BLOB 显示为十六进制，合法 UTF-8 按文本显示
+	if got := result.Rows[0][1]; got != "X'89504e47'" {
+		t.Errorf("binary blob = %v", got)
+	}
+	if got := result.Rows[1][1]; got != "text blob" {
+		t.Errorf("text blob = %v", got)
+	}
+
+	if _, err := db.Query("  ", 0); err == nil {
+		t.Error("expected error for empty statement")
+	}
+}
+
+func TestDatabaseReadOnly(t *testing.T) {
+	db, err := OpenDatabase(newTestDatabase(t), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("DELETE FROM users"); err == nil {
+		t.Error("Exec should be rejected on a read-only database")
+	}
+	if _, err := db.Query("DELETE FROM users", 0); err == nil {
+		t.Error("Query should not write on a read-only database")
+	}
+}
+
+func TestDatabaseEdit(t *testing.T) {
+	p := newTestDatabase(t)
+	db, err := OpenDatabase(p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 可写打开时 Query 仍是只读的，写语句必须走 Exec，否则修改不会被标记为需要写回
+	if _, err := db.Query("UPDATE users SET name = 'mallory'", 0); err == nil {
+		t.Error("Query should reject writes on a writable database")
+	}
+	result, err := db.Exec("UPDATE users SET name = 'dave' WHERE name = 'bob'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RowsAffected != 1 {
+		t.Errorf("rows affected = %d", result.RowsAffected)
+	}
+	// 查询结束后连接恢复可写
+	if _, err := db.Exec("DELETE FROM users WHERE name = 'carol'"); err != nil {
+		t.Fatalf("Exec after Query: %v", err)
+	}
+	if err := db.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// 合并 WAL 后只读重新打开主文件，修改仍在
+	reopened, err := OpenDatabase(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	names, err := reopened.Query("SELECT name FROM users ORDER BY id", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names.Rows) != 2 || names.Rows[0][0] != "alice" || names.Rows[1][0] != "dave" {
+		t.Errorf("rows = %v", names.Rows)
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"adb-tool-wails/adb"
 	"adb-tool-wails/applog"
 	"adb-tool-wails/util"
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,24 +27,59 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-// AyaDexVersion 当前 aya.dex 的版本号，需要与 server/build.gradle 中的 versionName 保持一致
-const AyaDexVersion = "1.2"
+const (
+	serverClass   = "io.liriliri.aya.Server"
+	remoteDexPath = "/data/local/tmp/aya/aya.dex"
+	// serverLogTags 服务端 logcat 标签，用于获取崩溃原因
+	serverLogTags = "Aya.Server Aya.Connection Aya.PackageManager Aya.StorageStatsManager AndroidRuntime"
+	// maxMessageSize 单条消息的上限，超过说明数据流已错位
+	maxMessageSize = 32 << 20
+	// requestTimeout 普通请求的超时，流式请求为相邻两块之间的超时
+	requestTimeout = 30 * time.Second
+)
+
+// errConnectionLost 连接被服务端异常断开（服务崩溃或被杀）
+var errConnectionLost = errors.New("aya connection lost")
+
+// pendingRequest 等待响应的请求，流式请求会收到多个分块
+type pendingRequest struct {
+	ch   chan *pb.Response
+	done chan struct{}
+	once sync.Once
+}
+
+func newPendingRequest() *pendingRequest {
+	return &pendingRequest{
+		ch:   make(chan *pb.Response, 16),
+		done: make(chan struct{}),
+	}
+}
+
+// finish 标记请求结束（取消、超时或连接断开），readLoop 不再投递该请求的分块
+func (p *pendingRequest) finish() {
+	p.once.Do(func() { close(p.done) })
+}
 
 type Client struct {
 	param       adb.ExecuteParams
 	conn        net.Conn
 	localPort   string
-	resolves    map[string]chan *pb.Response
+	resolves    map[string]*pendingRequest
 	mu          sync.Mutex
 	readDone    chan struct{}
 	readStarted bool
 	closed      bool
+	lost        bool
+
+	dexPath         string
+	dexChecksum     string
+	lastCrashReason string
 }
 
 func NewClient(param adb.ExecuteParams) *Client {
 	return &Client{
 		param:    param,
-		resolves: make(map[string]chan *pb.Response),
+		resolves: make(map[string]*pendingRequest),
 		readDone: make(chan struct{}),
 	}
 }
@@ -69,26 +111,31 @@ func (c *Client) Connect(localDexPath string) error {
 		return err
 	}
 
-	// 尝试连接已运行的服务
-	if err := c.tryConnect(); err == nil {
-		// 连接成功，先启动 readLoop 才能发送消息
-		c.mu.Lock()
-		c.readStarted = true
-		c.mu.Unlock()
-		go c.readLoop()
+	checksum, err := dexChecksum(localDexPath)
+	if err != nil {
+		return fmt.Errorf("read dex checksum failed: %w", err)
+	}
+	c.dexPath = localDexPath
+	c.dexChecksum = checksum
 
-		// 检查版本
-		remoteVersion, err := c.getRemoteVersion()
+	// 尝试连接已运行的服务
+	if err := c.attach(); err == nil {
+		// 校验设备上运行的 dex 与本地是否一致
+		remoteChecksum, err := c.getRemoteChecksum()
 		if err != nil {
-			applog.Warnf(applog.CategoryAya, "remote_version_failed device=%s err=%q", c.param.DeviceId, err.Error())
+			applog.Warnf(applog.CategoryAya, "remote_checksum_failed device=%s err=%q", c.param.DeviceId, err.Error())
 			c.Close()
-		} else if remoteVersion == AyaDexVersion {
-			// 版本一致，直接使用现有连接
-			applog.Infof(applog.CategoryAya, "server_reused device=%s version=%s", c.param.DeviceId, remoteVersion)
+		} else if remoteChecksum == checksum {
+			// 校验值一致，直接使用现有连接
+			applog.Infof(applog.CategoryAya, "server_reused device=%s checksum=%s", c.param.DeviceId, checksum)
+			return nil
+		} else if remoteChecksum == "" && c.remoteFileChecksum() == checksum {
+			// 旧版本服务不返回校验值，设备上的 dex 与本地一致时同样复用，避免每次连接都重新部署
+			applog.Infof(applog.CategoryAya, "server_reused_legacy device=%s checksum=%s", c.param.DeviceId, checksum)
 			return nil
 		} else {
-			// 版本不一致，需要更新
-			applog.Warnf(applog.CategoryAya, "server_version_mismatch device=%s remote=%s local=%s", c.param.DeviceId, remoteVersion, AyaDexVersion)
+			// 校验值不一致，需要更新
+			applog.Warnf(applog.CategoryAya, "server_checksum_mismatch device=%s remote=%s local=%s", c.param.DeviceId, remoteChecksum, checksum)
 			c.Close()
 		}
 	}
@@ -132,32 +179,79 @@ func (c *Client) Connect(localDexPath string) error {
 	}
 
 	// 连接
-	if err := c.tryConnect(); err != nil {
+	if err := c.attach(); err != nil {
 		return fmt.Errorf("connect failed: %w", err)
 	}
 
-	// 启动读取协程
+	// 重新部署后校验值仍不一致说明推送或启动的不是本地 dex，继续重试只会反复重启服务
+	remoteChecksum, err := c.getRemoteChecksum()
+	if err != nil {
+		c.Close()
+		return fmt.Errorf("read server checksum failed: %w", err)
+	}
+	if remoteChecksum == "" {
+		// 打包的 dex 是不返回校验值的旧版本服务，推送已经完成，按旧版本服务继续使用
+		applog.Warnf(applog.CategoryAya, "server_legacy_deployed device=%s local=%s", c.param.DeviceId, checksum)
+		return nil
+	}
+	if remoteChecksum != checksum {
+		c.Close()
+		applog.Warnf(applog.CategoryAya, "server_checksum_mismatch_after_deploy device=%s remote=%s local=%s", c.param.DeviceId, remoteChecksum, checksum)
+		return fmt.Errorf("server checksum mismatch after deploy: remote=%q local=%q", remoteChecksum, checksum)
+	}
+	applog.Infof(applog.CategoryAya, "server_deployed device=%s checksum=%s", c.param.DeviceId, checksum)
+
+	return nil
+}
+
+// attach 连接已运行的服务并启动读取协程
+func (c *Client) attach() error {
+	if err := c.tryConnect(); err != nil {
+		return err
+	}
+
 	c.mu.Lock()
 	c.readStarted = true
+	conn := c.conn
 	c.mu.Unlock()
-	go c.readLoop()
+	go c.readLoop(conn)
 
 	return nil
 }
 
-// getRemoteVersion 获取远端服务版本号
-func (c *Client) getRemoteVersion() (string, error) {
-	result, err := c.SendMessage("getVersion", nil)
+// dexChecksum 计算本地 dex 的 SHA-256，与服务端启动时计算的值比对
+func dexChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return "", err
 	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
 
-	version, ok := result["version"].(string)
-	if !ok {
-		return "", fmt.Errorf("invalid version response")
+// getRemoteChecksum 获取远端服务 dex 的校验值，旧版本服务没有该字段时返回空字符串
+func (c *Client) getRemoteChecksum() (string, error) {
+	result, err := c.sendMessage("getVersion", nil)
+	if err != nil {
+		return "", err
 	}
 
-	return version, nil
+	checksum, _ := result["checksum"].(string)
+	return checksum, nil
+}
+
+// remoteFileChecksum 计算设备上 dex 文件的 SHA-256，用于校验不返回校验值的旧版本服务，失败时返回空字符串
+func (c *Client) remoteFileChecksum() string {
+	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId, "sha256sum "+remoteDexPath)
+	output, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
 }
 
 // tryConnect 尝试连接到已运行的服务
@@ -214,7 +308,7 @@ func (c *Client) pushDex(localDexPath string) error {
 	}
 
 	// 推送文件
-	pushCmd := adb.BuildAdbCmd(c.param.AdbPath, c.param.DeviceId, fmt.Sprintf("push %s /data/local/tmp/aya/aya.dex", localDexPath))
+	pushCmd := adb.BuildAdbCmd(c.param.AdbPath, c.param.DeviceId, fmt.Sprintf("push %s %s", localDexPath, remoteDexPath))
 	if _, err := util.Exec(pushCmd, true, nil); err != nil {
 		return fmt.Errorf("push failed: %w", err)
 	}
@@ -228,7 +322,7 @@ func (c *Client) killServer() {
 	applog.Infof(applog.CategoryAya, "server_kill_requested device=%s", c.param.DeviceId)
 
 	// 方法1：通过 pkill 杀进程
-	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId, "pkill -f io.liriliri.aya.Server")
+	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId, "pkill -f "+serverClass)
 	util.Exec(cmd, true, nil) // 忽略错误，可能本来就没有进程
 
 	// 等待进程退出
@@ -249,10 +343,12 @@ func (c *Client) startServer() error {
 
 	applog.Infof(applog.CategoryAya, "server_start_requested device=%s", c.param.DeviceId)
 
-	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId, "CLASSPATH=/data/local/tmp/aya/aya.dex app_process /system/bin io.liriliri.aya.Server &")
+	// nohup 并重定向输出，adb shell 可以立即返回，服务进程不随 shell 会话退出
+	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId,
+		fmt.Sprintf("\"CLASSPATH=%s nohup app_process /system/bin %s >/dev/null 2>&1 &\"", remoteDexPath, serverClass))
 
 	applog.Infof(applog.CategoryAya, "server_start_cmd device=%s cmd=%q", c.param.DeviceId, cmd)
-	if err := util.ExecBackground(cmd); err != nil {
+	if _, err := util.Exec(cmd, false, nil); err != nil {
 		return fmt.Errorf("start server failed: %w", err)
 	}
 
@@ -268,22 +364,84 @@ func (c *Client) waitForServer(timeout time.Duration) error {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
+	checks := 0
 	for {
 		select {
 		case <-c.param.Ctxt.Done():
 			return c.param.Ctxt.Err()
 		case <-ticker.C:
 			if time.Now().After(deadline) {
-				return fmt.Errorf("server start timeout after %v", timeout)
+				return fmt.Errorf("server start timeout after %v: %s", timeout, c.recordCrashReason())
 			}
 			if c.isRunning() {
 				applog.Infof(applog.CategoryAya, "server_ready device=%s", c.param.DeviceId)
 				return nil
 			}
+			// 每秒确认一次进程仍然存活，启动即崩溃时无需等到超时
+			checks++
+			if checks%10 == 0 {
+				if pid, ok := c.serverPid(); ok && pid == 0 {
+					return fmt.Errorf("server exited during startup: %s", c.recordCrashReason())
+				}
+			}
 		}
 	}
 }
 
+// serverPid 查找服务进程 pid，ok 为 false 表示设备的 ps 不支持按参数查询
+func (c *Client) serverPid() (pid int, ok bool) {
+	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId, "ps -A -o PID,ARGS")
+	output, err := util.Exec(cmd, true, nil)
+	if err != nil || !strings.Contains(output, "ARGS") {
+		return 0, false
+	}
+	for _, line := range util.MultiLine(output) {
+		if !strings.Contains(line, serverClass) || strings.Contains(line, "ps -A") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[0]); err == nil {
+			return pid, true
+		}
+	}
+	return 0, true
+}
+
+// crashReason 从服务端 logcat 中提取最近的错误日志
+func (c *Client) crashReason() string {
+	cmd := adb.BuildAdbShellCmd(c.param.AdbPath, c.param.DeviceId, fmt.Sprintf("logcat -d -v brief -t 500 -s %s", serverLogTags))
+	output, err := util.Exec(cmd, true, nil)
+	if err != nil {
+		return fmt.Sprintf("read logcat failed: %v", err)
+	}
+
+	var errorLines []string
+	for _, line := range util.MultiLine(output) {
+		if strings.HasPrefix(line, "E/") || strings.HasPrefix(line, "F/") {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		return "no error found in logcat"
+	}
+	if len(errorLines) > 20 {
+		errorLines = errorLines[len(errorLines)-20:]
+	}
+	return strings.Join(errorLines, "\n")
+}
+
+// recordCrashReason 获取并保存崩溃原因，供 Health 返回给前端
+func (c *Client) recordCrashReason() string {
+	reason := c.crashReason()
+	c.mu.Lock()
+	c.lastCrashReason = reason
+	c.mu.Unlock()
+	return reason
+}
+
 // connectSocket 连接 Socket
 func (c *Client) connectSocket() error {
 	if err := c.checkCancelled(); err != nil {
@@ -345,89 +503,204 @@ func (c *Client) removeForward() {
 	}
 }
 
-// readLoop 读取响应的循环
-func (c *Client) readLoop() {
+// readLoop 读取响应的循环，按长度前缀读取完整消息，Close 关闭连接后读取返回错误并退出
+func (c *Client) readLoop(conn net.Conn) {
 	defer close(c.readDone)
 
-	buf := make([]byte, 0)
-	tempBuf := make([]byte, 4096)
-
+	reader := bufio.NewReaderSize(conn, 64*1024)
 	for {
-		c.mu.Lock()
-		conn := c.conn
-		closed := c.closed
-		c.mu.Unlock()
-
-		if conn == nil || closed {
-			return
-		}
-
-		// 设置读取超时，以便能定期检查关闭状态
-		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
-
-		n, err := conn.Read(tempBuf)
+		msgData, err := readDelimited(reader)
 		if err != nil {
-			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-				continue
-			}
-			if err != io.EOF {
-				c.mu.Lock()
-				if !c.closed {
+			c.mu.Lock()
+			if !c.closed {
+				if err != io.EOF {
 					applog.Warnf(applog.CategoryAya, "socket_read_failed device=%s err=%q", c.param.DeviceId, err.Error())
 				}
-				c.mu.Unlock()
+				// 非主动关闭导致的断开，唤醒所有等待中的请求
+				applog.Warnf(applog.CategoryAya, "connection_lost device=%s pending=%d", c.param.DeviceId, len(c.resolves))
+				c.lost = true
 			}
+			c.failPendingLocked()
+			c.mu.Unlock()
 			return
 		}
 
-		buf = append(buf, tempBuf[:n]...)
+		resp := &pb.Response{}
+		if err := proto.Unmarshal(msgData, resp); err != nil {
+			applog.Warnf(applog.CategoryAya, "response_unmarshal_failed device=%s err=%q", c.param.DeviceId, err.Error())
+			continue
+		}
 
-		for len(buf) > 0 {
-			length, headerSize := binary.Uvarint(buf)
+		c.dispatch(resp)
+	}
+}
 
-			if headerSize <= 0 {
-				break
-			}
+// readDelimited 读取一条 varint 长度前缀的消息，只分配消息本身大小的内存
+func readDelimited(reader *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message too large: %d bytes", length)
+	}
 
-			totalSize := headerSize + int(length)
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
 
-			if len(buf) < totalSize {
-				break
-			}
+// dispatch 将响应投递给对应的请求，最后一块投递后移除请求
+// 流式请求的消费方处理较慢时会阻塞读取，通道缓冲用于平滑这种情况
+func (c *Client) dispatch(resp *pb.Response) {
+	c.mu.Lock()
+	pending, ok := c.resolves[resp.Id]
+	if ok && !resp.Partial {
+		delete(c.resolves, resp.Id)
+	}
+	c.mu.Unlock()
 
-			msgData := buf[headerSize:totalSize]
-			buf = buf[totalSize:]
+	if !ok {
+		return
+	}
 
-			resp := &pb.Response{}
-			if err := proto.Unmarshal(msgData, resp); err != nil {
-				applog.Warnf(applog.CategoryAya, "response_unmarshal_failed device=%s err=%q", c.param.DeviceId, err.Error())
-				continue
-			}
+	select {
+	case pending.ch <- resp:
+	case <-pending.done:
+	}
+}
 
-			c.mu.Lock()
-			if ch, ok := c.resolves[resp.Id]; ok {
-				ch <- resp
-				delete(c.resolves, resp.Id)
-			}
-			c.mu.Unlock()
-		}
+// failPendingLocked 结束所有等待中的请求，调用方需持有 c.mu
+func (c *Client) failPendingLocked() {
+	for id, pending := range c.resolves {
+		pending.finish()
+		delete(c.resolves, id)
+	}
+}
+
+// removePending 移除请求并通知 readLoop 丢弃后续分块
+func (c *Client) removePending(id string) {
+	c.mu.Lock()
+	if pending, ok := c.resolves[id]; ok {
+		pending.finish()
+		delete(c.resolves, id)
 	}
+	c.mu.Unlock()
 }
 
-// SendMessage 发送消息并接收响应
+// SendMessage 发送消息并接收响应，服务异常退出时自动重启一次并重试
 func (c *Client) SendMessage(method string, params interface{}) (map[string]interface{}, error) {
+	result, err := c.sendMessage(method, params)
+	if err == nil || !errors.Is(err, errConnectionLost) || c.dexPath == "" {
+		return result, err
+	}
+
+	if restartErr := c.restart(); restartErr != nil {
+		return nil, fmt.Errorf("%w, restart failed: %v", err, restartErr)
+	}
+	return c.sendMessage(method, params)
+}
+
+// restart 记录崩溃原因后重新连接，服务已退出时会重新部署
+func (c *Client) restart() error {
+	reason := c.recordCrashReason()
+	applog.Warnf(applog.CategoryAya, "server_restart_requested device=%s reason=%q", c.param.DeviceId, reason)
+
+	c.Close()
+	if err := c.Connect(c.dexPath); err != nil {
+		return err
+	}
+
+	applog.Infof(applog.CategoryAya, "server_restarted device=%s", c.param.DeviceId)
+	return nil
+}
+
+func (c *Client) sendMessage(method string, params interface{}) (map[string]interface{}, error) {
+	id, pending, err := c.writeRequest(method, params, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.waitResponse(id, pending)
+	if err != nil {
+		return nil, err
+	}
+	applog.Infof(applog.CategoryAya, "response_received device=%s id=%s", c.param.DeviceId, resp.Id)
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.Result), &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %w, raw: %s", err, resp.Result)
+	}
+
+	return result, nil
+}
+
+// SendStream 发送流式请求，每收到一块调用一次 onChunk，最后一块 Final 为 true
+// onChunk 返回错误时停止接收，剩余分块会被丢弃；尚未收到任何分块时服务异常退出会自动重启一次并重试
+func (c *Client) SendStream(method string, params interface{}, onChunk func(Chunk) error) error {
+	received, err := c.sendStream(method, params, onChunk)
+	if err == nil || received > 0 || !errors.Is(err, errConnectionLost) || c.dexPath == "" {
+		return err
+	}
+
+	if restartErr := c.restart(); restartErr != nil {
+		return fmt.Errorf("%w, restart failed: %v", err, restartErr)
+	}
+	_, err = c.sendStream(method, params, onChunk)
+	return err
+}
+
+func (c *Client) sendStream(method string, params interface{}, onChunk func(Chunk) error) (int, error) {
+	id, pending, err := c.writeRequest(method, params, true)
+	if err != nil {
+		return 0, err
+	}
+
+	received := 0
+	for {
+		resp, err := c.waitResponse(id, pending)
+		if err != nil {
+			return received, err
+		}
+		received++
+
+		chunk := Chunk{
+			Seq:     int(resp.Seq),
+			Result:  resp.Result,
+			Payload: resp.Payload,
+			Final:   !resp.Partial,
+		}
+		if err := onChunk(chunk); err != nil {
+			c.removePending(id)
+			return received, err
+		}
+		if chunk.Final {
+			applog.Infof(applog.CategoryAya, "stream_completed device=%s id=%s chunks=%d", c.param.DeviceId, id, received)
+			return received, nil
+		}
+	}
+}
+
+// writeRequest 注册等待中的请求并写入 socket
+func (c *Client) writeRequest(method string, params interface{}, stream bool) (string, *pendingRequest, error) {
 	c.mu.Lock()
 	conn := c.conn
 	closed := c.closed
+	lost := c.lost
 	c.mu.Unlock()
 
+	if lost {
+		return "", nil, errConnectionLost
+	}
 	if conn == nil || closed {
-		return nil, fmt.Errorf("not connected")
+		return "", nil, fmt.Errorf("not connected")
 	}
 
 	paramsJSON, err := json.Marshal(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal params: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal params: %w", err)
 	}
 
 	id := uuid.New().String()
@@ -435,60 +708,68 @@ func (c *Client) SendMessage(method string, params interface{}) (map[string]inte
 		Id:     id,
 		Method: method,
 		Params: string(paramsJSON),
+		Stream: stream,
 	}
 
-	applog.Infof(applog.CategoryAya, "request_sent device=%s method=%s id=%s", c.param.DeviceId, method, id)
-
-	respCh := make(chan *pb.Response, 1)
-	c.mu.Lock()
-	c.resolves[id] = respCh
-	c.mu.Unlock()
-
 	reqData, err := proto.Marshal(req)
 	if err != nil {
-		c.mu.Lock()
-		delete(c.resolves, id)
-		c.mu.Unlock()
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	applog.Infof(applog.CategoryAya, "request_sent device=%s method=%s id=%s stream=%t", c.param.DeviceId, method, id, stream)
+
+	pending := newPendingRequest()
+	c.mu.Lock()
+	c.resolves[id] = pending
+	c.mu.Unlock()
+
 	lenBuf := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutUvarint(lenBuf, uint64(len(reqData)))
 	buf := append(lenBuf[:n], reqData...)
 
 	if _, err := conn.Write(buf); err != nil {
-		c.mu.Lock()
-		delete(c.resolves, id)
-		c.mu.Unlock()
-		return nil, fmt.Errorf("failed to write request: %w", err)
+		c.removePending(id)
+		return "", nil, fmt.Errorf("%w: failed to write request: %v", errConnectionLost, err)
 	}
 
+	return id, pending, nil
+}
+
+// waitResponse 等待请求的下一块响应
+func (c *Client) waitResponse(id string, pending *pendingRequest) (*pb.Response, error) {
 	ctx := c.param.Ctxt
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+
 	select {
-	case resp := <-respCh:
-		applog.Infof(applog.CategoryAya, "response_received device=%s id=%s", c.param.DeviceId, resp.Id)
+	case resp := <-pending.ch:
+		return resp, nil
 
-		var result map[string]interface{}
-		if err := json.Unmarshal([]byte(resp.Result), &result); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal result: %w, raw: %s", err, resp.Result)
+	case <-pending.done:
+		// 结束前已投递的分块仍然有效
+		select {
+		case resp := <-pending.ch:
+			return resp, nil
+		default:
 		}
-
-		return result, nil
-
-	case <-time.After(30 * time.Second):
 		c.mu.Lock()
-		delete(c.resolves, id)
+		lost := c.lost
 		c.mu.Unlock()
+		if lost {
+			return nil, errConnectionLost
+		}
+		return nil, fmt.Errorf("connection closed")
+
+	case <-timer.C:
+		c.removePending(id)
 		return nil, fmt.Errorf("request timeout")
 
 	case <-ctx.Done():
-		c.mu.Lock()
-		delete(c.resolves, id)
-		c.mu.Unlock()
+		c.removePending(id)
 		return nil, ctx.Err()
 	}
 }
@@ -526,13 +807,11 @@ func (c *Client) Close() error {
 	}
 
 	c.mu.Lock()
-	for id, ch := range c.resolves {
-		close(ch)
-		delete(c.resolves, id)
-	}
+	c.failPendingLocked()
 	c.conn = nil
 	// 重置状态以支持重连
 	c.closed = false
+	c.lost = false
 	c.readStarted = false
 	c.readDone = make(chan struct{})
 	c.mu.Unlock()
@@ -545,6 +824,88 @@ func (c *Client) Close() error {
 	return err
 }
 
+// Ping 发送心跳请求，返回往返延迟
+func (c *Client) Ping() (time.Duration, error) {
+	start := time.Now()
+	if _, err := c.sendMessage("ping", nil); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// Health 汇总服务的运行状态、心跳延迟和 dex 校验结果
+func (c *Client) Health() ServerHealth {
+	health := ServerHealth{
+		Running:       c.isRunning(),
+		LocalChecksum: c.dexChecksum,
+	}
+
+	c.mu.Lock()
+	health.Connected = c.conn != nil && !c.closed && !c.lost
+	c.mu.Unlock()
+
+	start := time.Now()
+	result, err := c.sendMessage("ping", nil)
+	if err != nil {
+		health.Error = err.Error()
+	} else {
+		health.LatencyMs = time.Since(start).Milliseconds()
+		if pid, ok := result["pid"].(float64); ok {
+			health.Pid = int(pid)
+		}
+		if uptime, ok := result["uptime"].(float64); ok {
+			health.UptimeMs = int64(uptime)
+		}
+		health.RemoteChecksum, _ = result["checksum"].(string)
+		if health.RemoteChecksum == "" {
+			// 旧版本服务心跳不带校验值，改用设备上 dex 文件的校验值
+			health.Legacy = true
+			health.RemoteChecksum = c.remoteFileChecksum()
+		}
+		health.ChecksumMatched = health.RemoteChecksum != "" && health.RemoteChecksum == c.dexChecksum
+	}
+
+	c.mu.Lock()
+	health.LastCrashReason = c.lastCrashReason
+	c.mu.Unlock()
+
+	return health
+}
+
+// Shutdown 请求服务端退出并关闭连接，服务无响应时强制结束进程
+func (c *Client) Shutdown() error {
+	applog.Infof(applog.CategoryAya, "server_shutdown_requested device=%s", c.param.DeviceId)
+
+	c.mu.Lock()
+	connected := c.conn != nil && !c.closed
+	c.mu.Unlock()
+	if !connected {
+		if err := c.attach(); err != nil {
+			applog.Warnf(applog.CategoryAya, "server_shutdown_attach_failed device=%s err=%q", c.param.DeviceId, err.Error())
+		}
+	}
+
+	_, sendErr := c.sendMessage("shutdown", nil)
+	closeErr := c.Close()
+	if sendErr != nil && !errors.Is(sendErr, errConnectionLost) {
+		applog.Warnf(applog.CategoryAya, "server_shutdown_request_failed device=%s err=%q", c.param.DeviceId, sendErr.Error())
+	}
+
+	// 等待服务释放 socket，超时仍在运行则强制结束
+	deadline := time.Now().Add(2 * time.Second)
+	for c.isRunning() {
+		if time.Now().After(deadline) {
+			applog.Warnf(applog.CategoryAya, "server_shutdown_timeout device=%s", c.param.DeviceId)
+			c.killServer()
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	applog.Infof(applog.CategoryAya, "server_shutdown_completed device=%s", c.param.DeviceId)
+	return closeErr
+}
+
 // GetPackageInfo 获取单个应用的详细信息
 func (c *Client) GetPackageInfo(packageName string) (*PackageInfo, error) {
 	params := map[string]interface{}{
@@ -629,29 +990,41 @@ func (c *Client) GetPackageInfo(packageName string) (*PackageInfo, error) {
 }
 
 // GetPackageInfos 批量获取应用信息
+// 服务端逐个应用返回分块，图标以 PNG 二进制放在 payload 中；旧版本服务在最后一块中一次性返回 packageInfos
 func (c *Client) GetPackageInfos(packageNames []string) ([]PackageInfo, error) {
 	params := map[string]interface{}{
 		"packageNames": packageNames,
 	}
-
-	result, err := c.SendMessage("getPackageInfos", params)
-	if err != nil {
-		return nil, fmt.Errorf("send message failed: %w", err)
+	// 旧版本服务忽略 userId，始终查询主用户
+	if userId, err := strconv.Atoi(c.param.UserId); err == nil {
+		params["userId"] = userId
 	}
 
-	packageInfosRaw, ok := result["packageInfos"]
-	if !ok {
-		return nil, fmt.Errorf("missing packageInfos field in response")
-	}
+	packageInfos := make([]PackageInfo, 0, len(packageNames))
+	err := c.SendStream("getPackageInfos", params, func(chunk Chunk) error {
+		if chunk.Final {
+			var final struct {
+				PackageInfos []PackageInfo `json:"packageInfos"`
+			}
+			if err := chunk.Decode(&final); err != nil {
+				return fmt.Errorf("unmarshal failed: %w", err)
+			}
+			packageInfos = append(packageInfos, final.PackageInfos...)
+			return nil
+		}
 
-	jsonBytes, err := json.Marshal(packageInfosRaw)
+		var info PackageInfo
+		if err := chunk.Decode(&info); err != nil {
+			return fmt.Errorf("unmarshal failed: %w", err)
+		}
+		if len(chunk.Payload) > 0 {
+			info.Icon = "data:image/png;base64," + base64.StdEncoding.EncodeToString(chunk.Payload)
+		}
+		packageInfos = append(packageInfos, info)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("marshal failed: %w", err)
-	}
-
-	var packageInfos []PackageInfo
-	if err := json.Unmarshal(jsonBytes, &packageInfos); err != nil {
-		return nil, fmt.Errorf("unmarshal failed: %w", err)
+		return nil, fmt.Errorf("send message failed: %w", err)
 	}
 
 	return packageInfos, nil
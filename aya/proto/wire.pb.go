@@ -22,10 +22,12 @@ const (
 )
 
 type Request struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Method        string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
-	Params        string                 `protobuf:"bytes,3,opt,name=params,proto3" json:"params,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Method string                 `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	Params string                 `protobuf:"bytes,3,opt,name=params,proto3" json:"params,omitempty"`
+	// 客户端支持分块响应
+	Stream        bool `protobuf:"varint,4,opt,name=stream,proto3" json:"stream,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,10 +83,23 @@ func (x *Request) GetParams() string {
 	return ""
 }
 
+func (x *Request) GetStream() bool {
+	if x != nil {
+		return x.Stream
+	}
+	return false
+}
+
 type Response struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Result        string                 `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Result string                 `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	// 后续还有相同 id 的分块，最后一块为 false
+	Partial bool `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	// 分块序号，从 0 开始
+	Seq int32 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	// 二进制数据，如图标、文件内容、截图
+	Payload       []byte `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -133,19 +148,44 @@ func (x *Response) GetResult() string {
 	return ""
 }
 
+func (x *Response) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *Response) GetSeq() int32 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Response) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
 var File_wire_proto protoreflect.FileDescriptor
 
 const file_wire_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"wire.proto\x12\x0fio.liriliri.aya\"I\n" +
+	"wire.proto\x12\x0fio.liriliri.aya\"a\n" +
 	"\aRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06method\x18\x02 \x01(\tR\x06method\x12\x16\n" +
-	"\x06params\x18\x03 \x01(\tR\x06params\"2\n" +
+	"\x06params\x18\x03 \x01(\tR\x06params\x12\x16\n" +
+	"\x06stream\x18\x04 \x01(\bR\x06stream\"x\n" +
 	"\bResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
-	"\x06result\x18\x02 \x01(\tR\x06resultB\x1aZ\x18adb-tool-wails/aya/protob\x06proto3"
+	"\x06result\x18\x02 \x01(\tR\x06result\x12\x18\n" +
+	"\apartial\x18\x03 \x01(\bR\apartial\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x05R\x03seq\x12\x18\n" +
+	"\apayload\x18\x05 \x01(\fR\apayloadB\x1aZ\x18adb-tool-wails/aya/protob\x06proto3"
 
 var (
 	file_wire_proto_rawDescOnce sync.Once
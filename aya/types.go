@@ -1,6 +1,7 @@
 package aya
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -26,6 +27,37 @@ type PackageInfo struct {
 	SignatureSha256s []string `json:"signatureSha256s"`
 }
 
+// ServerHealth Aya 服务的健康状态
+type ServerHealth struct {
+	Running         bool   `json:"running"`
+	Connected       bool   `json:"connected"`
+	Pid             int    `json:"pid"`
+	UptimeMs        int64  `json:"uptimeMs"`
+	LatencyMs       int64  `json:"latencyMs"`
+	LocalChecksum   string `json:"localChecksum"`
+	RemoteChecksum  string `json:"remoteChecksum"`
+	ChecksumMatched bool   `json:"checksumMatched"`
+	Legacy          bool   `json:"legacy"` // 服务不返回校验值，RemoteChecksum 取自设备上的 dex 文件
+	LastCrashReason string `json:"lastCrashReason,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Chunk 流式响应中的一块
+type Chunk struct {
+	Seq     int
+	Result  string // JSON 结果
+	Payload []byte // 二进制数据
+	Final   bool
+}
+
+// Decode 将分块的 JSON 结果解析到 v，结果为空时不做处理
+func (c Chunk) Decode(v interface{}) error {
+	if c.Result == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(c.Result), v)
+}
+
 func (p *PackageInfo) GetFirstInstallTimeFormatted() string {
 	return time.Unix(p.FirstInstallTime/1000, 0).Format("2006-01-02 15:04:05")
 }
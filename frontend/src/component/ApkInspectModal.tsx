@@ -0,0 +1,224 @@
+import React, {useEffect, useState} from 'react';
+import {Alert, Button, Descriptions, Empty, Modal, Popconfirm, Spin, Table, Tabs, Tag, Tooltip, message} from 'antd';
+import {FolderOpenOutlined} from '@ant-design/icons';
+import {InspectApk, InstallPackageFile, SelectApkFile} from "../../wailsjs/go/main/App";
+import {adb, main} from "../../wailsjs/go/models";
+import InstallFailureAlert, {defaultInstallOptions} from './InstallFailureAlert';
+import InstallOptionsPicker from './InstallOptionsPicker';
+import {useDeviceStore} from "../store/deviceStore";
+
+interface ApkInspectModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+}
+
+const versionTags: Record<string, {color: string; label: string}> = {
+    'not-installed': {color: 'blue', label: '未安装'},
+    upgrade: {color: 'green', label: '升级'},
+    same: {color: 'default', label: '相同版本'},
+    downgrade: {color: 'orange', label: '降级'},
+    unknown: {color: 'default', label: '无法比较'},
+};
+
+function formatBytes(raw: number): string {
+    if (raw < 1024) return `${raw} B`;
+    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
+    return `${(raw / 1024 / 1024).toFixed(1)} MB`;
+}
+
+const ApkInspectModal: React.FC<ApkInspectModalProps> = ({visible, onClose, deviceId}) => {
+    const [inspection, setInspection] = useState<main.ApkInspection | null>(null);
+    const [loading, setLoading] = useState(false);
+    const [installing, setInstalling] = useState(false);
+    const [options, setOptions] = useState<adb.InstallOptions>(defaultInstallOptions());
+    const selectedUserId = useDeviceStore(state => state.selectedUserId);
+    const [result, setResult] = useState<adb.InstallResult | null>(null);
+
+    const choose = async () => {
+        let path: string;
+        try {
+            path = await SelectApkFile();
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(msg);
+            return;
+        }
+        setResult(null);
+        setLoading(true);
+        try {
+            setInspection(await InspectApk(deviceId, path));
+        } catch (e: any) {
+            message.error(`解析 APK 失败: ${e?.message || e}`);
+        } finally {
+            setLoading(false);
+        }
+    };
+
+    // 打开时直接进入文件选择
+    useEffect(() => {
+        if (visible && !inspection && !loading) choose();
+    }, [visible]);
+
+    const close = () => {
+        setInspection(null);
+        setResult(null);
+        onClose();
+    };
+
+    const install = async (opts: adb.InstallOptions = options) => {
+        if (!inspection) return;
+        setOptions(opts);
+        setInstalling(true);
+        try {
+            const res = await InstallPackageFile(deviceId, inspection.apk.file, {...opts, user: selectedUserId});
+            setResult(res);
+            if (res.success) message.success('安装成功');
+        } finally {
+            setInstalling(false);
+        }
+    };
+
+    const apk = inspection?.apk;
+    const installed = inspection?.installed;
+    const signatureMismatch = inspection?.sameSignature === false;
+
+    const activityColumns = [
+        {title: '名称', dataIndex: 'name', key: 'name', ellipsis: true, render: (n: string) => <span className="font-mono text-xs">{n}</span>},
+        {
+            title: '', key: 'flags', width: 140,
+            render: (_: any, c: adb.ApkComponent) => (
+                <span>
+                    {c.launcher && <Tag color="blue">入口</Tag>}
+                    {c.exported && <Tag>exported</Tag>}
+                </span>
+            ),
+        },
+    ];
+
+    return (
+        <Modal
+            title="安装应用"
+            open={visible}
+            onCancel={close}
+            maskClosable={false}
+            width={900}
+            footer={
+                <div className="flex items-center justify-between">
+                    <div className="flex items-center gap-3">
+                        <Button icon={<FolderOpenOutlined/>} onClick={choose} loading={loading} disabled={installing}>选择 APK</Button>
+                        <InstallOptionsPicker value={options} onChange={setOptions} disabled={installing}/>
+                    </div>
+                    <div className="flex items-center gap-2">
+                        {signatureMismatch && apk && (
+                            <Popconfirm title={`将卸载 ${apk.package} 并清除其数据，确定继续？`}
+                                        onConfirm={() => install({...options, uninstallPackage: apk.package})}>
+                                <Button danger loading={installing} disabled={!!result?.success}>卸载后安装</Button>
+                            </Popconfirm>
+                        )}
+                        <Button type="primary" onClick={() => install()} loading={installing}
+                                disabled={!inspection || !!result?.success}>
+                            安装
+                        </Button>
+                    </div>
+                </div>
+            }
+        >
+            {loading ? (
+                <div className="flex justify-center py-10"><Spin tip="正在解析 APK..."/></div>
+            ) : !inspection || !apk ? (
+                <Empty description="选择 APK 后会显示清单、签名以及与已安装版本的对比" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+            ) : (
+                <div className="flex flex-col gap-3">
+                    <Descriptions size="small" column={2} bordered>
+                        <Descriptions.Item label="应用">{apk.label || '-'}</Descriptions.Item>
+                        <Descriptions.Item label="包名"><span className="font-mono">{apk.package}</span></Descriptions.Item>
+                        <Descriptions.Item label="版本">
+                            {apk.versionName || '-'} ({apk.versionCode})
+                            {installed && <span className="text-gray-500"> ← 已安装 {installed.versionName} ({installed.versionCode})</span>}
+                            {' '}<Tag color={versionTags[inspection.version]?.color}>{versionTags[inspection.version]?.label || inspection.version}</Tag>
+                        </Descriptions.Item>
+                        <Descriptions.Item label="SDK">
+                            min {apk.minSdkVersion} / target {apk.targetSdkVersion}
+                            {inspection.device.sdk > 0 && <span className="text-gray-500">（设备 {inspection.device.sdk}）</span>}
+                        </Descriptions.Item>
+                        <Descriptions.Item label="ABI">{apk.abis.length ? apk.abis.join(', ') : '无 so 库'}</Descriptions.Item>
+                        <Descriptions.Item label="大小">{formatBytes(apk.size)}</Descriptions.Item>
+                        <Descriptions.Item label="签名">
+                            {apk.signatureSchemes.join(' / ') || '未签名'}
+                            {inspection.sameSignature !== undefined && inspection.sameSignature !== null && (
+                                <Tag className="ml-2" color={inspection.sameSignature ? 'green' : 'red'}>
+                                    {inspection.sameSignature ? '与已安装版本一致' : '与已安装版本不一致'}
+                                </Tag>
+                            )}
+                        </Descriptions.Item>
+                        <Descriptions.Item label="标记">
+                            {apk.debuggable && <Tag>debuggable</Tag>}
+                            {apk.testOnly && <Tag>testOnly</Tag>}
+                            {apk.split && <Tag>split: {apk.split}</Tag>}
+                            {!apk.debuggable && !apk.testOnly && !apk.split && '-'}
+                        </Descriptions.Item>
+                    </Descriptions>
+
+                    {inspection.installedErr && <Alert type="info" showIcon message={inspection.installedErr}/>}
+                    {inspection.warnings.length > 0 && (
+                        <Alert type="warning" showIcon message={
+                            <div className="text-xs flex flex-col gap-1">{inspection.warnings.map(w => <div key={w}>{w}</div>)}</div>
+                        }/>
+                    )}
+
+                    <Tabs size="small" items={[
+                        {
+                            key: 'permissions', label: `权限 (${apk.permissions.length})`,
+                            children: (
+                                <div className="max-h-48 overflow-auto flex flex-col gap-0.5">
+                                    {apk.permissions.map(p => <span key={p} className="font-mono text-xs">{p}</span>)}
+                                </div>
+                            ),
+                        },
+                        {
+                            key: 'activities', label: `Activity (${apk.activities.length})`,
+                            children: (
+                                <Table size="small" rowKey="name" columns={activityColumns} dataSource={apk.activities}
+                                       pagination={false} scroll={{y: 180}}/>
+                            ),
+                        },
+                        {
+                            key: 'certificates', label: `证书 (${apk.certificates.length})`,
+                            children: (
+                                <div className="flex flex-col gap-2 text-xs">
+                                    {apk.certificates.map(c => (
+                                        <div key={c.sha256} className="flex flex-col gap-0.5">
+                                            <span>{c.subject}</span>
+                                            <Tooltip title="SHA-256"><span className="font-mono break-all">{c.sha256}</span></Tooltip>
+                                            <span className="text-gray-500">
+                                                有效期 {new Date(c.notBefore * 1000).toLocaleDateString()} - {new Date(c.notAfter * 1000).toLocaleDateString()}
+                                            </span>
+                                        </div>
+                                    ))}
+                                    {installed && installed.signatureSha256s?.length > 0 && (
+                                        <div className="flex flex-col gap-0.5 text-gray-500">
+                                            <span>已安装版本</span>
+                                            {installed.signatureSha256s.map(s => <span key={s} className="font-mono break-all">{s}</span>)}
+                                        </div>
+                                    )}
+                                </div>
+                            ),
+                        },
+                    ]}/>
+
+                    {result?.success && (
+                        <Alert type="success" message={`已安装 ${apk.package}`}
+                               description={<pre className="text-xs whitespace-pre-wrap max-h-40 overflow-auto m-0">{result.output}</pre>}/>
+                    )}
+                    {result && !result.success && (
+                        <InstallFailureAlert result={result} options={options} fallbackPackage={apk.package}
+                                             retrying={installing} onRetry={install}/>
+                    )}
+                </div>
+            )}
+        </Modal>
+    );
+};
+
+export default ApkInspectModal;
@@ -0,0 +1,189 @@
+import React, {useEffect, useState} from 'react';
+import {Alert, Button, Checkbox, Descriptions, Empty, Modal, Popconfirm, Tabs, Tag, message} from 'antd';
+import {FolderOpenOutlined} from '@ant-design/icons';
+import {BackupAppData, RestoreAppData, SelectAppBackup} from "../../wailsjs/go/main/App";
+import {adb} from "../../wailsjs/go/models";
+
+interface AppBackupModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    packageName: string;
+}
+
+const methodLabels: Record<string, string> = {
+    tar: '私有目录打包',
+    'adb-backup': 'adb backup',
+};
+
+function formatBytes(raw: number): string {
+    if (raw < 1024) return `${raw} B`;
+    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
+    return `${(raw / 1024 / 1024).toFixed(1)} MB`;
+}
+
+const AppBackupModal: React.FC<AppBackupModalProps> = ({visible, onClose, deviceId, packageName}) => {
+    const [tab, setTab] = useState('backup');
+    const [options, setOptions] = useState<adb.AppBackupOptions>({private: true, external: false});
+    const [backingUp, setBackingUp] = useState(false);
+    const [backup, setBackup] = useState<adb.AppBackupResult | null>(null);
+
+    const [check, setCheck] = useState<adb.AppBackupCheck | null>(null);
+    const [checking, setChecking] = useState(false);
+    const [force, setForce] = useState(false);
+    const [restoring, setRestoring] = useState(false);
+    const [restored, setRestored] = useState(false);
+
+    useEffect(() => {
+        if (visible) {
+            setTab(packageName ? 'backup' : 'restore');
+            setBackup(null);
+            setCheck(null);
+            setRestored(false);
+            setForce(false);
+        }
+    }, [visible]);
+
+    const runBackup = async () => {
+        setBackingUp(true);
+        setBackup(null);
+        try {
+            const res = await BackupAppData(deviceId, packageName, options);
+            setBackup(res);
+            message.success('备份完成');
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(`${msg}`);
+        } finally {
+            setBackingUp(false);
+        }
+    };
+
+    const chooseBackup = async () => {
+        setChecking(true);
+        try {
+            setCheck(await SelectAppBackup(deviceId));
+            setRestored(false);
+            setForce(false);
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(`${msg}`);
+        } finally {
+            setChecking(false);
+        }
+    };
+
+    const runRestore = async () => {
+        if (!check) return;
+        setRestoring(true);
+        try {
+            setCheck(await RestoreAppData(deviceId, check.file, force));
+            setRestored(true);
+            message.success('恢复完成');
+        } catch (e: any) {
+            message.error(`恢复失败: ${e?.message || e}`);
+        } finally {
+            setRestoring(false);
+        }
+    };
+
+    const meta = check?.meta;
+    const canRestore = !!check && check.errors.length === 0 && (!check.downgrade || force);
+
+    const backupTab = (
+        <div className="flex flex-col gap-3">
+            {!packageName ? (
+                <Empty description="请先在上方选择应用" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+            ) : (
+                <>
+                    <div className="text-sm">应用 <span className="font-mono">{packageName}</span></div>
+                    <div className="flex items-center gap-4">
+                        <Checkbox checked={options.private} disabled={backingUp}
+                                  onChange={e => setOptions({...options, private: e.target.checked})}>私有数据 /data/data</Checkbox>
+                        <Checkbox checked={options.external} disabled={backingUp}
+                                  onChange={e => setOptions({...options, external: e.target.checked})}>外部数据 /sdcard/Android/data</Checkbox>
+                    </div>
+                    <div className="text-xs text-gray-400">
+                        可调试应用通过 run-as 打包，已 root 的设备通过 root 打包，否则使用 adb backup（需要在设备上确认）。cache、code_cache 不会备份。
+                    </div>
+                    <div>
+                        <Button type="primary" onClick={runBackup} loading={backingUp} disabled={!options.private && !options.external}>
+                            备份到电脑
+                        </Button>
+                    </div>
+                </>
+            )}
+            {backup && (
+                <Alert type="success" showIcon message={`已保存 ${backup.file}（${formatBytes(backup.bytes)}）`}
+                       description={
+                           <div className="text-xs flex flex-col gap-1">
+                               <span>versionCode {backup.meta.versionCode} · {methodLabels[backup.meta.method] || '仅外部数据'}{backup.meta.access && ` (${backup.meta.access})`}</span>
+                               {backup.warnings.map(w => <span key={w} className="text-orange-500">{w}</span>)}
+                           </div>
+                       }/>
+            )}
+        </div>
+    );
+
+    const restoreTab = (
+        <div className="flex flex-col gap-3">
+            <div>
+                <Button icon={<FolderOpenOutlined/>} onClick={chooseBackup} loading={checking} disabled={restoring}>选择备份文件</Button>
+            </div>
+            {!check || !meta ? (
+                <Empty description="选择备份后会检查与设备上已安装版本的兼容性" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+            ) : (
+                <>
+                    <Descriptions size="small" column={2} bordered>
+                        <Descriptions.Item label="包名"><span className="font-mono">{meta.package}</span></Descriptions.Item>
+                        <Descriptions.Item label="备份时间">{new Date(meta.createdAt * 1000).toLocaleString()}</Descriptions.Item>
+                        <Descriptions.Item label="备份版本">{meta.versionName} ({meta.versionCode})</Descriptions.Item>
+                        <Descriptions.Item label="已安装版本">{check.installed ? check.versionCode : <Tag color="red">未安装</Tag>}</Descriptions.Item>
+                        <Descriptions.Item label="来源设备">{meta.device || meta.serial}{meta.sdk > 0 && ` · SDK ${meta.sdk}`}</Descriptions.Item>
+                        <Descriptions.Item label="内容">
+                            {meta.method && <Tag>{methodLabels[meta.method] || meta.method}</Tag>}
+                            {meta.external && <Tag>外部数据</Tag>}
+                            {check.access && <span className="text-xs text-gray-500">将通过 {check.access} 恢复</span>}
+                        </Descriptions.Item>
+                    </Descriptions>
+                    {check.errors.length > 0 && (
+                        <Alert type="error" showIcon message={<div className="text-xs flex flex-col gap-1">{check.errors.map(e => <div key={e}>{e}</div>)}</div>}/>
+                    )}
+                    {check.warnings.length > 0 && (
+                        <Alert type="warning" showIcon message={<div className="text-xs flex flex-col gap-1">{check.warnings.map(w => <div key={w}>{w}</div>)}</div>}/>
+                    )}
+                    {check.downgrade && (
+                        <Checkbox checked={force} disabled={restoring} onChange={e => setForce(e.target.checked)}>仍然恢复到低版本</Checkbox>
+                    )}
+                    {restored ? (
+                        <Alert type="success" showIcon message={`已恢复 ${meta.package} 的数据，应用已停止`}/>
+                    ) : (
+                        <div>
+                            <Popconfirm title={`将停止 ${meta.package} 并用备份替换当前数据，确定继续？`} onConfirm={runRestore} disabled={!canRestore}>
+                                <Button type="primary" danger loading={restoring} disabled={!canRestore}>恢复</Button>
+                            </Popconfirm>
+                        </div>
+                    )}
+                </>
+            )}
+        </div>
+    );
+
+    return (
+        <Modal
+            title="备份 / 恢复应用数据"
+            open={visible}
+            onCancel={onClose}
+            maskClosable={false}
+            width={760}
+            footer={null}
+        >
+            <Tabs activeKey={tab} onChange={setTab} items={[
+                {key: 'backup', label: '备份', children: backupTab, disabled: restoring},
+                {key: 'restore', label: '恢复', children: restoreTab, disabled: backingUp},
+            ]}/>
+        </Modal>
+    );
+};
+
+export default AppBackupModal;
@@ -0,0 +1,347 @@
+import React, {useCallback, useEffect, useMemo, useState} from 'react';
+import {Button, Empty, Input, Modal, Select, Spin, Switch, Table, Tabs, Tag, message} from 'antd';
+import {PlayCircleOutlined, ReloadOutlined, SaveOutlined} from '@ant-design/icons';
+import {
+    CloseAppDatabase,
+    CommitAppDatabase,
+    ExecAppDatabase,
+    ListAppDataFiles,
+    OpenAppDatabase,
+    QueryAppDatabase,
+    ReadSharedPrefs,
+    WriteSharedPrefs,
+} from "../../wailsjs/go/main/App";
+import {adb, appdata, main} from "../../wailsjs/go/models";
+
+interface AppDataModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    access: adb.PackageAccess;
+}
+
+const prefTypeColors: Record<string, string> = {
+    string: 'blue',
+    int: 'green',
+    long: 'cyan',
+    float: 'purple',
+    boolean: 'orange',
+    set: 'magenta',
+};
+
+// 写语句以这些关键字开头时走 ExecAppDatabase
+const writeStatement = /^\s*(insert|update|delete|replace|create|drop|alter)\b/i;
+
+const AppDataModal: React.FC<AppDataModalProps> = ({visible, onClose, deviceId, access}) => {
+    const [files, setFiles] = useState<adb.AppDataFiles | null>(null);
+    const [filesLoading, setFilesLoading] = useState(false);
+
+    // SharedPreferences
+    const [prefPath, setPrefPath] = useState<string>();
+    const [prefs, setPrefs] = useState<appdata.PrefEntry[]>([]);
+    const [prefsLoading, setPrefsLoading] = useState(false);
+    const [prefsDirty, setPrefsDirty] = useState(false);
+    const [prefsSaving, setPrefsSaving] = useState(false);
+
+    // 数据库
+    const [dbPath, setDbPath] = useState<string>();
+    const [dbWritable, setDbWritable] = useState(false);
+    const [database, setDatabase] = useState<main.AppDatabaseInfo | null>(null);
+    const [dbLoading, setDbLoading] = useState(false);
+    const [dbDirty, setDbDirty] = useState(false);
+    const [statement, setStatement] = useState('');
+    const [queryResult, setQueryResult] = useState<appdata.QueryResult | null>(null);
+    const [querying, setQuerying] = useState(false);
+
+    const loadFiles = useCallback(async () => {
+        setFilesLoading(true);
+        try {
+            setFiles(await ListAppDataFiles(deviceId, access));
+        } catch (e: any) {
+            message.error(`读取应用数据失败: ${e?.message || e}`);
+        } finally {
+            setFilesLoading(false);
+        }
+    }, [deviceId, access]);
+
+    const closeDatabase = useCallback(() => {
+        if (database) CloseAppDatabase(database.sessionId);
+        setDatabase(null);
+        setQueryResult(null);
+        setDbDirty(false);
+    }, [database]);
+
+    useEffect(() => {
+        if (visible) {
+            loadFiles();
+            return;
+        }
+        closeDatabase();
+        setPrefPath(undefined);
+        setPrefs([]);
+        setPrefsDirty(false);
+        setDbPath(undefined);
+        setStatement('');
+    }, [visible]);
+
+    const loadPrefs = async (path: string) => {
+        setPrefPath(path);
+        setPrefsLoading(true);
+        try {
+            setPrefs(await ReadSharedPrefs(deviceId, access, path) || []);
+            setPrefsDirty(false);
+        } catch (e: any) {
+            message.error(`读取失败: ${e?.message || e}`);
+            setPrefs([]);
+        } finally {
+            setPrefsLoading(false);
+        }
+    };
+
+    const updatePref = (name: string, value: string) => {
+        setPrefs(prev => prev.map(p => p.name !== name ? p
+            : p.type === 'set'
+                ? {...p, values: value.split('\n').filter(v => v !== '')}
+                : {...p, value}));
+        setPrefsDirty(true);
+    };
+
+    const savePrefs = () => {
+        if (!prefPath) return;
+        Modal.confirm({
+            title: '写回 SharedPreferences',
+            content: `写回前会强制停止 ${access.package}，确定继续吗？`,
+            okText: '写回',
+            cancelText: '取消',
+            onOk: async () => {
+                setPrefsSaving(true);
+                try {
+                    const result = await WriteSharedPrefs(deviceId, access, prefPath, prefs);
+                    if (result.error) {
+                        message.error(`写回失败: ${result.error}`);
+                        return;
+                    }
+                    message.success(result.res);
+                    setPrefsDirty(false);
+                } finally {
+                    setPrefsSaving(false);
+                }
+            },
+        });
+    };
+
+    const openDatabase = async (path: string, writable: boolean) => {
+        closeDatabase();
+        setDbPath(path);
+        setDbLoading(true);
+        try {
+            const info = await OpenAppDatabase(deviceId, access, path, writable);
+            setDatabase(info);
+            const first = info.tables?.find(t => t.type === 'table');
+            if (first) setStatement(`SELECT * FROM "${first.name}" LIMIT 100`);
+        } catch (e: any) {
+            message.error(`打开数据库失败: ${e?.message || e}`);
+        } finally {
+            setDbLoading(false);
+        }
+    };
+
+    const runStatement = async () => {
+        if (!database || !statement.trim()) return;
+        setQuerying(true);
+        try {
+            if (writeStatement.test(statement)) {
+                const result = await ExecAppDatabase(database.sessionId, statement);
+                message.success(`执行成功，影响 ${result.rowsAffected} 行`);
+                setDbDirty(true);
+                return;
+            }
+            setQueryResult(await QueryAppDatabase(database.sessionId, statement, 0));
+        } catch (e: any) {
+            message.error(`执行失败: ${e?.message || e}`);
+        } finally {
+            setQuerying(false);
+        }
+    };
+
+    const commitDatabase = () => {
+        if (!database) return;
+        Modal.confirm({
+            title: '写回数据库',
+            content: `写回前会强制停止 ${access.package}，设备上的 -wal/-shm 文件会被删除，确定继续吗？`,
+            okText: '写回',
+            cancelText: '取消',
+            onOk: async () => {
+                const result = await CommitAppDatabase(database.sessionId);
+                if (result.error) {
+                    message.error(`写回失败: ${result.error}`);
+                    return;
+                }
+                message.success(result.res);
+                setDbDirty(false);
+            },
+        });
+    };
+
+    const prefColumns = [
+        {title: '键', dataIndex: 'name', key: 'name', width: 220, ellipsis: true},
+        {
+            title: '类型', dataIndex: 'type', key: 'type', width: 90,
+            render: (type: string) => <Tag color={prefTypeColors[type]}>{type}</Tag>,
+        },
+        {
+            title: '值', key: 'value',
+            render: (_: any, entry: appdata.PrefEntry) => entry.type === 'null' ? (
+                <span className="text-gray-300">null</span>
+            ) : entry.type === 'boolean' ? (
+                <Switch size="small" checked={entry.value === 'true'}
+                        onChange={checked => updatePref(entry.name, String(checked))}/>
+            ) : (
+                <Input.TextArea
+                    autoSize={{minRows: 1, maxRows: 6}}
+                    value={entry.type === 'set' ? (entry.values || []).join('\n') : entry.value}
+                    onChange={e => updatePref(entry.name, e.target.value)}
+                    className="font-mono text-xs"
+                />
+            ),
+        },
+    ];
+
+    const resultColumns = useMemo(() => (queryResult?.columns || []).map((name, i) => ({
+        title: name,
+        key: `${i}`,
+        ellipsis: true,
+        render: (_: any, row: any[]) => row[i] === null
+            ? <span className="text-gray-300">NULL</span>
+            : <span className="font-mono text-xs">{String(row[i])}</span>,
+    })), [queryResult]);
+
+    const fileOptions = (entries?: adb.RemoteFileEntry[]) =>
+        (entries || []).map(e => ({value: e.path, label: e.name}));
+
+    return (
+        <Modal
+            title={
+                <div className="flex items-center justify-between">
+                    <span>应用数据 · <span className="font-mono">{access.package}</span></span>
+                    <Button type="link" icon={<ReloadOutlined/>} onClick={loadFiles} loading={filesLoading} className="mr-8">
+                        刷新
+                    </Button>
+                </div>
+            }
+            open={visible}
+            onCancel={onClose}
+            footer={null}
+            width={960}
+        >
+            <Tabs
+                items={[
+                    {
+                        key: 'prefs',
+                        label: `SharedPreferences (${files?.prefs?.length || 0})`,
+                        children: (
+                            <div className="flex flex-col gap-3">
+                                <div className="flex items-center gap-2">
+                                    <Select
+                                        className="flex-1"
+                                        placeholder="选择 shared_prefs 文件"
+                                        value={prefPath}
+                                        options={fileOptions(files?.prefs)}
+                                        onChange={loadPrefs}
+                                        showSearch
+                                    />
+                                    <Button type="primary" icon={<SaveOutlined/>} disabled={!prefsDirty}
+                                            loading={prefsSaving} onClick={savePrefs}>
+                                        写回设备
+                                    </Button>
+                                </div>
+                                <Table
+                                    size="small"
+                                    rowKey="name"
+                                    loading={prefsLoading}
+                                    columns={prefColumns}
+                                    dataSource={prefs}
+                                    pagination={false}
+                                    scroll={{y: '50vh'}}
+                                    locale={{emptyText: <Empty description="无数据" image={Empty.PRESENTED_IMAGE_SIMPLE}/>}}
+                                />
+                            </div>
+                        ),
+                    },
+                    {
+                        key: 'databases',
+                        label: `数据库 (${files?.databases?.length || 0})`,
+                        children: (
+                            <div className="flex flex-col gap-3">
+                                <div className="flex items-center gap-2">
+                                    <Select
+                                        className="flex-1"
+                                        placeholder="选择 databases 下的数据库"
+                                        value={dbPath}
+                                        options={fileOptions(files?.databases)}
+                                        onChange={path => openDatabase(path, dbWritable)}
+                                        showSearch
+                                    />
+                                    <span className="text-xs text-gray-500">允许修改</span>
+                                    <Switch size="small" checked={dbWritable} onChange={checked => {
+                                        setDbWritable(checked);
+                                        if (dbPath) openDatabase(dbPath, checked);
+                                    }}/>
+                                    {database?.writable && (
+                                        <Button type="primary" icon={<SaveOutlined/>} disabled={!dbDirty} onClick={commitDatabase}>
+                                            写回设备
+                                        </Button>
+                                    )}
+                                </div>
+                                {dbLoading ? (
+                                    <div className="flex justify-center py-8"><Spin tip="拉取数据库..."/></div>
+                                ) : database && (
+                                    <>
+                                        <div className="flex flex-wrap gap-1">
+                                            {(database.tables || []).map(t => (
+                                                <Tag key={t.name} className="cursor-pointer"
+                                                     color={t.type === 'view' ? 'purple' : undefined}
+                                                     onClick={() => setStatement(`SELECT * FROM "${t.name}" LIMIT 100`)}>
+                                                    {t.name}{t.type === 'table' ? ` (${t.rowCount})` : ''}
+                                                </Tag>
+                                            ))}
+                                        </div>
+                                        <div className="flex items-start gap-2">
+                                            <Input.TextArea
+                                                value={statement}
+                                                onChange={e => setStatement(e.target.value)}
+                                                autoSize={{minRows: 2, maxRows: 6}}
+                                                className="font-mono text-xs"
+                                                placeholder={database.writable ? 'SQL，写语句只修改本地副本，需写回设备后生效' : '只读查询'}
+                                            />
+                                            <Button type="primary" icon={<PlayCircleOutlined/>} loading={querying} onClick={runStatement}>
+                                                执行
+                                            </Button>
+                                        </div>
+                                        {queryResult && (
+                                            <>
+                                                <Table
+                                                    size="small"
+                                                    rowKey={(_, i) => `${i}`}
+                                                    columns={resultColumns}
+                                                    dataSource={queryResult.rows || []}
+                                                    pagination={{pageSize: 50, size: 'small'}}
+                                                    scroll={{x: 'max-content', y: '40vh'}}
+                                                />
+                                                {queryResult.truncated && (
+                                                    <span className="text-xs text-orange-500">结果过多，只显示前 {queryResult.rows.length} 行</span>
+                                                )}
+                                            </>
+                                        )}
+                                    </>
+                                )}
+                            </div>
+                        ),
+                    },
+                ]}
+            />
+        </Modal>
+    );
+};
+
+export default AppDataModal;
@@ -0,0 +1,172 @@
+import React, {useEffect, useRef, useState} from 'react';
+import {Button, Checkbox, Modal, Progress, Radio, Select, Table, Tag, Tooltip, message} from 'antd';
+import {CancelFileTransfer, ExportApps} from "../../wailsjs/go/main/App";
+import {adb, main} from "../../wailsjs/go/models";
+import {EventsOff, EventsOn} from "../../wailsjs/runtime/runtime";
+import {useAppListStore} from "../store/appListStore";
+
+interface AppExportModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    defaultPackages: string[];
+}
+
+const statusTags: Record<string, {color: string; label: string}> = {
+    running: {color: 'processing', label: '导出中'},
+    completed: {color: 'success', label: '完成'},
+    failed: {color: 'error', label: '失败'},
+    cancelled: {color: 'warning', label: '已取消'},
+};
+
+function formatBytes(raw: number): string {
+    if (raw < 1024) return `${raw} B`;
+    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
+    if (raw < 1024 * 1024 * 1024) return `${(raw / 1024 / 1024).toFixed(1)} MB`;
+    return `${(raw / 1024 / 1024 / 1024).toFixed(2)} GB`;
+}
+
+const AppExportModal: React.FC<AppExportModalProps> = ({visible, onClose, deviceId, defaultPackages}) => {
+    const apps = useAppListStore(state => state.apps);
+    const [packages, setPackages] = useState<string[]>([]);
+    const [includeObb, setIncludeObb] = useState(true);
+    const [includeData, setIncludeData] = useState(false);
+    const [format, setFormat] = useState<string>('apks');
+    const [running, setRunning] = useState(false);
+    const [current, setCurrent] = useState<main.AppExportEvent | null>(null);
+    const [results, setResults] = useState<adb.AppExportResult[]>([]);
+    const taskIdRef = useRef<string>('');
+
+    useEffect(() => {
+        if (visible) {
+            setPackages(defaultPackages.filter(p => !!p));
+            setResults([]);
+            setCurrent(null);
+        }
+    }, [visible]);
+
+    useEffect(() => {
+        EventsOn('app-export-progress', (event: main.AppExportEvent) => {
+            if (!taskIdRef.current) taskIdRef.current = event.taskId;
+            if (event.taskId !== taskIdRef.current) return;
+            setCurrent(event);
+            const result = event.result;
+            if (result) setResults(prev => [...prev.filter(r => r.package !== result.package), result]);
+        });
+        return () => EventsOff('app-export-progress');
+    }, []);
+
+    const start = async () => {
+        taskIdRef.current = '';
+        setResults([]);
+        setCurrent(null);
+        setRunning(true);
+        try {
+            const res = await ExportApps(deviceId, packages, {includeObb, includeData, format});
+            setResults(res);
+            const done = res.filter(r => r.status === 'completed').length;
+            if (done === res.length) message.success(`已导出 ${done} 个应用`);
+            else message.warning(`${done} 个应用导出成功，${res.length - done} 个未完成`);
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(`${msg}`);
+        } finally {
+            setRunning(false);
+        }
+    };
+
+    const stop = () => {
+        if (taskIdRef.current) CancelFileTransfer(taskIdRef.current);
+    };
+
+    const close = () => {
+        if (running) stop();
+        onClose();
+    };
+
+    const progress = current?.progress;
+    const percent = progress && progress.total > 0 ? Math.floor(progress.bytes * 100 / progress.total) : 0;
+
+    const columns = [
+        {title: '应用', dataIndex: 'package', key: 'package', ellipsis: true, render: (p: string) => <span className="font-mono text-xs">{p}</span>},
+        {
+            title: '状态', dataIndex: 'status', key: 'status', width: 90,
+            render: (s: string) => <Tag color={statusTags[s]?.color}>{statusTags[s]?.label || s}</Tag>,
+        },
+        {
+            title: '内容', key: 'content', width: 200,
+            render: (_: any, r: adb.AppExportResult) => (
+                <span className="text-xs text-gray-500">
+                    {r.splits} 个 APK{r.obbs > 0 && `，${r.obbs} 个 OBB`}{r.dataFiles > 0 && `，${r.dataFiles} 个数据文件`} · {formatBytes(r.bytes)}
+                </span>
+            ),
+        },
+        {
+            title: '文件 / 错误', key: 'file', ellipsis: true,
+            render: (_: any, r: adb.AppExportResult) => (
+                <Tooltip title={[r.file, ...r.errors].filter(Boolean).join('\n')}>
+                    <span className={`text-xs ${r.errors.length > 0 ? 'text-orange-500' : 'text-gray-500'}`}>
+                        {r.file || r.errors[0] || '-'}
+                    </span>
+                </Tooltip>
+            ),
+        },
+    ];
+
+    return (
+        <Modal
+            title="导出应用"
+            open={visible}
+            onCancel={close}
+            maskClosable={false}
+            width={860}
+            footer={
+                <div className="flex items-center justify-end gap-2">
+                    {running && <Button onClick={stop}>停止</Button>}
+                    <Button type="primary" onClick={start} loading={running} disabled={packages.length === 0}>
+                        导出 {packages.length} 个应用
+                    </Button>
+                </div>
+            }
+        >
+            <div className="flex flex-col gap-3">
+                <Select
+                    mode="tags"
+                    value={packages}
+                    onChange={setPackages}
+                    disabled={running}
+                    placeholder="选择或输入包名"
+                    maxTagCount="responsive"
+                    options={apps.map(a => ({value: a.packageName, label: `${a.label} (${a.packageName})`}))}
+                    optionFilterProp="label"
+                />
+                <div className="flex items-center gap-4">
+                    <Checkbox checked={includeObb} disabled={running} onChange={e => setIncludeObb(e.target.checked)}>包含 OBB</Checkbox>
+                    <Tooltip title="/sdcard/Android/data/<包名>，部分系统版本可能无权读取">
+                        <Checkbox checked={includeData} disabled={running} onChange={e => setIncludeData(e.target.checked)}>包含外部数据目录</Checkbox>
+                    </Tooltip>
+                    <Radio.Group value={format} disabled={running} onChange={e => setFormat(e.target.value)} size="small">
+                        <Radio.Button value="apks">.apks</Radio.Button>
+                        <Radio.Button value="zip">.zip</Radio.Button>
+                    </Radio.Group>
+                    <span className="text-xs text-gray-400">包内附带 manifest.json，可通过“安装拆分包”重新安装</span>
+                </div>
+                {running && current && (
+                    <div className="flex flex-col gap-1">
+                        <div className="text-xs text-gray-500">
+                            ({current.index + 1}/{current.count}) {current.package}
+                            {progress && progress.file && <span className="font-mono"> · {progress.file}</span>}
+                        </div>
+                        <Progress percent={percent} size="small"
+                                  format={() => progress ? `${formatBytes(progress.bytes)} / ${formatBytes(progress.total)}` : ''}/>
+                    </div>
+                )}
+                {results.length > 0 && (
+                    <Table size="small" rowKey="package" columns={columns} dataSource={results} pagination={false} scroll={{y: '40vh'}}/>
+                )}
+            </div>
+        </Modal>
+    );
+};
+
+export default AppExportModal;
@@ -0,0 +1,169 @@
+import React, {useCallback, useEffect, useMemo, useState} from 'react';
+import {Button, Checkbox, Input, Modal, Select, Space, Table, Tag, message} from 'antd';
+import {ReloadOutlined, SearchOutlined} from '@ant-design/icons';
+import {GetAppPermissions, GrantAppPermission, ResetAppPermission, RevokeAppPermission, SetAppOp} from "../../wailsjs/go/main/App";
+import {adb} from "../../wailsjs/go/models";
+
+interface AppPermissionModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    userId: string; // 为空时后端读取当前用户，之后的操作使用返回的用户
+    packageName: string;
+    label: string;
+}
+
+const appOpModeOptions = ['allow', 'ignore', 'deny', 'default', 'foreground'].map(mode => ({label: mode, value: mode}));
+
+// 权限名去掉 android.permission. 前缀，便于浏览
+const shortName = (name: string) => name.replace(/^android\.permission\./, '');
+
+const AppPermissionModal: React.FC<AppPermissionModalProps> = ({visible, onClose, deviceId, userId, packageName, label}) => {
+    const [info, setInfo] = useState<adb.AppPermissionInfo | null>(null);
+    const [loading, setLoading] = useState(false);
+    const [busy, setBusy] = useState('');
+    const [keyword, setKeyword] = useState('');
+    const [runtimeOnly, setRuntimeOnly] = useState(true);
+
+    const load = useCallback(async () => {
+        setLoading(true);
+        try {
+            setInfo(await GetAppPermissions(deviceId, userId, packageName));
+        } catch (e: any) {
+            setInfo(null);
+            message.error(`读取权限失败: ${e?.message || e}`);
+        } finally {
+            setLoading(false);
+        }
+    }, [deviceId, userId, packageName]);
+
+    useEffect(() => {
+        if (visible) {
+            setKeyword('');
+            load();
+        }
+    }, [visible, load]);
+
+    // 授权与 appops 都按读取时的用户执行，避免与列表显示的状态不一致
+    const targetUser = info?.userId ?? userId;
+
+    const run = async (key: string, action: string, call: () => Promise<{error?: string}>) => {
+        setBusy(key);
+        try {
+            const result = await call();
+            if (result.error) {
+                message.error(`${action}失败: ${result.error}`);
+                return;
+            }
+            message.success(`已${action}`);
+            await load();
+        } finally {
+            setBusy('');
+        }
+    };
+
+    const permissions = useMemo(() => {
+        const text = keyword.trim().toLowerCase();
+        return (info?.permissions || []).filter(p =>
+            (!runtimeOnly || p.runtime || p.appOp) && (!text || p.name.toLowerCase().includes(text)));
+    }, [info, keyword, runtimeOnly]);
+
+    const columns = [
+        {
+            title: '权限',
+            dataIndex: 'name',
+            render: (name: string, p: adb.AppPermission) => (
+                <div className="flex flex-col">
+                    <span className="font-mono text-xs break-all" title={name}>{shortName(name)}</span>
+                    {p.flags.length > 0 && <span className="text-xs text-gray-400 break-all">{p.flags.join(' | ')}</span>}
+                </div>
+            ),
+        },
+        {
+            title: '状态',
+            width: 130,
+            render: (_: unknown, p: adb.AppPermission) => (
+                <Space size={4} wrap>
+                    <Tag color={p.granted ? 'green' : 'default'}>{p.granted ? '已授予' : '未授予'}</Tag>
+                    {p.runtime && <Tag color="blue">运行时</Tag>}
+                    {!p.requested && <Tag color="orange" title="清单中未声明">未声明</Tag>}
+                </Space>
+            ),
+        },
+        {
+            title: 'AppOp',
+            width: 150,
+            render: (_: unknown, p: adb.AppPermission) => p.appOp ? (
+                <Select
+                    size="small"
+                    className="w-full"
+                    value={p.appOpMode || undefined}
+                    placeholder={p.appOp}
+                    options={appOpModeOptions}
+                    loading={busy === `op:${p.appOp}`}
+                    disabled={!!busy}
+                    onChange={mode => run(`op:${p.appOp}`, `将 ${p.appOp} 设为 ${mode}`,
+                        () => SetAppOp(deviceId, targetUser, packageName, p.appOp!, mode))}
+                />
+            ) : <span className="text-gray-300">-</span>,
+        },
+        {
+            title: '操作',
+            width: 190,
+            render: (_: unknown, p: adb.AppPermission) => p.runtime ? (
+                <Space size={0}>
+                    <Button type="link" size="small" disabled={p.granted || !!busy} loading={busy === `grant:${p.name}`}
+                            onClick={() => run(`grant:${p.name}`, `授予 ${shortName(p.name)}`,
+                                () => GrantAppPermission(deviceId, targetUser, packageName, p.name))}>授予</Button>
+                    <Button type="link" size="small" danger disabled={!p.granted || !!busy} loading={busy === `revoke:${p.name}`}
+                            onClick={() => run(`revoke:${p.name}`, `撤销 ${shortName(p.name)}`,
+                                () => RevokeAppPermission(deviceId, targetUser, packageName, p.name))}>撤销</Button>
+                    <Button type="link" size="small" disabled={!!busy} loading={busy === `reset:${p.name}`} title="撤销授权并恢复为未询问状态"
+                            onClick={() => run(`reset:${p.name}`, `重置 ${shortName(p.name)}`,
+                                () => ResetAppPermission(deviceId, targetUser, packageName, p.name))}>重置</Button>
+                </Space>
+            ) : <span className="text-xs text-gray-400">安装时授予</span>,
+        },
+    ];
+
+    return (
+        <Modal
+            title={`权限管理 - ${label}`}
+            open={visible}
+            onCancel={onClose}
+            footer={null}
+            width={900}
+            destroyOnHidden
+        >
+            <div className="flex items-center justify-between gap-3 mb-3">
+                <Space>
+                    <Input
+                        allowClear
+                        size="small"
+                        prefix={<SearchOutlined/>}
+                        placeholder="搜索权限"
+                        value={keyword}
+                        onChange={e => setKeyword(e.target.value)}
+                        className="!w-60"
+                    />
+                    <Checkbox checked={runtimeOnly} onChange={e => setRuntimeOnly(e.target.checked)}>只看运行时权限与 AppOp</Checkbox>
+                </Space>
+                <Space>
+                    {info && <span className="text-xs text-gray-500">用户 {info.userId}</span>}
+                    <Button size="small" icon={<ReloadOutlined/>} onClick={load} loading={loading}>刷新</Button>
+                </Space>
+            </div>
+            <Table
+                rowKey="name"
+                size="small"
+                loading={loading}
+                dataSource={permissions}
+                columns={columns}
+                pagination={false}
+                scroll={{y: 480}}
+            />
+        </Modal>
+    );
+};
+
+export default AppPermissionModal;
@@ -1,23 +1,55 @@
 // components/ApplicationList.tsx
 import { useEffect, useState, useMemo, useCallback, useRef, memo } from 'react';
-import { Input, Select, message, Space, Button, Progress, Empty, Spin, Pagination, Typography } from 'antd';
-import { SearchOutlined, ReloadOutlined, AppstoreOutlined, ClockCircleOutlined, FolderOutlined, SafetyCertificateOutlined } from '@ant-design/icons';
+import { Input, Select, message, Space, Button, Progress, Empty, Spin, Pagination, Typography, Dropdown, Modal, Tag } from 'antd';
+import { SearchOutlined, ReloadOutlined, AppstoreOutlined, ClockCircleOutlined, FolderOutlined, SafetyCertificateOutlined, DatabaseOutlined, ClearOutlined, ExportOutlined, HistoryOutlined, SettingOutlined, KeyOutlined } from '@ant-design/icons';
 import { useDeviceStore } from '../store/deviceStore';
 import { useAppListStore, PackageInfo, ProgressInfo } from '../store/appListStore';
-import {GetApplicationListWithProgress, CancelApplicationListLoading, LogMsg} from '../../wailsjs/go/main/App';
+import {GetApplicationListWithProgress, CancelApplicationListLoading, ExecuteAction, LogMsg, GetProtectedPackages, SetPackageState} from '../../wailsjs/go/main/App';
 import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime';
+import AppExportModal from './AppExportModal';
+import AppPermissionModal from './AppPermissionModal';
+import PackageHistoryModal, { packageOpLabels } from './PackageHistoryModal';
+import UserSelector from './UserSelector';
 
 const { Paragraph } = Typography;
 
-type FilterType = 'all' | 'user' | 'system';
+type FilterType = 'all' | 'user' | 'system' | 'disabled';
+type SortType = 'default' | 'totalSize' | 'appSize' | 'dataSize' | 'cacheSize';
 
 const formatTime = (timestamp: number) => {
     if (!timestamp) return '-';
     return new Date(timestamp).toLocaleDateString('zh-CN');
 };
 
+const formatSize = (bytes: number) => {
+    if (!bytes) return '-';
+    if (bytes < 1024) return `${bytes} B`;
+    if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+    if (bytes < 1024 * 1024 * 1024) return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+    return `${(bytes / (1024 * 1024 * 1024)).toFixed(2)} GB`;
+};
+
+// dataSize 已包含缓存，总大小 = 安装包 + 数据
+const getTotalSize = (app: PackageInfo) => (app.appSize || 0) + (app.dataSize || 0);
+
+// 修改应用状态前的确认说明
+const packageOpDescriptions: Record<string, string> = {
+    disable: '停用后应用从桌面消失且无法运行，数据保留，可随时启用。',
+    enable: '恢复为可用状态。',
+    suspend: '挂起后应用图标变灰且无法打开，通知被隐藏，数据保留。',
+    unsuspend: '恢复为可以打开的状态。',
+    uninstall: '仅为当前用户卸载（pm uninstall -k --user 0），保留数据，系统应用可通过“重新安装”恢复。',
+};
+
+const sizeGetters: Record<Exclude<SortType, 'default'>, (app: PackageInfo) => number> = {
+    totalSize: getTotalSize,
+    appSize: app => app.appSize || 0,
+    dataSize: app => app.dataSize || 0,
+    cacheSize: app => app.cacheSize || 0,
+};
+
 function ApplicationList() {
-    const { devices, selectedDevice } = useDeviceStore();
+    const { devices, selectedDevice, selectedUserId } = useDeviceStore();
     const {
         apps,
         isLoading,
@@ -31,8 +63,13 @@ function ApplicationList() {
 
     const [searchText, setSearchText] = useState('');
     const [filterType, setFilterType] = useState<FilterType>('all');
+    const [sortType, setSortType] = useState<SortType>('default');
     const [currentPage, setCurrentPage] = useState(1);
     const [pageSize, setPageSize] = useState(20);
+    const [exportOpen, setExportOpen] = useState(false);
+    const [historyOpen, setHistoryOpen] = useState(false);
+    const [permissionApp, setPermissionApp] = useState<PackageInfo | null>(null);
+    const [protectedPackages, setProtectedPackages] = useState<Record<string, string>>({});
 
     const mountedRef = useRef(true);
     const loadIdRef = useRef(0);
@@ -43,6 +80,12 @@ function ApplicationList() {
         return devices.length > 1 && selectedDevice ? selectedDevice.id : '';
     }, [devices.length, selectedDevice]);
 
+    // 不同用户的应用列表分开缓存
+    const getCacheKey = useCallback(() => {
+        const deviceIdParam = getDeviceIdParam();
+        return selectedUserId ? `${deviceIdParam}@user${selectedUserId}` : deviceIdParam;
+    }, [getDeviceIdParam, selectedUserId]);
+
     // 组件挂载/卸载处理
     useEffect(() => {
         mountedRef.current = true;
@@ -77,12 +120,13 @@ function ApplicationList() {
         }
 
         const deviceIdParam = getDeviceIdParam();
+        const cacheKey = getCacheKey();
 
         if (!forceRefresh) {
-            const cached = getAppsFromCache(deviceIdParam);
+            const cached = getAppsFromCache(cacheKey);
             if (cached && cached.length > 0) {
-                if (loadedDeviceId !== deviceIdParam) {
-                    setApps(deviceIdParam, cached);
+                if (loadedDeviceId !== cacheKey) {
+                    setApps(cacheKey, cached);
                 }
                 setLoading(false);
                 console.log('Using cached app list for device:', deviceIdParam || 'default');
@@ -99,17 +143,17 @@ function ApplicationList() {
         isLoadingRef.current = true;
 
         try {
-            const result = await GetApplicationListWithProgress(deviceIdParam);
+            const result = await GetApplicationListWithProgress(deviceIdParam, selectedUserId);
 
             if (!mountedRef.current || loadIdRef.current !== currentLoadId) {
                 return;
             }
 
             if (result && result.length > 0) {
-                setApps(deviceIdParam, result);
+                setApps(cacheKey, result);
                 message.success(`成功加载 ${result.length} 个应用`);
             } else {
-                setApps(deviceIdParam, []);
+                setApps(cacheKey, []);
                 message.info('未找到应用');
             }
         } catch (error: any) {
@@ -123,7 +167,7 @@ function ApplicationList() {
             }
 
             message.error(`加载失败: ${errorStr}`);
-            setApps(deviceIdParam, []);
+            setApps(cacheKey, []);
         } finally {
             isLoadingRef.current = false;
             setLoading(false);
@@ -133,8 +177,7 @@ function ApplicationList() {
     // 设备变化时自动加载（优先使用缓存）
     useEffect(() => {
         if (selectedDevice) {
-            const deviceIdParam = getDeviceIdParam();
-            const cached = getAppsFromCache(deviceIdParam);
+            const cached = getAppsFromCache(getCacheKey());
             // 没有缓存才强制刷新
             const forceRefresh = !cached || cached.length === 0;
             doLoadApps(forceRefresh);
@@ -147,7 +190,7 @@ function ApplicationList() {
                 progress: null,
             });
         }
-    }, [selectedDevice?.id]);
+    }, [selectedDevice?.id, selectedUserId]);
 
     // 手动刷新（强制重新加载）
     const handleRefresh = () => {
@@ -169,6 +212,9 @@ function ApplicationList() {
             case 'system':
                 result = result.filter(app => app.system);
                 break;
+            case 'disabled':
+                result = result.filter(app => !app.enabled);
+                break;
         }
 
         if (searchText.trim()) {
@@ -179,13 +225,92 @@ function ApplicationList() {
             );
         }
 
+        if (sortType !== 'default') {
+            const getSize = sizeGetters[sortType];
+            result = [...result].sort((a, b) => getSize(b) - getSize(a));
+        }
+
         return result;
-    }, [apps, searchText, filterType]);
+    }, [apps, searchText, filterType, sortType]);
 
     // 筛选条件变化时重置页码
     useEffect(() => {
         setCurrentPage(1);
-    }, [searchText, filterType]);
+    }, [searchText, filterType, sortType]);
+
+    const handleClearCache = useCallback(async (app: PackageInfo) => {
+        try {
+            const result = await ExecuteAction({
+                action: 'clear-cache',
+                targetPackageName: app.packageName,
+                deviceId: getDeviceIdParam(),
+                userId: selectedUserId,
+            });
+            if (result.error) {
+                message.error(`清除缓存失败: ${result.error}`);
+                return;
+            }
+            message.success(`已清除 ${app.label} 的缓存`);
+        } catch (error: any) {
+            message.error(`清除缓存失败: ${error?.toString() || ''}`);
+        }
+    }, [getDeviceIdParam, selectedUserId]);
+
+    useEffect(() => {
+        GetProtectedPackages().then(list => {
+            const map: Record<string, string> = {};
+            list.forEach(p => { map[p.package] = p.reason; });
+            setProtectedPackages(map);
+        });
+    }, []);
+
+    // 在列表中同步状态变化，避免整体刷新
+    const applyPackageState = useCallback((packageName: string, op: string) => {
+        const state = useAppListStore.getState();
+        let next = state.apps;
+        if (op === 'disable' || op === 'enable') {
+            next = next.map(app => app.packageName === packageName ? { ...app, enabled: op === 'enable' } : app);
+        } else if (op === 'uninstall') {
+            next = next.filter(app => app.packageName !== packageName);
+        } else if (op === 'reinstall') {
+            message.info('刷新列表后可以看到重新安装的应用');
+            return;
+        } else {
+            return;
+        }
+        setApps(getCacheKey(), next);
+    }, [getCacheKey, setApps]);
+
+    const handlePackageOp = useCallback((app: PackageInfo, op: string) => {
+        if (!selectedDevice) return;
+        const reason = protectedPackages[app.packageName];
+        const restrictive = op === 'disable' || op === 'suspend' || op === 'uninstall';
+        const forced = !!reason && restrictive;
+        Modal.confirm({
+            title: `${packageOpLabels[op]} ${app.label}？`,
+            content: (
+                <div className="flex flex-col gap-2">
+                    <span>{packageOpDescriptions[op]}</span>
+                    {forced && (
+                        <span className="text-red-500">
+                            {app.packageName} 是受保护的系统应用（{reason}），继续操作可能导致设备无法正常使用。
+                        </span>
+                    )}
+                </div>
+            ),
+            okText: forced ? '仍然执行' : packageOpLabels[op],
+            okButtonProps: { danger: restrictive },
+            onOk: async () => {
+                const result = await SetPackageState(selectedDevice.id.toString(), selectedUserId, app.packageName, op, forced);
+                if (result.error) {
+                    message.error(`${packageOpLabels[op]}失败: ${result.error}`);
+                    return;
+                }
+                message.success(`已${packageOpLabels[op]} ${app.label}，可在操作记录中撤销`);
+                applyPackageState(app.packageName, op);
+            },
+        });
+    }, [selectedDevice, selectedUserId, protectedPackages, applyPackageState]);
 
     // 分页
     const paginatedApps = useMemo(() => {
@@ -198,6 +323,7 @@ function ApplicationList() {
         total: apps.length,
         user: apps.filter(app => !app.system).length,
         system: apps.filter(app => app.system).length,
+        disabled: apps.filter(app => !app.enabled).length,
     }), [apps]);
 
     const handlePageChange = (page: number, size: number) => {
@@ -231,16 +357,44 @@ function ApplicationList() {
                                 { label: `全部应用 (${stats.total})`, value: 'all' },
                                 { label: `用户应用 (${stats.user})`, value: 'user' },
                                 { label: `系统应用 (${stats.system})`, value: 'system' },
+                                { label: `已停用 (${stats.disabled})`, value: 'disabled' },
+                            ]}
+                        />
+                        <Select
+                            value={sortType}
+                            onChange={setSortType}
+                            className="w-40"
+                            options={[
+                                { label: '默认排序', value: 'default' },
+                                { label: '占用空间最大', value: 'totalSize' },
+                                { label: '安装包最大', value: 'appSize' },
+                                { label: '数据最大', value: 'dataSize' },
+                                { label: '缓存最大', value: 'cacheSize' },
                             ]}
                         />
                     </div>
                     <Space>
+                        <UserSelector className="w-48" />
                         <span className="text-sm text-gray-500">
                             {filteredApps.length === apps.length
                                 ? `共 ${apps.length} 个应用`
                                 : `筛选出 ${filteredApps.length} / ${apps.length} 个应用`
                             }
                         </span>
+                        <Button
+                            icon={<HistoryOutlined />}
+                            onClick={() => setHistoryOpen(true)}
+                            disabled={!selectedDevice}
+                        >
+                            操作记录
+                        </Button>
+                        <Button
+                            icon={<ExportOutlined />}
+                            onClick={() => setExportOpen(true)}
+                            disabled={!selectedDevice || apps.length === 0}
+                        >
+                            批量导出
+                        </Button>
                         <Button
                             icon={<ReloadOutlined />}
                             onClick={handleRefresh}
@@ -279,12 +433,47 @@ function ApplicationList() {
                 ) : (
                     <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 gap-4">
                         {paginatedApps.map((app) => (
-                            <AppCard key={app.packageName} app={app} />
+                            <AppCard
+                                key={app.packageName}
+                                app={app}
+                                protectedReason={protectedPackages[app.packageName]}
+                                onClearCache={handleClearCache}
+                                onPackageOp={handlePackageOp}
+                                onOpenPermissions={setPermissionApp}
+                            />
                         ))}
                     </div>
                 )}
             </div>
 
+            <AppExportModal
+                visible={exportOpen}
+                onClose={() => setExportOpen(false)}
+                deviceId={getDeviceIdParam()}
+                defaultPackages={[]}
+            />
+
+            {selectedDevice && (
+                <PackageHistoryModal
+                    visible={historyOpen}
+                    onClose={() => setHistoryOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                    userId={selectedUserId}
+                    onChanged={applyPackageState}
+                />
+            )}
+
+            {selectedDevice && permissionApp && (
+                <AppPermissionModal
+                    visible={!!permissionApp}
+                    onClose={() => setPermissionApp(null)}
+                    deviceId={selectedDevice.id.toString()}
+                    userId={selectedUserId}
+                    packageName={permissionApp.packageName}
+                    label={permissionApp.label}
+                />
+            )}
+
             {/* 分页器 */}
             {filteredApps.length > 0 && (
                 <div className="bg-white border-t border-gray-200 px-6 py-4 flex-shrink-0">
@@ -305,7 +494,25 @@ function ApplicationList() {
 }
 
 // 使用 memo 优化 AppCard，避免不必要的重渲染
-const AppCard = memo(function AppCard({ app }: { app: PackageInfo }) {
+interface AppCardProps {
+    app: PackageInfo;
+    protectedReason?: string;
+    onClearCache: (app: PackageInfo) => void;
+    onPackageOp: (app: PackageInfo, op: string) => void;
+    onOpenPermissions: (app: PackageInfo) => void;
+}
+
+const AppCard = memo(function AppCard({ app, protectedReason, onClearCache, onPackageOp, onOpenPermissions }: AppCardProps) {
+    const opItems = [
+        app.enabled
+            ? { key: 'disable', label: '停用', danger: true }
+            : { key: 'enable', label: '启用' },
+        { key: 'suspend', label: '挂起', danger: true },
+        { key: 'unsuspend', label: '取消挂起' },
+        { type: 'divider' as const },
+        { key: 'uninstall', label: '为当前用户卸载（保留数据）', danger: true },
+    ];
+
     return (
         <div className="bg-white rounded-lg shadow-sm hover:shadow-md transition-all duration-200 p-4 border border-gray-100 h-full flex flex-col">
             <div className="flex items-start gap-3 mb-3">
@@ -329,7 +536,16 @@ const AppCard = memo(function AppCard({ app }: { app: PackageInfo }) {
                     <span className={`text-xs px-1.5 py-0.5 rounded ${app.system ? 'bg-orange-100 text-orange-700' : 'bg-blue-100 text-blue-700'}`}>
                         {app.system ? '系统应用' : '用户应用'}
                     </span>
+                    {!app.enabled && <Tag color="default" className="!ml-1.5 !text-xs">已停用</Tag>}
+                    {protectedReason && <Tag color="red" className="!ml-1.5 !text-xs" title={protectedReason}>受保护</Tag>}
                 </div>
+                <Dropdown
+                    trigger={['click']}
+                    menu={{ items: opItems, onClick: ({ key }) => onPackageOp(app, key) }}
+                >
+                    <Button type="text" size="small" icon={<SettingOutlined />} title="修改应用状态" />
+                </Dropdown>
+                <Button type="text" size="small" icon={<KeyOutlined />} title="权限管理" onClick={() => onOpenPermissions(app)} />
             </div>
 
             <div className="mb-3">
@@ -385,6 +601,32 @@ const AppCard = memo(function AppCard({ app }: { app: PackageInfo }) {
                 </div>
             )}
 
+            <div className="space-y-1.5 mb-3 pb-3 border-b border-gray-100">
+                <div className="flex items-center justify-between text-xs">
+                    <div className="flex items-center gap-1.5">
+                        <DatabaseOutlined className="text-gray-400" />
+                        <span className="text-gray-500">占用空间：</span>
+                    </div>
+                    <span className="font-medium text-gray-700">{formatSize(getTotalSize(app))}</span>
+                </div>
+                <div className="flex items-center justify-between text-xs text-gray-500">
+                    <span>安装包 {formatSize(app.appSize)}</span>
+                    <span>数据 {formatSize(app.dataSize)}</span>
+                </div>
+                <div className="flex items-center justify-between text-xs text-gray-500">
+                    <span>缓存 {formatSize(app.cacheSize)}</span>
+                    <Button
+                        type="link"
+                        size="small"
+                        icon={<ClearOutlined />}
+                        className="!text-xs !p-0 !h-auto"
+                        onClick={() => onClearCache(app)}
+                    >
+                        清除缓存
+                    </Button>
+                </div>
+            </div>
+
             <div className="space-y-1.5 mb-3 pb-3 border-b border-gray-100">
                 <div className="flex items-center justify-between text-xs">
                     <div className="flex items-center gap-1.5">
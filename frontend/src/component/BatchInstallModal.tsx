@@ -0,0 +1,245 @@
+import React, {useEffect, useRef, useState} from 'react';
+import {Button, Empty, Modal, Select, Table, Tag, Tooltip, message} from 'antd';
+import {CloseOutlined, FolderOpenOutlined, InboxOutlined} from '@ant-design/icons';
+import {CancelInstallQueue, InstallPackageFile, InstallQueue, SelectInstallFiles} from "../../wailsjs/go/main/App";
+import {adb, main} from "../../wailsjs/go/models";
+import {EventsOff, EventsOn, OnFileDrop, OnFileDropOff} from "../../wailsjs/runtime/runtime";
+import {useDeviceStore} from "../store/deviceStore";
+import InstallFailureAlert, {defaultInstallOptions} from './InstallFailureAlert';
+import InstallOptionsPicker from './InstallOptionsPicker';
+
+interface BatchInstallModalProps {
+    visible: boolean;
+    onClose: () => void;
+}
+
+const installExtensions = ['.apk', '.apks', '.xapk', '.aab'];
+
+const statusTags: Record<string, {color: string; label: string}> = {
+    pending: {color: 'default', label: '等待中'},
+    installing: {color: 'processing', label: '安装中'},
+    completed: {color: 'success', label: '成功'},
+    failed: {color: 'error', label: '失败'},
+    cancelled: {color: 'warning', label: '已取消'},
+};
+
+function baseName(p: string): string {
+    return p.split(/[\\/]/).pop() || p;
+}
+
+const BatchInstallModal: React.FC<BatchInstallModalProps> = ({visible, onClose}) => {
+    const {devices, selectedDevice} = useDeviceStore();
+    const [deviceIds, setDeviceIds] = useState<string[]>([]);
+    const [files, setFiles] = useState<string[]>([]);
+    const [options, setOptions] = useState<adb.InstallOptions>(defaultInstallOptions());
+    const [items, setItems] = useState<main.InstallQueueItem[]>([]);
+    const [running, setRunning] = useState(false);
+    const [retrying, setRetrying] = useState<string>('');
+    const queueIdRef = useRef<string>('');
+    // 每项重试时实际使用的选项，用于在此基础上继续叠加修复
+    const itemOptions = useRef<Record<string, adb.InstallOptions>>({});
+
+    useEffect(() => {
+        if (visible && deviceIds.length === 0 && selectedDevice) {
+            setDeviceIds([selectedDevice.id.toString()]);
+        }
+    }, [visible, selectedDevice]);
+
+    const addFiles = (paths: string[]) => {
+        const accepted = paths.filter(p => installExtensions.some(ext => p.toLowerCase().endsWith(ext)));
+        if (accepted.length < paths.length) {
+            message.warning(`已忽略 ${paths.length - accepted.length} 个不支持的文件`);
+        }
+        setFiles(prev => Array.from(new Set([...prev, ...accepted])));
+    };
+
+    // 只在弹窗打开时接收拖入的文件，拖放区域通过 --wails-drop-target 声明
+    useEffect(() => {
+        if (!visible) return;
+        OnFileDrop((_x, _y, paths) => {
+            if (!running) addFiles(paths);
+        }, true);
+        return () => OnFileDropOff();
+    }, [visible, running]);
+
+    useEffect(() => {
+        EventsOn('install-queue-progress', (event: main.InstallQueueEvent) => {
+            if (!queueIdRef.current) queueIdRef.current = event.queueId;
+            if (event.queueId !== queueIdRef.current || event.done) return;
+            setItems(prev => {
+                const index = prev.findIndex(i => i.id === event.item.id);
+                if (index < 0) return [...prev, event.item];
+                const next = [...prev];
+                next[index] = event.item;
+                return next;
+            });
+        });
+        return () => EventsOff('install-queue-progress');
+    }, []);
+
+    const choose = async () => {
+        try {
+            addFiles(await SelectInstallFiles());
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(msg);
+        }
+    };
+
+    const start = async () => {
+        queueIdRef.current = '';
+        itemOptions.current = {};
+        setItems([]);
+        setRunning(true);
+        try {
+            const result = await InstallQueue(deviceIds, files, options);
+            setItems(result);
+            const failed = result.filter(i => i.status !== 'completed').length;
+            if (failed === 0) message.success(`已完成 ${result.length} 项安装`);
+            else message.warning(`${result.length - failed} 项成功，${failed} 项未完成`);
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        } finally {
+            setRunning(false);
+        }
+    };
+
+    const stop = () => {
+        if (queueIdRef.current) CancelInstallQueue(queueIdRef.current);
+    };
+
+    const retry = async (item: main.InstallQueueItem, opts: adb.InstallOptions) => {
+        itemOptions.current[item.id] = opts;
+        setRetrying(item.id);
+        setItems(prev => prev.map(i => i.id === item.id ? {...i, status: 'installing'} as main.InstallQueueItem : i));
+        try {
+            const result = await InstallPackageFile(item.deviceId, item.path, opts);
+            setItems(prev => prev.map(i => i.id === item.id
+                ? {...i, status: result.success ? 'completed' : 'failed', result} as main.InstallQueueItem : i));
+        } finally {
+            setRetrying('');
+        }
+    };
+
+    const close = () => {
+        if (running) stop();
+        onClose();
+    };
+
+    const deviceName = (id: string) => devices.find(d => d.id.toString() === id)?.name || id;
+
+    const columns = [
+        {
+            title: '设备', dataIndex: 'deviceId', key: 'deviceId', width: 160, ellipsis: true,
+            render: (id: string) => <span className="text-xs">{deviceName(id)}</span>,
+        },
+        {
+            title: '安装包', dataIndex: 'path', key: 'path', ellipsis: true,
+            render: (p: string) => <Tooltip title={p}><span className="font-mono text-xs">{baseName(p)}</span></Tooltip>,
+        },
+        {
+            title: '状态', dataIndex: 'status', key: 'status', width: 90,
+            render: (s: string) => <Tag color={statusTags[s]?.color}>{statusTags[s]?.label || s}</Tag>,
+        },
+        {
+            title: '结果', key: 'result', width: 280, ellipsis: true,
+            render: (_: any, item: main.InstallQueueItem) => {
+                if (item.status === 'completed') {
+                    return <span className="text-xs text-gray-500">已安装 {item.result.installed.length} 个 APK</span>;
+                }
+                if (item.status !== 'failed') return null;
+                const failure = item.result.failure;
+                return (
+                    <Tooltip title={failure?.suggestion || item.result.output}>
+                        <span className="text-xs text-red-500">{failure ? `${failure.code}：${failure.explanation}` : item.result.output}</span>
+                    </Tooltip>
+                );
+            },
+        },
+    ];
+
+    const finished = items.filter(i => i.status === 'completed' || i.status === 'failed' || i.status === 'cancelled').length;
+
+    return (
+        <Modal
+            title="批量安装"
+            open={visible}
+            onCancel={close}
+            maskClosable={false}
+            width={960}
+            footer={
+                <div className="flex items-center justify-between">
+                    <InstallOptionsPicker value={options} onChange={setOptions} disabled={running}/>
+                    <div className="flex items-center gap-2">
+                        {running && <span className="text-xs text-gray-500">{finished} / {items.length}</span>}
+                        {running && <Button onClick={stop}>停止</Button>}
+                        <Button type="primary" onClick={start} loading={running}
+                                disabled={files.length === 0 || deviceIds.length === 0}>
+                            安装 {files.length} 个安装包到 {deviceIds.length} 台设备
+                        </Button>
+                    </div>
+                </div>
+            }
+        >
+            <div className="flex flex-col gap-3">
+                <Select
+                    mode="multiple"
+                    value={deviceIds}
+                    onChange={setDeviceIds}
+                    disabled={running}
+                    placeholder="选择目标设备"
+                    options={devices.map(d => ({value: d.id.toString(), label: d.name || d.id}))}
+                />
+                <div
+                    className="border border-dashed border-gray-300 rounded p-3 flex flex-col gap-2"
+                    style={{'--wails-drop-target': 'drop'} as React.CSSProperties}
+                >
+                    <div className="flex items-center justify-between">
+                        <span className="text-xs text-gray-500">
+                            <InboxOutlined/> 拖入或选择 .apk / .apks / .xapk / .aab，每个文件作为一个应用安装
+                        </span>
+                        <div className="flex gap-2">
+                            <Button size="small" icon={<FolderOpenOutlined/>} onClick={choose} disabled={running}>添加文件</Button>
+                            <Button size="small" onClick={() => setFiles([])} disabled={running || files.length === 0}>清空</Button>
+                        </div>
+                    </div>
+                    {files.length > 0 ? (
+                        <div className="flex flex-wrap gap-1 max-h-24 overflow-auto">
+                            {files.map(f => (
+                                <Tag key={f} closable={!running} onClose={() => setFiles(prev => prev.filter(p => p !== f))}
+                                     closeIcon={<CloseOutlined/>}>
+                                    <Tooltip title={f}><span className="font-mono text-xs">{baseName(f)}</span></Tooltip>
+                                </Tag>
+                            ))}
+                        </div>
+                    ) : (
+                        <Empty description="尚未添加安装包" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+                    )}
+                </div>
+                {items.length > 0 && (
+                    <Table
+                        size="small"
+                        rowKey="id"
+                        columns={columns}
+                        dataSource={items}
+                        pagination={false}
+                        scroll={{y: '40vh'}}
+                        expandable={{
+                            rowExpandable: item => item.status === 'failed',
+                            expandedRowRender: item => (
+                                <InstallFailureAlert
+                                    result={item.result}
+                                    options={itemOptions.current[item.id] || options}
+                                    retrying={retrying === item.id}
+                                    onRetry={running ? undefined : opts => retry(item, opts)}
+                                />
+                            ),
+                        }}
+                    />
+                )}
+            </div>
+        </Modal>
+    );
+};
+
+export default BatchInstallModal;
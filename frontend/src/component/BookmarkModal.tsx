@@ -0,0 +1,141 @@
+import React, {useEffect, useState} from 'react';
+import {Button, Empty, Input, Modal, Select, Table, Tooltip, message} from 'antd';
+import {ArrowDownOutlined, ArrowUpOutlined, DeleteOutlined, EditOutlined} from '@ant-design/icons';
+import {DeleteBookmark, ReorderBookmarks, SaveBookmark} from "../../wailsjs/go/main/App";
+import {main} from "../../wailsjs/go/models";
+
+interface BookmarkModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    deviceName: string;
+    bookmarks: main.Bookmark[];
+    onChanged: () => void;
+}
+
+const emptyBookmark = (): main.Bookmark => ({
+    id: '', label: '', path: '', package: '', deviceId: '', order: 0, lastUsed: 0, createdAt: 0,
+});
+
+const BookmarkModal: React.FC<BookmarkModalProps> = ({visible, onClose, deviceId, deviceName, bookmarks, onChanged}) => {
+    const [editing, setEditing] = useState<main.Bookmark>(emptyBookmark());
+    const [saving, setSaving] = useState(false);
+
+    useEffect(() => {
+        if (!visible) setEditing(emptyBookmark());
+    }, [visible]);
+
+    const save = async () => {
+        setSaving(true);
+        try {
+            await SaveBookmark(editing);
+            message.success(editing.id ? '已保存' : '已添加');
+            setEditing(emptyBookmark());
+            onChanged();
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        } finally {
+            setSaving(false);
+        }
+    };
+
+    const remove = async (b: main.Bookmark) => {
+        try {
+            await DeleteBookmark(b.id);
+            if (editing.id === b.id) setEditing(emptyBookmark());
+            onChanged();
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        }
+    };
+
+    // 列表只包含当前设备可见的收藏，其余收藏由后端保持在后面
+    const move = async (index: number, delta: number) => {
+        const ids = bookmarks.map(b => b.id);
+        const target = index + delta;
+        if (target < 0 || target >= ids.length) return;
+        [ids[index], ids[target]] = [ids[target], ids[index]];
+        try {
+            await ReorderBookmarks(ids);
+            onChanged();
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        }
+    };
+
+    const scopeOptions = [
+        {value: '', label: '所有设备'},
+        ...(deviceId ? [{value: deviceId, label: `仅 ${deviceName || deviceId}`}] : []),
+    ];
+    // 编辑其他设备的收藏时保留原范围
+    if (editing.deviceId && editing.deviceId !== deviceId) {
+        scopeOptions.push({value: editing.deviceId, label: `仅 ${editing.deviceId}`});
+    }
+
+    const columns = [
+        {title: '名称', dataIndex: 'label', key: 'label', width: 140, ellipsis: true},
+        {
+            title: '路径', dataIndex: 'path', key: 'path', ellipsis: true,
+            render: (p: string, b: main.Bookmark) => (
+                <span className="font-mono text-xs">
+                    {p}{b.package && <span className="text-gray-400"> ({b.package})</span>}
+                </span>
+            ),
+        },
+        {
+            title: '范围', dataIndex: 'deviceId', key: 'deviceId', width: 110, ellipsis: true,
+            render: (id: string) => <span className="text-xs text-gray-500">{id ? (id === deviceId ? '当前设备' : id) : '所有设备'}</span>,
+        },
+        {
+            title: '最近使用', dataIndex: 'lastUsed', key: 'lastUsed', width: 160,
+            render: (t: number) => <span className="text-xs text-gray-500">{t > 0 ? new Date(t).toLocaleString() : '-'}</span>,
+        },
+        {
+            title: '', key: 'actions', width: 130,
+            render: (_: any, b: main.Bookmark, index: number) => (
+                <div className="flex gap-0">
+                    <Button type="text" size="small" icon={<ArrowUpOutlined/>} disabled={index === 0} onClick={() => move(index, -1)}/>
+                    <Button type="text" size="small" icon={<ArrowDownOutlined/>} disabled={index === bookmarks.length - 1}
+                            onClick={() => move(index, 1)}/>
+                    <Button type="text" size="small" icon={<EditOutlined/>} onClick={() => setEditing({...b})}/>
+                    <Button type="text" size="small" danger icon={<DeleteOutlined/>} onClick={() => remove(b)}/>
+                </div>
+            ),
+        },
+    ];
+
+    return (
+        <Modal title="管理收藏" open={visible} onCancel={onClose} footer={null} width={900}>
+            <div className="flex flex-col gap-3">
+                <div className="flex items-center gap-2">
+                    <Input value={editing.label} placeholder="名称（可选）" style={{width: 140}}
+                           onChange={e => setEditing({...editing, label: e.target.value})}/>
+                    <Tooltip title="可使用 {package} 占位符，如 /sdcard/Android/data/{package}/files">
+                        <Input value={editing.path} placeholder="/sdcard/Android/data/{package}/files" className="flex-1"
+                               style={{fontFamily: 'monospace'}}
+                               onChange={e => setEditing({...editing, path: e.target.value})}/>
+                    </Tooltip>
+                    <Input value={editing.package} placeholder="默认包名（可选）" style={{width: 180}}
+                           onChange={e => setEditing({...editing, package: e.target.value})}/>
+                    <Select value={editing.deviceId} options={scopeOptions} style={{width: 150}}
+                            onChange={v => setEditing({...editing, deviceId: v})}/>
+                    <Button type="primary" loading={saving} disabled={!editing.path.trim()} onClick={save}>
+                        {editing.id ? '保存' : '添加'}
+                    </Button>
+                    {editing.id && <Button onClick={() => setEditing(emptyBookmark())}>取消</Button>}
+                </div>
+                <Table
+                    size="small"
+                    rowKey="id"
+                    columns={columns}
+                    dataSource={bookmarks}
+                    pagination={false}
+                    scroll={{y: '50vh'}}
+                    locale={{emptyText: <Empty description="暂无收藏路径" image={Empty.PRESENTED_IMAGE_SIMPLE}/>}}
+                />
+            </div>
+        </Modal>
+    );
+};
+
+export default BookmarkModal;
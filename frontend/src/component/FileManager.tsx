@@ -1,5 +1,5 @@
 import {useCallback, useEffect, useMemo, useRef, useState} from 'react';
-import {Button, Dropdown, Empty, Input, Modal, Popconfirm, Spin, Tree, Tooltip, message} from 'antd';
+import {Button, Checkbox, Dropdown, Empty, Input, List, Modal, Popconfirm, Progress, Select, Spin, Tree, Tooltip, message} from 'antd';
 import type {TreeDataNode, MenuProps} from 'antd';
 import {
     DeleteOutlined,
@@ -8,12 +8,49 @@ import {
     ReloadOutlined,
     UploadOutlined,
     EnterOutlined,
+    EditOutlined,
+    CopyOutlined,
+    PlusOutlined,
     LoadingOutlined,
     StarOutlined,
     StarFilled,
+    SearchOutlined,
 } from '@ant-design/icons';
-import {DeleteRemoteFile, DownloadFile, ListDirectory, ReadFileContent, UploadFile, GetBookmarkPaths, SetBookmarkPaths} from "../../wailsjs/go/main/App";
+import {
+    CancelFileTransfer,
+    ChmodRemoteFile,
+    CopyRemoteFile,
+    ConfirmDeleteRemoteFiles,
+    ListRemoteTrash,
+    PrepareDeleteRemoteFiles,
+    PrepareDeleteRemoteFilesAs,
+    PurgeRemoteTrash,
+    RestoreRemoteTrash,
+    MakeRemoteDir,
+    RenameRemoteFile,
+    TouchRemoteFile,
+    DownloadFiles,
+    ListDirectory,
+    PreviewRemoteFile,
+    PreviewRemoteFileAs,
+    ResolvePackageAccess,
+    ListDirectoryAs,
+    DownloadFilesAs,
+    UploadFilesAs,
+    UploadDirectory,
+    UploadFiles,
+    ListBookmarks,
+    SaveBookmark,
+    DeleteBookmark,
+    OpenBookmark,
+} from "../../wailsjs/go/main/App";
+import {EventsOff, EventsOn} from "../../wailsjs/runtime/runtime";
+import {adb, main} from "../../wailsjs/go/models";
 import {useDeviceStore} from "../store/deviceStore";
+import AppDataModal from "./AppDataModal";
+import SyncModal from "./SyncModal";
+import SearchModal from "./SearchModal";
+import BookmarkModal from "./BookmarkModal";
 
 interface FileEntry {
     permissions: string;
@@ -22,67 +59,112 @@ interface FileEntry {
     date: string;
     name: string;
     isDirectory: boolean;
+    isSymlink: boolean;
+    linkTarget: string;
     fullPath: string;
 }
 
 type ExtTreeNode = TreeDataNode & {_entry?: FileEntry};
 
-function formatSize(raw: number): string {
-    if (raw < 0) return '-';
-    if (raw < 1024) return `${raw} B`;
-    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
-    if (raw < 1024 * 1024 * 1024) return `${(raw / (1024 * 1024)).toFixed(1)} MB`;
-    return `${(raw / (1024 * 1024 * 1024)).toFixed(2)} GB`;
+type ConflictPolicy = 'skip' | 'overwrite' | 'rename';
+
+type FileOpKind = 'mkdir' | 'touch' | 'rename' | 'copy' | 'chmod';
+
+interface FileOpState {
+    kind: FileOpKind;
+    entry?: FileEntry;
+    value: string;
 }
 
-function parseLsOutput(output: string, parentPath: string): FileEntry[] {
-    const lines = output.split('\n');
-    const entries: FileEntry[] = [];
+const fileOpTitles: Record<FileOpKind, string> = {
+    mkdir: '新建文件夹',
+    touch: '新建文件',
+    rename: '重命名 / 移动',
+    copy: '复制到',
+    chmod: '修改权限',
+};
 
-    for (const line of lines) {
-        const trimmed = line.trim();
-        if (!trimmed || trimmed.startsWith('total')) continue;
+function parentOf(fullPath: string): string {
+    return fullPath.substring(0, fullPath.lastIndexOf('/')) || '/';
+}
 
-        const parts = trimmed.split(/\s+/);
-        if (parts.length < 7) continue;
+function joinPath(dir: string, name: string): string {
+    return dir === '/' ? `/${name}` : `${dir.replace(/\/+$/, '')}/${name}`;
+}
 
-        const permissions = parts[0];
-        const nameStartIndex = 7;
-        const nameStr = parts.slice(nameStartIndex).join(' ');
-        if (!nameStr) continue;
+/** 将 rwxr-xr-x 形式的权限转换为八进制 */
+function modeToOctal(mode: string): string {
+    const bits = mode.slice(1, 10);
+    let result = '';
+    for (let i = 0; i < 9; i += 3) {
+        let n = 0;
+        if (bits[i] === 'r') n += 4;
+        if (bits[i + 1] === 'w') n += 2;
+        if (bits[i + 2] && bits[i + 2] !== '-' && bits[i + 2] !== 'S' && bits[i + 2] !== 'T') n += 1;
+        result += n;
+    }
+    return result;
+}
+
+const conflictPolicyOptions = [
+    {value: 'rename', label: '冲突时重命名'},
+    {value: 'overwrite', label: '冲突时覆盖'},
+    {value: 'skip', label: '冲突时跳过'},
+];
 
-        // 跳过软链接
-        const isSymlink = permissions.startsWith('l');
-        if (isSymlink) continue;
+function formatDuration(seconds: number): string {
+    if (seconds < 60) return `${seconds}s`;
+    if (seconds < 3600) return `${Math.floor(seconds / 60)}m${seconds % 60}s`;
+    return `${Math.floor(seconds / 3600)}h${Math.floor((seconds % 3600) / 60)}m`;
+}
 
-        const name = nameStr;
+/** 汇总传输结果提示 */
+function showTransferResult(action: string, result: adb.TransferResult) {
+    const summary = `${action}完成: 成功 ${result.transferred}，跳过 ${result.skipped}，失败 ${result.failed}`;
+    if (result.status === 'cancelled') {
+        message.info(`${action}已取消`);
+    } else if (result.failed > 0) {
+        message.warning(summary);
+    } else {
+        message.success(summary);
+    }
+}
 
-        if (name === '.' || name === '..') continue;
+const previewKindLabels: Record<string, string> = {
+    text: '文本',
+    json: 'JSON',
+    xml: 'XML',
+    image: '图片',
+    sqlite: 'SQLite',
+    binary: '二进制',
+    empty: '空文件',
+};
 
-        const isDirectory = permissions.startsWith('d');
-        const date = `${parts[5]} ${parts[6]}`;
-        const sizeNum = parseInt(parts[4], 10);
-        const sizeRaw = isDirectory ? -1 : (isNaN(sizeNum) ? -1 : sizeNum);
-        const fullPath = parentPath === '/' ? `/${name}` : `${parentPath}/${name}`;
+function formatSize(raw: number): string {
+    if (raw < 0) return '-';
+    if (raw < 1024) return `${raw} B`;
+    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
+    if (raw < 1024 * 1024 * 1024) return `${(raw / (1024 * 1024)).toFixed(1)} MB`;
+    return `${(raw / (1024 * 1024 * 1024)).toFixed(2)} GB`;
+}
 
-        entries.push({
-            permissions,
+/** 将后端解析好的目录条目转换为前端展示结构，后端已按目录优先、名称排序 */
+function toFileEntries(list: adb.RemoteFileEntry[]): FileEntry[] {
+    return list.map(item => {
+        const isDirectory = item.type === 'directory' || (item.type === 'symlink' && !!item.linkIsDir);
+        const sizeRaw = isDirectory ? -1 : item.size;
+        return {
+            permissions: item.mode,
             size: formatSize(sizeRaw),
             sizeRaw,
-            date,
-            name,
+            date: item.modTime,
+            name: item.name,
             isDirectory,
-            fullPath,
-        });
-    }
-
-    entries.sort((a, b) => {
-        if (a.isDirectory && !b.isDirectory) return -1;
-        if (!a.isDirectory && b.isDirectory) return 1;
-        return a.name.localeCompare(b.name);
+            isSymlink: item.type === 'symlink',
+            linkTarget: item.linkTarget || '',
+            fullPath: item.path,
+        };
     });
-
-    return entries;
 }
 
 /** 纯数据的 TreeNode 构建，title 使用 renderTitle 回调延迟渲染 */
@@ -110,16 +192,33 @@ function FileManager() {
     const {selectedDevice} = useDeviceStore();
     const [treeData, setTreeData] = useState<ExtTreeNode[]>([]);
     const [loading, setLoading] = useState(false);
-    const [fileContent, setFileContent] = useState<string | null>(null);
+    const [preview, setPreview] = useState<adb.FilePreview | null>(null);
     const [viewingFileName, setViewingFileName] = useState('');
     const [fileLoading, setFileLoading] = useState(false);
+    const [loadingMore, setLoadingMore] = useState(false);
     const [pathInput, setPathInput] = useState('/');
     const [filterText, setFilterText] = useState('');
     const [expandedKeys, setExpandedKeys] = useState<React.Key[]>([]);
     const [uploading, setUploading] = useState(false);
     const [loadingKeys, setLoadingKeys] = useState<Set<string>>(new Set());
     const [treeHeight, setTreeHeight] = useState(400);
-    const [bookmarks, setBookmarks] = useState<string[]>([]);
+    const [bookmarks, setBookmarks] = useState<main.Bookmark[]>([]);
+    const [bookmarkManagerOpen, setBookmarkManagerOpen] = useState(false);
+    const [conflictPolicy, setConflictPolicy] = useState<ConflictPolicy>('rename');
+    const [checkedKeys, setCheckedKeys] = useState<React.Key[]>([]);
+    const [transfer, setTransfer] = useState<adb.TransferProgress | null>(null);
+    const [fileOp, setFileOp] = useState<FileOpState | null>(null);
+    const [trashOpen, setTrashOpen] = useState(false);
+    const [trashEntries, setTrashEntries] = useState<adb.TrashEntry[]>([]);
+    const [trashLoading, setTrashLoading] = useState(false);
+    // 以应用身份浏览私有目录，绑定到解析时的设备
+    const [packageAccess, setPackageAccess] = useState<{deviceId: string; access: adb.PackageAccess} | null>(null);
+    const [accessPackageInput, setAccessPackageInput] = useState('');
+    const [resolvingAccess, setResolvingAccess] = useState(false);
+    const [appDataOpen, setAppDataOpen] = useState(false);
+    const [syncOpen, setSyncOpen] = useState(false);
+    const [searchOpen, setSearchOpen] = useState(false);
+    const access = packageAccess && packageAccess.deviceId === selectedDevice?.id ? packageAccess.access : null;
     const treeContainerRef = useRef<HTMLDivElement>(null);
     // 节点索引 Map，key -> node 引用
     const nodeMapRef = useRef<Map<string, ExtTreeNode>>(new Map());
@@ -131,62 +230,261 @@ function FileManager() {
         if (!selectedDevice) return;
         setFileLoading(true);
         setViewingFileName(entry.name);
-        setFileContent(null);
-        ReadFileContent(selectedDevice.id, entry.fullPath).then(result => {
-            if (result.error) {
-                message.error(`读取失败: ${result.error}`);
-                return;
-            }
-            setFileContent(result.res);
+        setPreview(null);
+        const request = access
+            ? PreviewRemoteFileAs(selectedDevice.id, access, entry.fullPath, 0, 0, '')
+            : PreviewRemoteFile(selectedDevice.id, entry.fullPath, 0, 0, '');
+        request.then(result => {
+            setPreview(result);
         }).catch(e => {
-            message.error(`读取失败: ${e.message || e}`);
+            message.error(`读取失败: ${e?.message || e}`);
         }).finally(() => setFileLoading(false));
-    }, [selectedDevice]);
+    }, [selectedDevice, access]);
 
-    const handleDelete = useCallback((entry: FileEntry) => {
+    // 从上一页的 nextOffset 继续读取，内容追加到已加载部分之后
+    const handleLoadMore = useCallback(async () => {
+        if (!selectedDevice || !preview || !preview.hasMore) return;
+        setLoadingMore(true);
+        try {
+            const next = access
+                ? await PreviewRemoteFileAs(selectedDevice.id, access, preview.path, preview.nextOffset, 0, preview.mode)
+                : await PreviewRemoteFile(selectedDevice.id, preview.path, preview.nextOffset, 0, preview.mode);
+            setPreview({...next, kind: preview.kind, summary: preview.summary, offset: preview.offset, content: preview.content + next.content});
+        } catch (e: any) {
+            message.error(`读取失败: ${e?.message || e}`);
+        } finally {
+            setLoadingMore(false);
+        }
+    }, [selectedDevice, preview, access]);
+
+    const openFileOp = useCallback((kind: FileOpKind, entry?: FileEntry) => {
+        let value = '';
+        if (kind === 'rename') value = entry?.fullPath || '';
+        if (kind === 'copy') value = entry ? `${entry.fullPath}_copy` : '';
+        if (kind === 'chmod') value = entry ? modeToOctal(entry.permissions) : '';
+        setFileOp({kind, entry, value});
+    }, []);
+
+    const runFileOp = useCallback(async (op: FileOpState, force: boolean) => {
         if (!selectedDevice) return;
-        DeleteRemoteFile(selectedDevice.id, entry.fullPath).then(result => {
-            if (result.error) {
-                message.error(`删除失败: ${result.error}`);
+        const value = op.value.trim();
+        if (!value) return;
+        const deviceId = selectedDevice.id;
+        const base = pathInput.trim() || '/';
+        let result: {error: string; res: string} | undefined;
+        let refreshPaths: string[] = [];
+        switch (op.kind) {
+            case 'mkdir':
+            case 'touch': {
+                const target = value.startsWith('/') ? value : joinPath(base, value);
+                result = op.kind === 'mkdir'
+                    ? await MakeRemoteDir(deviceId, target, force)
+                    : await TouchRemoteFile(deviceId, target, force);
+                refreshPaths = [parentOf(target)];
+                break;
+            }
+            case 'rename':
+                result = await RenameRemoteFile(deviceId, op.entry!.fullPath, value, force);
+                refreshPaths = [parentOf(op.entry!.fullPath), parentOf(value)];
+                break;
+            case 'copy':
+                result = await CopyRemoteFile(deviceId, op.entry!.fullPath, value, force);
+                refreshPaths = [parentOf(value)];
+                break;
+            case 'chmod':
+                result = await ChmodRemoteFile(deviceId, op.entry!.fullPath, value, op.entry!.isDirectory, force);
+                refreshPaths = [parentOf(op.entry!.fullPath)];
+                break;
+        }
+        if (!result) return;
+        if (result.error) {
+            // 受保护路径需要用户二次确认后强制执行
+            if (!force && result.error.includes('拒绝操作')) {
+                Modal.confirm({
+                    title: '受保护的路径',
+                    content: `${result.error}，仍要强制执行吗？`,
+                    okText: '强制执行',
+                    okButtonProps: {danger: true},
+                    cancelText: '取消',
+                    onOk: () => runFileOp(op, true),
+                });
                 return;
             }
-            message.success(`已删除: ${entry.name}`);
-            const parentPath = entry.fullPath.substring(0, entry.fullPath.lastIndexOf('/')) || '/';
-            refreshPathRef.current?.(parentPath);
-        }).catch(e => message.error(`删除失败: ${e.message || e}`));
-    }, [selectedDevice]);
+            message.error(`${fileOpTitles[op.kind]}失败: ${result.error}`);
+            return;
+        }
+        message.success(result.res || `${fileOpTitles[op.kind]}成功`);
+        setFileOp(null);
+        for (const p of new Set(refreshPaths)) {
+            refreshPathRef.current?.(p);
+        }
+    }, [selectedDevice, pathInput]);
 
-    const handleDownload = useCallback((entry: FileEntry) => {
-        if (!selectedDevice) return;
-        DownloadFile(selectedDevice.id, entry.fullPath).then(result => {
-            if (result.error) {
-                if (result.error !== '已取消') message.error(`下载失败: ${result.error}`);
+    // 删除分两步：先预览将被删除的内容，确认后凭令牌删除
+    const deletePaths = useCallback(async (paths: string[], force = false) => {
+        if (!selectedDevice || paths.length === 0) return;
+        let preview: adb.DeletePreview;
+        try {
+            // 应用私有目录由后端重新确定访问方式，并且只允许删除数据目录之下的路径
+            preview = access
+                ? await PrepareDeleteRemoteFilesAs(selectedDevice.id, access.package, paths)
+                : await PrepareDeleteRemoteFiles(selectedDevice.id, paths, force);
+        } catch (e: any) {
+            const msg = String(e?.message || e);
+            if (!force && !access && msg.includes('拒绝操作')) {
+                Modal.confirm({
+                    title: '受保护的路径',
+                    content: `${msg}，仍要强制删除吗？`,
+                    okText: '强制删除',
+                    okButtonProps: {danger: true},
+                    cancelText: '取消',
+                    onOk: () => deletePaths(paths, true),
+                });
                 return;
             }
-            message.success(`下载完成: ${entry.name}`);
-        }).catch(e => message.error(`下载失败: ${e.message || e}`));
+            message.error(`删除失败: ${msg}`);
+            return;
+        }
+
+        let useTrash = preview.trashAvailable;
+        const names = preview.items.map(item => item.path.substring(item.path.lastIndexOf('/') + 1));
+        Modal.confirm({
+            title: '确认删除',
+            width: 480,
+            okText: '删除',
+            okButtonProps: {danger: true},
+            cancelText: '取消',
+            content: (
+                <div className="flex flex-col gap-2">
+                    <div className="break-all">
+                        {names.length === 1 ? names[0] : `${names.slice(0, 3).join('、')}${names.length > 3 ? ` 等 ${names.length} 项` : ''}`}
+                    </div>
+                    <div className="text-gray-500">
+                        共 {preview.files} 个文件、{preview.dirs} 个目录，{formatSize(preview.bytes)}
+                    </div>
+                    {access && <div className="text-orange-500">将以 {access.package} 的身份永久删除，无法恢复</div>}
+                    {preview.items.filter(item => item.error).map(item => (
+                        <div key={item.path} className="text-orange-500 text-xs break-all">{item.path}: {item.error}</div>
+                    ))}
+                    <Checkbox defaultChecked={useTrash} disabled={!preview.trashAvailable}
+                              onChange={e => useTrash = e.target.checked}>
+                        移到回收站{preview.trashAvailable ? '' : '（仅支持内部存储）'}
+                    </Checkbox>
+                </div>
+            ),
+            onOk: async () => {
+                try {
+                    const result = await ConfirmDeleteRemoteFiles(preview.token, useTrash);
+                    const done = result.deleted.length + result.trashed.length;
+                    if (result.errors.length > 0) {
+                        message.error(`删除失败: ${result.errors.join('; ')}`);
+                    } else {
+                        message.success(useTrash ? `已移到回收站: ${done} 项` : `已删除: ${done} 项`);
+                    }
+                    setCheckedKeys(keys => keys.filter(k => !paths.includes(String(k))));
+                    const parents = new Set(preview.items.map(item => item.path.substring(0, item.path.lastIndexOf('/')) || '/'));
+                    for (const p of parents) {
+                        refreshPathRef.current?.(p);
+                    }
+                } catch (e: any) {
+                    message.error(`删除失败: ${e?.message || e}`);
+                }
+            },
+        });
+    }, [selectedDevice, access]);
+
+    const handleDelete = useCallback((entry: FileEntry) => {
+        deletePaths([entry.fullPath]);
+    }, [deletePaths]);
+
+    const loadTrash = useCallback(async () => {
+        if (!selectedDevice) return;
+        setTrashLoading(true);
+        try {
+            setTrashEntries(await ListRemoteTrash(selectedDevice.id));
+        } catch (e: any) {
+            message.error(`读取回收站失败: ${e?.message || e}`);
+        } finally {
+            setTrashLoading(false);
+        }
     }, [selectedDevice]);
 
+    const openTrash = useCallback(() => {
+        setTrashOpen(true);
+        loadTrash();
+    }, [loadTrash]);
+
+    const handleTrashAction = useCallback(async (action: 'restore' | 'purge', entry?: adb.TrashEntry) => {
+        if (!selectedDevice) return;
+        const result = action === 'restore'
+            ? await RestoreRemoteTrash(selectedDevice.id, entry!.id)
+            : await PurgeRemoteTrash(selectedDevice.id, entry?.id ?? '');
+        if (result.error) {
+            message.error(`${action === 'restore' ? '恢复' : '删除'}失败: ${result.error}`);
+            return;
+        }
+        message.success(result.res);
+        if (action === 'restore') {
+            refreshPathRef.current?.(entry!.originalPath.substring(0, entry!.originalPath.lastIndexOf('/')) || '/');
+        }
+        loadTrash();
+    }, [selectedDevice, loadTrash]);
+
+    const downloadPaths = useCallback(async (paths: string[]) => {
+        if (!selectedDevice || paths.length === 0) return;
+        try {
+            const result = access
+                ? await DownloadFilesAs(selectedDevice.id, access, paths, conflictPolicy)
+                : await DownloadFiles(selectedDevice.id, paths, conflictPolicy);
+            showTransferResult('下载', result);
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(`下载失败: ${msg}`);
+        } finally {
+            setTransfer(null);
+        }
+    }, [selectedDevice, conflictPolicy, access]);
+
+    const handleDownload = useCallback((entry: FileEntry) => {
+        downloadPaths([entry.fullPath]);
+    }, [downloadPaths]);
+
     const loadEntries = useCallback(async (path: string): Promise<FileEntry[]> => {
         if (!selectedDevice) return [];
-        const result = await ListDirectory(selectedDevice.id, path);
-        if (result.error) {
-            message.error(`加载失败: ${result.error}`);
+        try {
+            const list = access
+                ? await ListDirectoryAs(selectedDevice.id, access, path)
+                : await ListDirectory(selectedDevice.id, path);
+            const entries = toFileEntries(list || []);
+            entriesMapRef.current.set(path, entries);
+            return entries;
+        } catch (e: any) {
+            message.error(`加载失败: ${e?.message || e}`);
             return [];
         }
-        const entries = parseLsOutput(result.res, path);
-        entriesMapRef.current.set(path, entries);
-        return entries;
+    }, [selectedDevice, access]);
+
+    // 收藏按设备过滤，切换设备时重新加载
+    const loadBookmarks = useCallback(() => {
+        ListBookmarks(selectedDevice?.id || '')
+            .then(list => setBookmarks(list || []))
+            .catch(e => message.error(`加载收藏失败: ${e?.message || e}`));
     }, [selectedDevice]);
 
-    const toggleBookmark = useCallback((path: string) => {
+    const toggleBookmark = useCallback(async (path: string) => {
         if (path === '/') return;
-        setBookmarks(prev => {
-            const next = prev.includes(path) ? prev.filter(p => p !== path) : [...prev, path];
-            SetBookmarkPaths(next);
-            return next;
-        });
-    }, []);
+        const existing = bookmarks.find(b => b.path === path);
+        try {
+            if (existing) {
+                await DeleteBookmark(existing.id);
+            } else {
+                await SaveBookmark({id: '', label: '', path, package: '', deviceId: '', order: 0, lastUsed: 0, createdAt: 0});
+            }
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        }
+        loadBookmarks();
+    }, [bookmarks, loadBookmarks]);
 
     // titleRender：按需渲染每个节点的标题，避免存储大量 JSX
     const titleRender = useCallback((nodeData: any) => {
@@ -194,11 +492,14 @@ function FileManager() {
         if (!entry) return nodeData.title;
 
         const isNodeLoading = loadingKeys.has(entry.fullPath);
-        const isFav = entry.isDirectory && bookmarks.includes(entry.fullPath);
+        const isFav = entry.isDirectory && bookmarks.some(b => b.path === entry.fullPath);
 
         return (
             <div className="flex items-center gap-2 group py-0.5 w-full min-w-0">
                 <span className="truncate text-gray-800 text-sm">{entry.name}</span>
+                {entry.isSymlink && (
+                    <span className="truncate text-gray-400 text-xs flex-shrink min-w-0">→ {entry.linkTarget}</span>
+                )}
                 {isNodeLoading && <LoadingOutlined className="text-blue-400 text-xs"/>}
                 <span className="text-gray-400 text-xs font-mono flex-shrink-0">{entry.size}</span>
                 <span className="text-gray-300 text-xs font-mono flex-shrink-0">{entry.permissions}</span>
@@ -221,28 +522,39 @@ function FileManager() {
                                     className="!text-gray-400 hover:!text-blue-500"/>
                         </Tooltip>
                     )}
+                    {!access && (
+                        <>
+                            <Tooltip title="重命名 / 移动">
+                                <Button type="text" size="small" icon={<EditOutlined/>}
+                                        onClick={() => openFileOp('rename', entry)}
+                                        className="!text-gray-400 hover:!text-blue-500"/>
+                            </Tooltip>
+                            <Tooltip title="复制">
+                                <Button type="text" size="small" icon={<CopyOutlined/>}
+                                        onClick={() => openFileOp('copy', entry)}
+                                        className="!text-gray-400 hover:!text-blue-500"/>
+                            </Tooltip>
+                            <Tooltip title="修改权限">
+                                <Button type="text" size="small" icon={<i className="fa-solid fa-key text-xs"/>}
+                                        onClick={() => openFileOp('chmod', entry)}
+                                        className="!text-gray-400 hover:!text-blue-500"/>
+                            </Tooltip>
+                        </>
+                    )}
                     <Tooltip title="下载">
                         <Button type="text" size="small" icon={<DownloadOutlined/>}
                                 onClick={() => handleDownload(entry)}
                                 className="!text-gray-400 hover:!text-green-500"/>
                     </Tooltip>
-                    <Popconfirm
-                        title="确认删除"
-                        description={`确定要删除 ${entry.name} 吗？`}
-                        onConfirm={() => handleDelete(entry)}
-                        okText="删除"
-                        cancelText="取消"
-                        okButtonProps={{danger: true}}
-                    >
-                        <Tooltip title="删除">
-                            <Button type="text" size="small" danger icon={<DeleteOutlined/>}
-                                    className="!text-gray-400 hover:!text-red-500"/>
-                        </Tooltip>
-                    </Popconfirm>
+                    <Tooltip title="删除">
+                        <Button type="text" size="small" danger icon={<DeleteOutlined/>}
+                                onClick={() => handleDelete(entry)}
+                                className="!text-gray-400 hover:!text-red-500"/>
+                    </Tooltip>
                 </span>
             </div>
         );
-    }, [handleView, handleDelete, handleDownload, loadingKeys, bookmarks, toggleBookmark]);
+    }, [handleView, handleDelete, handleDownload, openFileOp, loadingKeys, bookmarks, toggleBookmark, access]);
 
     // 加载根目录（重置展开状态）
     const loadRoot = useCallback(async (rootPath: string = '/') => {
@@ -255,6 +567,7 @@ function FileManager() {
             registerNodes(nodes, nodeMapRef.current);
             setTreeData(nodes);
             setExpandedKeys([]);
+            setCheckedKeys([]);
         } finally {
             setLoading(false);
         }
@@ -308,10 +621,26 @@ function FileManager() {
 
     useEffect(() => {
         if (selectedDevice) {
-            loadRoot('/');
-            setPathInput('/');
+            const root = access ? access.dataDir : '/';
+            loadRoot(root);
+            setPathInput(root);
         }
-    }, [selectedDevice?.id]);
+    }, [selectedDevice?.id, access]);
+
+    const handleResolveAccess = async () => {
+        const pkg = accessPackageInput.trim();
+        if (!selectedDevice || !pkg) return;
+        setResolvingAccess(true);
+        try {
+            const resolved = await ResolvePackageAccess(selectedDevice.id, pkg);
+            setPackageAccess({deviceId: selectedDevice.id, access: resolved});
+            message.success(`已切换为 ${pkg} 的身份（${resolved.mode}）`);
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        } finally {
+            setResolvingAccess(false);
+        }
+    };
 
     // 监听树容器高度变化，驱动虚拟滚动
     useEffect(() => {
@@ -326,28 +655,50 @@ function FileManager() {
         return () => ro.disconnect();
     }, []);
 
+    // 收藏没有设置包名时，{package} 使用当前应用身份的包名，其次是输入框中的包名
+    const openBookmark = useCallback(async (bookmark: main.Bookmark) => {
+        try {
+            const p = await OpenBookmark(bookmark.id, access?.package || accessPackageInput.trim());
+            setPathInput(p);
+            loadRoot(p);
+            loadBookmarks();
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        }
+    }, [access, accessPackageInput, loadBookmarks, loadRoot]);
+
     // 加载收藏路径
     useEffect(() => {
-        GetBookmarkPaths().then(paths => setBookmarks(paths || []));
-    }, []);
+        loadBookmarks();
+    }, [loadBookmarks]);
 
-    const bookmarkMenuItems: MenuProps['items'] = bookmarks.length > 0
-        ? bookmarks.map((p, i) => ({
-            key: i,
-            label: (
-                <div className="flex items-center justify-between gap-3 min-w-[200px]">
-                    <span className="font-mono text-sm truncate">{p}</span>
-                    <Tooltip title="取消收藏">
-                        <DeleteOutlined
-                            className="text-gray-400 hover:text-red-500 flex-shrink-0"
-                            onClick={e => { e.stopPropagation(); toggleBookmark(p); }}
-                        />
-                    </Tooltip>
-                </div>
-            ),
-            onClick: () => { setPathInput(p); loadRoot(p); },
-        }))
-        : [{key: 'empty', label: <span className="text-gray-400 text-sm">暂无收藏路径</span>, disabled: true}];
+    const bookmarkMenuItems: MenuProps['items'] = [
+        ...(bookmarks.length > 0
+            ? bookmarks.map(b => ({
+                key: b.id,
+                label: (
+                    <div className="flex items-center justify-between gap-3 min-w-[240px] max-w-[420px]">
+                        <div className="flex flex-col min-w-0">
+                            <span className="text-sm truncate">{b.label}</span>
+                            <span className="font-mono text-xs text-gray-400 truncate">{b.path}</span>
+                        </div>
+                        <Tooltip title="取消收藏">
+                            <DeleteOutlined
+                                className="text-gray-400 hover:text-red-500 flex-shrink-0"
+                                onClick={e => {
+                                    e.stopPropagation();
+                                    DeleteBookmark(b.id).then(loadBookmarks);
+                                }}
+                            />
+                        </Tooltip>
+                    </div>
+                ),
+                onClick: () => openBookmark(b),
+            }))
+            : [{key: 'empty', label: <span className="text-gray-400 text-sm">暂无收藏路径</span>, disabled: true}]),
+        {type: 'divider'},
+        {key: 'manage', label: '管理收藏...', onClick: () => setBookmarkManagerOpen(true)},
+    ];
 
     // 懒加载子目录
     const onLoadData = useCallback(async (treeNode: any) => {
@@ -407,7 +758,7 @@ function FileManager() {
         loadRoot(normalized);
     };
 
-    const handleUpload = async () => {
+    const handleUpload = async (kind: 'files' | 'directory') => {
         if (!selectedDevice) {
             message.warning('请先连接设备');
             return;
@@ -415,20 +766,32 @@ function FileManager() {
         const dest = pathInput.trim() || '/sdcard/';
         setUploading(true);
         try {
-            const result = await UploadFile(selectedDevice.id, dest);
-            if (result.error) {
-                if (result.error !== '已取消') message.error(`上传失败: ${result.error}`);
-                return;
-            }
-            message.success('上传成功');
+            const result = access
+                ? await UploadFilesAs(selectedDevice.id, access, dest, conflictPolicy)
+                : kind === 'files'
+                    ? await UploadFiles(selectedDevice.id, dest, conflictPolicy)
+                    : await UploadDirectory(selectedDevice.id, dest, conflictPolicy);
+            showTransferResult('上传', result);
             loadRoot(pathInput);
         } catch (e: any) {
-            message.error(`上传失败: ${e.message || e}`);
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(`上传失败: ${msg}`);
         } finally {
             setUploading(false);
+            setTransfer(null);
         }
     };
 
+    // 监听传输进度
+    useEffect(() => {
+        EventsOn('file-transfer-progress', (progress: adb.TransferProgress) => {
+            setTransfer(progress.status === 'running' ? progress : null);
+        });
+        return () => {
+            EventsOff('file-transfer-progress');
+        };
+    }, []);
+
     // 过滤树节点
     const filteredTreeData = useMemo(() => {
         if (!filterText) return treeData;
@@ -487,14 +850,69 @@ function FileManager() {
                                 </Button>
                             </Tooltip>
                         </Dropdown>
+                        <Dropdown
+                            trigger={['click']}
+                            menu={{
+                                items: [
+                                    {key: 'mkdir', label: '新建文件夹', onClick: () => openFileOp('mkdir')},
+                                    {key: 'touch', label: '新建文件', onClick: () => openFileOp('touch')},
+                                ],
+                            }}
+                        >
+                            <Button icon={<PlusOutlined/>} size="middle" disabled={!selectedDevice || !!access}>
+                                新建
+                            </Button>
+                        </Dropdown>
+                        <Select
+                            size="middle"
+                            value={conflictPolicy}
+                            onChange={v => setConflictPolicy(v)}
+                            options={conflictPolicyOptions}
+                            style={{width: 132}}
+                        />
+                        <Dropdown
+                            trigger={['click']}
+                            disabled={uploading}
+                            menu={{
+                                items: [
+                                    {key: 'files', label: '上传文件', onClick: () => handleUpload('files')},
+                                    {key: 'directory', label: '上传文件夹', disabled: !!access, onClick: () => handleUpload('directory')},
+                                ],
+                            }}
+                        >
+                            <Button icon={<UploadOutlined/>} size="middle" loading={uploading}>
+                                上传
+                            </Button>
+                        </Dropdown>
+                        <Button
+                            icon={<DownloadOutlined/>}
+                            size="middle"
+                            disabled={checkedKeys.length === 0 || transfer !== null}
+                            onClick={() => downloadPaths(checkedKeys.map(k => String(k)))}
+                        >
+                            下载选中{checkedKeys.length > 0 ? ` (${checkedKeys.length})` : ''}
+                        </Button>
                         <Button
-                            icon={<UploadOutlined/>}
+                            icon={<DeleteOutlined/>}
                             size="middle"
-                            onClick={handleUpload}
-                            loading={uploading}
+                            danger
+                            disabled={checkedKeys.length === 0}
+                            onClick={() => deletePaths(checkedKeys.map(k => String(k)))}
                         >
-                            上传
+                            删除选中
                         </Button>
+                        <Tooltip title="搜索文件">
+                            <Button icon={<SearchOutlined/>} size="middle"
+                                    disabled={!selectedDevice || !!access} onClick={() => setSearchOpen(true)}/>
+                        </Tooltip>
+                        <Tooltip title="目录同步">
+                            <Button icon={<i className="fa-solid fa-arrows-rotate"/>} size="middle"
+                                    disabled={!selectedDevice || !!access} onClick={() => setSyncOpen(true)}/>
+                        </Tooltip>
+                        <Tooltip title="回收站">
+                            <Button icon={<i className="fa-solid fa-trash-can-arrow-up"/>} size="middle"
+                                    disabled={!selectedDevice || !!access} onClick={openTrash}/>
+                        </Tooltip>
                         <Button
                             icon={<ReloadOutlined/>}
                             size="middle"
@@ -505,8 +923,33 @@ function FileManager() {
                         </Button>
                     </div>
                 </div>
-                {/* 第二行：过滤 */}
-                <div className="flex items-center">
+                {/* 传输进度 */}
+                {transfer && (
+                    <div className="flex items-center gap-3 text-xs text-gray-500">
+                        <span className="flex-shrink-0">
+                            {transfer.direction === 'upload' ? '上传' : '下载'} {transfer.fileIndex + 1}/{transfer.fileCount}
+                        </span>
+                        <span className="truncate font-mono min-w-0 flex-1">{transfer.file}</span>
+                        <Progress
+                            percent={transfer.fileTotal > 0 ? Math.floor(transfer.fileBytes * 100 / transfer.fileTotal) : 0}
+                            size="small"
+                            className="!w-24 !m-0"
+                        />
+                        <Progress
+                            percent={transfer.total > 0 ? Math.floor(transfer.bytes * 100 / transfer.total) : 0}
+                            size="small"
+                            className="!w-40 !m-0"
+                        />
+                        <span className="flex-shrink-0 font-mono">
+                            {formatSize(Math.floor(transfer.bytesPerSec))}/s · 剩余 {formatDuration(transfer.etaSeconds)}
+                        </span>
+                        <Button size="small" danger onClick={() => CancelFileTransfer(transfer.taskId)}>
+                            取消
+                        </Button>
+                    </div>
+                )}
+                {/* 第二行：过滤 + 以应用身份浏览 */}
+                <div className="flex items-center gap-2">
                     <Input
                         size="middle"
                         placeholder="过滤文件名..."
@@ -514,7 +957,29 @@ function FileManager() {
                         onChange={e => setFilterText(e.target.value)}
                         allowClear
                         prefix={<i className="fa-solid fa-filter text-gray-400"/>}
+                        className="flex-1"
                     />
+                    {access ? (
+                        <div className="flex items-center gap-2 flex-shrink-0">
+                            <span className="text-xs text-gray-500">
+                                以 <span className="font-mono text-gray-800">{access.package}</span> 身份浏览（{access.mode}）
+                            </span>
+                            <Button size="middle" onClick={() => setAppDataOpen(true)}>应用数据</Button>
+                            <Button size="middle" onClick={() => setPackageAccess(null)}>退出</Button>
+                        </div>
+                    ) : (
+                        <Input.Search
+                            size="middle"
+                            placeholder="以应用身份浏览：输入包名"
+                            value={accessPackageInput}
+                            onChange={e => setAccessPackageInput(e.target.value)}
+                            onSearch={handleResolveAccess}
+                            enterButton="进入"
+                            loading={resolvingAccess}
+                            disabled={!selectedDevice}
+                            style={{width: 320, fontFamily: 'monospace'}}
+                        />
+                    )}
                 </div>
             </div>
 
@@ -536,6 +1001,10 @@ function FileManager() {
                     <Tree
                         showIcon
                         blockNode
+                        checkable
+                        checkStrictly
+                        checkedKeys={{checked: checkedKeys, halfChecked: []}}
+                        onCheck={(keys: any) => setCheckedKeys(keys.checked ?? keys)}
                         virtual
                         height={treeHeight}
                         loadData={onLoadData}
@@ -550,30 +1019,183 @@ function FileManager() {
                 )}
             </div>
 
+            {/* 文件操作 Modal */}
+            <Modal
+                title={fileOp ? fileOpTitles[fileOp.kind] : ''}
+                open={fileOp !== null}
+                onCancel={() => setFileOp(null)}
+                onOk={() => fileOp && runFileOp(fileOp, false)}
+                okText="确定"
+                cancelText="取消"
+                destroyOnClose
+            >
+                {fileOp && (
+                    <div className="flex flex-col gap-2">
+                        {fileOp.entry && (
+                            <span className="text-xs text-gray-400 font-mono break-all">{fileOp.entry.fullPath}</span>
+                        )}
+                        <Input
+                            autoFocus
+                            value={fileOp.value}
+                            onChange={e => setFileOp({...fileOp, value: e.target.value})}
+                            onPressEnter={() => runFileOp(fileOp, false)}
+                            placeholder={fileOp.kind === 'chmod'
+                                ? '如 755 或 u+x'
+                                : fileOp.kind === 'mkdir' || fileOp.kind === 'touch'
+                                    ? `名称或绝对路径，相对于 ${pathInput || '/'}`
+                                    : '目标绝对路径'}
+                            style={{fontFamily: 'monospace'}}
+                        />
+                        {fileOp.kind === 'chmod' && fileOp.entry?.isDirectory && (
+                            <span className="text-xs text-gray-400">目录将递归修改权限</span>
+                        )}
+                    </div>
+                )}
+            </Modal>
+
+            {access && selectedDevice && (
+                <AppDataModal
+                    visible={appDataOpen}
+                    onClose={() => setAppDataOpen(false)}
+                    deviceId={selectedDevice.id}
+                    access={access}
+                />
+            )}
+
+            {selectedDevice && (
+                <SyncModal
+                    visible={syncOpen}
+                    onClose={() => setSyncOpen(false)}
+                    deviceId={selectedDevice.id}
+                    defaultRemoteDir={pathInput}
+                    progress={transfer}
+                    onFinished={dir => refreshPathRef.current?.(dir)}
+                />
+            )}
+
+            {selectedDevice && (
+                <SearchModal
+                    visible={searchOpen}
+                    onClose={() => setSearchOpen(false)}
+                    deviceId={selectedDevice.id}
+                    defaultRoot={pathInput}
+                    bookmarks={bookmarks.filter(b => !b.path.includes('{package}') || b.package)
+                        .map(b => b.path.replace(/\{package\}/g, b.package))}
+                    onLocate={dir => {
+                        setSearchOpen(false);
+                        setPathInput(dir);
+                        loadRoot(dir);
+                    }}
+                    onPreview={(path, name) => handleView({name, fullPath: path} as FileEntry)}
+                />
+            )}
+
+            <BookmarkModal
+                visible={bookmarkManagerOpen}
+                onClose={() => setBookmarkManagerOpen(false)}
+                deviceId={selectedDevice?.id || ''}
+                deviceName={selectedDevice?.name || ''}
+                bookmarks={bookmarks}
+                onChanged={loadBookmarks}
+            />
+
+            {/* 回收站 Modal */}
+            <Modal
+                title="回收站"
+                open={trashOpen}
+                onCancel={() => setTrashOpen(false)}
+                width={640}
+                footer={
+                    <Popconfirm
+                        title="清空回收站"
+                        description="回收站中的文件将被永久删除"
+                        onConfirm={() => handleTrashAction('purge')}
+                        okText="清空"
+                        cancelText="取消"
+                        okButtonProps={{danger: true}}
+                    >
+                        <Button danger disabled={trashEntries.length === 0}>清空回收站</Button>
+                    </Popconfirm>
+                }
+            >
+                <List
+                    loading={trashLoading}
+                    dataSource={trashEntries}
+                    locale={{emptyText: <Empty description="回收站为空" image={Empty.PRESENTED_IMAGE_SIMPLE}/>}}
+                    className="max-h-[60vh] overflow-auto"
+                    renderItem={entry => (
+                        <List.Item
+                            actions={[
+                                <Button key="restore" type="link" size="small"
+                                        onClick={() => handleTrashAction('restore', entry)}>恢复</Button>,
+                                <Popconfirm key="purge" title="永久删除" description={`确定要永久删除 ${entry.name} 吗？`}
+                                            onConfirm={() => handleTrashAction('purge', entry)}
+                                            okText="删除" cancelText="取消" okButtonProps={{danger: true}}>
+                                    <Button type="link" size="small" danger>永久删除</Button>
+                                </Popconfirm>,
+                            ]}
+                        >
+                            <List.Item.Meta
+                                title={entry.name}
+                                description={
+                                    <span className="text-xs font-mono break-all">
+                                        {entry.originalPath}
+                                        {entry.deletedAt > 0 && ` · ${new Date(entry.deletedAt).toLocaleString()}`}
+                                    </span>
+                                }
+                            />
+                        </List.Item>
+                    )}
+                />
+            </Modal>
+
             {/* 文件内容查看 Modal */}
             <Modal
                 title={
                     <div className="flex items-center gap-2">
                         <i className="fa-regular fa-file text-blue-400"/>
                         <span>{viewingFileName}</span>
+                        {preview && (
+                            <span className="text-xs text-gray-400 font-normal">
+                                {previewKindLabels[preview.kind] || preview.kind} · {formatSize(preview.size)}
+                            </span>
+                        )}
                     </div>
                 }
-                open={fileContent !== null || fileLoading}
+                open={preview !== null || fileLoading}
                 onCancel={() => {
-                    setFileContent(null);
+                    setPreview(null);
                     setViewingFileName('');
                 }}
-                footer={null}
+                footer={preview?.hasMore ? (
+                    <div className="flex items-center justify-between">
+                        <span className="text-xs text-gray-400">
+                            已加载 {formatSize(preview.nextOffset)} / {formatSize(preview.size)}
+                        </span>
+                        <Button onClick={handleLoadMore} loading={loadingMore}>加载更多</Button>
+                    </div>
+                ) : null}
                 width={720}
             >
-                {fileLoading ? (
+                {fileLoading || !preview ? (
                     <div className="flex justify-center py-8">
                         <Spin tip="读取中..."/>
                     </div>
                 ) : (
-                    <pre className="bg-gray-900 text-green-400 rounded-lg p-4 text-xs font-mono overflow-auto max-h-[60vh] whitespace-pre-wrap break-all">
-                        {fileContent}
-                    </pre>
+                    <div className="flex flex-col gap-2">
+                        {preview.summary && <div className="text-xs text-gray-500">{preview.summary}</div>}
+                        {preview.kind === 'empty' ? (
+                            <Empty description="空文件" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+                        ) : preview.dataUrl ? (
+                            <div className="flex justify-center bg-gray-100 rounded-lg p-2 max-h-[60vh] overflow-auto">
+                                <img src={preview.dataUrl} alt={preview.name} className="max-w-full object-contain"/>
+                            </div>
+                        ) : (
+                            <pre className={`bg-gray-900 ${preview.mode === 'hex' ? 'text-amber-300 whitespace-pre' : 'text-green-400 whitespace-pre-wrap break-all'} rounded-lg p-4 text-xs font-mono overflow-auto max-h-[60vh]`}>
+                                {preview.content}
+                            </pre>
+                        )}
+                    </div>
                 )}
             </Modal>
         </div>
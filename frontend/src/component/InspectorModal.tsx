@@ -0,0 +1,327 @@
+import React, {useCallback, useEffect, useMemo, useState} from 'react';
+import {Button, Descriptions, Empty, Modal, Select, Spin, Tabs, Tag, Tree, message} from 'antd';
+import type {DataNode} from 'antd/es/tree';
+import {ReloadOutlined} from '@ant-design/icons';
+import {CaptureScreenshot, GetActivityStack, GetFragmentTree, GetViewHierarchy} from "../../wailsjs/go/main/App";
+import {adb} from "../../wailsjs/go/models";
+
+interface InspectorModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+}
+
+// 控件在树中的路径作为 key，例如 0-2-1
+type ViewIndex = Record<string, adb.ViewNode>;
+
+const shortClass = (name: string) => name.substring(name.lastIndexOf('.') + 1);
+
+function viewTitle(node: adb.ViewNode): string {
+    const id = node.resourceId ? ` #${node.resourceId.substring(node.resourceId.indexOf('/') + 1)}` : '';
+    const text = node.text || node.contentDesc;
+    return `${shortClass(node.class)}${id}${text ? ` "${text}"` : ''}`;
+}
+
+function buildViewTree(nodes: adb.ViewNode[], prefix: string, index: ViewIndex): DataNode[] {
+    return nodes.map((node, i) => {
+        const key = prefix ? `${prefix}-${i}` : `${i}`;
+        index[key] = node;
+        return {key, title: viewTitle(node), children: buildViewTree(node.children || [], key, index)};
+    });
+}
+
+function buildFragmentTree(nodes: adb.FragmentNode[], prefix: string): DataNode[] {
+    return nodes.map((node, i) => {
+        const key = prefix ? `${prefix}-${i}` : `${i}`;
+        return {
+            key,
+            title: (
+                <span>
+                    <span className="font-mono">{node.name}</span>
+                    {node.tag && <span className="text-gray-400"> tag={node.tag}</span>}
+                    {node.id && <span className="text-gray-400"> id={node.id}</span>}
+                    {!node.added && <Tag className="!ml-1">未添加</Tag>}
+                    {node.hidden && <Tag className="!ml-1">隐藏</Tag>}
+                </span>
+            ),
+            children: buildFragmentTree(node.children || [], key),
+        };
+    });
+}
+
+const area = (b: adb.ViewBounds) => (b.right - b.left) * (b.bottom - b.top);
+
+// hitTest 返回包含坐标的最小控件，与设备上的触摸命中规则近似
+function hitTest(index: ViewIndex, x: number, y: number): string {
+    let found = '';
+    for (const [key, node] of Object.entries(index)) {
+        const b = node.bounds;
+        if (x < b.left || x >= b.right || y < b.top || y >= b.bottom) continue;
+        if (!found || area(b) <= area(index[found].bounds)) found = key;
+    }
+    return found;
+}
+
+const InspectorModal: React.FC<InspectorModalProps> = ({visible, onClose, deviceId}) => {
+    const [tab, setTab] = useState('view');
+    const [stack, setStack] = useState<adb.ActivityStack | null>(null);
+    const [stackLoading, setStackLoading] = useState(false);
+    const [fragmentActivity, setFragmentActivity] = useState('');
+    const [fragments, setFragments] = useState<adb.FragmentTree | null>(null);
+    const [fragmentLoading, setFragmentLoading] = useState(false);
+    const [hierarchy, setHierarchy] = useState<adb.ViewHierarchy | null>(null);
+    const [screenshot, setScreenshot] = useState('');
+    const [imageSize, setImageSize] = useState({width: 0, height: 0});
+    const [viewLoading, setViewLoading] = useState(false);
+    const [selectedKey, setSelectedKey] = useState('');
+    const [hoverKey, setHoverKey] = useState('');
+
+    const loadStack = useCallback(async () => {
+        setStackLoading(true);
+        try {
+            setStack(await GetActivityStack(deviceId));
+        } catch (e: any) {
+            message.error(`读取任务栈失败: ${e?.message || e}`);
+        } finally {
+            setStackLoading(false);
+        }
+    }, [deviceId]);
+
+    const loadFragments = useCallback(async (activity: string) => {
+        setFragmentLoading(true);
+        try {
+            setFragments(await GetFragmentTree(deviceId, activity));
+        } catch (e: any) {
+            message.error(`读取 Fragment 失败: ${e?.message || e}`);
+        } finally {
+            setFragmentLoading(false);
+        }
+    }, [deviceId]);
+
+    // 截图与控件树同时读取，尽量保证两者对应同一画面
+    const loadView = useCallback(async () => {
+        setViewLoading(true);
+        setSelectedKey('');
+        setHoverKey('');
+        try {
+            const [image, tree] = await Promise.all([CaptureScreenshot(deviceId), GetViewHierarchy(deviceId)]);
+            setScreenshot(image);
+            setHierarchy(tree);
+        } catch (e: any) {
+            message.error(`读取界面失败: ${e?.message || e}`);
+        } finally {
+            setViewLoading(false);
+        }
+    }, [deviceId]);
+
+    useEffect(() => {
+        if (!visible) return;
+        setFragmentActivity('');
+        loadStack();
+        loadFragments('');
+        loadView();
+    }, [visible, loadStack, loadFragments, loadView]);
+
+    const {viewTree, viewIndex} = useMemo(() => {
+        const index: ViewIndex = {};
+        const tree = buildViewTree(hierarchy?.nodes || [], '', index);
+        return {viewTree: tree, viewIndex: index};
+    }, [hierarchy]);
+
+    const activityOptions = useMemo(() => {
+        const components = new Set<string>();
+        stack?.displays.forEach(d => d.tasks.forEach(t => t.activities.forEach(a => components.add(a.component))));
+        return Array.from(components).map(c => ({label: c, value: c}));
+    }, [stack]);
+
+    const onImageClick = (e: React.MouseEvent<HTMLDivElement>) => {
+        if (!imageSize.width) return;
+        const rect = e.currentTarget.getBoundingClientRect();
+        const x = (e.clientX - rect.left) / rect.width * imageSize.width;
+        const y = (e.clientY - rect.top) / rect.height * imageSize.height;
+        setSelectedKey(hitTest(viewIndex, x, y));
+    };
+
+    const boxStyle = (b: adb.ViewBounds): React.CSSProperties => ({
+        left: `${b.left / imageSize.width * 100}%`,
+        top: `${b.top / imageSize.height * 100}%`,
+        width: `${(b.right - b.left) / imageSize.width * 100}%`,
+        height: `${(b.bottom - b.top) / imageSize.height * 100}%`,
+    });
+
+    const selected = viewIndex[selectedKey];
+    const hovered = viewIndex[hoverKey];
+
+    const viewTab = (
+        <Spin spinning={viewLoading}>
+            <div className="flex gap-4 h-[560px]">
+                <div className="flex-shrink-0 h-full flex items-start justify-center bg-gray-100 rounded">
+                    {screenshot ? (
+                        <div className="relative h-full cursor-crosshair" onClick={onImageClick}>
+                            <img
+                                src={screenshot}
+                                alt="screenshot"
+                                className="h-full w-auto select-none"
+                                draggable={false}
+                                onLoad={e => setImageSize({width: e.currentTarget.naturalWidth, height: e.currentTarget.naturalHeight})}
+                            />
+                            {imageSize.width > 0 && hovered && hoverKey !== selectedKey && (
+                                <div className="absolute border border-blue-400 bg-blue-400/10 pointer-events-none" style={boxStyle(hovered.bounds)}/>
+                            )}
+                            {imageSize.width > 0 && selected && (
+                                <div className="absolute border-2 border-red-500 bg-red-500/10 pointer-events-none" style={boxStyle(selected.bounds)}/>
+                            )}
+                        </div>
+                    ) : (
+                        <Empty className="!mt-20 w-64" description="暂无截图" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+                    )}
+                </div>
+                <div className="flex-1 min-w-0 flex flex-col gap-3">
+                    <div className="flex-1 min-h-0 overflow-auto border border-gray-100 rounded p-1">
+                        {viewTree.length > 0 ? (
+                            <Tree
+                                showLine
+                                blockNode
+                                defaultExpandAll
+                                treeData={viewTree}
+                                selectedKeys={selectedKey ? [selectedKey] : []}
+                                onSelect={keys => setSelectedKey(keys.length ? String(keys[0]) : '')}
+                                titleRender={node => (
+                                    <span className="text-xs font-mono"
+                                          onMouseEnter={() => setHoverKey(String(node.key))}
+                                          onMouseLeave={() => setHoverKey('')}>
+                                        {node.title as string}
+                                    </span>
+                                )}
+                            />
+                        ) : (
+                            <Empty description="暂无控件树" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+                        )}
+                    </div>
+                    {selected && (
+                        <Descriptions size="small" column={2} bordered className="flex-shrink-0">
+                            <Descriptions.Item label="class" span={2}><span className="font-mono text-xs">{selected.class}</span></Descriptions.Item>
+                            <Descriptions.Item label="resource-id" span={2}><span className="font-mono text-xs">{selected.resourceId || '-'}</span></Descriptions.Item>
+                            <Descriptions.Item label="text">{selected.text || '-'}</Descriptions.Item>
+                            <Descriptions.Item label="content-desc">{selected.contentDesc || '-'}</Descriptions.Item>
+                            <Descriptions.Item label="bounds" span={2}>
+                                <span className="font-mono text-xs">
+                                    [{selected.bounds.left},{selected.bounds.top}][{selected.bounds.right},{selected.bounds.bottom}]
+                                </span>
+                            </Descriptions.Item>
+                            <Descriptions.Item label="状态" span={2}>
+                                {selected.clickable && <Tag>clickable</Tag>}
+                                {selected.scrollable && <Tag>scrollable</Tag>}
+                                {selected.focused && <Tag>focused</Tag>}
+                                {selected.selected && <Tag>selected</Tag>}
+                                {selected.checked && <Tag>checked</Tag>}
+                                {!selected.enabled && <Tag color="orange">disabled</Tag>}
+                            </Descriptions.Item>
+                        </Descriptions>
+                    )}
+                </div>
+            </div>
+        </Spin>
+    );
+
+    const stackTab = (
+        <Spin spinning={stackLoading}>
+            <div className="h-[560px] overflow-auto flex flex-col gap-3">
+                {stack?.resumedActivity && (
+                    <div className="text-sm">当前 Activity <span className="font-mono">{stack.resumedActivity}</span></div>
+                )}
+                {(stack?.displays || []).map(display => (
+                    <div key={display.displayId} className="flex flex-col gap-2">
+                        <div className="text-xs text-gray-500">屏幕 #{display.displayId}</div>
+                        {display.tasks.map(task => (
+                            <div key={task.taskId} className={`border rounded p-2 ${task.visible ? 'border-blue-200' : 'border-gray-100'}`}>
+                                <div className="flex items-center gap-2 text-xs mb-1">
+                                    <span className="font-semibold">任务 #{task.taskId}</span>
+                                    {task.affinity && <span className="text-gray-500 font-mono">{task.affinity}</span>}
+                                    {task.activityType && <Tag>{task.activityType}</Tag>}
+                                    {task.windowMode && <Tag>{task.windowMode}</Tag>}
+                                    {!task.visible && <Tag color="default">不可见</Tag>}
+                                </div>
+                                {task.activities.map((activity, i) => (
+                                    <div key={i} className="flex items-center gap-2 text-xs py-0.5 pl-3">
+                                        <span className={`font-mono ${activity.resumed ? 'text-blue-600 font-semibold' : ''}`} title={activity.intent}>
+                                            {activity.component}
+                                        </span>
+                                        <Tag>{activity.launchMode}</Tag>
+                                        {activity.state && <Tag color={activity.resumed ? 'blue' : 'default'}>{activity.state}</Tag>}
+                                        {activity.userId !== 0 && <Tag color="purple">用户 {activity.userId}</Tag>}
+                                        <Button type="link" size="small" className="!p-0 !h-auto !text-xs" onClick={() => {
+                                            setFragmentActivity(activity.component);
+                                            loadFragments(activity.component);
+                                            setTab('fragment');
+                                        }}>Fragment</Button>
+                                    </div>
+                                ))}
+                            </div>
+                        ))}
+                    </div>
+                ))}
+                {!stackLoading && !stack?.displays.length && <Empty description="暂无任务" image={Empty.PRESENTED_IMAGE_SIMPLE}/>}
+            </div>
+        </Spin>
+    );
+
+    const fragmentTreeData = useMemo(() => buildFragmentTree(fragments?.fragments || [], ''), [fragments]);
+    const fragmentTab = (
+        <div className="h-[560px] flex flex-col gap-3">
+            <Select
+                allowClear
+                showSearch
+                size="small"
+                placeholder="栈顶 Activity"
+                value={fragmentActivity || undefined}
+                options={activityOptions}
+                onChange={value => {
+                    setFragmentActivity(value || '');
+                    loadFragments(value || '');
+                }}
+            />
+            <Spin spinning={fragmentLoading} wrapperClassName="flex-1 min-h-0 overflow-auto">
+                {fragments?.activity && <div className="text-xs text-gray-500 mb-2 font-mono">{fragments.activity}</div>}
+                {fragmentTreeData.length > 0 ? (
+                    <Tree showLine defaultExpandAll selectable={false} treeData={fragmentTreeData} key={fragments?.activity}/>
+                ) : (
+                    <Empty description="没有 Fragment 或应用不可调试" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+                )}
+            </Spin>
+        </div>
+    );
+
+    const reload = () => {
+        if (tab === 'view') loadView();
+        else if (tab === 'stack') loadStack();
+        else loadFragments(fragmentActivity);
+    };
+
+    return (
+        <Modal
+            title="界面检查器"
+            open={visible}
+            onCancel={onClose}
+            footer={null}
+            width={1100}
+            destroyOnHidden
+        >
+            <Tabs
+                activeKey={tab}
+                onChange={setTab}
+                tabBarExtraContent={
+                    <Button size="small" icon={<ReloadOutlined/>} onClick={reload}
+                            loading={viewLoading || stackLoading || fragmentLoading}>刷新</Button>
+                }
+                items={[
+                    {key: 'view', label: '控件树', children: viewTab},
+                    {key: 'stack', label: '任务栈', children: stackTab},
+                    {key: 'fragment', label: 'Fragment', children: fragmentTab},
+                ]}
+            />
+        </Modal>
+    );
+};
+
+export default InspectorModal;
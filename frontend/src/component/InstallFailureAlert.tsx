@@ -0,0 +1,73 @@
+import React from 'react';
+import {Alert, Button, Popconfirm} from 'antd';
+import {adb} from "../../wailsjs/go/models";
+
+export const defaultInstallOptions = (): adb.InstallOptions => ({
+    replace: true, downgrade: true, allowTest: true, grantPermissions: false, uninstallPackage: '', user: '',
+});
+
+const fixLabels: Record<string, string> = {
+    uninstall: '卸载后重新安装',
+    downgrade: '允许降级 (-d) 重试',
+    'test-only': '允许测试应用 (-t) 重试',
+    replace: '覆盖安装 (-r) 重试',
+};
+
+// 根据失败原因生成修复后的安装选项，无法自动修复时返回 null
+export function applyInstallFix(options: adb.InstallOptions, failure: adb.InstallFailure, fallbackPackage: string): adb.InstallOptions | null {
+    switch (failure.fix) {
+        case 'uninstall': {
+            const pkg = failure.package || fallbackPackage;
+            return pkg ? {...options, uninstallPackage: pkg} : null;
+        }
+        case 'downgrade':
+            return {...options, downgrade: true};
+        case 'test-only':
+            return {...options, allowTest: true};
+        case 'replace':
+            return {...options, replace: true};
+        default:
+            return null;
+    }
+}
+
+interface InstallFailureAlertProps {
+    result: adb.InstallResult;
+    options: adb.InstallOptions;
+    fallbackPackage?: string;
+    retrying?: boolean;
+    onRetry?: (options: adb.InstallOptions) => void;
+    showOutput?: boolean;
+}
+
+// 展示安装失败的原因、建议，并在可以自动修复时提供重试按钮
+const InstallFailureAlert: React.FC<InstallFailureAlertProps> = ({result, options, fallbackPackage = '', retrying, onRetry, showOutput = true}) => {
+    const failure = result.failure;
+    const fixed = failure && onRetry ? applyInstallFix(options, failure, fallbackPackage) : null;
+    const fixButton = fixed && failure && (
+        failure.fix === 'uninstall' ? (
+            <Popconfirm title={`将卸载 ${fixed.uninstallPackage} 并清除其数据，确定继续？`} onConfirm={() => onRetry?.(fixed)}>
+                <Button size="small" danger loading={retrying}>{fixLabels[failure.fix]}</Button>
+            </Popconfirm>
+        ) : (
+            <Button size="small" loading={retrying} onClick={() => onRetry?.(fixed)}>{fixLabels[failure.fix]}</Button>
+        )
+    );
+
+    return (
+        <Alert
+            type="error"
+            message={failure ? `安装失败：${failure.code}` : '安装失败'}
+            action={fixButton}
+            description={
+                <div className="text-xs flex flex-col gap-1">
+                    {failure && <div>{failure.explanation}{failure.message && <span className="text-gray-500">（{failure.message}）</span>}</div>}
+                    {failure && <div>建议：{failure.suggestion}</div>}
+                    {showOutput && <pre className="whitespace-pre-wrap max-h-40 overflow-auto m-0">{result.output}</pre>}
+                </div>
+            }
+        />
+    );
+};
+
+export default InstallFailureAlert;
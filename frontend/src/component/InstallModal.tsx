@@ -0,0 +1,186 @@
+import React, {useState} from 'react';
+import {Alert, Button, Checkbox, Empty, Modal, Spin, Table, Tag, message} from 'antd';
+import {FolderOpenOutlined} from '@ant-design/icons';
+import InstallFailureAlert, {defaultInstallOptions} from './InstallFailureAlert';
+import InstallOptionsPicker from './InstallOptionsPicker';
+import {useDeviceStore} from "../store/deviceStore";
+import {DiscardInstallPlan, PlanInstall, RunInstall, SelectInstallFiles} from "../../wailsjs/go/main/App";
+import {adb} from "../../wailsjs/go/models";
+
+interface InstallModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+}
+
+const kindTags: Record<string, {color: string; label: string}> = {
+    master: {color: 'blue', label: '主包'},
+    abi: {color: 'purple', label: 'ABI'},
+    density: {color: 'cyan', label: '密度'},
+    language: {color: 'green', label: '语言'},
+    other: {color: 'default', label: '其他'},
+};
+
+function formatBytes(raw: number): string {
+    if (raw < 1024) return `${raw} B`;
+    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
+    return `${(raw / 1024 / 1024).toFixed(1)} MB`;
+}
+
+const InstallModal: React.FC<InstallModalProps> = ({visible, onClose, deviceId}) => {
+    const [plan, setPlan] = useState<adb.InstallPlan | null>(null);
+    const [planning, setPlanning] = useState(false);
+    const [installing, setInstalling] = useState(false);
+    const [result, setResult] = useState<adb.InstallResult | null>(null);
+    const [options, setOptions] = useState<adb.InstallOptions>(defaultInstallOptions());
+    const selectedUserId = useDeviceStore(state => state.selectedUserId);
+
+    const reset = () => {
+        setPlan(null);
+        setResult(null);
+    };
+
+    // 安装成功后后端已清理解压目录，其余情况由前端放弃
+    const close = () => {
+        if (plan && !result?.success) DiscardInstallPlan(plan);
+        reset();
+        onClose();
+    };
+
+    const choose = async () => {
+        let paths: string[];
+        try {
+            paths = await SelectInstallFiles();
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(msg);
+            return;
+        }
+        if (plan && !result?.success) DiscardInstallPlan(plan);
+        reset();
+        setPlanning(true);
+        try {
+            setPlan(await PlanInstall(deviceId, paths));
+        } catch (e: any) {
+            message.error(`解析安装包失败: ${e?.message || e}`);
+        } finally {
+            setPlanning(false);
+        }
+    };
+
+    const toggle = (index: number, checked: boolean) => {
+        if (!plan) return;
+        const splits = plan.splits.map((s, i) => i === index ? {...s, selected: checked} : s);
+        setPlan({...plan, splits} as adb.InstallPlan);
+    };
+
+    // 失败时保留解压目录，便于按建议修改选项后重试
+    const install = async (opts: adb.InstallOptions = options) => {
+        if (!plan) return;
+        setOptions(opts);
+        setInstalling(true);
+        try {
+            const res = await RunInstall(deviceId, plan, {...opts, user: selectedUserId}, true);
+            setResult(res);
+            if (res.success) message.success('安装成功');
+        } finally {
+            setInstalling(false);
+        }
+    };
+
+    const selectedCount = plan?.splits.filter(s => s.selected).length || 0;
+
+    const columns = [
+        {
+            title: '', key: 'selected', width: 40,
+            render: (_: any, s: adb.ApkSplit, index: number) => (
+                <Checkbox checked={s.selected} disabled={!!result?.success} onChange={e => toggle(index, e.target.checked)}/>
+            ),
+        },
+        {title: '文件', dataIndex: 'name', key: 'name', ellipsis: true, render: (n: string) => <span className="font-mono text-xs">{n}</span>},
+        {title: '模块', dataIndex: 'module', key: 'module', width: 100, ellipsis: true},
+        {
+            title: '类型', key: 'kind', width: 130,
+            render: (_: any, s: adb.ApkSplit) => (
+                <span>
+                    <Tag color={kindTags[s.kind]?.color}>{kindTags[s.kind]?.label || s.kind}</Tag>
+                    {s.qualifier && <span className="text-xs text-gray-500">{s.qualifier}</span>}
+                </span>
+            ),
+        },
+        {title: '大小', dataIndex: 'size', key: 'size', width: 90, render: (size: number) => formatBytes(size)},
+        {title: '说明', dataIndex: 'reason', key: 'reason', width: 200, ellipsis: true, render: (r: string) => <span className="text-xs text-gray-500">{r}</span>},
+    ];
+
+    return (
+        <Modal
+            title="安装拆分包 / APKS / XAPK / AAB"
+            open={visible}
+            onCancel={close}
+            maskClosable={false}
+            width={900}
+            footer={
+                <div className="flex items-center justify-between">
+                    <div className="flex items-center gap-3">
+                        <Button icon={<FolderOpenOutlined/>} onClick={choose} loading={planning} disabled={installing}>
+                            选择安装包
+                        </Button>
+                        <InstallOptionsPicker value={options} onChange={setOptions} disabled={installing}/>
+                    </div>
+                    <Button type="primary" onClick={() => install()} loading={installing}
+                            disabled={!plan || selectedCount === 0 || !!result?.success}>
+                        安装选中的 {selectedCount} 个 APK
+                    </Button>
+                </div>
+            }
+        >
+            {planning ? (
+                <div className="flex justify-center py-10"><Spin tip="正在解析安装包..."/></div>
+            ) : !plan ? (
+                <Empty description="支持一个 .apks/.xapk/.aab，或同一应用的多个 .apk" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+            ) : (
+                <div className="flex flex-col gap-3">
+                    <div className="text-xs text-gray-500 flex flex-wrap gap-x-4 gap-y-1">
+                        <span className="font-mono truncate max-w-full">{plan.source}</span>
+                        {plan.package && <span>包名：{plan.package}</span>}
+                        <span>设备 ABI：{(plan.device.abis || []).join(', ') || '-'}</span>
+                        <span>密度：{plan.device.density || '-'}dpi</span>
+                        <span>语言：{(plan.device.locales || []).join(', ') || '-'}</span>
+                        <span>SDK：{plan.device.sdk || '-'}</span>
+                    </div>
+                    <Table
+                        size="small"
+                        rowKey="name"
+                        columns={columns}
+                        dataSource={plan.splits}
+                        pagination={false}
+                        scroll={{y: '40vh'}}
+                    />
+                    {plan.obbs.length > 0 && (
+                        <div className="text-xs text-gray-500">
+                            安装后推送 OBB：{plan.obbs.map(o => `${o.remotePath} (${formatBytes(o.size)})`).join('，')}
+                        </div>
+                    )}
+                    {result?.success && (
+                        <Alert
+                            type="success"
+                            message={`已安装 ${result.installed.length} 个 APK${result.obbs.length ? `，推送 ${result.obbs.length} 个 OBB` : ''}`}
+                            description={
+                                <div className="text-xs">
+                                    <div className="font-mono">{result.installed.join('、')}</div>
+                                    <pre className="whitespace-pre-wrap max-h-40 overflow-auto m-0">{result.output}</pre>
+                                </div>
+                            }
+                        />
+                    )}
+                    {result && !result.success && (
+                        <InstallFailureAlert result={result} options={options} fallbackPackage={plan.package}
+                                             retrying={installing} onRetry={install}/>
+                    )}
+                </div>
+            )}
+        </Modal>
+    );
+};
+
+export default InstallModal;
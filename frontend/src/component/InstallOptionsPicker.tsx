@@ -0,0 +1,32 @@
+import React from 'react';
+import {Checkbox, Tooltip} from 'antd';
+import {adb} from "../../wailsjs/go/models";
+
+interface InstallOptionsPickerProps {
+    value: adb.InstallOptions;
+    onChange: (value: adb.InstallOptions) => void;
+    disabled?: boolean;
+}
+
+const flags: {key: 'replace' | 'downgrade' | 'allowTest' | 'grantPermissions'; label: string; tip: string}[] = [
+    {key: 'replace', label: '-r', tip: '覆盖安装，保留应用数据'},
+    {key: 'downgrade', label: '-d', tip: '允许降级安装'},
+    {key: 'allowTest', label: '-t', tip: '允许安装 testOnly 应用'},
+    {key: 'grantPermissions', label: '-g', tip: '安装时授予清单中声明的所有运行时权限'},
+];
+
+// adb install 参数选择
+const InstallOptionsPicker: React.FC<InstallOptionsPickerProps> = ({value, onChange, disabled}) => (
+    <div className="flex items-center gap-1">
+        {flags.map(f => (
+            <Tooltip key={f.key} title={f.tip}>
+                <Checkbox checked={value[f.key]} disabled={disabled}
+                          onChange={e => onChange({...value, [f.key]: e.target.checked})}>
+                    <span className="font-mono text-xs">{f.label}</span>
+                </Checkbox>
+            </Tooltip>
+        ))}
+    </div>
+);
+
+export default InstallOptionsPicker;
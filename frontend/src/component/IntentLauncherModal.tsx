@@ -0,0 +1,343 @@
+import React, {useEffect, useMemo, useState} from 'react';
+import {Alert, AutoComplete, Button, Checkbox, Descriptions, Input, Modal, Popconfirm, Radio, Select, message} from 'antd';
+import {DeleteOutlined, PlusOutlined, SaveOutlined} from '@ant-design/icons';
+import {
+    DeleteIntentPreset,
+    GetIntentFlags,
+    ListIntentComponents,
+    ListIntentPresets,
+    SaveIntentPreset,
+    SendIntent
+} from "../../wailsjs/go/main/App";
+import {adb, main} from "../../wailsjs/go/models";
+import {useDeviceStore} from "../store/deviceStore";
+
+interface IntentLauncherModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    packageName: string;
+}
+
+const modeOptions = [
+    {value: 'activity', label: '启动 Activity'},
+    {value: 'broadcast', label: '发送广播'},
+    {value: 'service', label: '启动服务'},
+    {value: 'foreground-service', label: '启动前台服务'},
+];
+
+// 清单中的组件类型与发送方式的对应关系
+const modeKinds: Record<string, string> = {
+    activity: 'activity',
+    broadcast: 'receiver',
+    service: 'service',
+    'foreground-service': 'service',
+};
+
+const extraTypes = ['string', 'int', 'long', 'float', 'bool', 'uri', 'component', 'null', 'string-array', 'int-array', 'long-array'];
+
+const commonActions = [
+    'android.intent.action.VIEW',
+    'android.intent.action.MAIN',
+    'android.intent.action.SEND',
+    'android.intent.action.BOOT_COMPLETED',
+    'android.settings.SETTINGS',
+    'android.settings.APPLICATION_DETAILS_SETTINGS',
+];
+
+const commonCategories = [
+    'android.intent.category.DEFAULT',
+    'android.intent.category.BROWSABLE',
+    'android.intent.category.LAUNCHER',
+    'android.intent.category.HOME',
+];
+
+function emptySpec(packageName: string): adb.IntentSpec {
+    return {
+        mode: 'activity', action: '', data: '', mimeType: '', package: packageName, component: '',
+        categories: [], extras: [], flags: [], wait: true, forceStop: false,
+    } as adb.IntentSpec;
+}
+
+const IntentLauncherModal: React.FC<IntentLauncherModalProps> = ({visible, onClose, deviceId, packageName}) => {
+    const selectedUserId = useDeviceStore(state => state.selectedUserId);
+    const [spec, setSpec] = useState<adb.IntentSpec>(emptySpec(packageName));
+    const [flagNames, setFlagNames] = useState<string[]>([]);
+    const [components, setComponents] = useState<adb.IntentComponent[]>([]);
+    const [loadingComponents, setLoadingComponents] = useState(false);
+    const [presets, setPresets] = useState<main.IntentPreset[]>([]);
+    const [presetId, setPresetId] = useState('');
+    const [presetName, setPresetName] = useState('');
+    const [sending, setSending] = useState(false);
+    const [result, setResult] = useState<adb.IntentResult | null>(null);
+    const [error, setError] = useState('');
+
+    const loadPresets = async () => {
+        try {
+            setPresets(await ListIntentPresets(packageName));
+        } catch (e: any) {
+            message.error(`读取预设失败: ${e?.message || e}`);
+        }
+    };
+
+    useEffect(() => {
+        if (visible) {
+            setSpec(emptySpec(packageName));
+            setComponents([]);
+            setPresetId('');
+            setPresetName('');
+            setResult(null);
+            setError('');
+            loadPresets();
+            GetIntentFlags().then(setFlagNames);
+        }
+    }, [visible, packageName]);
+
+    const update = (patch: Partial<adb.IntentSpec>) => setSpec(prev => ({...prev, ...patch}));
+
+    const loadComponents = async () => {
+        if (!packageName) return;
+        setLoadingComponents(true);
+        try {
+            setComponents(await ListIntentComponents(deviceId, selectedUserId, packageName));
+        } catch (e: any) {
+            message.error(`读取组件失败: ${e?.message || e}`);
+        } finally {
+            setLoadingComponents(false);
+        }
+    };
+
+    const kindComponents = useMemo(
+        () => components.filter(c => c.kind === modeKinds[spec.mode]),
+        [components, spec.mode],
+    );
+
+    // 与 am 的写法一致，同包名下的类名使用 . 开头的简写
+    const componentValue = (c: adb.IntentComponent) => c.name.startsWith(packageName + '.')
+        ? `${packageName}/${c.name.substring(packageName.length)}`
+        : `${packageName}/${c.name}`;
+
+    const componentOptions = kindComponents.map(c => ({
+        value: componentValue(c),
+        label: <span className="font-mono text-xs">{c.name}</span>,
+    }));
+
+    // 选中组件时优先补全该组件 intent-filter 中的 action
+    const selected = kindComponents.find(c => spec.component === componentValue(c) || spec.component.endsWith('/' + c.name));
+    const actionOptions = Array.from(new Set([
+        ...(selected?.actions || []),
+        ...kindComponents.flatMap(c => c.actions),
+        ...commonActions,
+    ])).map(a => ({value: a}));
+    const categoryOptions = Array.from(new Set([
+        ...(selected?.categories || []),
+        ...commonCategories,
+    ])).map(c => ({value: c, label: c}));
+    const schemes = Array.from(new Set(kindComponents.flatMap(c => c.schemes)));
+
+    const applyPreset = (id: string) => {
+        const preset = presets.find(p => p.id === id);
+        if (!preset) return;
+        setPresetId(id);
+        setPresetName(preset.name);
+        setSpec({...emptySpec(packageName), ...preset.spec});
+        setResult(null);
+        setError('');
+    };
+
+    const savePreset = async () => {
+        try {
+            const existing = presets.find(p => p.id === presetId && p.name === presetName.trim());
+            const saved = await SaveIntentPreset({
+                id: existing ? existing.id : '',
+                name: presetName,
+                package: packageName,
+                spec,
+                createdAt: 0,
+                lastUsed: 0,
+            } as main.IntentPreset);
+            setPresetId(saved.id);
+            message.success(existing ? '预设已更新' : '预设已保存');
+            loadPresets();
+        } catch (e: any) {
+            message.error(`${e?.message || e}`);
+        }
+    };
+
+    const deletePreset = async () => {
+        try {
+            await DeleteIntentPreset(presetId);
+            setPresetId('');
+            setPresetName('');
+            loadPresets();
+        } catch (e: any) {
+            message.error(`删除失败: ${e?.message || e}`);
+        }
+    };
+
+    const send = async () => {
+        setSending(true);
+        setResult(null);
+        setError('');
+        try {
+            setResult(await SendIntent(deviceId, selectedUserId, spec, presetId));
+        } catch (e: any) {
+            setError(`${e?.message || e}`);
+        } finally {
+            setSending(false);
+        }
+    };
+
+    const setExtra = (index: number, patch: Partial<adb.IntentExtra>) => {
+        update({extras: spec.extras.map((x, i) => i === index ? {...x, ...patch} : x)});
+    };
+
+    const timing = (ms: number) => ms >= 0 ? `${ms} ms` : '-';
+
+    return (
+        <Modal
+            title="Intent / 深链接"
+            open={visible}
+            onCancel={onClose}
+            maskClosable={false}
+            width={820}
+            footer={
+                <div className="flex items-center justify-between">
+                    <div className="flex items-center gap-2">
+                        <Input
+                            value={presetName}
+                            onChange={e => setPresetName(e.target.value)}
+                            placeholder="预设名称"
+                            className="w-48"
+                        />
+                        <Button icon={<SaveOutlined/>} onClick={savePreset} disabled={!presetName.trim()}>保存预设</Button>
+                    </div>
+                    <div className="flex items-center gap-2">
+                        <Button onClick={onClose}>关闭</Button>
+                        <Button type="primary" onClick={send} loading={sending}>发送</Button>
+                    </div>
+                </div>
+            }
+        >
+            <div className="flex flex-col gap-3">
+                <div className="flex items-center gap-2">
+                    <Select
+                        className="flex-1"
+                        value={presetId || undefined}
+                        onChange={applyPreset}
+                        placeholder={presets.length ? '选择预设' : '暂无预设'}
+                        options={presets.map(p => ({
+                            value: p.id,
+                            label: <span>{p.name}{p.package && <span className="ml-2 text-xs text-gray-400 font-mono">{p.package}</span>}</span>,
+                        }))}
+                    />
+                    <Popconfirm title="删除该预设？" onConfirm={deletePreset} disabled={!presetId}>
+                        <Button icon={<DeleteOutlined/>} disabled={!presetId}/>
+                    </Popconfirm>
+                    <Button onClick={loadComponents} loading={loadingComponents} disabled={!packageName}>
+                        {components.length ? `已读取 ${components.length} 个组件` : '读取组件'}
+                    </Button>
+                </div>
+
+                <Radio.Group
+                    value={spec.mode}
+                    onChange={e => update({mode: e.target.value, component: ''})}
+                    optionType="button"
+                    options={modeOptions}
+                />
+
+                <div className="grid grid-cols-2 gap-2">
+                    <AutoComplete
+                        value={spec.action}
+                        onChange={v => update({action: v})}
+                        options={actionOptions}
+                        filterOption={(input, option) => (option?.value as string).toLowerCase().includes(input.toLowerCase())}
+                    >
+                        <Input addonBefore="action" className="font-mono" allowClear/>
+                    </AutoComplete>
+                    <AutoComplete
+                        value={spec.component}
+                        onChange={v => update({component: v})}
+                        options={componentOptions}
+                        filterOption={(input, option) => (option?.value as string).toLowerCase().includes(input.toLowerCase())}
+                    >
+                        <Input addonBefore="component" className="font-mono" placeholder="包名/.类名" allowClear/>
+                    </AutoComplete>
+                    <AutoComplete
+                        value={spec.data}
+                        onChange={v => update({data: v})}
+                        options={schemes.map(s => ({value: s}))}
+                    >
+                        <Input addonBefore="data" className="font-mono" placeholder="https://example.com/path" allowClear/>
+                    </AutoComplete>
+                    <Input addonBefore="type" className="font-mono" value={spec.mimeType} onChange={e => update({mimeType: e.target.value})}
+                           placeholder="MIME 类型，如 text/plain" allowClear/>
+                    <Input addonBefore="package" className="font-mono" value={spec.package} onChange={e => update({package: e.target.value})}
+                           placeholder="未指定组件时限定包名" allowClear/>
+                    <Select
+                        mode="tags"
+                        value={spec.categories}
+                        onChange={v => update({categories: v})}
+                        options={categoryOptions}
+                        placeholder="category"
+                    />
+                </div>
+
+                <Select
+                    mode="multiple"
+                    value={spec.flags}
+                    onChange={v => update({flags: v})}
+                    options={flagNames.map(f => ({value: f, label: <span className="font-mono text-xs">{f}</span>}))}
+                    placeholder="flags"
+                />
+
+                <div className="flex flex-col gap-2">
+                    {spec.extras.map((extra, i) => (
+                        <div key={i} className="flex items-center gap-2">
+                            <Input className="w-48 font-mono" value={extra.key} placeholder="key"
+                                   onChange={e => setExtra(i, {key: e.target.value})}/>
+                            <Select className="w-36" value={extra.type} onChange={v => setExtra(i, {type: v})}
+                                    options={extraTypes.map(t => ({value: t, label: t}))}/>
+                            <Input className="flex-1 font-mono" value={extra.value} disabled={extra.type === 'null'}
+                                   placeholder={extra.type.endsWith('-array') ? '多个值用逗号分隔' : 'value'}
+                                   onChange={e => setExtra(i, {value: e.target.value})}/>
+                            <Button icon={<DeleteOutlined/>} onClick={() => update({extras: spec.extras.filter((_, j) => j !== i)})}/>
+                        </div>
+                    ))}
+                    <div>
+                        <Button size="small" icon={<PlusOutlined/>}
+                                onClick={() => update({extras: [...spec.extras, {key: '', type: 'string', value: ''}]})}>
+                            添加 extra
+                        </Button>
+                    </div>
+                </div>
+
+                {spec.mode === 'activity' && (
+                    <div className="flex items-center gap-4">
+                        <Checkbox checked={spec.wait} onChange={e => update({wait: e.target.checked})}>等待启动完成并统计耗时 (-W)</Checkbox>
+                        <Checkbox checked={spec.forceStop} onChange={e => update({forceStop: e.target.checked})}>启动前停止应用 (-S)</Checkbox>
+                    </div>
+                )}
+
+                {error && <Alert type="error" showIcon message={<span className="text-xs break-all">{error}</span>}/>}
+                {result && (
+                    <div className="flex flex-col gap-2">
+                        {result.warning && <Alert type="warning" showIcon message={result.warning}/>}
+                        {result.status && (
+                            <Descriptions size="small" column={3} bordered>
+                                <Descriptions.Item label="Status">{result.status}</Descriptions.Item>
+                                <Descriptions.Item label="LaunchState">{result.launchState || '-'}</Descriptions.Item>
+                                <Descriptions.Item label="TotalTime">{timing(result.totalTime)}</Descriptions.Item>
+                                <Descriptions.Item label="Activity" span={2}><span className="font-mono text-xs">{result.activity}</span></Descriptions.Item>
+                                <Descriptions.Item label="WaitTime">{timing(result.waitTime)}</Descriptions.Item>
+                            </Descriptions>
+                        )}
+                        <pre className="text-xs bg-gray-50 p-2 rounded whitespace-pre-wrap break-all max-h-40 overflow-auto">{result.output}</pre>
+                    </div>
+                )}
+            </div>
+        </Modal>
+    );
+};
+
+export default IntentLauncherModal;
@@ -278,6 +278,7 @@ const MemoryMonitor: React.FC = () => {
                 action: 'format-sys-info',
                 targetPackageName: processName,
                 deviceId: selectedDevice.id,
+                userId: '',
             });
 
             if (result && !result.error) {
@@ -330,6 +331,7 @@ const MemoryMonitor: React.FC = () => {
             action: 'dump-pid',
             targetPackageName: processName,
             deviceId: selectedDevice.id,
+            userId: '',
         });
 
         if (result.error) {
@@ -0,0 +1,241 @@
+import React, {useEffect, useMemo, useState} from 'react';
+import {Button, Checkbox, Empty, Input, Modal, Popconfirm, Select, Table, Tabs, Tag, Tooltip, message} from 'antd';
+import {SwapOutlined} from '@ant-design/icons';
+import {
+    ComparePackages,
+    DeletePackageSnapshot,
+    ListPackageSnapshots,
+    TakePackageSnapshot
+} from "../../wailsjs/go/main/App";
+import {main} from "../../wailsjs/go/models";
+import {useDeviceStore} from "../store/deviceStore";
+
+interface PackageDiffModalProps {
+    visible: boolean;
+    onClose: () => void;
+}
+
+const changeTags: Record<string, {color: string; label: string}> = {
+    added: {color: 'green', label: '新增'},
+    removed: {color: 'red', label: '缺少'},
+    upgraded: {color: 'blue', label: '升级'},
+    downgraded: {color: 'orange', label: '降级'},
+    signature: {color: 'magenta', label: '签名不同'},
+};
+
+// 选择框的值：device:<id> 表示实时读取设备，snapshot:<id> 表示保存的快照
+function toSource(value: string, selectedDeviceId: string, selectedUserId: string): main.PackageDiffSource {
+    const [kind, id] = [value.substring(0, value.indexOf(':')), value.substring(value.indexOf(':') + 1)];
+    if (kind === 'snapshot') return {snapshotId: id, deviceId: '', userId: ''};
+    return {snapshotId: '', deviceId: id, userId: id === selectedDeviceId ? selectedUserId : ''};
+}
+
+function sideLabel(meta: main.PackageSnapshotMeta): string {
+    return meta.id ? meta.name : `${meta.deviceName || meta.deviceId}（实时）`;
+}
+
+const version = (e?: main.PackageSnapshotEntry) => e ? `${e.versionName || '-'} (${e.versionCode})` : '-';
+const signature = (e?: main.PackageSnapshotEntry) => e && e.signatures.length ? e.signatures.map(s => s.substring(0, 12)).join(', ') : '-';
+
+const PackageDiffModal: React.FC<PackageDiffModalProps> = ({visible, onClose}) => {
+    const {devices, selectedDevice, selectedUserId} = useDeviceStore();
+    const [snapshots, setSnapshots] = useState<main.PackageSnapshotMeta[]>([]);
+    const [left, setLeft] = useState<string>();
+    const [right, setRight] = useState<string>();
+    const [comparing, setComparing] = useState(false);
+    const [diff, setDiff] = useState<main.PackageDiff | null>(null);
+    const [filter, setFilter] = useState('');
+    const [hideSystem, setHideSystem] = useState(false);
+
+    const [snapshotDevice, setSnapshotDevice] = useState<string>();
+    const [snapshotName, setSnapshotName] = useState('');
+    const [snapshotting, setSnapshotting] = useState(false);
+
+    const loadSnapshots = async () => {
+        try {
+            setSnapshots(await ListPackageSnapshots());
+        } catch (e: any) {
+            message.error(`读取快照失败: ${e?.message || e}`);
+        }
+    };
+
+    useEffect(() => {
+        if (visible) {
+            loadSnapshots();
+            setDiff(null);
+            setFilter('');
+            setSnapshotDevice(selectedDevice?.id);
+            if (!left && selectedDevice) setLeft(`device:${selectedDevice.id}`);
+        }
+    }, [visible]);
+
+    const sourceOptions = [
+        {
+            label: '设备（实时读取）',
+            options: devices.map(d => ({value: `device:${d.id}`, label: d.name || d.id})),
+        },
+        {
+            label: '快照',
+            options: snapshots.map(s => ({
+                value: `snapshot:${s.id}`,
+                label: `${s.name} · ${s.packages} 个应用`,
+            })),
+        },
+    ];
+
+    const compare = async () => {
+        if (!left || !right) return;
+        setComparing(true);
+        setDiff(null);
+        try {
+            const deviceId = selectedDevice?.id || '';
+            setDiff(await ComparePackages(toSource(left, deviceId, selectedUserId), toSource(right, deviceId, selectedUserId)));
+            setFilter('');
+        } catch (e: any) {
+            message.error(`比较失败: ${e?.message || e}`);
+        } finally {
+            setComparing(false);
+        }
+    };
+
+    const takeSnapshot = async () => {
+        if (!snapshotDevice) return;
+        setSnapshotting(true);
+        try {
+            const userId = snapshotDevice === selectedDevice?.id ? selectedUserId : '';
+            const meta = await TakePackageSnapshot(snapshotDevice, userId, snapshotName);
+            message.success(`已保存快照 ${meta.name}（${meta.packages} 个应用）`);
+            setSnapshotName('');
+            loadSnapshots();
+        } catch (e: any) {
+            message.error(`保存快照失败: ${e?.message || e}`);
+        } finally {
+            setSnapshotting(false);
+        }
+    };
+
+    const deleteSnapshot = async (id: string) => {
+        try {
+            await DeletePackageSnapshot(id);
+            if (left === `snapshot:${id}`) setLeft(undefined);
+            if (right === `snapshot:${id}`) setRight(undefined);
+            loadSnapshots();
+        } catch (e: any) {
+            message.error(`删除失败: ${e?.message || e}`);
+        }
+    };
+
+    const entries = useMemo(() => (diff?.entries || []).filter(e => {
+        if (filter && e.change !== filter) return false;
+        return !(hideSystem && (e.left || e.right)?.system);
+    }), [diff, filter, hideSystem]);
+
+    const diffColumns = [
+        {
+            title: '应用', dataIndex: 'package', key: 'package', ellipsis: true,
+            render: (p: string, e: main.PackageDiffEntry) => (
+                <span className="font-mono text-xs">
+                    {p}{(e.left || e.right)?.system && <Tag className="ml-2">系统</Tag>}
+                </span>
+            ),
+        },
+        {
+            title: '变化', dataIndex: 'change', key: 'change', width: 90,
+            render: (c: string) => <Tag color={changeTags[c]?.color}>{changeTags[c]?.label || c}</Tag>,
+        },
+        {title: '左侧版本', key: 'left', width: 150, render: (_: any, e: main.PackageDiffEntry) => <span className="text-xs">{version(e.left)}</span>},
+        {title: '右侧版本', key: 'right', width: 150, render: (_: any, e: main.PackageDiffEntry) => <span className="text-xs">{version(e.right)}</span>},
+        {
+            title: '签名 SHA-256', key: 'signature', width: 130,
+            render: (_: any, e: main.PackageDiffEntry) => e.change === 'signature' ? (
+                <Tooltip title={<div className="font-mono text-xs">左: {e.left?.signatures.join(', ')}<br/>右: {e.right?.signatures.join(', ')}</div>}>
+                    <span className="font-mono text-xs text-pink-600">{signature(e.left)} → {signature(e.right)}</span>
+                </Tooltip>
+            ) : <span className="font-mono text-xs text-gray-400">{signature(e.left || e.right)}</span>,
+        },
+    ];
+
+    const compareTab = (
+        <div className="flex flex-col gap-3">
+            <div className="flex items-center gap-2">
+                <Select className="flex-1" value={left} onChange={setLeft} options={sourceOptions} placeholder="左侧（基准）"/>
+                <Button icon={<SwapOutlined/>} onClick={() => { setLeft(right); setRight(left); }}/>
+                <Select className="flex-1" value={right} onChange={setRight} options={sourceOptions} placeholder="右侧（待检查）"/>
+                <Button type="primary" onClick={compare} loading={comparing} disabled={!left || !right || left === right}>比较</Button>
+            </div>
+            {!diff ? (
+                <Empty description="选择两台设备或快照进行比较，实时读取设备需要几十秒" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+            ) : (
+                <>
+                    <div className="flex items-center gap-2 flex-wrap text-sm">
+                        <span className="text-gray-500">{sideLabel(diff.left)} → {sideLabel(diff.right)}</span>
+                        <Tag.CheckableTag checked={filter === ''} onChange={() => setFilter('')}>
+                            全部差异 {diff.entries.length}
+                        </Tag.CheckableTag>
+                        {Object.keys(changeTags).map(c => (
+                            <Tag.CheckableTag key={c} checked={filter === c} onChange={() => setFilter(filter === c ? '' : c)}>
+                                {changeTags[c].label} {diff.counts[c] || 0}
+                            </Tag.CheckableTag>
+                        ))}
+                        <span className="text-gray-400">相同 {diff.identical}</span>
+                        <Checkbox className="ml-auto" checked={hideSystem} onChange={e => setHideSystem(e.target.checked)}>隐藏系统应用</Checkbox>
+                    </div>
+                    {diff.entries.length === 0 ? (
+                        <Empty description="两侧安装的应用、版本与签名完全一致" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+                    ) : (
+                        <Table size="small" rowKey="package" columns={diffColumns} dataSource={entries}
+                               pagination={{pageSize: 12, hideOnSinglePage: true}}/>
+                    )}
+                </>
+            )}
+        </div>
+    );
+
+    const snapshotColumns = [
+        {title: '名称', dataIndex: 'name', key: 'name', ellipsis: true},
+        {title: '设备', key: 'device', ellipsis: true, render: (_: any, s: main.PackageSnapshotMeta) => <span className="text-xs">{s.deviceName || s.deviceId}{s.userId && ` · 用户 ${s.userId}`}</span>},
+        {title: '应用数', dataIndex: 'packages', key: 'packages', width: 80},
+        {title: '时间', dataIndex: 'createdAt', key: 'createdAt', width: 170, render: (t: number) => <span className="text-xs">{new Date(t).toLocaleString()}</span>},
+        {
+            title: '', key: 'delete', width: 70,
+            render: (_: any, s: main.PackageSnapshotMeta) => (
+                <Popconfirm title="删除该快照？" onConfirm={() => deleteSnapshot(s.id)}>
+                    <Button size="small" danger type="link">删除</Button>
+                </Popconfirm>
+            ),
+        },
+    ];
+
+    const snapshotTab = (
+        <div className="flex flex-col gap-3">
+            <div className="flex items-center gap-2">
+                <Select className="w-56" value={snapshotDevice} onChange={setSnapshotDevice} placeholder="选择设备"
+                        options={devices.map(d => ({value: d.id, label: d.name || d.id}))}/>
+                <Input className="flex-1" value={snapshotName} onChange={e => setSnapshotName(e.target.value)}
+                       placeholder="快照名称，默认为设备名与时间"/>
+                <Button type="primary" onClick={takeSnapshot} loading={snapshotting} disabled={!snapshotDevice}>保存快照</Button>
+            </div>
+            <Table size="small" rowKey="id" columns={snapshotColumns} dataSource={snapshots}
+                   pagination={{pageSize: 8, hideOnSinglePage: true}}
+                   locale={{emptyText: '还没有快照，保存后可以与其他设备或之后的状态比较'}}/>
+        </div>
+    );
+
+    return (
+        <Modal
+            title="应用差异比较"
+            open={visible}
+            onCancel={onClose}
+            maskClosable={false}
+            width={920}
+            footer={null}
+        >
+            <Tabs items={[
+                {key: 'compare', label: '比较', children: compareTab},
+                {key: 'snapshots', label: `快照（${snapshots.length}）`, children: snapshotTab},
+            ]}/>
+        </Modal>
+    );
+};
+
+export default PackageDiffModal;
@@ -0,0 +1,161 @@
+import React, {useCallback, useEffect, useState} from 'react';
+import {Button, Input, Modal, Popconfirm, Table, Tag, message} from 'antd';
+import {ClearPackageHistory, GetPackageHistory, SetPackageState, UndoPackageState} from "../../wailsjs/go/main/App";
+import {main} from "../../wailsjs/go/models";
+
+interface PackageHistoryModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    userId: string; // 重新安装时的目标用户
+    // 撤销或重新安装成功后通知列表更新，op 为实际执行的操作
+    onChanged: (packageName: string, op: string) => void;
+}
+
+export const packageOpLabels: Record<string, string> = {
+    disable: '停用',
+    enable: '启用',
+    suspend: '挂起',
+    unsuspend: '取消挂起',
+    uninstall: '为用户卸载',
+    reinstall: '重新安装',
+};
+
+const inverseOps: Record<string, string> = {
+    disable: 'enable',
+    enable: 'disable',
+    suspend: 'unsuspend',
+    unsuspend: 'suspend',
+    uninstall: 'reinstall',
+    reinstall: 'uninstall',
+};
+
+const PackageHistoryModal: React.FC<PackageHistoryModalProps> = ({visible, onClose, deviceId, userId, onChanged}) => {
+    const [records, setRecords] = useState<main.PackageStateRecord[]>([]);
+    const [loading, setLoading] = useState(false);
+    const [undoing, setUndoing] = useState('');
+    const [reinstallName, setReinstallName] = useState('');
+    const [reinstalling, setReinstalling] = useState(false);
+
+    const load = useCallback(async () => {
+        setLoading(true);
+        try {
+            setRecords(await GetPackageHistory(deviceId));
+        } catch (e: any) {
+            message.error(`读取操作记录失败: ${e?.message || e}`);
+        } finally {
+            setLoading(false);
+        }
+    }, [deviceId]);
+
+    useEffect(() => {
+        if (visible) load();
+    }, [visible, load]);
+
+    const undo = async (record: main.PackageStateRecord) => {
+        setUndoing(record.id);
+        try {
+            const result = await UndoPackageState(record.id);
+            if (result.error) {
+                message.error(`撤销失败: ${result.error}`);
+                return;
+            }
+            message.success(`已${packageOpLabels[inverseOps[record.op]]} ${record.package}`);
+            onChanged(record.package, inverseOps[record.op]);
+            load();
+        } finally {
+            setUndoing('');
+        }
+    };
+
+    const reinstall = async () => {
+        const name = reinstallName.trim();
+        if (!name) return;
+        setReinstalling(true);
+        try {
+            const result = await SetPackageState(deviceId, userId, name, 'reinstall', false);
+            if (result.error) {
+                message.error(`重新安装失败: ${result.error}`);
+                return;
+            }
+            message.success(`已重新安装 ${name}`);
+            setReinstallName('');
+            onChanged(name, 'reinstall');
+            load();
+        } finally {
+            setReinstalling(false);
+        }
+    };
+
+    const clear = async () => {
+        try {
+            await ClearPackageHistory();
+            setRecords([]);
+        } catch (e: any) {
+            message.error(`清空失败: ${e?.message || e}`);
+        }
+    };
+
+    const columns = [
+        {
+            title: '时间', dataIndex: 'time', key: 'time', width: 170,
+            render: (t: number) => <span className="text-xs">{new Date(t).toLocaleString()}</span>,
+        },
+        {title: '应用', dataIndex: 'package', key: 'package', ellipsis: true, render: (p: string) => <span className="font-mono text-xs">{p}</span>},
+        {title: '用户', dataIndex: 'userId', key: 'userId', width: 70, render: (u: string) => <span className="text-xs">{u || '当前'}</span>},
+        {
+            title: '操作', key: 'op', width: 150,
+            render: (_: any, r: main.PackageStateRecord) => (
+                <span>
+                    <Tag>{packageOpLabels[r.op] || r.op}</Tag>
+                    {r.forced && <Tag color="red">强制</Tag>}
+                </span>
+            ),
+        },
+        {
+            title: '', key: 'undo', width: 110,
+            render: (_: any, r: main.PackageStateRecord) => r.undoneAt ? (
+                <span className="text-xs text-gray-400">已撤销</span>
+            ) : (
+                <Button size="small" loading={undoing === r.id} disabled={!!undoing} onClick={() => undo(r)}>
+                    撤销（{packageOpLabels[inverseOps[r.op]]}）
+                </Button>
+            ),
+        },
+    ];
+
+    return (
+        <Modal
+            title="应用状态操作记录"
+            open={visible}
+            onCancel={onClose}
+            width={820}
+            footer={
+                <div className="flex items-center justify-between">
+                    <Popconfirm title="清空所有操作记录？清空后无法再撤销" onConfirm={clear}>
+                        <Button danger disabled={records.length === 0}>清空记录</Button>
+                    </Popconfirm>
+                    <Button onClick={onClose}>关闭</Button>
+                </div>
+            }
+        >
+            <div className="flex flex-col gap-3">
+                <div className="flex items-center gap-2">
+                    <Input
+                        value={reinstallName}
+                        onChange={e => setReinstallName(e.target.value)}
+                        onPressEnter={reinstall}
+                        placeholder="重新安装为当前用户卸载的系统应用，输入包名"
+                        className="font-mono"
+                        allowClear
+                    />
+                    <Button onClick={reinstall} loading={reinstalling} disabled={!reinstallName.trim()}>重新安装</Button>
+                </div>
+                <Table size="small" rowKey="id" columns={columns} dataSource={records} loading={loading}
+                       pagination={{pageSize: 10, hideOnSinglePage: true}}/>
+            </div>
+        </Modal>
+    );
+};
+
+export default PackageHistoryModal;
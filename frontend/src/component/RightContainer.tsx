@@ -6,6 +6,16 @@ import {Empty, Input, message, Select} from "antd";
 import {useDeviceStore} from "../store/deviceStore";
 import TerminalPanel from './TerminalPanel';
 import DeviceInfoCard from './DeviceInfoCard';
+import InstallModal from './InstallModal';
+import ApkInspectModal from './ApkInspectModal';
+import BatchInstallModal from './BatchInstallModal';
+import AppExportModal from './AppExportModal';
+import AppBackupModal from './AppBackupModal';
+import IntentLauncherModal from './IntentLauncherModal';
+import StartupMeasureModal from './StartupMeasureModal';
+import PackageDiffModal from './PackageDiffModal';
+import InspectorModal from './InspectorModal';
+import UserSelector from './UserSelector';
 
 interface CommandLog {
     id: number;
@@ -36,6 +46,15 @@ function RightContainer() {
     const [properties, setProperties] = useState<SystemProperty[]>([]);
     const [searchText, setSearchText] = useState('');
     const [autoOpenTerminal, setAutoOpenTerminal] = useState(true);
+    const [installOpen, setInstallOpen] = useState(false);
+    const [apkInspectOpen, setApkInspectOpen] = useState(false);
+    const [batchInstallOpen, setBatchInstallOpen] = useState(false);
+    const [appExportOpen, setAppExportOpen] = useState(false);
+    const [appBackupOpen, setAppBackupOpen] = useState(false);
+    const [intentOpen, setIntentOpen] = useState(false);
+    const [startupOpen, setStartupOpen] = useState(false);
+    const [packageDiffOpen, setPackageDiffOpen] = useState(false);
+    const [inspectorOpen, setInspectorOpen] = useState(false);
 
     const [selectedPackage, setSelectedPackage] = useState<string>('');
     const [packageList, setPackageList] = useState<string[]>([]);
@@ -43,7 +62,7 @@ function RightContainer() {
     // 新增：设备信息状态
     const [deviceInfoString, setDeviceInfoString] = useState<string | null>(null);
 
-    const {devices, selectedDevice} = useDeviceStore();
+    const {devices, selectedDevice, selectedUserId} = useDeviceStore();
 
     const filteredQuickActions = useMemo(() => {
         const keyword = searchText.trim().toLowerCase();
@@ -79,6 +98,7 @@ function RightContainer() {
                 action: "get-system-info", // 你需要在后端实现这个 action
                 targetPackageName: "",
                 deviceId: devices.length > 1 ? selectedDevice.id.toString() : "",
+                userId: "",
             });
 
             if (!result.error && result.res) {
@@ -102,6 +122,92 @@ function RightContainer() {
             return
         }
 
+        // 安装前先解析 APK 并与已安装版本比较
+        if (action.action === 'install-app') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setApkInspectOpen(true);
+            return;
+        }
+
+        // 拆分包安装需要先解析并确认要安装的 APK
+        if (action.action === 'install-bundle') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setInstallOpen(true);
+            return;
+        }
+
+        // 导出应用在弹窗中选择包名与附带内容
+        if (action.action === 'export-app-bundle') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setAppExportOpen(true);
+            return;
+        }
+
+        // 备份使用当前选择的应用，恢复时包名取自备份文件
+        if (action.action === 'backup-app-data') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setAppBackupOpen(true);
+            return;
+        }
+
+        // Intent 在弹窗中编辑，组件补全与预设使用当前选择的应用
+        if (action.action === 'intent-launcher') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setIntentOpen(true);
+            return;
+        }
+
+        // 启动耗时需要多次启动，在弹窗中设置次数与启动类型
+        if (action.action === 'measure-startup') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setStartupOpen(true);
+            return;
+        }
+
+        // 截图、控件树、任务栈与 Fragment 在同一个弹窗中查看
+        if (action.action === 'view-inspector') {
+            if (!selectedDevice) {
+                message.error("请先连接设备");
+                return;
+            }
+            setInspectorOpen(true);
+            return;
+        }
+
+        // 差异比较可以只比较已保存的快照，不要求连接设备
+        if (action.action === 'package-diff') {
+            setPackageDiffOpen(true);
+            return;
+        }
+
+        // 批量安装在弹窗中选择设备与安装包
+        if (action.action === 'install-batch') {
+            if (devices.length === 0) {
+                message.error("请先连接设备");
+                return;
+            }
+            setBatchInstallOpen(true);
+            return;
+        }
+
         if (autoOpenTerminal && !showTerminal) {
             setShowTerminal(true);
         }
@@ -132,6 +238,7 @@ function RightContainer() {
                 action: action.action,
                 targetPackageName: selectedPackage ? selectedPackage : "",
                 deviceId: devices.length > 1 ? selectedDevice ? selectedDevice.id.toString() : "" : "",
+                userId: selectedUserId,
             });
 
             // 添加命令日志到 Terminal
@@ -260,6 +367,7 @@ function RightContainer() {
                 action: "get-all-packages",
                 targetPackageName: "",
                 deviceId: devices.length > 1 ? selectedDevice ? selectedDevice.id.toString() : "" : "",
+                userId: selectedUserId,
             });
 
             const stored = localStorage.getItem('selectedPackage');
@@ -287,7 +395,7 @@ function RightContainer() {
             fetchDeviceInfo();
         };
         fetchData();
-    }, [selectedDevice])
+    }, [selectedDevice, selectedUserId])
 
     const handlePackageChange = (value: string) => {
         setSelectedPackage(value);
@@ -318,6 +426,76 @@ function RightContainer() {
                 properties={properties}
             />
 
+            {selectedDevice && (
+                <ApkInspectModal
+                    visible={apkInspectOpen}
+                    onClose={() => setApkInspectOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                />
+            )}
+
+            {selectedDevice && (
+                <InstallModal
+                    visible={installOpen}
+                    onClose={() => setInstallOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                />
+            )}
+
+            {selectedDevice && (
+                <AppExportModal
+                    visible={appExportOpen}
+                    onClose={() => setAppExportOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                    defaultPackages={selectedPackage ? [selectedPackage] : []}
+                />
+            )}
+
+            {selectedDevice && (
+                <AppBackupModal
+                    visible={appBackupOpen}
+                    onClose={() => setAppBackupOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                    packageName={selectedPackage}
+                />
+            )}
+
+            {selectedDevice && (
+                <IntentLauncherModal
+                    visible={intentOpen}
+                    onClose={() => setIntentOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                    packageName={selectedPackage}
+                />
+            )}
+
+            {selectedDevice && (
+                <StartupMeasureModal
+                    visible={startupOpen}
+                    onClose={() => setStartupOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                    packageName={selectedPackage}
+                />
+            )}
+
+            {selectedDevice && (
+                <InspectorModal
+                    visible={inspectorOpen}
+                    onClose={() => setInspectorOpen(false)}
+                    deviceId={selectedDevice.id.toString()}
+                />
+            )}
+
+            <BatchInstallModal
+                visible={batchInstallOpen}
+                onClose={() => setBatchInstallOpen(false)}
+            />
+
+            <PackageDiffModal
+                visible={packageDiffOpen}
+                onClose={() => setPackageDiffOpen(false)}
+            />
+
             {/* 主内容区域 */}
             <div className="flex-1 flex flex-col p-6 bg-gray-50 gap-6 overflow-y-auto overflow-x-hidden">
 
@@ -352,6 +530,7 @@ function RightContainer() {
 
                             {section.title === '应用' && (
                                 <div className="flex items-center gap-2 package-select-wrapper">
+                                    <UserSelector/>
                                     <span className="text-gray-600">
                                        连接手机后输入或者选择应用包名：
                                     </span>
@@ -0,0 +1,222 @@
+import React, {useEffect, useRef, useState} from 'react';
+import {Button, Checkbox, Empty, Input, InputNumber, Modal, Select, Table, Tag, Tooltip, message} from 'antd';
+import {AimOutlined, EyeOutlined, SearchOutlined, StopOutlined} from '@ant-design/icons';
+import {CancelRemoteSearch, SearchRemoteFiles} from "../../wailsjs/go/main/App";
+import {EventsOff, EventsOn} from "../../wailsjs/runtime/runtime";
+import {adb, main} from "../../wailsjs/go/models";
+
+interface SearchModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    defaultRoot: string;
+    bookmarks: string[];
+    onLocate: (dir: string) => void;
+    onPreview: (path: string, name: string) => void;
+}
+
+const typeOptions = [
+    {value: '', label: '文件和目录'},
+    {value: 'f', label: '仅文件'},
+    {value: 'd', label: '仅目录'},
+];
+
+// 修改时间范围，值为距今的秒数，负数表示早于该时间
+const timeOptions = [
+    {value: 0, label: '修改时间不限'},
+    {value: 3600, label: '1 小时内'},
+    {value: 86400, label: '24 小时内'},
+    {value: 7 * 86400, label: '7 天内'},
+    {value: 30 * 86400, label: '30 天内'},
+    {value: -30 * 86400, label: '30 天以前'},
+];
+
+const MB = 1024 * 1024;
+
+function formatBytes(raw: number): string {
+    if (raw < 1024) return `${raw} B`;
+    if (raw < MB) return `${(raw / 1024).toFixed(1)} KB`;
+    if (raw < 1024 * MB) return `${(raw / MB).toFixed(1)} MB`;
+    return `${(raw / MB / 1024).toFixed(2)} GB`;
+}
+
+function parentDir(p: string): string {
+    const i = p.lastIndexOf('/');
+    return i > 0 ? p.substring(0, i) : '/';
+}
+
+const SearchModal: React.FC<SearchModalProps> = ({visible, onClose, deviceId, defaultRoot, bookmarks, onLocate, onPreview}) => {
+    const [root, setRoot] = useState(defaultRoot);
+    const [inBookmarks, setInBookmarks] = useState(false);
+    const [name, setName] = useState('');
+    const [content, setContent] = useState('');
+    const [ignoreCase, setIgnoreCase] = useState(true);
+    const [type, setType] = useState('');
+    const [minSizeMB, setMinSizeMB] = useState<number | null>(null);
+    const [maxSizeMB, setMaxSizeMB] = useState<number | null>(null);
+    const [timeRange, setTimeRange] = useState(0);
+    const [matches, setMatches] = useState<adb.SearchMatch[]>([]);
+    const [searching, setSearching] = useState(false);
+    const [summary, setSummary] = useState<adb.SearchSummary | null>(null);
+    const taskIdRef = useRef('');
+
+    useEffect(() => {
+        if (visible) setRoot(defaultRoot);
+    }, [visible]);
+
+    // 搜索结果分批推送，任务 id 随第一个事件到达
+    useEffect(() => {
+        EventsOn('file-search-progress', (event: main.RemoteSearchEvent) => {
+            taskIdRef.current = event.taskId;
+            if (event.matches?.length) {
+                setMatches(prev => [...prev, ...event.matches]);
+            }
+        });
+        return () => {
+            EventsOff('file-search-progress');
+        };
+    }, []);
+
+    const search = async () => {
+        if (!root.trim() && !inBookmarks) return;
+        const now = Math.floor(Date.now() / 1000);
+        const options: adb.SearchOptions = {
+            roots: root.trim() ? [root.trim()] : [],
+            name: name.trim(),
+            ignoreCase,
+            type,
+            minSize: minSizeMB ? Math.round(minSizeMB * MB) : 0,
+            maxSize: maxSizeMB ? Math.round(maxSizeMB * MB) : 0,
+            modifiedAfter: timeRange > 0 ? now - timeRange : 0,
+            modifiedBefore: timeRange < 0 ? now + timeRange : 0,
+            content,
+            maxResults: 0,
+            inBookmarks,
+        };
+        setMatches([]);
+        setSummary(null);
+        setSearching(true);
+        try {
+            const result = await SearchRemoteFiles(deviceId, options);
+            setSummary(result);
+            if (result.errors?.length) {
+                message.warning(result.errors.join('；'));
+            }
+        } catch (e: any) {
+            message.error(`搜索失败: ${e?.message || e}`);
+        } finally {
+            setSearching(false);
+            taskIdRef.current = '';
+        }
+    };
+
+    const cancel = () => {
+        if (taskIdRef.current) CancelRemoteSearch(taskIdRef.current);
+    };
+
+    const close = () => {
+        cancel();
+        onClose();
+    };
+
+    const columns = [
+        {
+            title: '路径', dataIndex: 'path', key: 'path', ellipsis: true,
+            render: (p: string, match: adb.SearchMatch) => (
+                <span className="font-mono text-xs">
+                    {match.isDir && <i className="fa-solid fa-folder text-yellow-500 mr-1"/>}{p}
+                </span>
+            ),
+        },
+        {
+            title: '大小', dataIndex: 'size', key: 'size', width: 100,
+            render: (size: number, match: adb.SearchMatch) => match.isDir ? '-' : formatBytes(size),
+        },
+        {
+            title: '修改时间', dataIndex: 'modTime', key: 'modTime', width: 170,
+            render: (t: number) => <span className="text-xs text-gray-500">{new Date(t * 1000).toLocaleString()}</span>,
+        },
+        {
+            title: '', key: 'actions', width: 80,
+            render: (_: any, match: adb.SearchMatch) => (
+                <div className="flex gap-1">
+                    <Tooltip title="在目录树中打开">
+                        <Button type="text" size="small" icon={<AimOutlined/>}
+                                onClick={() => onLocate(match.isDir ? match.path : parentDir(match.path))}/>
+                    </Tooltip>
+                    {!match.isDir && (
+                        <Tooltip title="预览">
+                            <Button type="text" size="small" icon={<EyeOutlined/>}
+                                    onClick={() => onPreview(match.path, match.name)}/>
+                        </Tooltip>
+                    )}
+                </div>
+            ),
+        },
+    ];
+
+    return (
+        <Modal
+            title="搜索设备文件"
+            open={visible}
+            onCancel={close}
+            footer={
+                <div className="flex items-center justify-between">
+                    <span className="text-xs text-gray-500">
+                        {searching ? `已找到 ${matches.length} 个...` : summary && `共 ${summary.matches} 个结果${summary.truncated ? '（已达上限，请缩小范围）' : ''}`}
+                    </span>
+                    {searching ? (
+                        <Button icon={<StopOutlined/>} onClick={cancel}>停止</Button>
+                    ) : (
+                        <Button type="primary" icon={<SearchOutlined/>} onClick={search}
+                                disabled={!root.trim() && !inBookmarks}>
+                            搜索
+                        </Button>
+                    )}
+                </div>
+            }
+            width={960}
+            maskClosable={false}
+        >
+            <div className="flex flex-col gap-3">
+                <div className="flex items-center gap-2">
+                    <Input value={root} onChange={e => setRoot(e.target.value)} placeholder="搜索目录，如 /sdcard"
+                           className="flex-1" style={{fontFamily: 'monospace'}} onPressEnter={search}/>
+                    <Tooltip title={bookmarks.length > 0 ? bookmarks.join('\n') : '暂无收藏路径'}>
+                        <Checkbox checked={inBookmarks} disabled={bookmarks.length === 0}
+                                  onChange={e => setInBookmarks(e.target.checked)}>
+                            同时搜索收藏的目录 ({bookmarks.length})
+                        </Checkbox>
+                    </Tooltip>
+                </div>
+                <div className="flex items-center gap-2">
+                    <Input value={name} onChange={e => setName(e.target.value)} onPressEnter={search}
+                           placeholder="文件名，支持 * ? 通配符" className="flex-1"/>
+                    <Input value={content} onChange={e => setContent(e.target.value)} onPressEnter={search}
+                           placeholder="文件内容包含（可选，较慢）" className="flex-1"/>
+                    <Checkbox checked={ignoreCase} onChange={e => setIgnoreCase(e.target.checked)}>忽略大小写</Checkbox>
+                </div>
+                <div className="flex items-center gap-2">
+                    <Select value={type} options={typeOptions} onChange={setType} style={{width: 130}}
+                            disabled={!!content || !!minSizeMB || !!maxSizeMB}/>
+                    <InputNumber value={minSizeMB} onChange={setMinSizeMB} min={0} placeholder="最小" addonAfter="MB" style={{width: 140}}/>
+                    <span className="text-gray-400">-</span>
+                    <InputNumber value={maxSizeMB} onChange={setMaxSizeMB} min={0} placeholder="最大" addonAfter="MB" style={{width: 140}}/>
+                    <Select value={timeRange} options={timeOptions} onChange={setTimeRange} style={{width: 140}}/>
+                    {(content || minSizeMB || maxSizeMB) ? <Tag>只搜索文件</Tag> : null}
+                </div>
+                <Table
+                    size="small"
+                    rowKey="path"
+                    columns={columns}
+                    dataSource={matches}
+                    pagination={{pageSize: 100, size: 'small', showSizeChanger: false}}
+                    scroll={{y: '45vh'}}
+                    locale={{emptyText: <Empty description={searching ? '搜索中...' : '没有结果'} image={Empty.PRESENTED_IMAGE_SIMPLE}/>}}
+                />
+            </div>
+        </Modal>
+    );
+};
+
+export default SearchModal;
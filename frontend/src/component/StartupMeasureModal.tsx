@@ -0,0 +1,193 @@
+import React, {useEffect, useRef, useState} from 'react';
+import {Alert, Button, Checkbox, Empty, InputNumber, Modal, Progress, Radio, Table, Tag, message} from 'antd';
+import {CancelStartupMeasure, MeasureStartup, SaveFileAsCsv} from "../../wailsjs/go/main/App";
+import {adb, main} from "../../wailsjs/go/models";
+import {EventsOff, EventsOn} from "../../wailsjs/runtime/runtime";
+import {useDeviceStore} from "../store/deviceStore";
+
+interface StartupMeasureModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    packageName: string;
+}
+
+const modeOptions = [
+    {value: 'cold', label: '冷启动'},
+    {value: 'warm', label: '温启动'},
+    {value: 'hot', label: '热启动'},
+];
+
+const modeHints: Record<string, string> = {
+    cold: '每次启动前停止应用，进程从头创建',
+    warm: '启动前按返回键销毁 Activity，进程保留；Android 12 起可能实际为热启动，以 LaunchState 为准',
+    hot: '启动前回到桌面，Activity 保留在后台',
+};
+
+const ms = (v: number) => v >= 0 ? `${v} ms` : '-';
+
+// 每次启动一行，末尾附上统计
+function generateCSV(report: adb.StartupReport): string {
+    const rows = [['index', 'status', 'launch_state', 'total_time_ms', 'wait_time_ms', 'error']];
+    report.runs.forEach(r => rows.push([
+        `${r.index}`, r.status, r.launchState, `${r.totalTime}`, `${r.waitTime}`, r.error,
+    ]));
+    rows.push([]);
+    rows.push(['metric', 'count', 'min', 'median', 'p90', 'max', 'mean']);
+    ([['total_time_ms', report.totalTime], ['wait_time_ms', report.waitTime]] as [string, adb.StartupStats][]).forEach(([name, s]) => {
+        rows.push([name, `${s.count}`, `${s.min}`, `${s.median}`, `${s.p90}`, `${s.max}`, `${s.mean}`]);
+    });
+    const escape = (v: string) => /[",\n]/.test(v) ? `"${v.replace(/"/g, '""')}"` : v;
+    return [
+        `# package,${report.package}`,
+        `# activity,${report.activity}`,
+        `# mode,${report.mode}`,
+        `# drop_caches,${report.dropCaches}`,
+        ...rows.map(r => r.map(v => escape(v || '')).join(',')),
+    ].join('\n');
+}
+
+const StartupMeasureModal: React.FC<StartupMeasureModalProps> = ({visible, onClose, deviceId, packageName}) => {
+    const selectedUserId = useDeviceStore(state => state.selectedUserId);
+    const [mode, setMode] = useState('cold');
+    const [runs, setRuns] = useState(5);
+    const [intervalMs, setIntervalMs] = useState(1000);
+    const [dropCaches, setDropCaches] = useState(false);
+    const [running, setRunning] = useState(false);
+    const [progressRuns, setProgressRuns] = useState<adb.StartupRun[]>([]);
+    const [report, setReport] = useState<adb.StartupReport | null>(null);
+    const taskIdRef = useRef<string>('');
+
+    useEffect(() => {
+        if (visible) {
+            setProgressRuns([]);
+            setReport(null);
+        }
+    }, [visible]);
+
+    useEffect(() => {
+        EventsOn('startup-measure-progress', (event: main.StartupMeasureEvent) => {
+            if (!taskIdRef.current) taskIdRef.current = event.taskId;
+            if (event.taskId !== taskIdRef.current) return;
+            const run = event.run;
+            if (run) setProgressRuns(prev => [...prev, run]);
+        });
+        return () => EventsOff('startup-measure-progress');
+    }, []);
+
+    const start = async () => {
+        taskIdRef.current = '';
+        setRunning(true);
+        setProgressRuns([]);
+        setReport(null);
+        try {
+            const res = await MeasureStartup(deviceId, selectedUserId, packageName, {mode, runs, dropCaches, intervalMs});
+            setReport(res);
+        } catch (e: any) {
+            message.error(`测量失败: ${e?.message || e}`);
+        } finally {
+            setRunning(false);
+            taskIdRef.current = '';
+        }
+    };
+
+    const cancel = () => {
+        if (taskIdRef.current) CancelStartupMeasure(taskIdRef.current);
+    };
+
+    const exportCSV = async () => {
+        if (!report) return;
+        const result = await SaveFileAsCsv(generateCSV(report), `startup_${report.package}_${report.mode}`);
+        if (result.error) {
+            if (result.error !== '用户取消保存') message.error(`导出失败: ${result.error}`);
+            return;
+        }
+        message.success('已导出');
+    };
+
+    const tableRuns = report ? report.runs : progressRuns;
+    const columns = [
+        {title: '#', dataIndex: 'index', key: 'index', width: 50},
+        {
+            title: 'LaunchState', dataIndex: 'launchState', key: 'launchState', width: 110,
+            render: (s: string) => s ? <Tag>{s}</Tag> : '-',
+        },
+        {title: 'TotalTime', dataIndex: 'totalTime', key: 'totalTime', width: 100, render: ms},
+        {title: 'WaitTime', dataIndex: 'waitTime', key: 'waitTime', width: 100, render: ms},
+        {
+            title: '结果', key: 'error', ellipsis: true,
+            render: (_: any, r: adb.StartupRun) => r.error
+                ? <span className="text-xs text-red-500">{r.error}</span>
+                : <span className="text-xs text-green-600">{r.status || 'ok'}</span>,
+        },
+    ];
+
+    const statsRow = (label: string, s: adb.StartupStats) => (
+        <div className="grid grid-cols-6 gap-2 text-sm">
+            <span className="text-gray-500">{label}</span>
+            <span>最小 {s.count ? ms(s.min) : '-'}</span>
+            <span className="font-semibold">中位数 {s.count ? ms(s.median) : '-'}</span>
+            <span>P90 {s.count ? ms(s.p90) : '-'}</span>
+            <span>最大 {s.count ? ms(s.max) : '-'}</span>
+            <span>平均 {s.count ? ms(s.mean) : '-'}</span>
+        </div>
+    );
+
+    return (
+        <Modal
+            title="启动耗时测量"
+            open={visible}
+            onCancel={running ? undefined : onClose}
+            closable={!running}
+            maskClosable={false}
+            width={780}
+            footer={
+                <div className="flex items-center justify-between">
+                    <Button onClick={exportCSV} disabled={!report || report.runs.length === 0}>导出 CSV</Button>
+                    <div className="flex items-center gap-2">
+                        {running
+                            ? <Button danger onClick={cancel}>停止</Button>
+                            : <Button onClick={onClose}>关闭</Button>}
+                        <Button type="primary" onClick={start} loading={running} disabled={!packageName}>开始</Button>
+                    </div>
+                </div>
+            }
+        >
+            {!packageName ? (
+                <Empty description="请先在上方选择应用" image={Empty.PRESENTED_IMAGE_SIMPLE}/>
+            ) : (
+                <div className="flex flex-col gap-3">
+                    <div className="text-sm">应用 <span className="font-mono">{packageName}</span></div>
+                    <div className="flex items-center gap-4 flex-wrap">
+                        <Radio.Group value={mode} onChange={e => setMode(e.target.value)} optionType="button"
+                                     options={modeOptions} disabled={running}/>
+                        <span className="text-sm">次数 <InputNumber min={1} max={50} value={runs} disabled={running}
+                                                                  onChange={v => setRuns(v || 1)}/></span>
+                        <span className="text-sm">间隔 <InputNumber min={0} max={60000} step={500} value={intervalMs} disabled={running}
+                                                                  onChange={v => setIntervalMs(v || 0)}/> ms</span>
+                        {mode === 'cold' && (
+                            <Checkbox checked={dropCaches} disabled={running} onChange={e => setDropCaches(e.target.checked)}>
+                                清空页缓存（需要 root）
+                            </Checkbox>
+                        )}
+                    </div>
+                    <div className="text-xs text-gray-400">{modeHints[mode]}</div>
+
+                    {running && <Progress percent={Math.round(progressRuns.length / runs * 100)} size="small"/>}
+                    {report?.warnings.map(w => <Alert key={w} type="warning" showIcon message={w}/>)}
+                    {report && (
+                        <div className="flex flex-col gap-1 p-3 bg-gray-50 rounded">
+                            <div className="text-xs text-gray-500 font-mono">{report.activity}</div>
+                            {statsRow('TotalTime', report.totalTime)}
+                            {statsRow('WaitTime', report.waitTime)}
+                        </div>
+                    )}
+                    <Table size="small" rowKey="index" columns={columns} dataSource={tableRuns}
+                           pagination={{pageSize: 10, hideOnSinglePage: true}}/>
+                </div>
+            )}
+        </Modal>
+    );
+};
+
+export default StartupMeasureModal;
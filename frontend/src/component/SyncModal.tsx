@@ -0,0 +1,214 @@
+import React, {useEffect, useMemo, useState} from 'react';
+import {Button, Checkbox, Empty, Input, Modal, Progress, Select, Table, Tag, message} from 'antd';
+import {FolderOpenOutlined, SwapOutlined} from '@ant-design/icons';
+import {
+    ApplySync,
+    CancelFileTransfer,
+    CompareSyncDirectories,
+    SelectSyncLocalDirectory,
+} from "../../wailsjs/go/main/App";
+import {adb} from "../../wailsjs/go/models";
+
+interface SyncModalProps {
+    visible: boolean;
+    onClose: () => void;
+    deviceId: string;
+    defaultRemoteDir: string;
+    progress: adb.TransferProgress | null;
+    onFinished: (remoteDir: string) => void;
+}
+
+const directionOptions = [
+    {value: 'push', label: '本地 → 设备'},
+    {value: 'pull', label: '设备 → 本地'},
+    {value: 'both', label: '双向（较新的覆盖）'},
+];
+
+const compareOptions = [
+    {value: 'mtime', label: '大小 + 修改时间'},
+    {value: 'hash', label: 'MD5（较慢）'},
+];
+
+const actionTags: Record<string, {color: string; label: string}> = {
+    'push': {color: 'blue', label: '上传'},
+    'pull': {color: 'green', label: '下载'},
+    'delete-remote': {color: 'red', label: '删除设备文件'},
+    'delete-local': {color: 'red', label: '删除本地文件'},
+    'keep': {color: 'default', label: '保留'},
+    'conflict': {color: 'orange', label: '冲突'},
+    'same': {color: 'default', label: '相同'},
+};
+
+function formatBytes(raw: number): string {
+    if (raw < 1024) return `${raw} B`;
+    if (raw < 1024 * 1024) return `${(raw / 1024).toFixed(1)} KB`;
+    if (raw < 1024 * 1024 * 1024) return `${(raw / 1024 / 1024).toFixed(1)} MB`;
+    return `${(raw / 1024 / 1024 / 1024).toFixed(2)} GB`;
+}
+
+function formatTime(seconds: number): string {
+    return seconds > 0 ? new Date(seconds * 1000).toLocaleString() : '-';
+}
+
+const SyncModal: React.FC<SyncModalProps> = ({visible, onClose, deviceId, defaultRemoteDir, progress, onFinished}) => {
+    const [localDir, setLocalDir] = useState('');
+    const [remoteDir, setRemoteDir] = useState(defaultRemoteDir);
+    const [options, setOptions] = useState<adb.SyncOptions>({direction: 'push', compare: 'mtime', deleteExtras: false});
+    const [diff, setDiff] = useState<adb.SyncDiff | null>(null);
+    const [comparing, setComparing] = useState(false);
+    const [applying, setApplying] = useState(false);
+    const [showSame, setShowSame] = useState(false);
+
+    useEffect(() => {
+        if (visible) {
+            setRemoteDir(defaultRemoteDir);
+            setDiff(null);
+        }
+    }, [visible]);
+
+    const chooseLocalDir = async () => {
+        try {
+            setLocalDir(await SelectSyncLocalDirectory());
+            setDiff(null);
+        } catch (e: any) {
+            const msg = e?.message || e;
+            if (msg !== '已取消') message.error(msg);
+        }
+    };
+
+    const compare = async () => {
+        if (!localDir || !remoteDir) return;
+        setComparing(true);
+        try {
+            setDiff(await CompareSyncDirectories(deviceId, localDir, remoteDir, options));
+        } catch (e: any) {
+            message.error(`比较失败: ${e?.message || e}`);
+        } finally {
+            setComparing(false);
+        }
+    };
+
+    const apply = async () => {
+        if (!diff) return;
+        setApplying(true);
+        try {
+            const result = await ApplySync(deviceId, diff);
+            if (result.errors.length > 0) {
+                Modal.error({
+                    title: '同步完成，部分文件失败',
+                    content: <pre className="text-xs whitespace-pre-wrap max-h-60 overflow-auto">{result.errors.join('\n')}</pre>,
+                });
+            } else {
+                message.success(`同步完成：上传 ${result.upload.transferred || 0}，下载 ${result.download.transferred || 0}，删除 ${result.deleted}`);
+            }
+            onFinished(diff.remoteDir);
+            setDiff(null);
+        } catch (e: any) {
+            message.error(`同步失败: ${e?.message || e}`);
+        } finally {
+            setApplying(false);
+        }
+    };
+
+    const items = useMemo(() => (diff?.items || []).filter(item => showSame || item.action !== 'same'), [diff, showSame]);
+    const pending = diff ? diff.push + diff.pull + diff.deleteRemote + diff.deleteLocal : 0;
+
+    const columns = [
+        {title: '路径', dataIndex: 'path', key: 'path', ellipsis: true},
+        {
+            title: '动作', dataIndex: 'action', key: 'action', width: 120,
+            render: (action: string) => <Tag color={actionTags[action]?.color}>{actionTags[action]?.label || action}</Tag>,
+        },
+        {
+            title: '本地', key: 'local', width: 190,
+            render: (_: any, item: adb.SyncItem) => item.localExists ? (
+                <div className="text-xs text-gray-500">{formatBytes(item.localSize)}<br/>{formatTime(item.localModTime)}</div>
+            ) : <span className="text-gray-300">-</span>,
+        },
+        {
+            title: '设备', key: 'remote', width: 190,
+            render: (_: any, item: adb.SyncItem) => item.remoteExists ? (
+                <div className="text-xs text-gray-500">{formatBytes(item.remoteSize)}<br/>{formatTime(item.remoteModTime)}</div>
+            ) : <span className="text-gray-300">-</span>,
+        },
+    ];
+
+    return (
+        <Modal
+            title="目录同步"
+            open={visible}
+            onCancel={applying ? undefined : onClose}
+            closable={!applying}
+            maskClosable={false}
+            width={900}
+            footer={
+                <div className="flex items-center justify-between">
+                    <span className="text-xs text-gray-500">
+                        {diff && `上传 ${diff.push} (${formatBytes(diff.pushBytes)}) · 下载 ${diff.pull} (${formatBytes(diff.pullBytes)}) · 删除 ${diff.deleteRemote + diff.deleteLocal} · 冲突 ${diff.conflict} · 相同 ${diff.same}`}
+                    </span>
+                    <div className="flex gap-2">
+                        <Button onClick={compare} loading={comparing} disabled={!localDir || !remoteDir || applying}>比较</Button>
+                        <Button type="primary" danger={!!diff && (diff.deleteRemote + diff.deleteLocal) > 0}
+                                onClick={apply} loading={applying} disabled={!diff || pending === 0}>
+                            执行同步
+                        </Button>
+                    </div>
+                </div>
+            }
+        >
+            <div className="flex flex-col gap-3">
+                <div className="flex items-center gap-2">
+                    <Input value={localDir} placeholder="本地目录" readOnly className="flex-1"
+                           addonAfter={<FolderOpenOutlined className="cursor-pointer" onClick={chooseLocalDir}/>}/>
+                    <SwapOutlined className="text-gray-400"/>
+                    <Input value={remoteDir} placeholder="设备目录，如 /sdcard/TestMedia" className="flex-1"
+                           style={{fontFamily: 'monospace'}}
+                           onChange={e => {
+                               setRemoteDir(e.target.value);
+                               setDiff(null);
+                           }}/>
+                </div>
+                <div className="flex items-center gap-3">
+                    <Select value={options.direction} options={directionOptions} style={{width: 180}}
+                            onChange={direction => {
+                                setOptions({...options, direction});
+                                setDiff(null);
+                            }}/>
+                    <Select value={options.compare} options={compareOptions} style={{width: 160}}
+                            onChange={compare => {
+                                setOptions({...options, compare});
+                                setDiff(null);
+                            }}/>
+                    <Checkbox checked={options.deleteExtras} disabled={options.direction === 'both'}
+                              onChange={e => {
+                                  setOptions({...options, deleteExtras: e.target.checked});
+                                  setDiff(null);
+                              }}>
+                        删除目标端多余的文件
+                    </Checkbox>
+                    <Checkbox checked={showSame} onChange={e => setShowSame(e.target.checked)}>显示相同文件</Checkbox>
+                </div>
+                {applying && progress && (
+                    <div className="flex items-center gap-2">
+                        <Progress percent={progress.total > 0 ? Math.floor(progress.bytes / progress.total * 100) : 0}
+                                  size="small" className="flex-1"/>
+                        <span className="text-xs text-gray-500">{progress.fileIndex + 1}/{progress.fileCount}</span>
+                        <Button size="small" onClick={() => CancelFileTransfer(progress.taskId)}>取消</Button>
+                    </div>
+                )}
+                <Table
+                    size="small"
+                    rowKey="path"
+                    loading={comparing}
+                    columns={columns}
+                    dataSource={items}
+                    pagination={{pageSize: 100, size: 'small'}}
+                    scroll={{y: '45vh'}}
+                    locale={{emptyText: <Empty description={diff ? '没有差异' : '选择目录后点击比较'} image={Empty.PRESENTED_IMAGE_SIMPLE}/>}}
+                />
+            </div>
+        </Modal>
+    );
+};
+
+export default SyncModal;
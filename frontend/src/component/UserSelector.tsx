@@ -0,0 +1,52 @@
+import React, {useEffect, useState} from 'react';
+import {Select, Tag} from 'antd';
+import {ListDeviceUsers} from "../../wailsjs/go/main/App";
+import {adb} from "../../wailsjs/go/models";
+import {useDeviceStore} from "../store/deviceStore";
+
+const userTypeTags: Record<string, {color: string; label: string}> = {
+    work: {color: 'blue', label: '工作资料'},
+    guest: {color: 'default', label: '访客'},
+    profile: {color: 'purple', label: '资料'},
+};
+
+// UserSelector 选择 pm/am 命令的目标用户，设备只有一个用户时不显示
+const UserSelector: React.FC<{className?: string}> = ({className}) => {
+    const {selectedDevice, selectedUserId, setSelectedUserId} = useDeviceStore();
+    const [users, setUsers] = useState<adb.DeviceUser[]>([]);
+
+    useEffect(() => {
+        setUsers([]);
+        if (!selectedDevice) return;
+        ListDeviceUsers(selectedDevice.id.toString())
+            .then(setUsers)
+            .catch(() => setUsers([]));
+    }, [selectedDevice?.id]);
+
+    if (users.length <= 1) return null;
+
+    return (
+        <Select
+            value={selectedUserId}
+            onChange={setSelectedUserId}
+            className={className ?? 'min-w-[200px]'}
+            popupMatchSelectWidth={false}
+            options={[
+                {value: '', label: '当前用户'},
+                ...users.map(u => ({
+                    value: u.id.toString(),
+                    label: (
+                        <span>
+                            {u.name} ({u.id})
+                            {userTypeTags[u.type] && <Tag className="!ml-1.5" color={userTypeTags[u.type].color}>{userTypeTags[u.type].label}</Tag>}
+                            {u.current && <Tag className="!ml-1" color="green">前台</Tag>}
+                            {!u.running && <Tag className="!ml-1">未运行</Tag>}
+                        </span>
+                    ),
+                })),
+            ]}
+        />
+    );
+};
+
+export default UserSelector;
@@ -1,10 +1,14 @@
 export type ActionType =
     | 'install-app'
+    | 'install-bundle'
+    | 'install-batch'
     | 'screenshot'
     | 'view-current-activity'
     | 'view-all-activities'
     | 'view-current-fragment'
+    | 'view-inspector'
     | 'clear-data'
+    | 'clear-cache'
     | 'reset-permissions'
     | 'force-stop'
     | 'restart-app'
@@ -23,6 +27,11 @@ export type ActionType =
     | 'grant-permissions'
     | 'install-app-path'
     | 'export-app'
+    | 'export-app-bundle'
+    | 'backup-app-data'
+    | 'intent-launcher'
+    | 'measure-startup'
+    | 'package-diff'
     | 'clear-restart-app'
     | 'get-system-info'
     | 'jump-locale'
@@ -68,11 +77,14 @@ export const quickActions: QuickActionSection[] = [
         title: "常用",
         items: [
             { icon: 'fa-box-open', label: '安装应用', color: 'text-blue-500', bgColor: 'bg-blue-50', action: 'install-app' },
+            { icon: 'fa-boxes-stacked', label: '安装拆分包 / APKS / XAPK', color: 'text-sky-500', bgColor: 'bg-sky-50', action: 'install-bundle' },
+            { icon: 'fa-layer-group', label: '批量安装', color: 'text-indigo-500', bgColor: 'bg-indigo-50', action: 'install-batch' },
             { icon: 'fa-camera', label: '截图保存到电脑', color: 'text-green-500', bgColor: 'bg-green-50', action: 'screenshot' },
             { icon: 'fa-tag', label: '查看当前应用包名', color: 'text-amber-500', bgColor: 'bg-amber-50', action: 'view-package' },
             { icon: 'fa-eye', label: '查看当前 Activity', color: 'text-purple-500', bgColor: 'bg-purple-50', action: 'view-current-activity' },
             { icon: 'fa-list', label: '查看所有 Activity', color: 'text-indigo-500', bgColor: 'bg-indigo-50', action: 'view-all-activities' },
             { icon: 'fa-puzzle-piece', label: '查看当前 Fragment', color: 'text-pink-500', bgColor: 'bg-pink-50', action: 'view-current-fragment' },
+            { icon: 'fa-object-group', label: '界面检查器（控件树 / 任务栈 / Fragment）', color: 'text-violet-500', bgColor: 'bg-violet-50', action: 'view-inspector' },
         ]
     },
     {
@@ -83,6 +95,11 @@ export const quickActions: QuickActionSection[] = [
             { icon: 'fa-file-lines', label: '获取应用信息', color: 'text-sky-600', bgColor: 'bg-sky-50', action: 'get-package-info' },
             { icon: 'fa-memory', label: '查看内存 meminfo', color: 'text-purple-600', bgColor: 'bg-purple-50', action: 'dump-memory-info' },
             { icon: 'fa-download', label: '保存应用 APK 到电脑', color: 'text-indigo-700', bgColor: 'bg-indigo-50', action: 'export-app' },
+            { icon: 'fa-file-zipper', label: '导出应用（含拆分包 / OBB / 数据）', color: 'text-indigo-600', bgColor: 'bg-indigo-50', action: 'export-app-bundle' },
+            { icon: 'fa-box-archive', label: '备份 / 恢复应用数据', color: 'text-teal-600', bgColor: 'bg-teal-50', action: 'backup-app-data' },
+            { icon: 'fa-link', label: 'Intent / 深链接', color: 'text-sky-600', bgColor: 'bg-sky-50', action: 'intent-launcher' },
+            { icon: 'fa-stopwatch', label: '测量启动耗时', color: 'text-rose-500', bgColor: 'bg-rose-50', action: 'measure-startup' },
+            { icon: 'fa-code-compare', label: '应用差异比较（设备 / 快照）', color: 'text-fuchsia-600', bgColor: 'bg-fuchsia-50', action: 'package-diff' },
             { icon: 'fa-key', label: '授予所有权限', color: 'text-emerald-500', bgColor: 'bg-emerald-50', action: 'grant-permissions' },
             { icon: 'fa-shield-alt', label: '重置权限', color: 'text-orange-500', bgColor: 'bg-orange-50', action: 'reset-permissions' },
             { icon: 'fa-circle-info', label: '跳转应用详情页', color: 'text-blue-500', bgColor: 'bg-blue-50', action: 'jump-application-detail' },
@@ -92,6 +109,7 @@ export const quickActions: QuickActionSection[] = [
             { icon: 'fa-layer-group', label: '导出线程信息', color: 'text-lime-600', bgColor: 'bg-lime-50', action: 'dump-thread' },
             { icon: 'fa-skull-crossbones', label: '杀死应用', color: 'text-gray-700', bgColor: 'bg-gray-100', action: 'force-stop' },
             { icon: 'fa-trash-alt', label: '清除数据', color: 'text-red-500', bgColor: 'bg-red-50', action: 'clear-data' },
+            { icon: 'fa-eraser', label: '清除缓存', color: 'text-orange-600', bgColor: 'bg-orange-50', action: 'clear-cache' },
             { icon: 'fa-broom', label: '清除数据并重启应用', color: 'text-pink-600', bgColor: 'bg-pink-50', action: 'clear-restart-app' },
             { icon: 'fa-rotate-right', label: '重启应用', color: 'text-teal-500', bgColor: 'bg-teal-50', action: 'restart-app' },
             { icon: 'fa-circle-minus', label: '卸载应用', color: 'text-rose-600', bgColor: 'bg-rose-50', action: 'uninstall-app' },
@@ -7,6 +7,9 @@ interface DeviceStore {
     selectedDevice: DeviceInfo | null;  // 单个设备或 null
     setSelectedDevices: (device: DeviceInfo | null) => void;
     toggleDevice: (device: DeviceInfo) => void;
+    // pm/am 命令的目标用户，空字符串表示当前用户；切换设备时重置
+    selectedUserId: string;
+    setSelectedUserId: (userId: string) => void;
 }
 
 export interface DeviceInfo {
@@ -18,13 +21,19 @@ export const useDeviceStore = create<DeviceStore>((set) => ({
     devices: [],  // 初始化为 null
     setDevices: (devices) => set({ devices: devices }),
     selectedDevice: null,  // 初始化为 null
-    setSelectedDevices: (device) => set({ selectedDevice: device }),
+    setSelectedDevices: (device) => set((state) => (
+        state.selectedDevice?.id === device?.id
+            ? { selectedDevice: device }
+            : { selectedDevice: device, selectedUserId: '' }
+    )),
     toggleDevice: (device) => set((state) => {
         // 如果当前选中的就是这个设备，则取消选中
         if (state.selectedDevice?.id === device.id) {
-            return { selectedDevice: null };
+            return { selectedDevice: null, selectedUserId: '' };
         }
         // 否则选中这个设备
-        return { selectedDevice: device };
-    })
-}));
\ No newline at end of file
+        return { selectedDevice: device, selectedUserId: '' };
+    }),
+    selectedUserId: '',
+    setSelectedUserId: (userId) => set({ selectedUserId: userId }),
+}));
@@ -12,6 +12,7 @@ import android.os.Build
 import android.util.Base64
 import android.util.DisplayMetrics
 import android.util.Log
+import com.google.protobuf.ByteString
 import org.json.JSONArray
 import org.json.JSONObject
 import java.io.File
@@ -40,7 +41,7 @@ class Connection(private val client: LocalSocket) : Thread() {
                     break
                 }
                 val params = request.params.ifEmpty { "{}" }
-                handleRequest(request.id, request.method, params)
+                handleRequest(request.id, request.method, params, request.stream)
             } catch (e: Exception) {
                 Log.e(TAG, "Failed to handle request", e)
                 break
@@ -51,14 +52,30 @@ class Connection(private val client: LocalSocket) : Thread() {
         Log.i(TAG, "Client disconnected")
     }
 
-    private fun handleRequest(id: String, method: String, params: String) {
-        Log.i(TAG, "Request method: $method, params: $params")
+    private fun handleRequest(id: String, method: String, params: String, stream: Boolean) {
+        Log.i(TAG, "Request method: $method, params: $params, stream: $stream")
+
+        if (method == "getPackageInfos" && stream) {
+            streamPackageInfos(id, JSONObject(params))
+            return
+        }
 
         val result = JSONObject()
 
         when (method) {
             "getVersion" -> {
                 result.put("version", getVersion())
+                result.put("checksum", Server.checksum)
+            }
+
+            "ping" -> {
+                result.put("pid", android.os.Process.myPid())
+                result.put("uptime", System.currentTimeMillis() - Server.startTime)
+                result.put("checksum", Server.checksum)
+            }
+
+            "shutdown" -> {
+                result.put("shutdown", true)
             }
 
             "getPackageInfos" -> {
@@ -71,8 +88,53 @@ class Connection(private val client: LocalSocket) : Thread() {
         }
 
         Log.i(TAG, "Response: $result")
-        Wire.Response.newBuilder().setId(id).setResult(result.toString()).build()
-            .writeDelimitedTo(client.outputStream)
+        writeResponse(id, result)
+
+        if (method == "shutdown") {
+            client.outputStream.flush()
+            Server.shutdown()
+        }
+    }
+
+    private fun writeResponse(
+        id: String,
+        result: JSONObject,
+        partial: Boolean = false,
+        seq: Int = 0,
+        payload: ByteArray? = null
+    ) {
+        val builder = Wire.Response.newBuilder()
+            .setId(id)
+            .setResult(result.toString())
+            .setPartial(partial)
+            .setSeq(seq)
+        if (payload != null) {
+            builder.payload = ByteString.copyFrom(payload)
+        }
+        builder.build().writeDelimitedTo(client.outputStream)
+    }
+
+    // 每个应用单独一块，图标以 PNG 二进制放在 payload 中，避免拼接成一个巨大的 JSON 字符串
+    private fun streamPackageInfos(id: String, params: JSONObject) {
+        val packageNames = Util.jsonArrayToStringArray(params.getJSONArray("packageNames"))
+        val userId = params.optInt("userId", 0)
+        var seq = 0
+
+        packageNames.forEach {
+            try {
+                val packageInfo = getPackageInfo(it, userId)
+                writeResponse(id, packageInfo.info, true, seq, packageInfo.icon)
+                seq++
+            } catch (e: Exception) {
+                Log.e(TAG, "Fail to get package info", e)
+            }
+        }
+
+        val result = JSONObject()
+        result.put("count", seq)
+        result.put("userId", userId)
+        writeResponse(id, result, false, seq)
+        Log.i(TAG, "Stream response: $id, chunks: ${seq + 1}")
     }
 
     private fun getVersion(): String {
@@ -81,11 +143,20 @@ class Connection(private val client: LocalSocket) : Thread() {
 
     private fun getPackageInfos(params: JSONObject): JSONArray {
         val packageNames = Util.jsonArrayToStringArray(params.getJSONArray("packageNames"))
+        val userId = params.optInt("userId", 0)
         val result = JSONArray()
 
         packageNames.forEach {
             try {
-                result.put(getPackageInfo(it))
+                val packageInfo = getPackageInfo(it, userId)
+                var icon = ""
+                if (packageInfo.icon != null) {
+                    icon = "data:image/png;base64,${
+                        Base64.encodeToString(packageInfo.icon, Base64.NO_WRAP)
+                    }"
+                }
+                packageInfo.info.put("icon", icon)
+                result.put(packageInfo.info)
             } catch (e: Exception) {
                 Log.e(TAG, "Fail to get package info", e)
             }
@@ -94,8 +165,11 @@ class Connection(private val client: LocalSocket) : Thread() {
         return result
     }
 
+    private class PackageInfoResult(val info: JSONObject, val icon: ByteArray?)
+
+    // userId 为目标用户，只安装在工作资料等其他用户下的应用在主用户中查不到
     @TargetApi(Build.VERSION_CODES.P)
-    private fun getPackageInfo(packageName: String): JSONObject {
+    private fun getPackageInfo(packageName: String, userId: Int): PackageInfoResult {
         var flags = PackageManager.GET_ACTIVITIES
         if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.P) {
             flags = flags or PackageManager.GET_SIGNING_CERTIFICATES
@@ -103,7 +177,7 @@ class Connection(private val client: LocalSocket) : Thread() {
             flags = flags or PackageManager.GET_SIGNATURES
         }
         val packageInfo =
-            ServiceManager.packageManager.getPackageInfo(packageName, flags)
+            ServiceManager.packageManager.getPackageInfo(packageName, flags, userId)
 
         val info = JSONObject()
         info.put("packageName", packageInfo.packageName)
@@ -135,16 +209,15 @@ class Connection(private val client: LocalSocket) : Thread() {
         info.put("system", system)
 
         var label = packageName
-        var icon = ""
+        var icon: ByteArray? = null
 
         val cacheKey = "$packageName.$apkSize"
+        var resources: Resources? = null
 
         if (packageCache.has(cacheKey)) {
-            val cacheInfo = packageCache.getJSONObject(cacheKey)
-            label = cacheInfo.getString("label")
-            icon = cacheInfo.getString("icon")
+            label = packageCache.getJSONObject(cacheKey).getString("label")
         } else {
-            val resources = getResources(apkPath)
+            resources = getResources(apkPath)
             val labelRes = applicationInfo.labelRes
             if (labelRes != 0) {
                 try {
@@ -153,58 +226,49 @@ class Connection(private val client: LocalSocket) : Thread() {
                     Log.e(TAG, "Failed to get label for $packageName")
                 }
             }
-
-            if (applicationInfo.icon != 0) {
-                try {
-                    val iconCachePath = "$ICON_CACHE_DIR/$cacheKey.png"
-                    val file = File(iconCachePath)
-                    if (file.exists()) {
-                        icon = "data:image/png;base64,${
-                            Base64.encodeToString(file.readBytes(), Base64.NO_WRAP)
-                        }"
-                    } else {
-                        val resIcon = resources.getDrawable(applicationInfo.icon)
-                        val bitmapIcon = Util.drawableToBitmap(resIcon)
-                        val pngIcon = Util.bitMapToPng(bitmapIcon, 20)
-                        icon = "data:image/png;base64,${
-                            Base64.encodeToString(
-                                pngIcon,
-                                Base64.NO_WRAP
-                            )
-                        }"
-                        file.writeBytes(pngIcon)
-                    }
-                } catch (e: Exception) {
-                    Log.e(TAG, "Failed to get icon for $packageName")
-                }
-            }
             val cacheInfo = JSONObject()
             cacheInfo.put("label", label)
-            cacheInfo.put("icon", icon)
             packageCache.put(cacheKey, cacheInfo)
         }
         info.put("label", label)
-        info.put("icon", icon)
+
+        // 图标只缓存在文件中，内存里不保留 base64 字符串
+        if (applicationInfo.icon != 0) {
+            try {
+                val file = File("$ICON_CACHE_DIR/$cacheKey.png")
+                if (file.exists()) {
+                    icon = file.readBytes()
+                } else {
+                    val resIcon = (resources ?: getResources(apkPath)).getDrawable(applicationInfo.icon)
+                    val bitmapIcon = Util.drawableToBitmap(resIcon)
+                    icon = Util.bitMapToPng(bitmapIcon, 20)
+                    file.writeBytes(icon)
+                }
+            } catch (e: Exception) {
+                Log.e(TAG, "Failed to get icon for $packageName")
+            }
+        }
 
         if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.N) {
             info.put("minSdkVersion", applicationInfo.minSdkVersion)
             info.put("targetSdkVersion", applicationInfo.targetSdkVersion)
         }
 
-        //if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.O && Build.VERSION.SDK_INT < Build.VERSION_CODES.UPSIDE_DOWN_CAKE) {
-        //    try {
-        //        val stats = ServiceManager.storageStatsManager.queryStatsForPackage(
-        //            packageName
-        //        )
-        //        info.put("appSize", stats.appBytes)
-        //        info.put("dataSize", stats.dataBytes)
-        //        info.put("cacheSize", stats.cacheBytes)
-        //    } catch (e: Exception) {
-        //        Log.e(TAG, "Failed to get storage stats for $packageName", e)
-        //    }
-        //}
-
-        return info
+        if (Build.VERSION.SDK_INT >= Build.VERSION_CODES.O) {
+            try {
+                val stats = ServiceManager.storageStatsManager.queryStatsForPackage(
+                    packageName, userId
+                )
+                info.put("appSize", stats.appBytes)
+                info.put("dataSize", stats.dataBytes)
+                info.put("cacheSize", stats.cacheBytes)
+            } catch (e: Exception) {
+                // shell 用户在部分 ROM 上没有 PACKAGE_USAGE_STATS，由客户端回退到 dumpsys diskstats
+                Log.e(TAG, "Failed to get storage stats for $packageName", e)
+            }
+        }
+
+        return PackageInfoResult(info, icon)
     }
 
     private fun getResources(apkPath: String): Resources {
@@ -21,9 +21,9 @@ class PackageManager(private val manager: IInterface) {
         )
     }
 
-    fun getPackageInfo(packageName: String, flags: Int): PackageInfo {
-        Log.i(TAG, "Get package info: $packageName")
+    fun getPackageInfo(packageName: String, flags: Int, userId: Int = 0): PackageInfo {
+        Log.i(TAG, "Get package info: $packageName, user: $userId")
 
-        return getPackageInfoMethod.invoke(manager, packageName, flags, 0) as PackageInfo
+        return getPackageInfoMethod.invoke(manager, packageName, flags, userId) as PackageInfo
     }
 }
\ No newline at end of file
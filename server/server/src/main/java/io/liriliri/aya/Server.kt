@@ -2,12 +2,23 @@ package io.liriliri.aya
 
 import android.net.LocalServerSocket
 import android.util.Log
+import java.io.File
 import java.util.concurrent.Executors
+import kotlin.system.exitProcess
 
 class Server {
     companion object {
         private const val TAG = "Aya.Server"
 
+        val startTime = System.currentTimeMillis()
+
+        // 启动时计算，避免 dex 被新版本覆盖后返回错误的校验值
+        var checksum = ""
+            private set
+
+        @Volatile
+        private var serverSocket: LocalServerSocket? = null
+
         @JvmStatic
         fun main(args: Array<String>) {
             try {
@@ -16,14 +27,31 @@ class Server {
                 Log.e(TAG, "Fail to start server", e)
             }
         }
+
+        fun shutdown() {
+            Log.i(TAG, "Shutdown server")
+            try {
+                serverSocket?.close()
+            } catch (e: Exception) {
+                Log.e(TAG, "Fail to close server socket", e)
+            }
+            exitProcess(0)
+        }
     }
 
     private val executor = Executors.newCachedThreadPool()
     fun start(args: Array<String>) {
         Log.i(TAG, "Start server")
 
+        try {
+            checksum = Util.sha256(File(System.getProperty("java.class.path", "")))
+        } catch (e: Exception) {
+            Log.e(TAG, "Fail to compute dex checksum", e)
+        }
+
         val server = LocalServerSocket("aya")
-        Log.i(TAG, "Server started, listening on ${server.localSocketAddress}")
+        serverSocket = server
+        Log.i(TAG, "Server started, listening on ${server.localSocketAddress}, checksum: $checksum")
 
         while (true) {
             val conn = Connection(server.accept())
@@ -10,6 +10,7 @@ import java.lang.reflect.Method
 class StorageStatsManager(private val manager: IInterface) {
     companion object {
         private const val TAG = "Aya.StorageStatsManager"
+        private const val CALLING_PACKAGE = "com.android.shell"
     }
 
     private val queryStatsForPackageMethod: Method by lazy {
@@ -20,9 +21,9 @@ class StorageStatsManager(private val manager: IInterface) {
     }
 
     @TargetApi(Build.VERSION_CODES.O)
-    fun queryStatsForPackage(packageName: String): StorageStats {
-        Log.i(TAG, "Query storage stats: $packageName")
+    fun queryStatsForPackage(packageName: String, userId: Int = 0): StorageStats {
+        Log.i(TAG, "Query storage stats: $packageName, user: $userId")
 
-        return queryStatsForPackageMethod.invoke(manager, null, packageName, 0, "") as StorageStats
+        return queryStatsForPackageMethod.invoke(manager, null, packageName, userId, CALLING_PACKAGE) as StorageStats
     }
 }
\ No newline at end of file
@@ -5,6 +5,8 @@ import android.graphics.Canvas
 import android.graphics.drawable.Drawable
 import org.json.JSONArray
 import java.io.ByteArrayOutputStream
+import java.io.File
+import java.security.MessageDigest
 
 object Util {
     fun jsonArrayToStringArray(jsonArray: JSONArray): Array<String> {
@@ -33,4 +35,17 @@ object Util {
         bitmap.compress(Bitmap.CompressFormat.PNG, quality, stream)
         return stream.toByteArray()
     }
-}
\ No newline at end of file
+
+    fun sha256(file: File): String {
+        val md = MessageDigest.getInstance("SHA-256")
+        file.inputStream().use { input ->
+            val buffer = ByteArray(8192)
+            var read = input.read(buffer)
+            while (read > 0) {
+                md.update(buffer, 0, read)
+                read = input.read(buffer)
+            }
+        }
+        return md.digest().joinToString("") { byte -> "%02x".format(byte) }
+    }
+}
@@ -2,6 +2,10 @@ package storage
 
 const (
 	KeyAdbPath          = "adb_path"
-	KeyBookmarkPaths    = "bookmark_paths"
+	KeyBookmarkPaths    = "bookmark_paths" // 旧版的路径列表，读取收藏时迁移到 KeyBookmarks
+	KeyBookmarks        = "bookmarks"
 	KeyAutoOpenTerminal = "auto_open_terminal"
+	KeyPackageHistory   = "package_state_history"
+	KeyIntentPresets    = "intent_presets"
+	KeyPackageSnapshots = "package_snapshots" // 快照列表，每个快照的明细保存在 package_snapshots:<id>
 )
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os/exec"
@@ -56,6 +58,82 @@ func Exec(command string, ignoreError bool, exitWhen func(string) bool) (string,
 	return stderrStr, nil
 }
 
+// ExecContext executes a shell command and kills the whole process tree when ctx is cancelled
+func ExecContext(ctx context.Context, command string) (string, error) {
+	cmd := shellCommand(command)
+	ConfigureCommand(cmd)
+	ConfigureCancel(cmd)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		outputStr := normalizeCommandOutput(output.Bytes())
+		if err != nil {
+			return outputStr, fmt.Errorf("%w: %s", err, strings.TrimSpace(outputStr))
+		}
+		return outputStr, nil
+	case <-ctx.Done():
+		KillProcessTree(cmd)
+		<-done
+		return normalizeCommandOutput(output.Bytes()), ctx.Err()
+	}
+}
+
+// ExecStream executes a shell command and calls onLine for every stdout line as it arrives;
+// stderr is collected and returned with the error. The process tree is killed when ctx is cancelled
+func ExecStream(ctx context.Context, command string, onLine func(string)) error {
+	cmd := shellCommand(command)
+	ConfigureCommand(cmd)
+	ConfigureCancel(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			KillProcessTree(cmd)
+		case <-stopped:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		onLine(strings.TrimRight(normalizeCommandOutput(scanner.Bytes()), "\r"))
+	}
+	err = cmd.Wait()
+	close(stopped)
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(normalizeCommandOutput(stderr.Bytes())))
+	}
+	return nil
+}
+
 func ExecBackground(command string) error {
 	cmd := shellCommand(command)
 	ConfigureCommand(cmd)
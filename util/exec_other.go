@@ -2,7 +2,26 @@
 
 package util
 
-import "os/exec"
+import (
+	"os/exec"
+	"syscall"
+)
 
 func ConfigureCommand(cmd *exec.Cmd) {
 }
+
+// ConfigureCancel 让子进程使用独立的进程组，取消时可以连同 sh 启动的 adb 一起结束
+func ConfigureCancel(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// KillProcessTree 结束命令所在的进程组
+func KillProcessTree(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"os/exec"
+	"strconv"
 	"syscall"
 )
 
@@ -13,3 +14,19 @@ func ConfigureCommand(cmd *exec.Cmd) {
 		CreationFlags: createNoWindow,
 	}
 }
+
+// ConfigureCancel Windows 上通过 taskkill /T 结束进程树，无需额外设置
+func ConfigureCancel(cmd *exec.Cmd) {
+}
+
+// KillProcessTree 结束 cmd /C 及其启动的 adb 进程
+func KillProcessTree(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+	ConfigureCommand(kill)
+	if err := kill.Run(); err != nil {
+		_ = cmd.Process.Kill()
+	}
+}